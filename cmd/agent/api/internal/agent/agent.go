@@ -16,6 +16,7 @@ import (
 	"net"
 	"net/http"
 	"sort"
+	"strconv"
 	"time"
 
 	"github.com/gorilla/mux"
@@ -29,6 +30,7 @@ import (
 	dogstatsdDebug "github.com/DataDog/datadog-agent/comp/dogstatsd/serverDebug"
 	logsAgent "github.com/DataDog/datadog-agent/comp/logs/agent"
 	"github.com/DataDog/datadog-agent/comp/metadata/host"
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
 	"github.com/DataDog/datadog-agent/pkg/aggregator/sender"
 	"github.com/DataDog/datadog-agent/pkg/autodiscovery"
 	"github.com/DataDog/datadog-agent/pkg/config"
@@ -80,6 +82,7 @@ func SetupHandlers(
 	r.HandleFunc("/config/list-runtime", settingshttp.Server.ListConfigurable).Methods("GET")
 	r.HandleFunc("/config/{setting}", settingshttp.Server.GetValue).Methods("GET")
 	r.HandleFunc("/config/{setting}", settingshttp.Server.SetValue).Methods("POST")
+	r.HandleFunc("/metrics-cardinality", getMetricsCardinality).Methods("GET")
 	r.HandleFunc("/tagger-list", getTaggerList).Methods("GET")
 	r.HandleFunc("/workload-list", getWorkloadList).Methods("GET")
 	r.HandleFunc("/secrets", secretInfo).Methods("GET")
@@ -329,6 +332,37 @@ func getDogstatsdStats(w http.ResponseWriter, r *http.Request, dogstatsdServer d
 	w.Write(jsonStats)
 }
 
+func getMetricsCardinality(w http.ResponseWriter, r *http.Request) {
+	log.Info("Got a request for the metrics cardinality.")
+
+	topMetrics, topTagKeys := 10, 5
+	params := r.URL.Query()
+	for param, value := range map[string]*int{"top": &topMetrics, "tag_keys": &topTagKeys} {
+		if v := params.Get(param); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n <= 0 {
+				setJSONError(w, fmt.Errorf("invalid %s parameter %q, a positive integer is expected", param, v), 400)
+				return
+			}
+			*value = n
+		}
+	}
+
+	report, err := aggregator.GetCardinalityReport(topMetrics, topTagKeys)
+	if err != nil {
+		setJSONError(w, log.Errorf("Error getting the metrics cardinality: %v", err), 503)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	jsonReport, err := json.Marshal(report)
+	if err != nil {
+		setJSONError(w, log.Errorf("Unable to marshal the metrics cardinality: %v", err), 500)
+		return
+	}
+	w.Write(jsonReport)
+}
+
 func getFormattedStatus(w http.ResponseWriter, r *http.Request) {
 	log.Info("Got a request for the formatted status. Making formatted status.")
 	s, err := status.GetAndFormatStatus()
@@ -13,6 +13,7 @@ import (
 	"net"
 	"os"
 	"os/signal"
+	"regexp"
 	"syscall"
 
 	"go.uber.org/fx"
@@ -35,6 +36,8 @@ import (
 
 const (
 	defaultIterations = 1
+	defaultSpeed      = 1
+	defaultTopMetrics = 10
 )
 
 // cliParams are the command-line arguments for this subcommand
@@ -47,6 +50,13 @@ type cliParams struct {
 	dsdVerboseReplay    bool
 	dsdMmapReplay       bool
 	dsdReplayIterations int
+	dsdReplaySpeed      float64
+	dsdMetricNameFilter string
+	dsdTagsFilter       []string
+	dsdPidsFilter       []int32
+	dsdSummarize        bool
+	dsdTopMetrics       int
+	dsdDiffFilePath     string
 }
 
 // Commands returns a slice of subcommands for the 'agent' command.
@@ -70,12 +80,50 @@ func Commands(globalParams *command.GlobalParams) []*cobra.Command {
 	dogstatsdReplayCmd.Flags().StringVarP(&cliParams.dsdReplayFilePath, "file", "f", "", "Input file with traffic captured with dogstatsd-capture.")
 	dogstatsdReplayCmd.Flags().BoolVarP(&cliParams.dsdVerboseReplay, "verbose", "v", false, "Verbose replay.")
 	dogstatsdReplayCmd.Flags().BoolVarP(&cliParams.dsdMmapReplay, "mmap", "m", true, "Mmap file for replay. Set to false to load the entire file into memory instead")
-	dogstatsdReplayCmd.Flags().IntVarP(&cliParams.dsdReplayIterations, "loops", "l", defaultIterations, "Number of iterations to replay.")
+	dogstatsdReplayCmd.Flags().IntVarP(&cliParams.dsdReplayIterations, "loops", "l", defaultIterations, "Number of iterations to replay, 0 to loop until interrupted.")
+	dogstatsdReplayCmd.Flags().Float64VarP(&cliParams.dsdReplaySpeed, "speed", "s", defaultSpeed, "Replay speed multiplier, 0 to replay as fast as possible.")
+	dogstatsdReplayCmd.Flags().StringVar(&cliParams.dsdMetricNameFilter, "metric-name", "", "Only replay the metrics with a name matching this regular expression, events and service checks are dropped.")
+	dogstatsdReplayCmd.Flags().StringSliceVar(&cliParams.dsdTagsFilter, "tag", nil, "Only replay the messages holding this tag. Can be repeated, all the tags must match.")
+	dogstatsdReplayCmd.Flags().Int32SliceVar(&cliParams.dsdPidsFilter, "pid", nil, "Only replay the packets sent by this origin PID. Can be repeated.")
+	dogstatsdReplayCmd.Flags().BoolVar(&cliParams.dsdSummarize, "summarize", false, "Print a summary of the capture instead of replaying it, the agent doesn't need to be running.")
+	dogstatsdReplayCmd.Flags().IntVar(&cliParams.dsdTopMetrics, "top", defaultTopMetrics, "Number of metrics listed by the summary and the diff.")
+	dogstatsdReplayCmd.Flags().StringVar(&cliParams.dsdDiffFilePath, "diff", "", "Print the metrics whose contexts or samples differ between the capture given with --file and this capture instead of replaying it, the agent doesn't need to be running.")
 
 	return []*cobra.Command{dogstatsdReplayCmd}
 }
 
+// trafficFilter returns the filter built from the command-line arguments
+func (cliParams *cliParams) trafficFilter() (*replay.TrafficFilter, error) {
+	filter := &replay.TrafficFilter{
+		Tags: cliParams.dsdTagsFilter,
+		Pids: cliParams.dsdPidsFilter,
+	}
+	if cliParams.dsdMetricNameFilter != "" {
+		re, err := regexp.Compile(cliParams.dsdMetricNameFilter)
+		if err != nil {
+			return nil, fmt.Errorf("invalid metric name filter: %w", err)
+		}
+		filter.MetricName = re
+	}
+	return filter, nil
+}
+
 func dogstatsdReplay(log log.Component, config config.Component, cliParams *cliParams) error {
+	if cliParams.dsdReplaySpeed < 0 {
+		return fmt.Errorf("the replay speed must be positive, got %v", cliParams.dsdReplaySpeed)
+	}
+	filter, err := cliParams.trafficFilter()
+	if err != nil {
+		return err
+	}
+
+	if cliParams.dsdDiffFilePath != "" {
+		return diffCaptures(cliParams, filter)
+	}
+	if cliParams.dsdSummarize {
+		return summarizeCapture(cliParams, filter)
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
@@ -131,6 +179,7 @@ func dogstatsdReplay(log log.Component, config config.Component, cliParams *cliP
 		fmt.Printf("could not open: %s\n", cliParams.dsdReplayFilePath)
 		return err
 	}
+	reader.SetSpeed(cliParams.dsdReplaySpeed)
 
 	s := pkgconfig.Datadog.GetString("dogstatsd_socket")
 	if s == "" {
@@ -190,8 +239,12 @@ func dogstatsdReplay(log log.Component, config config.Component, cliParams *cliP
 			case msg := <-reader.Traffic:
 				// The cadence is enforced by the reader. The reader will only write to
 				// the traffic channel when it estimates the payload should be submitted.
+				payload := filter.Filter(msg)
+				if len(payload) == 0 {
+					continue
+				}
 				n, oobn, err := conn.(*net.UnixConn).WriteMsgUnix(
-					msg.Payload[:msg.PayloadSize], replay.GetUcredsForPid(msg.Pid), addr)
+					payload, replay.GetUcredsForPid(msg.Pid), addr)
 				if err != nil {
 					return err
 				}
@@ -224,3 +277,79 @@ func dogstatsdReplay(log log.Component, config config.Component, cliParams *cliP
 	fmt.Println("replay done")
 	return err
 }
+
+// readSummary returns the statistics of the capture at path.
+func readSummary(path string, mmap bool, filter *replay.TrafficFilter) (*replay.CaptureSummary, error) {
+	reader, err := replay.NewTrafficCaptureReader(path, 1, mmap)
+	if reader != nil {
+		defer reader.Close()
+	}
+	if err != nil {
+		fmt.Printf("could not open: %s\n", path)
+		return nil, err
+	}
+	return replay.SummarizeCapture(reader, filter)
+}
+
+// summarizeCapture prints the statistics of a capture, it doesn't need a running agent.
+func summarizeCapture(cliParams *cliParams, filter *replay.TrafficFilter) error {
+	summary, err := readSummary(cliParams.dsdReplayFilePath, cliParams.dsdMmapReplay, filter)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Capture: %s\n", cliParams.dsdReplayFilePath)
+	fmt.Printf("Duration: %s\n", summary.Duration)
+	fmt.Printf("Packets: %d (%d bytes) from %d processes\n", summary.Packets, summary.Bytes, len(summary.PacketsByPid))
+	fmt.Printf("Metric samples: %d, events: %d, service checks: %d\n", summary.Samples, summary.Events, summary.ServiceChecks)
+	fmt.Printf("Metrics: %d, contexts: %d\n", summary.Metrics(), summary.Contexts())
+
+	printMetrics := func(title string, metrics []replay.MetricSummary) {
+		fmt.Printf("\n%s:\n", title)
+		fmt.Printf("%10s %10s  %s\n", "CONTEXTS", "SAMPLES", "NAME")
+		for _, m := range metrics {
+			fmt.Printf("%10d %10d  %s\n", m.Contexts, m.Samples, m.Name)
+		}
+	}
+	printMetrics(fmt.Sprintf("Top %d metrics by contexts", cliParams.dsdTopMetrics), summary.TopMetricsByContexts(cliParams.dsdTopMetrics))
+	printMetrics(fmt.Sprintf("Top %d metrics by samples", cliParams.dsdTopMetrics), summary.TopMetricsBySamples(cliParams.dsdTopMetrics))
+
+	return nil
+}
+
+// diffCaptures prints the metrics which differ between two captures, it doesn't need a running agent.
+func diffCaptures(cliParams *cliParams, filter *replay.TrafficFilter) error {
+	before, err := readSummary(cliParams.dsdReplayFilePath, cliParams.dsdMmapReplay, filter)
+	if err != nil {
+		return err
+	}
+	after, err := readSummary(cliParams.dsdDiffFilePath, cliParams.dsdMmapReplay, filter)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range []struct {
+		title   string
+		path    string
+		summary *replay.CaptureSummary
+	}{{"Before", cliParams.dsdReplayFilePath, before}, {"After", cliParams.dsdDiffFilePath, after}} {
+		fmt.Printf("%s: %s, %s, %d packets, %d metric samples, %d metrics, %d contexts\n",
+			c.title, c.path, c.summary.Duration, c.summary.Packets, c.summary.Samples, c.summary.Metrics(), c.summary.Contexts())
+	}
+
+	diffs := replay.DiffSummaries(before, after)
+	fmt.Printf("\n%d metrics differ", len(diffs))
+	if cliParams.dsdTopMetrics > 0 && len(diffs) > cliParams.dsdTopMetrics {
+		fmt.Printf(", top %d by changed contexts", cliParams.dsdTopMetrics)
+		diffs = diffs[:cliParams.dsdTopMetrics]
+	}
+	fmt.Printf(":\n")
+	fmt.Printf("%16s %8s %8s %22s  %s\n", "CONTEXTS", "NEW", "REMOVED", "SAMPLES", "NAME")
+	for _, d := range diffs {
+		fmt.Printf("%16s %8d %8d %22s  %s\n",
+			fmt.Sprintf("%d -> %d", d.ContextsBefore, d.ContextsAfter), d.NewContexts, d.RemovedContexts,
+			fmt.Sprintf("%d -> %d", d.SamplesBefore, d.SamplesAfter), d.Name)
+	}
+
+	return nil
+}
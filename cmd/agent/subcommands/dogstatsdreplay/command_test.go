@@ -25,3 +25,33 @@ func TestCommand(t *testing.T) {
 			require.Equal(t, false, coreParams.ConfigLoadSecrets())
 		})
 }
+
+func TestCommandFilters(t *testing.T) {
+	fxutil.TestOneShotSubcommand(t,
+		Commands(&command.GlobalParams{}),
+		[]string{"dogstatsd-replay", "-f", "capture.dog", "--speed", "0", "--metric-name", "^web\\.", "--tag", "env:prod", "--tag", "service:web", "--pid", "42", "--summarize", "--top", "5"},
+		dogstatsdReplay,
+		func(cliParams *cliParams) {
+			require.Equal(t, 0.0, cliParams.dsdReplaySpeed)
+			require.Equal(t, []string{"env:prod", "service:web"}, cliParams.dsdTagsFilter)
+			require.Equal(t, []int32{42}, cliParams.dsdPidsFilter)
+			require.True(t, cliParams.dsdSummarize)
+			require.Equal(t, 5, cliParams.dsdTopMetrics)
+
+			filter, err := cliParams.trafficFilter()
+			require.NoError(t, err)
+			require.True(t, filter.MetricName.MatchString("web.requests"))
+		})
+}
+
+func TestCommandDiff(t *testing.T) {
+	fxutil.TestOneShotSubcommand(t,
+		Commands(&command.GlobalParams{}),
+		[]string{"dogstatsd-replay", "-f", "before.dog", "--diff", "after.dog", "--top", "20"},
+		dogstatsdReplay,
+		func(cliParams *cliParams) {
+			require.Equal(t, "before.dog", cliParams.dsdReplayFilePath)
+			require.Equal(t, "after.dog", cliParams.dsdDiffFilePath)
+			require.Equal(t, 20, cliParams.dsdTopMetrics)
+		})
+}
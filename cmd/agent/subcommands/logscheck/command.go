@@ -0,0 +1,191 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package logscheck implements 'agent logs check'.
+package logscheck
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"go.uber.org/fx"
+
+	"github.com/DataDog/datadog-agent/cmd/agent/command"
+	"github.com/DataDog/datadog-agent/comp/core"
+	"github.com/DataDog/datadog-agent/comp/core/config"
+	logsconfig "github.com/DataDog/datadog-agent/comp/logs/agent/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/dryrun"
+	"github.com/DataDog/datadog-agent/pkg/util/fxutil"
+)
+
+// cliParams are the command-line arguments for this subcommand
+type cliParams struct {
+	*command.GlobalParams
+
+	// samplePath is the file holding the sample log lines
+	samplePath string
+
+	// configPath is an integration configuration file holding logs configurations,
+	// only the global processing rules are applied when empty
+	configPath string
+
+	jsonOutput bool
+}
+
+// Commands returns a slice of subcommands for the 'agent' command.
+func Commands(globalParams *command.GlobalParams) []*cobra.Command {
+	cliParams := &cliParams{
+		GlobalParams: globalParams,
+	}
+
+	logsCmd := &cobra.Command{
+		Use:   "logs",
+		Short: "Logs pipeline related commands",
+		Long:  ``,
+	}
+
+	checkCmd := &cobra.Command{
+		Use:   "check <sample file>",
+		Short: "Run sample log lines through the logs pipeline and print the resulting messages",
+		Long: `Decode the sample log lines the way the logs agent does, multi-line aggregation included,
+apply the processing rules of logs_config.processing_rules and of the logs configurations
+given with --config, and print the messages that would be sent along with the rules which
+dropped or modified them. Nothing is sent to Datadog.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cliParams.samplePath = args[0]
+			return fxutil.OneShot(check,
+				fx.Supply(cliParams),
+				fx.Supply(command.GetDefaultCoreBundleParams(cliParams.GlobalParams)),
+				core.Bundle,
+			)
+		},
+	}
+	checkCmd.Flags().StringVarP(&cliParams.configPath, "config", "c", "", "integration configuration file holding the logs configurations to check")
+	checkCmd.Flags().BoolVarP(&cliParams.jsonOutput, "json", "j", false, "print the results as JSON")
+	logsCmd.AddCommand(checkCmd)
+
+	return []*cobra.Command{logsCmd}
+}
+
+// checkedConfig holds the results of a logs configuration.
+type checkedConfig struct {
+	Config  string          `json:"config"`
+	Results []dryrun.Result `json:"results"`
+}
+
+func check(config config.Component, cliParams *cliParams) error {
+	globalRules, err := logsconfig.GlobalProcessingRules(config)
+	if err != nil {
+		return fmt.Errorf("invalid logs_config.processing_rules: %v", err)
+	}
+
+	configs, err := loadConfigs(cliParams.configPath)
+	if err != nil {
+		return err
+	}
+
+	sample, err := os.ReadFile(cliParams.samplePath)
+	if err != nil {
+		return fmt.Errorf("could not read the sample: %v", err)
+	}
+
+	var checked []checkedConfig
+	for i, cfg := range configs {
+		results, err := dryrun.Run(cfg, globalRules, bytes.NewReader(sample))
+		if err != nil {
+			return err
+		}
+		checked = append(checked, checkedConfig{Config: describeConfig(cliParams.configPath, i, cfg), Results: results})
+	}
+
+	if cliParams.jsonOutput {
+		return printJSON(os.Stdout, checked)
+	}
+	printText(color.Output, checked)
+	return nil
+}
+
+// loadConfigs returns the validated logs configurations of the integration configuration file,
+// or a file configuration without any rule if path is empty.
+func loadConfigs(path string) ([]*logsconfig.LogsConfig, error) {
+	if path == "" {
+		return []*logsconfig.LogsConfig{{Type: logsconfig.FileType, Path: "sample"}}, nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read the logs configuration: %v", err)
+	}
+	configs, err := logsconfig.ParseYAML(content)
+	if err != nil {
+		return nil, err
+	}
+	if len(configs) == 0 {
+		return nil, fmt.Errorf("no logs configuration found in %s", path)
+	}
+	for i, cfg := range configs {
+		if err := cfg.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid logs configuration #%d in %s: %v", i+1, path, err)
+		}
+	}
+	return configs, nil
+}
+
+func describeConfig(path string, index int, cfg *logsconfig.LogsConfig) string {
+	if path == "" {
+		return "logs_config.processing_rules"
+	}
+	return fmt.Sprintf("%s #%d (type: %s)", path, index+1, cfg.Type)
+}
+
+func printJSON(w io.Writer, checked []checkedConfig) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(checked)
+}
+
+func printText(w io.Writer, checked []checkedConfig) {
+	for _, c := range checked {
+		fmt.Fprintf(w, "=== %s ===\n", color.BlueString(c.Config))
+		sent := 0
+		for i, result := range c.Results {
+			state := color.GreenString("SENT")
+			if result.Dropped {
+				state = color.RedString("DROPPED")
+			} else {
+				sent++
+			}
+			fmt.Fprintf(w, "\n[%d] %s\n", i+1, state)
+			fmt.Fprintf(w, "  Content: %s\n", result.Content)
+			fmt.Fprintf(w, "  Status: %s\n", result.Status)
+			if result.Service != "" {
+				fmt.Fprintf(w, "  Service: %s\n", result.Service)
+			}
+			if result.Source != "" {
+				fmt.Fprintf(w, "  Source: %s\n", result.Source)
+			}
+			if len(result.Tags) > 0 {
+				fmt.Fprintf(w, "  Tags: %s\n", strings.Join(result.Tags, ","))
+			}
+			for _, rule := range result.MatchedRules {
+				if rule.Dropped {
+					fmt.Fprintf(w, "  Rule: %s (%s), dropped the message\n", rule.Name, rule.Type)
+				} else {
+					fmt.Fprintf(w, "  Rule: %s (%s)\n", rule.Name, rule.Type)
+				}
+			}
+			for _, metric := range result.Metrics {
+				fmt.Fprintf(w, "  Metric: %s %s %v [%s]\n", metric.Type, metric.Name, metric.Value, strings.Join(metric.Tags, ","))
+			}
+		}
+		fmt.Fprintf(w, "\n%d message(s) decoded, %d sent, %d dropped\n\n", len(c.Results), sent, len(c.Results)-sent)
+	}
+}
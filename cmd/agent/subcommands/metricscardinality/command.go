@@ -0,0 +1,137 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package metricscardinality implements 'agent metrics-cardinality'.
+package metricscardinality
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"go.uber.org/fx"
+
+	"github.com/DataDog/datadog-agent/cmd/agent/command"
+	"github.com/DataDog/datadog-agent/comp/core"
+	"github.com/DataDog/datadog-agent/comp/core/config"
+	"github.com/DataDog/datadog-agent/comp/core/log"
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
+	"github.com/DataDog/datadog-agent/pkg/api/util"
+	pkgconfig "github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/fxutil"
+)
+
+// cliParams are the command-line arguments for this subcommand
+type cliParams struct {
+	*command.GlobalParams
+
+	// subcommand-specific flags
+
+	topMetrics      int
+	topTagKeys      int
+	jsonStatus      bool
+	prettyPrintJSON bool
+}
+
+// Commands returns a slice of subcommands for the 'agent' command.
+func Commands(globalParams *command.GlobalParams) []*cobra.Command {
+	cliParams := &cliParams{
+		GlobalParams: globalParams,
+	}
+
+	metricsCardinalityCmd := &cobra.Command{
+		Use:   "metrics-cardinality",
+		Short: "Print the metric names and tag keys with the most contexts in the aggregator",
+		Long: `Print the metric names with the most live contexts in the aggregator, for DogStatsD
+and for the checks, along with, for each of them, the tag keys with the most distinct values.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return fxutil.OneShot(requestMetricsCardinality,
+				fx.Supply(cliParams),
+				fx.Supply(command.GetDefaultCoreBundleParams(cliParams.GlobalParams)),
+				core.Bundle,
+			)
+		},
+	}
+
+	metricsCardinalityCmd.Flags().IntVarP(&cliParams.topMetrics, "top", "n", 10, "number of metric names to print")
+	metricsCardinalityCmd.Flags().IntVarP(&cliParams.topTagKeys, "tag-keys", "t", 5, "number of tag keys to print for each metric name")
+	metricsCardinalityCmd.Flags().BoolVarP(&cliParams.jsonStatus, "json", "j", false, "print out raw json")
+	metricsCardinalityCmd.Flags().BoolVarP(&cliParams.prettyPrintJSON, "pretty-json", "p", false, "pretty print JSON")
+
+	return []*cobra.Command{metricsCardinalityCmd}
+}
+
+func requestMetricsCardinality(log log.Component, config config.Component, cliParams *cliParams) error {
+	if cliParams.topMetrics <= 0 || cliParams.topTagKeys <= 0 {
+		return fmt.Errorf("--top and --tag-keys must be positive")
+	}
+
+	c := util.GetClient(false) // FIX: get certificates right then make this true
+	ipcAddress, err := pkgconfig.GetIPCAddress()
+	if err != nil {
+		return err
+	}
+	query := url.Values{}
+	query.Set("top", fmt.Sprint(cliParams.topMetrics))
+	query.Set("tag_keys", fmt.Sprint(cliParams.topTagKeys))
+	urlstr := fmt.Sprintf("https://%v:%v/agent/metrics-cardinality?%s", ipcAddress, pkgconfig.Datadog.GetInt("cmd_port"), query.Encode())
+
+	// Set session token
+	if err := util.SetAuthToken(); err != nil {
+		return err
+	}
+
+	r, err := util.DoGet(c, urlstr, util.LeaveConnectionOpen)
+	if err != nil {
+		var errMap = make(map[string]string)
+		json.Unmarshal(r, &errMap) //nolint:errcheck
+		// If the error has been marshalled into a json object, check it and return it properly
+		if e, found := errMap["error"]; found {
+			return errors.New(e)
+		}
+		fmt.Printf("Could not reach agent: %v \nMake sure the agent is running before requesting the metrics cardinality and contact support if you continue having issues. \n", err)
+		return err
+	}
+
+	// The rendering is done in the client so that the agent has less work to do
+	if cliParams.prettyPrintJSON {
+		var prettyJSON bytes.Buffer
+		json.Indent(&prettyJSON, r, "", "  ") //nolint:errcheck
+		fmt.Println(prettyJSON.String())
+		return nil
+	} else if cliParams.jsonStatus {
+		fmt.Println(string(r))
+		return nil
+	}
+
+	var report aggregator.CardinalityReport
+	if err := json.Unmarshal(r, &report); err != nil {
+		return fmt.Errorf("could not parse the metrics cardinality: %v", err)
+	}
+	printReport(color.Output, report)
+	return nil
+}
+
+func printReport(w io.Writer, report aggregator.CardinalityReport) {
+	printStats(w, "DogStatsD", report.Dogstatsd)
+	printStats(w, "Checks", report.Checks)
+}
+
+func printStats(w io.Writer, title string, stats aggregator.CardinalityStats) {
+	fmt.Fprintf(w, "=== %s ===\n", color.BlueString(title))
+	fmt.Fprintf(w, "%d contexts, %d metric names\n\n", stats.Contexts, stats.MetricNames)
+	for _, metric := range stats.Metrics {
+		fmt.Fprintf(w, "%s: %d contexts\n", color.GreenString(metric.Name), metric.Contexts)
+		for _, tagKey := range metric.TagKeys {
+			fmt.Fprintf(w, "  %s: %d values\n", tagKey.Key, tagKey.Values)
+		}
+	}
+	fmt.Fprintln(w)
+}
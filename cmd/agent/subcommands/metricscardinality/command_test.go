@@ -0,0 +1,29 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package metricscardinality
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/cmd/agent/command"
+	"github.com/DataDog/datadog-agent/comp/core"
+	"github.com/DataDog/datadog-agent/pkg/util/fxutil"
+)
+
+func TestCommand(t *testing.T) {
+	fxutil.TestOneShotSubcommand(t,
+		Commands(&command.GlobalParams{}),
+		[]string{"metrics-cardinality", "--top", "20", "--json"},
+		requestMetricsCardinality,
+		func(cliParams *cliParams, coreParams core.BundleParams) {
+			require.Equal(t, 20, cliParams.topMetrics)
+			require.Equal(t, 5, cliParams.topTagKeys)
+			require.True(t, cliParams.jsonStatus)
+			require.Equal(t, false, coreParams.ConfigLoadSecrets())
+		})
+}
@@ -289,6 +289,7 @@ func getSharedFxOption() fx.Option {
 		fx.Provide(func(config config.Component, log log.Component, sharedForwarder defaultforwarder.Component) (*aggregator.AgentDemultiplexer, error) {
 			opts := aggregator.DefaultAgentDemultiplexerOptions()
 			opts.EnableNoAggregationPipeline = config.GetBool("dogstatsd_no_aggregation_pipeline")
+			opts.DogstatsdMaxLateness = time.Duration(config.GetInt("dogstatsd_max_lateness")) * time.Second
 			opts.UseDogstatsdContextLimiter = true
 			opts.DogstatsdMaxMetricsTags = config.GetInt("dogstatsd_max_metrics_tags")
 			hostnameDetected, err := hostname.Get(context.TODO())
@@ -370,7 +371,7 @@ func startAgent(
 	}
 
 	// init settings that can be changed at runtime
-	if err := initRuntimeSettings(serverDebug); err != nil {
+	if err := initRuntimeSettings(serverDebug, server); err != nil {
 		log.Warnf("Can't initiliaze the runtime settings: %v", err)
 	}
 
@@ -444,6 +445,11 @@ func startAgent(
 				// LoadAndRun is called later on
 				common.AC.AddConfigProvider(rcProvider, true, 10*time.Second)
 			}
+
+			if pkgconfig.Datadog.GetBool("remote_configuration.dogstatsd.enabled") {
+				// Update the dogstatsd metric blocklist and mapper profiles through remote-config
+				rcclient.Subscribe(data.ProductMetricControl, server.MetricControlUpdateCallback)
+			}
 		}
 	}
 
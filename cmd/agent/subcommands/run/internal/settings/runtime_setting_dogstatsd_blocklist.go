@@ -0,0 +1,84 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package settings
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	dogstatsdServer "github.com/DataDog/datadog-agent/comp/dogstatsd/server"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/config/settings"
+)
+
+// DsdBlocklistRuntimeSetting wraps operations to change the dogstatsd metric blocklist at runtime.
+type DsdBlocklistRuntimeSetting struct {
+	Server dogstatsdServer.Component
+	source settings.Source
+}
+
+func NewDsdBlocklistRuntimeSetting(server dogstatsdServer.Component) *DsdBlocklistRuntimeSetting {
+	return &DsdBlocklistRuntimeSetting{
+		Server: server,
+		source: settings.SourceDefault,
+	}
+}
+
+// Description returns the runtime setting's description
+func (s *DsdBlocklistRuntimeSetting) Description() string {
+	return "Set the metric names dropped by dogstatsd. Possible values: a JSON array or a comma-separated list of metric names"
+}
+
+// Hidden returns whether or not this setting is hidden from the list of runtime settings
+func (s *DsdBlocklistRuntimeSetting) Hidden() bool {
+	return false
+}
+
+// Name returns the name of the runtime setting
+func (s *DsdBlocklistRuntimeSetting) Name() string {
+	return "statsd_metric_blocklist"
+}
+
+// Get returns the current value of the runtime setting
+func (s *DsdBlocklistRuntimeSetting) Get() (interface{}, error) {
+	return config.Datadog.GetStringSlice("statsd_metric_blocklist"), nil
+}
+
+// Set changes the value of the runtime setting
+func (s *DsdBlocklistRuntimeSetting) Set(v interface{}, source settings.Source) error {
+	var blocklist []string
+
+	switch value := v.(type) {
+	case []string:
+		blocklist = value
+	case string:
+		value = strings.TrimSpace(value)
+		if strings.HasPrefix(value, "[") {
+			if err := json.Unmarshal([]byte(value), &blocklist); err != nil {
+				return fmt.Errorf("DsdBlocklistRuntimeSetting: invalid JSON array: %v", err)
+			}
+			break
+		}
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				blocklist = append(blocklist, name)
+			}
+		}
+	default:
+		return fmt.Errorf("DsdBlocklistRuntimeSetting: unsupported type %T", v)
+	}
+
+	s.Server.SetBlocklist(blocklist, config.Datadog.GetBool("statsd_metric_blocklist_match_prefix"))
+
+	config.Datadog.Set("statsd_metric_blocklist", blocklist)
+	s.source = source
+	return nil
+}
+
+func (s *DsdBlocklistRuntimeSetting) GetSource() settings.Source {
+	return s.source
+}
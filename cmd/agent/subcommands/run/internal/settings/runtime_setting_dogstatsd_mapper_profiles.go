@@ -0,0 +1,76 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package settings
+
+import (
+	"encoding/json"
+	"fmt"
+
+	dogstatsdServer "github.com/DataDog/datadog-agent/comp/dogstatsd/server"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/config/settings"
+)
+
+// DsdMapperProfilesRuntimeSetting wraps operations to change the dogstatsd mapper profiles at runtime.
+type DsdMapperProfilesRuntimeSetting struct {
+	Server dogstatsdServer.Component
+	source settings.Source
+}
+
+func NewDsdMapperProfilesRuntimeSetting(server dogstatsdServer.Component) *DsdMapperProfilesRuntimeSetting {
+	return &DsdMapperProfilesRuntimeSetting{
+		Server: server,
+		source: settings.SourceDefault,
+	}
+}
+
+// Description returns the runtime setting's description
+func (s *DsdMapperProfilesRuntimeSetting) Description() string {
+	return "Set the dogstatsd mapper profiles. Possible values: a JSON array of profiles, an empty array disables the mapper"
+}
+
+// Hidden returns whether or not this setting is hidden from the list of runtime settings
+func (s *DsdMapperProfilesRuntimeSetting) Hidden() bool {
+	return false
+}
+
+// Name returns the name of the runtime setting
+func (s *DsdMapperProfilesRuntimeSetting) Name() string {
+	return "dogstatsd_mapper_profiles"
+}
+
+// Get returns the current value of the runtime setting
+func (s *DsdMapperProfilesRuntimeSetting) Get() (interface{}, error) {
+	return config.GetDogstatsdMappingProfiles()
+}
+
+// Set changes the value of the runtime setting
+func (s *DsdMapperProfilesRuntimeSetting) Set(v interface{}, source settings.Source) error {
+	var profiles []config.MappingProfile
+
+	switch value := v.(type) {
+	case []config.MappingProfile:
+		profiles = value
+	case string:
+		if err := json.Unmarshal([]byte(value), &profiles); err != nil {
+			return fmt.Errorf("DsdMapperProfilesRuntimeSetting: invalid JSON array: %v", err)
+		}
+	default:
+		return fmt.Errorf("DsdMapperProfilesRuntimeSetting: unsupported type %T", v)
+	}
+
+	if err := s.Server.SetMappingProfiles(profiles); err != nil {
+		return fmt.Errorf("DsdMapperProfilesRuntimeSetting: %v", err)
+	}
+
+	config.Datadog.Set("dogstatsd_mapper_profiles", profiles)
+	s.source = source
+	return nil
+}
+
+func (s *DsdMapperProfilesRuntimeSetting) GetSource() settings.Source {
+	return s.source
+}
@@ -19,6 +19,7 @@ import (
 	"go.uber.org/fx"
 
 	"github.com/DataDog/datadog-agent/pkg/aggregator"
+	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/config/settings"
 	"github.com/DataDog/datadog-agent/pkg/util/fxutil"
 )
@@ -101,3 +102,36 @@ func TestDogstatsdMetricsStats(t *testing.T) {
 	assert.Nil(err)
 	assert.Equal(v, true)
 }
+
+func TestDogstatsdBlocklist(t *testing.T) {
+	mockConfig := config.Mock(t)
+	s := NewDsdBlocklistRuntimeSetting(fxutil.Test[server.Component](t, server.MockModule))
+
+	require.NoError(t, s.Set(`["foo", "bar"]`, settings.SourceCLI))
+	assert.Equal(t, []string{"foo", "bar"}, mockConfig.GetStringSlice("statsd_metric_blocklist"))
+	assert.Equal(t, settings.SourceCLI, s.GetSource())
+
+	require.NoError(t, s.Set("foo, baz", settings.SourceCLI))
+	v, err := s.Get()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"foo", "baz"}, v)
+
+	assert.Error(t, s.Set(`["foo"`, settings.SourceCLI))
+	assert.Error(t, s.Set(42, settings.SourceCLI))
+}
+
+func TestDogstatsdMapperProfiles(t *testing.T) {
+	config.Mock(t)
+	s := NewDsdMapperProfilesRuntimeSetting(fxutil.Test[server.Component](t, server.MockModule))
+
+	require.NoError(t, s.Set(`[{"name": "test", "prefix": "test.", "mappings": [{"match": "test.job.*", "name": "test.job"}]}]`, settings.SourceCLI))
+	v, err := s.Get()
+	require.NoError(t, err)
+	assert.Equal(t, []config.MappingProfile{{
+		Name:     "test",
+		Prefix:   "test.",
+		Mappings: []config.MetricMapping{{Match: "test.job.*", Name: "test.job"}},
+	}}, v)
+
+	assert.Error(t, s.Set(`{"name": "test"}`, settings.SourceCLI))
+}
@@ -17,6 +17,7 @@ import (
 	"log"
 	"os"
 	"os/signal"
+	"time"
 
 	"github.com/DataDog/datadog-agent/comp/core"
 	"github.com/DataDog/datadog-agent/comp/core/config"
@@ -103,6 +104,7 @@ func newDemultiplexer(logcomp corelog.Component, cfg config.Component, fwd defau
 	}
 	opts := aggregator.DefaultAgentDemultiplexerOptions()
 	opts.EnableNoAggregationPipeline = cfg.GetBool("dogstatsd_no_aggregation_pipeline")
+	opts.DogstatsdMaxLateness = time.Duration(cfg.GetInt("dogstatsd_max_lateness")) * time.Second
 	opts.UseDogstatsdContextLimiter = true
 	opts.DogstatsdMaxMetricsTags = cfg.GetInt("dogstatsd_max_metrics_tags")
 	return aggregator.InitAndStartAgentDemultiplexer(logcomp, fwd, opts, host)
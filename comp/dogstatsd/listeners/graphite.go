@@ -0,0 +1,211 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package listeners
+
+import (
+	"bufio"
+	"errors"
+	"expvar"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/comp/dogstatsd/packets"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+var (
+	graphiteExpvars             = expvar.NewMap("dogstatsd-graphite")
+	graphiteConnections         = expvar.Int{}
+	graphitePacketReadingErrors = expvar.Int{}
+	graphitePackets             = expvar.Int{}
+	graphiteBytes               = expvar.Int{}
+)
+
+func init() {
+	graphiteExpvars.Set("Connections", &graphiteConnections)
+	graphiteExpvars.Set("PacketReadingErrors", &graphitePacketReadingErrors)
+	graphiteExpvars.Set("Packets", &graphitePackets)
+	graphiteExpvars.Set("Bytes", &graphiteBytes)
+}
+
+// GraphiteListener implements the StatsdListener interface for the Graphite plaintext
+// protocol. It listens to the same port in TCP and UDP, and sends back packets of
+// newline separated Graphite lines, parsed by the server.
+// Origin detection is not implemented for Graphite.
+type GraphiteListener struct {
+	tcpListener     net.Listener
+	udpConn         *net.UDPConn
+	packetsBuffer   *packets.Buffer
+	packetAssembler *packets.Assembler
+	bufferSize      int
+
+	// connsMu must be held when accessing conns and stopped
+	connsMu sync.Mutex
+	conns   map[net.Conn]struct{}
+	stopped bool
+	wg      sync.WaitGroup
+}
+
+// NewGraphiteListener returns an idle Graphite listener
+func NewGraphiteListener(packetOut chan packets.Packets, sharedPacketPoolManager *packets.PoolManager, cfg config.Reader) (*GraphiteListener, error) {
+	var url string
+
+	port := strconv.Itoa(cfg.GetInt("dogstatsd_graphite.port"))
+	if cfg.GetBool("dogstatsd_non_local_traffic") {
+		// Listen to all network interfaces
+		url = fmt.Sprintf(":%s", port)
+	} else {
+		url = net.JoinHostPort(config.GetBindHostFromConfig(cfg), port)
+	}
+
+	tcpListener, err := net.Listen("tcp", url)
+	if err != nil {
+		return nil, fmt.Errorf("can't listen: %s", err)
+	}
+
+	// listen to the same port in UDP, the actual one if it was picked by the system
+	addr, err := net.ResolveUDPAddr("udp", tcpListener.Addr().String())
+	if err != nil {
+		tcpListener.Close()
+		return nil, fmt.Errorf("could not resolve udp addr: %s", err)
+	}
+	udpConn, err := net.ListenUDP("udp", addr)
+	if err != nil {
+		tcpListener.Close()
+		return nil, fmt.Errorf("can't listen: %s", err)
+	}
+
+	bufferSize := cfg.GetInt("dogstatsd_buffer_size")
+	packetsBufferSize := cfg.GetInt("dogstatsd_packet_buffer_size")
+	flushTimeout := cfg.GetDuration("dogstatsd_packet_buffer_flush_timeout")
+
+	packetsBuffer := packets.NewBuffer(uint(packetsBufferSize), flushTimeout, packetOut)
+	packetAssembler := packets.NewAssembler(flushTimeout, packetsBuffer, sharedPacketPoolManager, packets.Graphite)
+
+	listener := &GraphiteListener{
+		tcpListener:     tcpListener,
+		udpConn:         udpConn,
+		packetsBuffer:   packetsBuffer,
+		packetAssembler: packetAssembler,
+		bufferSize:      bufferSize,
+		conns:           map[net.Conn]struct{}{},
+	}
+	log.Debugf("dogstatsd-graphite: %s successfully initialized", tcpListener.Addr())
+	return listener, nil
+}
+
+// LocalAddr returns the local network address of the listener, the same port is used
+// for TCP and UDP.
+func (l *GraphiteListener) LocalAddr() string {
+	return l.tcpListener.Addr().String()
+}
+
+// Listen runs the intake loops. Should be called in its own goroutine
+func (l *GraphiteListener) Listen() {
+	log.Infof("dogstatsd-graphite: starting to listen on %s", l.tcpListener.Addr())
+	go l.listenUDP()
+	l.listenTCP()
+}
+
+func (l *GraphiteListener) listenTCP() {
+	for {
+		conn, err := l.tcpListener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			log.Errorf("dogstatsd-graphite: error accepting a connection: %v", err)
+			continue
+		}
+
+		l.connsMu.Lock()
+		if l.stopped {
+			l.connsMu.Unlock()
+			conn.Close()
+			return
+		}
+		l.conns[conn] = struct{}{}
+		l.wg.Add(1)
+		l.connsMu.Unlock()
+		graphiteConnections.Add(1)
+
+		go l.handleConnection(conn)
+	}
+}
+
+// handleConnection reads the lines sent on a TCP connection until it is closed.
+func (l *GraphiteListener) handleConnection(conn net.Conn) {
+	defer func() {
+		l.connsMu.Lock()
+		delete(l.conns, conn)
+		l.connsMu.Unlock()
+		conn.Close()
+		graphiteConnections.Add(-1)
+		l.wg.Done()
+	}()
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 0, 4096), l.bufferSize)
+	for scanner.Scan() {
+		t1 := time.Now()
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		graphitePackets.Add(1)
+		graphiteBytes.Add(int64(len(line)))
+		tlmGraphitePackets.Inc("tcp", "ok")
+		l.packetAssembler.AddMessage(line)
+		tlmListener.Observe(float64(time.Since(t1).Nanoseconds()), "graphite")
+	}
+	if err := scanner.Err(); err != nil && !errors.Is(err, net.ErrClosed) {
+		log.Debugf("dogstatsd-graphite: closing the connection of %s: %v", conn.RemoteAddr(), err)
+		graphitePacketReadingErrors.Add(1)
+		tlmGraphitePackets.Inc("tcp", "error")
+	}
+}
+
+func (l *GraphiteListener) listenUDP() {
+	buffer := make([]byte, l.bufferSize)
+	for {
+		n, _, err := l.udpConn.ReadFrom(buffer)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			log.Errorf("dogstatsd-graphite: error reading packet: %v", err)
+			graphitePacketReadingErrors.Add(1)
+			tlmGraphitePackets.Inc("udp", "error")
+			continue
+		}
+		graphitePackets.Add(1)
+		graphiteBytes.Add(int64(n))
+		tlmGraphitePackets.Inc("udp", "ok")
+		// a datagram can hold several lines, the server splits them
+		l.packetAssembler.AddMessage(buffer[:n])
+	}
+}
+
+// Stop closes the connections and stops listening
+func (l *GraphiteListener) Stop() {
+	l.tcpListener.Close()
+	l.udpConn.Close()
+
+	l.connsMu.Lock()
+	l.stopped = true
+	for conn := range l.conns {
+		conn.Close()
+	}
+	l.connsMu.Unlock()
+	l.wg.Wait()
+
+	l.packetAssembler.Close()
+	l.packetsBuffer.Close()
+}
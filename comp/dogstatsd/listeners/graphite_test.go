@@ -0,0 +1,111 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build !windows
+
+package listeners
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/comp/dogstatsd/packets"
+)
+
+func newTestGraphiteListener(t *testing.T) (*GraphiteListener, chan packets.Packets) {
+	cfg := fulfillDepsWithConfig(t, map[string]interface{}{
+		"dogstatsd_graphite.port":               0,
+		"dogstatsd_packet_buffer_flush_timeout": 10 * time.Millisecond,
+	})
+	packetsChannel := make(chan packets.Packets, 10)
+	l, err := NewGraphiteListener(packetsChannel, newPacketPoolManagerUDP(cfg), cfg)
+	require.NoError(t, err)
+	go l.Listen()
+	return l, packetsChannel
+}
+
+// readGraphiteLines returns the lines received until count lines are read
+func readGraphiteLines(t *testing.T, packetsChannel chan packets.Packets, count int) []string {
+	var lines []string
+	timeout := time.After(2 * time.Second)
+	for len(lines) < count {
+		select {
+		case received := <-packetsChannel:
+			for _, packet := range received {
+				assert.Equal(t, packets.Graphite, packet.Source)
+				assert.Equal(t, packets.NoOrigin, packet.Origin)
+				for _, line := range strings.Split(string(packet.Contents), "\n") {
+					if line != "" {
+						lines = append(lines, line)
+					}
+				}
+			}
+		case <-timeout:
+			require.FailNow(t, "timeout waiting for the graphite lines", "received %v", lines)
+		}
+	}
+	return lines
+}
+
+func TestGraphiteListenerTCP(t *testing.T) {
+	l, packetsChannel := newTestGraphiteListener(t)
+	defer l.Stop()
+
+	conn, err := net.Dial("tcp", l.LocalAddr())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = conn.Write([]byte("cpu.load 0.5 1700000000\r\ndisk.used;dc=dc1 "))
+	require.NoError(t, err)
+	_, err = conn.Write([]byte("42 1700000000\n\n"))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"cpu.load 0.5 1700000000", "disk.used;dc=dc1 42 1700000000"}, readGraphiteLines(t, packetsChannel, 2))
+}
+
+func TestGraphiteListenerUDP(t *testing.T) {
+	l, packetsChannel := newTestGraphiteListener(t)
+	defer l.Stop()
+
+	conn, err := net.Dial("udp", l.LocalAddr())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = conn.Write([]byte("cpu.load 0.5 1700000000\nmemory.used 12 1700000000\n"))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"cpu.load 0.5 1700000000", "memory.used 12 1700000000"}, readGraphiteLines(t, packetsChannel, 2))
+}
+
+func TestGraphiteListenerStopClosesConnections(t *testing.T) {
+	l, _ := newTestGraphiteListener(t)
+
+	conn, err := net.Dial("tcp", l.LocalAddr())
+	require.NoError(t, err)
+	defer conn.Close()
+	// wait for the connection to be tracked
+	require.Eventually(t, func() bool {
+		l.connsMu.Lock()
+		defer l.connsMu.Unlock()
+		return len(l.conns) == 1
+	}, 2*time.Second, 10*time.Millisecond)
+
+	l.Stop()
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, err = conn.Read(make([]byte, 1))
+	assert.Error(t, err)
+	assert.False(t, isTimeout(err), "the connection should have been closed by the listener")
+}
+
+func isTimeout(err error) bool {
+	netErr, ok := err.(net.Error)
+	return ok && netErr.Timeout()
+}
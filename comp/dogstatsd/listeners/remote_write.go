@@ -0,0 +1,176 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package listeners
+
+import (
+	"errors"
+	"expvar"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/klauspost/compress/snappy"
+
+	"github.com/DataDog/datadog-agent/comp/dogstatsd/listeners/remotewrite"
+	"github.com/DataDog/datadog-agent/comp/dogstatsd/packets"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/util/containers"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+var (
+	remoteWriteExpvars       = expvar.NewMap("dogstatsd-remote-write")
+	remoteWriteRequestErrors = expvar.Int{}
+	remoteWriteRequests      = expvar.Int{}
+	remoteWriteSamples       = expvar.Int{}
+)
+
+// ContainerIDHeader is the header holding the ID of the container sending a remote-write
+// request, used for origin detection.
+const ContainerIDHeader = "Datadog-Container-ID"
+
+func init() {
+	remoteWriteExpvars.Set("RequestErrors", &remoteWriteRequestErrors)
+	remoteWriteExpvars.Set("Requests", &remoteWriteRequests)
+	remoteWriteExpvars.Set("Samples", &remoteWriteSamples)
+}
+
+// RemoteWriteHandler receives the samples of a remote-write request. origin is the tagger
+// entity of the container which sent the request, or packets.NoOrigin.
+type RemoteWriteHandler func(samples []metrics.MetricSample, origin string)
+
+// RemoteWriteListener implements the StatsdListener interface for the Prometheus
+// remote-write protocol. It listens to a given TCP address for HTTP requests holding
+// snappy compressed protobuf messages, and hands their samples to a RemoteWriteHandler.
+// Origin detection relies on the Datadog-Container-ID header.
+type RemoteWriteListener struct {
+	listener        net.Listener
+	server          *http.Server
+	converter       *remotewrite.Converter
+	handler         RemoteWriteHandler
+	originDetection bool
+	maxRequestSize  int
+}
+
+// NewRemoteWriteListener returns an idle Prometheus remote-write listener
+func NewRemoteWriteListener(cfg config.Reader, handler RemoteWriteHandler) (*RemoteWriteListener, error) {
+	var url string
+
+	port := strconv.Itoa(cfg.GetInt("dogstatsd_remote_write.port"))
+	if cfg.GetBool("dogstatsd_non_local_traffic") {
+		// Listen to all network interfaces
+		url = fmt.Sprintf(":%s", port)
+	} else {
+		url = net.JoinHostPort(config.GetBindHostFromConfig(cfg), port)
+	}
+
+	listener, err := net.Listen("tcp", url)
+	if err != nil {
+		return nil, fmt.Errorf("can't listen: %s", err)
+	}
+
+	l := &RemoteWriteListener{
+		listener:        listener,
+		converter:       remotewrite.NewConverter(time.Duration(cfg.GetInt("dogstatsd_remote_write.series_expiry")) * time.Second),
+		handler:         handler,
+		originDetection: cfg.GetBool("dogstatsd_origin_detection"),
+		maxRequestSize:  cfg.GetInt("dogstatsd_remote_write.max_request_size"),
+	}
+	l.server = &http.Server{
+		Handler:           l,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	log.Debugf("dogstatsd-remote-write: %s successfully initialized", listener.Addr())
+	return l, nil
+}
+
+// LocalAddr returns the local network address of the listener.
+func (l *RemoteWriteListener) LocalAddr() string {
+	return l.listener.Addr().String()
+}
+
+// Listen runs the HTTP server. Should be called in its own goroutine
+func (l *RemoteWriteListener) Listen() {
+	log.Infof("dogstatsd-remote-write: starting to listen on %s", l.listener.Addr())
+	if err := l.server.Serve(l.listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Errorf("dogstatsd-remote-write: the server stopped: %v", err)
+	}
+}
+
+// Stop closes the HTTP server and stops listening
+func (l *RemoteWriteListener) Stop() {
+	l.server.Close()
+}
+
+// ServeHTTP handles a remote-write request. As Prometheus retries the requests answered
+// with a 5xx status, the invalid requests are answered with a 4xx status.
+func (l *RemoteWriteListener) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	t1 := time.Now()
+	defer func() {
+		tlmListener.Observe(float64(time.Since(t1).Nanoseconds()), "remote_write")
+	}()
+	remoteWriteRequests.Add(1)
+
+	if r.Method != http.MethodPost {
+		l.fail(w, http.StatusMethodNotAllowed, "only POST requests are accepted")
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, int64(l.maxRequestSize)+1))
+	if err != nil {
+		l.fail(w, http.StatusBadRequest, fmt.Sprintf("could not read the request: %v", err))
+		return
+	}
+	if len(body) > l.maxRequestSize {
+		l.fail(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("the request is larger than %d bytes", l.maxRequestSize))
+		return
+	}
+
+	size, err := snappy.DecodedLen(body)
+	if err == nil && size > l.maxRequestSize {
+		err = fmt.Errorf("the decompressed request is larger than %d bytes", l.maxRequestSize)
+	}
+	var data []byte
+	if err == nil {
+		data, err = snappy.Decode(nil, body)
+	}
+	if err != nil {
+		l.fail(w, http.StatusBadRequest, fmt.Sprintf("invalid snappy payload: %v", err))
+		return
+	}
+
+	req, err := remotewrite.Unmarshal(data)
+	if err != nil {
+		l.fail(w, http.StatusBadRequest, fmt.Sprintf("invalid protobuf payload: %v", err))
+		return
+	}
+
+	origin := packets.NoOrigin
+	if containerID := r.Header.Get(ContainerIDHeader); l.originDetection && containerID != "" {
+		origin = containers.BuildTaggerEntityName(containerID)
+	}
+
+	samples := l.converter.Convert(req, nil)
+	if len(samples) > 0 {
+		l.handler(samples, origin)
+	}
+
+	remoteWriteSamples.Add(int64(len(samples)))
+	tlmRemoteWriteSamples.Add(float64(len(samples)))
+	tlmRemoteWriteRequests.Inc("ok")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (l *RemoteWriteListener) fail(w http.ResponseWriter, status int, message string) {
+	log.Debugf("dogstatsd-remote-write: rejecting a request: %s", message)
+	remoteWriteRequestErrors.Add(1)
+	tlmRemoteWriteRequests.Inc("error")
+	http.Error(w, message, status)
+}
@@ -0,0 +1,130 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build !windows
+
+package listeners
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	"github.com/klauspost/compress/snappy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/comp/dogstatsd/listeners/remotewrite"
+	"github.com/DataDog/datadog-agent/comp/dogstatsd/packets"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+type remoteWriteCall struct {
+	samples []metrics.MetricSample
+	origin  string
+}
+
+func newTestRemoteWriteListener(t *testing.T, overrides map[string]interface{}) (*RemoteWriteListener, chan remoteWriteCall) {
+	overrides["dogstatsd_remote_write.port"] = 0
+	cfg := fulfillDepsWithConfig(t, overrides)
+
+	calls := make(chan remoteWriteCall, 10)
+	l, err := NewRemoteWriteListener(cfg, func(samples []metrics.MetricSample, origin string) {
+		calls <- remoteWriteCall{samples: samples, origin: origin}
+	})
+	require.NoError(t, err)
+	go l.Listen()
+	t.Cleanup(l.Stop)
+	return l, calls
+}
+
+func postRemoteWrite(t *testing.T, l *RemoteWriteListener, body []byte, containerID string) *http.Response {
+	req, err := http.NewRequest(http.MethodPost, "http://"+l.LocalAddr()+"/api/v1/write", bytes.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	if containerID != "" {
+		req.Header.Set(ContainerIDHeader, containerID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	return resp
+}
+
+func encodeRemoteWrite(req *remotewrite.WriteRequest) []byte {
+	return snappy.Encode(nil, remotewrite.Marshal(req))
+}
+
+func TestRemoteWriteListener(t *testing.T) {
+	l, calls := newTestRemoteWriteListener(t, map[string]interface{}{"dogstatsd_origin_detection": true})
+
+	body := encodeRemoteWrite(&remotewrite.WriteRequest{
+		Timeseries: []remotewrite.TimeSeries{
+			{
+				Labels:  []remotewrite.Label{{Name: "__name__", Value: "temperature"}, {Name: "room", Value: "kitchen"}},
+				Samples: []remotewrite.Sample{{Value: 21.5, Timestamp: 1000}},
+			},
+			{
+				Labels:  []remotewrite.Label{{Name: "__name__", Value: "http_requests_total"}},
+				Samples: []remotewrite.Sample{{Value: 10, Timestamp: 1000}, {Value: 12, Timestamp: 2000}},
+			},
+		},
+	})
+
+	resp := postRemoteWrite(t, l, body, "abcdef")
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	call := <-calls
+	assert.Equal(t, "container_id://abcdef", call.origin)
+	assert.Equal(t, []metrics.MetricSample{
+		{Name: "temperature", Value: 21.5, Mtype: metrics.GaugeType, Tags: []string{"room:kitchen"}, SampleRate: 1},
+		{Name: "http_requests_total", Value: 2, Mtype: metrics.CountType, Tags: []string{}, SampleRate: 1},
+	}, call.samples)
+
+	// without the header, the origin is unknown
+	resp = postRemoteWrite(t, l, body, "")
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	call = <-calls
+	assert.Equal(t, packets.NoOrigin, call.origin)
+}
+
+func TestRemoteWriteListenerOriginDetectionDisabled(t *testing.T) {
+	l, calls := newTestRemoteWriteListener(t, map[string]interface{}{"dogstatsd_origin_detection": false})
+
+	resp := postRemoteWrite(t, l, encodeRemoteWrite(&remotewrite.WriteRequest{
+		Timeseries: []remotewrite.TimeSeries{{
+			Labels:  []remotewrite.Label{{Name: "__name__", Value: "up"}},
+			Samples: []remotewrite.Sample{{Value: 1, Timestamp: 1000}},
+		}},
+	}), "abcdef")
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	assert.Equal(t, packets.NoOrigin, (<-calls).origin)
+}
+
+func TestRemoteWriteListenerInvalidRequests(t *testing.T) {
+	l, calls := newTestRemoteWriteListener(t, map[string]interface{}{"dogstatsd_remote_write.max_request_size": 64})
+
+	resp, err := http.Get("http://" + l.LocalAddr() + "/api/v1/write")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+
+	resp = postRemoteWrite(t, l, []byte("not snappy"), "")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp = postRemoteWrite(t, l, snappy.Encode(nil, []byte("not protobuf")), "")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp = postRemoteWrite(t, l, bytes.Repeat([]byte{0}, 65), "")
+	assert.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+
+	// compresses well but is too large once decompressed
+	resp = postRemoteWrite(t, l, snappy.Encode(nil, bytes.Repeat([]byte{0}, 1024)), "")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	assert.Empty(t, calls)
+}
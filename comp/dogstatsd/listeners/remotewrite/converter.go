@@ -0,0 +1,351 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package remotewrite
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+const metricNameLabel = "__name__"
+
+// the native histograms schemas, custom buckets are not supported
+const (
+	minSchema = -4
+	maxSchema = 8
+)
+
+// cumulativeSuffixes are the suffixes of the series holding a cumulative value
+var cumulativeSuffixes = []string{"_total", "_bucket", "_count", "_sum"}
+
+// Converter converts the remote-write series into metric samples:
+//   - the gauges are sent as gauges,
+//   - the counters, and the _bucket, _count and _sum series of the classic histograms
+//     and summaries, are sent as counts holding their increase since the previous sample,
+//   - the native histograms are sent as distributions, one sample per bucket weighted by
+//     its increase.
+//
+// The type of a series comes from the metadata sent by Prometheus, the naming conventions
+// are used until it is known. The first sample of a cumulative series is only used as a
+// reference, and the series which are not updated during the expiry are forgotten.
+//
+// A Converter is safe for concurrent use.
+type Converter struct {
+	mu        sync.Mutex
+	types     map[string]MetricType
+	series    map[string]*seriesState
+	expiry    time.Duration
+	lastPurge time.Time
+}
+
+type seriesState struct {
+	lastSeen  time.Time
+	timestamp int64
+	value     float64
+	histogram *histogramState
+}
+
+type histogramState struct {
+	schema        int32
+	zeroThreshold float64
+	zeroCount     float64
+	count         float64
+	positive      map[int32]float64
+	negative      map[int32]float64
+}
+
+// NewConverter returns a Converter forgetting the series which are not updated during expiry.
+func NewConverter(expiry time.Duration) *Converter {
+	return &Converter{
+		types:     map[string]MetricType{},
+		series:    map[string]*seriesState{},
+		expiry:    expiry,
+		lastPurge: time.Now(),
+	}
+}
+
+// Convert appends the samples of req to samples. The series label become tags, and the
+// samples of the same native histogram share the same tags slice.
+func (c *Converter) Convert(req *WriteRequest, samples []metrics.MetricSample) []metrics.MetricSample {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	for _, md := range req.Metadata {
+		c.types[md.MetricFamilyName] = md.Type
+	}
+
+	for i := range req.Timeseries {
+		ts := &req.Timeseries[i]
+		name, tags := nameAndTags(ts.Labels)
+		if name == "" {
+			continue
+		}
+		key := name + "\x00" + strings.Join(tags, "\x00")
+
+		if len(ts.Samples) > 0 {
+			if c.isCumulative(name) {
+				samples = c.convertCounter(samples, key, name, tags, ts.Samples, now)
+			} else {
+				samples = convertGauge(samples, name, tags, ts.Samples)
+			}
+		}
+		for j := range ts.Histograms {
+			samples = c.convertHistogram(samples, key, name, tags, &ts.Histograms[j], now)
+		}
+	}
+
+	if now.Sub(c.lastPurge) > c.expiry {
+		for key, state := range c.series {
+			if now.Sub(state.lastSeen) > c.expiry {
+				delete(c.series, key)
+			}
+		}
+		c.lastPurge = now
+	}
+	return samples
+}
+
+// nameAndTags returns the metric name of a series and its other labels as sorted tags.
+func nameAndTags(labels []Label) (string, []string) {
+	var name string
+	tags := make([]string, 0, len(labels))
+	for _, l := range labels {
+		if l.Name == metricNameLabel {
+			name = l.Value
+		} else if l.Value != "" {
+			tags = append(tags, l.Name+":"+l.Value)
+		}
+	}
+	sort.Strings(tags)
+	return name, tags
+}
+
+// isCumulative returns true if the series holds a cumulative value.
+func (c *Converter) isCumulative(name string) bool {
+	if t, found := c.types[name]; found {
+		return t == MetricTypeCounter
+	}
+	for _, suffix := range cumulativeSuffixes {
+		family := strings.TrimSuffix(name, suffix)
+		if family == name {
+			continue
+		}
+		if t, found := c.types[family]; found {
+			return t == MetricTypeCounter || t == MetricTypeHistogram || t == MetricTypeSummary
+		}
+		// without metadata, rely on the naming conventions
+		return true
+	}
+	return false
+}
+
+func convertGauge(samples []metrics.MetricSample, name string, tags []string, values []Sample) []metrics.MetricSample {
+	for _, s := range values {
+		if math.IsNaN(s.Value) || math.IsInf(s.Value, 0) {
+			continue
+		}
+		samples = append(samples, metrics.MetricSample{
+			Name:       name,
+			Value:      s.Value,
+			Mtype:      metrics.GaugeType,
+			Tags:       tags,
+			SampleRate: 1,
+		})
+	}
+	return samples
+}
+
+func (c *Converter) convertCounter(samples []metrics.MetricSample, key, name string, tags []string, values []Sample, now time.Time) []metrics.MetricSample {
+	for _, s := range values {
+		// the stale markers are NaNs, the series has disappeared
+		if math.IsNaN(s.Value) {
+			delete(c.series, key)
+			continue
+		}
+		if math.IsInf(s.Value, 0) {
+			continue
+		}
+
+		state, found := c.series[key]
+		if !found {
+			c.series[key] = &seriesState{lastSeen: now, timestamp: s.Timestamp, value: s.Value}
+			continue
+		}
+		// drop the samples sent again when a request is retried
+		if s.Timestamp <= state.timestamp {
+			continue
+		}
+
+		increase := s.Value - state.value
+		if increase < 0 {
+			// the counter has been reset
+			increase = s.Value
+		}
+		state.lastSeen, state.timestamp, state.value = now, s.Timestamp, s.Value
+
+		samples = append(samples, metrics.MetricSample{
+			Name:       name,
+			Value:      increase,
+			Mtype:      metrics.CountType,
+			Tags:       tags,
+			SampleRate: 1,
+		})
+	}
+	return samples
+}
+
+func (c *Converter) convertHistogram(samples []metrics.MetricSample, key, name string, tags []string, h *Histogram, now time.Time) []metrics.MetricSample {
+	if h.Schema < minSchema || h.Schema > maxSchema {
+		return samples
+	}
+
+	current := &histogramState{
+		schema:        h.Schema,
+		zeroThreshold: h.ZeroThreshold,
+		zeroCount:     h.ZeroCount,
+		count:         h.Count,
+		positive:      bucketCounts(h.PositiveSpans, h.PositiveDeltas, h.PositiveCounts),
+		negative:      bucketCounts(h.NegativeSpans, h.NegativeDeltas, h.NegativeCounts),
+	}
+
+	if h.ResetHint == ResetHintGauge {
+		return appendBuckets(samples, name, tags, current, nil)
+	}
+
+	state, found := c.series[key]
+	if !found || state.histogram == nil {
+		c.series[key] = &seriesState{lastSeen: now, timestamp: h.Timestamp, histogram: current}
+		return samples
+	}
+	if h.Timestamp <= state.timestamp {
+		return samples
+	}
+
+	previous := state.histogram
+	state.lastSeen, state.timestamp, state.histogram = now, h.Timestamp, current
+
+	// the buckets can't be compared when the resolution changes, wait for the next sample
+	if previous.schema != current.schema || previous.zeroThreshold != current.zeroThreshold {
+		return samples
+	}
+	if h.ResetHint == ResetHintYes || current.isReset(previous) {
+		return appendBuckets(samples, name, tags, current, nil)
+	}
+	return appendBuckets(samples, name, tags, current, previous)
+}
+
+// isReset returns true if a count of h is lower than in previous.
+func (h *histogramState) isReset(previous *histogramState) bool {
+	if h.count < previous.count || h.zeroCount < previous.zeroCount {
+		return true
+	}
+	for index, count := range previous.positive {
+		if h.positive[index] < count {
+			return true
+		}
+	}
+	for index, count := range previous.negative {
+		if h.negative[index] < count {
+			return true
+		}
+	}
+	return false
+}
+
+// bucketCounts returns the absolute count of each bucket index, from the deltas of an integer
+// histogram or from the counts of a float histogram.
+func bucketCounts(spans []BucketSpan, deltas []int64, counts []float64) map[int32]float64 {
+	buckets := map[int32]float64{}
+	var index int32
+	var current int64
+	i := 0
+	for _, span := range spans {
+		index += span.Offset
+		for j := uint32(0); j < span.Length; j++ {
+			if len(deltas) > 0 {
+				if i >= len(deltas) {
+					return buckets
+				}
+				current += deltas[i]
+				buckets[index] = float64(current)
+			} else {
+				if i >= len(counts) {
+					return buckets
+				}
+				buckets[index] = counts[i]
+			}
+			i++
+			index++
+		}
+	}
+	return buckets
+}
+
+// appendBuckets appends a distribution sample per bucket of h, weighted by its increase
+// since previous, or by its count if previous is nil.
+func appendBuckets(samples []metrics.MetricSample, name string, tags []string, h *histogramState, previous *histogramState) []metrics.MetricSample {
+	appendBucket := func(value, count float64) {
+		n := math.Round(count)
+		if n < 1 {
+			return
+		}
+		samples = append(samples, metrics.MetricSample{
+			Name:       name,
+			Value:      value,
+			Mtype:      metrics.DistributionType,
+			Tags:       tags,
+			SampleRate: sampleRateFor(n),
+		})
+	}
+
+	zeroCount := h.zeroCount
+	previousPositive, previousNegative := map[int32]float64(nil), map[int32]float64(nil)
+	if previous != nil {
+		zeroCount -= previous.zeroCount
+		previousPositive, previousNegative = previous.positive, previous.negative
+	}
+	appendBucket(0, zeroCount)
+
+	for _, index := range sortedIndexes(h.negative) {
+		appendBucket(-bucketValue(h.schema, index), h.negative[index]-previousNegative[index])
+	}
+	for _, index := range sortedIndexes(h.positive) {
+		appendBucket(bucketValue(h.schema, index), h.positive[index]-previousPositive[index])
+	}
+	return samples
+}
+
+func sortedIndexes(buckets map[int32]float64) []int32 {
+	indexes := make([]int32, 0, len(buckets))
+	for index := range buckets {
+		indexes = append(indexes, index)
+	}
+	sort.Slice(indexes, func(i, j int) bool { return indexes[i] < indexes[j] })
+	return indexes
+}
+
+// bucketValue returns the geometric middle of the bucket index of a schema, the bucket
+// covers the (base^(index-1), base^index] range with base = 2^(2^-schema).
+func bucketValue(schema int32, index int32) float64 {
+	return math.Exp2((float64(index) - 0.5) * math.Exp2(-float64(schema)))
+}
+
+// sampleRateFor returns the sample rate making the aggregator count a sample n times. As the
+// aggregator counts a sample int(1/sampleRate) times, the rate is lowered until the division
+// isn't rounded below n.
+func sampleRateFor(n float64) float64 {
+	rate := 1 / n
+	for 1/rate < n {
+		rate = math.Nextafter(rate, 0)
+	}
+	return rate
+}
@@ -0,0 +1,170 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package remotewrite
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+func series(name string, samples ...Sample) TimeSeries {
+	return TimeSeries{
+		Labels:  []Label{{Name: "job", Value: "web"}, {Name: "__name__", Value: name}, {Name: "empty", Value: ""}},
+		Samples: samples,
+	}
+}
+
+func TestConvertGauges(t *testing.T) {
+	c := NewConverter(time.Minute)
+	samples := c.Convert(&WriteRequest{
+		Timeseries: []TimeSeries{
+			series("memory_bytes", Sample{Value: 10, Timestamp: 1000}, Sample{Value: math.NaN(), Timestamp: 2000}),
+			{Labels: []Label{{Name: "job", Value: "nameless"}}, Samples: []Sample{{Value: 1, Timestamp: 1000}}},
+		},
+	}, nil)
+
+	assert.Equal(t, []metrics.MetricSample{
+		{Name: "memory_bytes", Value: 10, Mtype: metrics.GaugeType, Tags: []string{"job:web"}, SampleRate: 1},
+	}, samples)
+}
+
+func TestConvertCounters(t *testing.T) {
+	c := NewConverter(time.Minute)
+	convert := func(value float64, timestamp int64) []metrics.MetricSample {
+		return c.Convert(&WriteRequest{
+			Timeseries: []TimeSeries{series("http_requests_total", Sample{Value: value, Timestamp: timestamp})},
+		}, nil)
+	}
+
+	// the first sample is a reference
+	assert.Empty(t, convert(10, 1000))
+	assert.Equal(t, []metrics.MetricSample{
+		{Name: "http_requests_total", Value: 5, Mtype: metrics.CountType, Tags: []string{"job:web"}, SampleRate: 1},
+	}, convert(15, 2000))
+	// a retried request is ignored
+	assert.Empty(t, convert(15, 2000))
+	// after a reset, the new value is the increase
+	samples := convert(3, 3000)
+	require.Len(t, samples, 1)
+	assert.Equal(t, 3.0, samples[0].Value)
+	// a stale marker forgets the series
+	assert.Empty(t, convert(math.Float64frombits(0x7ff0000000000002), 4000))
+	assert.Empty(t, convert(8, 5000))
+}
+
+func TestConvertUsesMetadata(t *testing.T) {
+	c := NewConverter(time.Minute)
+	req := &WriteRequest{
+		Timeseries: []TimeSeries{
+			series("queue_count", Sample{Value: 3, Timestamp: 1000}),
+			series("jobs", Sample{Value: 3, Timestamp: 1000}),
+			series("latency_seconds_sum", Sample{Value: 3, Timestamp: 1000}),
+		},
+	}
+
+	// without metadata, queue_count looks like a counter
+	samples := c.Convert(req, nil)
+	require.Len(t, samples, 1)
+	assert.Equal(t, "jobs", samples[0].Name)
+	assert.Equal(t, metrics.GaugeType, samples[0].Mtype)
+
+	req.Metadata = []MetricMetadata{
+		{Type: MetricTypeGauge, MetricFamilyName: "queue_count"},
+		{Type: MetricTypeCounter, MetricFamilyName: "jobs"},
+		{Type: MetricTypeGaugeHistogram, MetricFamilyName: "latency_seconds"},
+	}
+	samples = c.Convert(req, nil)
+	require.Len(t, samples, 2)
+	assert.Equal(t, "queue_count", samples[0].Name)
+	assert.Equal(t, metrics.GaugeType, samples[0].Mtype)
+	assert.Equal(t, "latency_seconds_sum", samples[1].Name)
+	assert.Equal(t, metrics.GaugeType, samples[1].Mtype)
+}
+
+func TestConvertNativeHistograms(t *testing.T) {
+	c := NewConverter(time.Minute)
+	convert := func(h Histogram) []metrics.MetricSample {
+		return c.Convert(&WriteRequest{
+			Timeseries: []TimeSeries{{
+				Labels:     []Label{{Name: "__name__", Value: "latency_seconds"}},
+				Histograms: []Histogram{h},
+			}},
+		}, nil)
+	}
+
+	// buckets 0 and 1 of schema 0 cover (0.5, 1] and (1, 2]
+	assert.Empty(t, convert(Histogram{
+		Count:          4,
+		ZeroCount:      1,
+		PositiveSpans:  []BucketSpan{{Offset: 0, Length: 2}},
+		PositiveDeltas: []int64{2, -1},
+		Timestamp:      1000,
+	}))
+
+	samples := convert(Histogram{
+		Count:          10,
+		ZeroCount:      1,
+		PositiveSpans:  []BucketSpan{{Offset: 0, Length: 2}},
+		PositiveDeltas: []int64{3, 2},
+		NegativeSpans:  []BucketSpan{{Offset: 1, Length: 1}},
+		NegativeDeltas: []int64{1},
+		Timestamp:      2000,
+	})
+	require.Len(t, samples, 3)
+	for _, s := range samples {
+		assert.Equal(t, metrics.DistributionType, s.Mtype)
+		assert.Empty(t, s.Tags)
+	}
+	assert.InDelta(t, -math.Sqrt2, samples[0].Value, 1e-9)
+	assert.Equal(t, 1, int(1/samples[0].SampleRate))
+	assert.InDelta(t, math.Sqrt2/2, samples[1].Value, 1e-9)
+	assert.Equal(t, 1, int(1/samples[1].SampleRate))
+	assert.InDelta(t, math.Sqrt2, samples[2].Value, 1e-9)
+	assert.Equal(t, 4, int(1/samples[2].SampleRate))
+
+	// gauge histograms send their absolute counts
+	samples = c.Convert(&WriteRequest{
+		Timeseries: []TimeSeries{{
+			Labels: []Label{{Name: "__name__", Value: "queue_sizes"}},
+			Histograms: []Histogram{{
+				Count:          49,
+				Schema:         2,
+				PositiveSpans:  []BucketSpan{{Offset: 3, Length: 1}},
+				PositiveCounts: []float64{49},
+				ResetHint:      ResetHintGauge,
+				Timestamp:      1000,
+			}},
+		}},
+	}, nil)
+	require.Len(t, samples, 1)
+	assert.InDelta(t, math.Exp2(2.5/4), samples[0].Value, 1e-9)
+	assert.Equal(t, 49, int(1/samples[0].SampleRate))
+}
+
+func TestSampleRateFor(t *testing.T) {
+	for n := 1; n < 100000; n++ {
+		rate := sampleRateFor(float64(n))
+		require.Equal(t, n, int(1/rate), "n=%d", n)
+	}
+}
+
+func TestConverterExpiry(t *testing.T) {
+	c := NewConverter(10 * time.Millisecond)
+	c.Convert(&WriteRequest{
+		Timeseries: []TimeSeries{series("http_requests_total", Sample{Value: 10, Timestamp: 1000})},
+	}, nil)
+	assert.Len(t, c.series, 1)
+
+	time.Sleep(20 * time.Millisecond)
+	c.Convert(&WriteRequest{}, nil)
+	assert.Empty(t, c.series)
+}
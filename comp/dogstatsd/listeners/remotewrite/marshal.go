@@ -0,0 +1,129 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package remotewrite
+
+import (
+	"math"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Marshal encodes req into an uncompressed remote-write protobuf message. The histograms
+// holding absolute bucket counts are encoded as float histograms, the others as integer
+// histograms.
+func Marshal(req *WriteRequest) []byte {
+	var b []byte
+	for i := range req.Timeseries {
+		b = appendMessage(b, 1, req.Timeseries[i].marshal())
+	}
+	for _, md := range req.Metadata {
+		var m []byte
+		m = appendVarint(m, 1, uint64(md.Type))
+		m = appendString(m, 2, md.MetricFamilyName)
+		b = appendMessage(b, 3, m)
+	}
+	return b
+}
+
+func (ts *TimeSeries) marshal() []byte {
+	var b []byte
+	for _, l := range ts.Labels {
+		var m []byte
+		m = appendString(m, 1, l.Name)
+		m = appendString(m, 2, l.Value)
+		b = appendMessage(b, 1, m)
+	}
+	for _, s := range ts.Samples {
+		var m []byte
+		m = appendDouble(m, 1, s.Value)
+		m = appendVarint(m, 2, uint64(s.Timestamp))
+		b = appendMessage(b, 2, m)
+	}
+	for i := range ts.Histograms {
+		b = appendMessage(b, 4, ts.Histograms[i].marshal())
+	}
+	return b
+}
+
+func (h *Histogram) marshal() []byte {
+	float := len(h.PositiveCounts) > 0 || len(h.NegativeCounts) > 0
+
+	var b []byte
+	if float {
+		b = appendDouble(b, 2, h.Count)
+	} else {
+		b = appendVarint(b, 1, uint64(h.Count))
+	}
+	b = appendDouble(b, 3, h.Sum)
+	b = appendVarint(b, 4, protowire.EncodeZigZag(int64(h.Schema)))
+	b = appendDouble(b, 5, h.ZeroThreshold)
+	if float {
+		b = appendDouble(b, 7, h.ZeroCount)
+	} else {
+		b = appendVarint(b, 6, uint64(h.ZeroCount))
+	}
+	b = appendSpans(b, 8, h.NegativeSpans)
+	b = appendPackedSint64s(b, 9, h.NegativeDeltas)
+	b = appendPackedDoubles(b, 10, h.NegativeCounts)
+	b = appendSpans(b, 11, h.PositiveSpans)
+	b = appendPackedSint64s(b, 12, h.PositiveDeltas)
+	b = appendPackedDoubles(b, 13, h.PositiveCounts)
+	b = appendVarint(b, 14, uint64(h.ResetHint))
+	b = appendVarint(b, 15, uint64(h.Timestamp))
+	return b
+}
+
+func appendSpans(b []byte, num protowire.Number, spans []BucketSpan) []byte {
+	for _, span := range spans {
+		var m []byte
+		m = appendVarint(m, 1, protowire.EncodeZigZag(int64(span.Offset)))
+		m = appendVarint(m, 2, uint64(span.Length))
+		b = appendMessage(b, num, m)
+	}
+	return b
+}
+
+func appendPackedSint64s(b []byte, num protowire.Number, values []int64) []byte {
+	if len(values) == 0 {
+		return b
+	}
+	var m []byte
+	for _, v := range values {
+		m = protowire.AppendVarint(m, protowire.EncodeZigZag(v))
+	}
+	return appendMessage(b, num, m)
+}
+
+func appendPackedDoubles(b []byte, num protowire.Number, values []float64) []byte {
+	if len(values) == 0 {
+		return b
+	}
+	var m []byte
+	for _, v := range values {
+		m = protowire.AppendFixed64(m, math.Float64bits(v))
+	}
+	return appendMessage(b, num, m)
+}
+
+func appendMessage(b []byte, num protowire.Number, m []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, m)
+}
+
+func appendString(b []byte, num protowire.Number, s string) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, s)
+}
+
+func appendVarint(b []byte, num protowire.Number, v uint64) []byte {
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, v)
+}
+
+func appendDouble(b []byte, num protowire.Number, v float64) []byte {
+	b = protowire.AppendTag(b, num, protowire.Fixed64Type)
+	return protowire.AppendFixed64(b, math.Float64bits(v))
+}
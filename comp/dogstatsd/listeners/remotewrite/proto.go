@@ -0,0 +1,331 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package remotewrite decodes the Prometheus remote-write requests and converts
+// their series into metric samples.
+package remotewrite
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// MetricType is the type of a metric family, as sent in the remote-write metadata.
+type MetricType int32
+
+// Metric family types
+const (
+	MetricTypeUnknown MetricType = iota
+	MetricTypeCounter
+	MetricTypeGauge
+	MetricTypeHistogram
+	MetricTypeGaugeHistogram
+	MetricTypeSummary
+	MetricTypeInfo
+	MetricTypeStateset
+)
+
+// ResetHint tells whether a native histogram is a counter which has been reset,
+// or a gauge histogram.
+type ResetHint int32
+
+// Native histogram reset hints
+const (
+	ResetHintUnknown ResetHint = iota
+	ResetHintYes
+	ResetHintNo
+	ResetHintGauge
+)
+
+// WriteRequest is a remote-write request.
+type WriteRequest struct {
+	Timeseries []TimeSeries
+	Metadata   []MetricMetadata
+}
+
+// TimeSeries holds the samples and the native histograms of a series.
+type TimeSeries struct {
+	Labels     []Label
+	Samples    []Sample
+	Histograms []Histogram
+}
+
+// Label is a label of a series, the metric name is held by the __name__ label.
+type Label struct {
+	Name  string
+	Value string
+}
+
+// Sample is a float sample, its timestamp is in milliseconds.
+type Sample struct {
+	Value     float64
+	Timestamp int64
+}
+
+// MetricMetadata holds the type of a metric family.
+type MetricMetadata struct {
+	Type             MetricType
+	MetricFamilyName string
+}
+
+// BucketSpan is a run of consecutive buckets of a native histogram, Offset is
+// the gap with the previous span, or the index of the first bucket for the first span.
+type BucketSpan struct {
+	Offset int32
+	Length uint32
+}
+
+// Histogram is a native histogram sample. The integer counts are converted to
+// floats; the bucket counts are either deltas between consecutive buckets
+// (integer histograms) or absolute counts (float histograms).
+type Histogram struct {
+	Count          float64
+	Sum            float64
+	Schema         int32
+	ZeroThreshold  float64
+	ZeroCount      float64
+	NegativeSpans  []BucketSpan
+	NegativeDeltas []int64
+	NegativeCounts []float64
+	PositiveSpans  []BucketSpan
+	PositiveDeltas []int64
+	PositiveCounts []float64
+	ResetHint      ResetHint
+	Timestamp      int64
+}
+
+var errTruncated = errors.New("truncated message")
+
+// Unmarshal decodes an uncompressed remote-write protobuf message. The unknown fields,
+// like the exemplars, are skipped.
+func Unmarshal(data []byte) (*WriteRequest, error) {
+	req := &WriteRequest{}
+	err := forEachField(data, func(num protowire.Number, typ protowire.Type, v []byte, _ uint64) error {
+		switch {
+		case num == 1 && typ == protowire.BytesType:
+			var ts TimeSeries
+			if err := ts.unmarshal(v); err != nil {
+				return fmt.Errorf("invalid time series: %w", err)
+			}
+			req.Timeseries = append(req.Timeseries, ts)
+		case num == 3 && typ == protowire.BytesType:
+			var md MetricMetadata
+			if err := md.unmarshal(v); err != nil {
+				return fmt.Errorf("invalid metadata: %w", err)
+			}
+			req.Metadata = append(req.Metadata, md)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+func (ts *TimeSeries) unmarshal(data []byte) error {
+	return forEachField(data, func(num protowire.Number, typ protowire.Type, v []byte, _ uint64) error {
+		if typ != protowire.BytesType {
+			return nil
+		}
+		switch num {
+		case 1:
+			var l Label
+			if err := l.unmarshal(v); err != nil {
+				return err
+			}
+			ts.Labels = append(ts.Labels, l)
+		case 2:
+			var s Sample
+			if err := s.unmarshal(v); err != nil {
+				return err
+			}
+			ts.Samples = append(ts.Samples, s)
+		case 4:
+			var h Histogram
+			if err := h.unmarshal(v); err != nil {
+				return err
+			}
+			ts.Histograms = append(ts.Histograms, h)
+		}
+		return nil
+	})
+}
+
+func (l *Label) unmarshal(data []byte) error {
+	return forEachField(data, func(num protowire.Number, typ protowire.Type, v []byte, _ uint64) error {
+		if typ != protowire.BytesType {
+			return nil
+		}
+		switch num {
+		case 1:
+			l.Name = string(v)
+		case 2:
+			l.Value = string(v)
+		}
+		return nil
+	})
+}
+
+func (s *Sample) unmarshal(data []byte) error {
+	return forEachField(data, func(num protowire.Number, typ protowire.Type, _ []byte, x uint64) error {
+		switch {
+		case num == 1 && typ == protowire.Fixed64Type:
+			s.Value = math.Float64frombits(x)
+		case num == 2 && typ == protowire.VarintType:
+			s.Timestamp = int64(x)
+		}
+		return nil
+	})
+}
+
+func (md *MetricMetadata) unmarshal(data []byte) error {
+	return forEachField(data, func(num protowire.Number, typ protowire.Type, v []byte, x uint64) error {
+		switch {
+		case num == 1 && typ == protowire.VarintType:
+			md.Type = MetricType(x)
+		case num == 2 && typ == protowire.BytesType:
+			md.MetricFamilyName = string(v)
+		}
+		return nil
+	})
+}
+
+func (s *BucketSpan) unmarshal(data []byte) error {
+	return forEachField(data, func(num protowire.Number, typ protowire.Type, _ []byte, x uint64) error {
+		if typ != protowire.VarintType {
+			return nil
+		}
+		switch num {
+		case 1:
+			s.Offset = int32(protowire.DecodeZigZag(x))
+		case 2:
+			s.Length = uint32(x)
+		}
+		return nil
+	})
+}
+
+func (h *Histogram) unmarshal(data []byte) error {
+	return forEachField(data, func(num protowire.Number, typ protowire.Type, v []byte, x uint64) error {
+		var err error
+		switch num {
+		case 1:
+			h.Count = float64(x)
+		case 2:
+			h.Count = math.Float64frombits(x)
+		case 3:
+			h.Sum = math.Float64frombits(x)
+		case 4:
+			h.Schema = int32(protowire.DecodeZigZag(x))
+		case 5:
+			h.ZeroThreshold = math.Float64frombits(x)
+		case 6:
+			h.ZeroCount = float64(x)
+		case 7:
+			h.ZeroCount = math.Float64frombits(x)
+		case 8, 11:
+			var span BucketSpan
+			if typ == protowire.BytesType {
+				err = span.unmarshal(v)
+			}
+			if num == 8 {
+				h.NegativeSpans = append(h.NegativeSpans, span)
+			} else {
+				h.PositiveSpans = append(h.PositiveSpans, span)
+			}
+		case 9:
+			h.NegativeDeltas, err = appendSint64s(h.NegativeDeltas, typ, v, x)
+		case 10:
+			h.NegativeCounts, err = appendDoubles(h.NegativeCounts, typ, v, x)
+		case 12:
+			h.PositiveDeltas, err = appendSint64s(h.PositiveDeltas, typ, v, x)
+		case 13:
+			h.PositiveCounts, err = appendDoubles(h.PositiveCounts, typ, v, x)
+		case 14:
+			h.ResetHint = ResetHint(x)
+		case 15:
+			h.Timestamp = int64(x)
+		}
+		return err
+	})
+}
+
+// appendSint64s appends a packed or a single repeated sint64 field to values.
+func appendSint64s(values []int64, typ protowire.Type, v []byte, x uint64) ([]int64, error) {
+	if typ != protowire.BytesType {
+		return append(values, protowire.DecodeZigZag(x)), nil
+	}
+	for len(v) > 0 {
+		x, n := protowire.ConsumeVarint(v)
+		if n < 0 {
+			return values, errTruncated
+		}
+		values = append(values, protowire.DecodeZigZag(x))
+		v = v[n:]
+	}
+	return values, nil
+}
+
+// appendDoubles appends a packed or a single repeated double field to values.
+func appendDoubles(values []float64, typ protowire.Type, v []byte, x uint64) ([]float64, error) {
+	if typ != protowire.BytesType {
+		return append(values, math.Float64frombits(x)), nil
+	}
+	for len(v) > 0 {
+		x, n := protowire.ConsumeFixed64(v)
+		if n < 0 {
+			return values, errTruncated
+		}
+		values = append(values, math.Float64frombits(x))
+		v = v[n:]
+	}
+	return values, nil
+}
+
+// forEachField calls fn with every field of a protobuf message: v holds the content of the
+// length-delimited fields and x the value of the numeric ones. The groups are skipped.
+func forEachField(data []byte, fn func(num protowire.Number, typ protowire.Type, v []byte, x uint64) error) error {
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return errTruncated
+		}
+		data = data[n:]
+
+		var v []byte
+		var x uint64
+		switch typ {
+		case protowire.VarintType:
+			x, n = protowire.ConsumeVarint(data)
+		case protowire.Fixed64Type:
+			x, n = protowire.ConsumeFixed64(data)
+		case protowire.Fixed32Type:
+			var x32 uint32
+			x32, n = protowire.ConsumeFixed32(data)
+			x = uint64(x32)
+		case protowire.BytesType:
+			v, n = protowire.ConsumeBytes(data)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, data)
+			if n >= 0 {
+				data = data[n:]
+				continue
+			}
+		}
+		if n < 0 {
+			return errTruncated
+		}
+		data = data[n:]
+
+		if err := fn(num, typ, v, x); err != nil {
+			return err
+		}
+	}
+	return nil
+}
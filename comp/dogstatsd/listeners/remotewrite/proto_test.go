@@ -0,0 +1,77 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package remotewrite
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarshalUnmarshal(t *testing.T) {
+	req := &WriteRequest{
+		Timeseries: []TimeSeries{
+			{
+				Labels:  []Label{{Name: "__name__", Value: "http_requests_total"}, {Name: "code", Value: "200"}},
+				Samples: []Sample{{Value: 12, Timestamp: 1000}, {Value: 15.5, Timestamp: 2000}},
+			},
+			{
+				Labels: []Label{{Name: "__name__", Value: "request_duration_seconds"}},
+				Histograms: []Histogram{
+					{
+						Count:          6,
+						Sum:            1.5,
+						Schema:         -1,
+						ZeroThreshold:  0.001,
+						ZeroCount:      1,
+						PositiveSpans:  []BucketSpan{{Offset: -2, Length: 2}, {Offset: 1, Length: 1}},
+						PositiveDeltas: []int64{2, -1, 1},
+						NegativeSpans:  []BucketSpan{{Offset: 0, Length: 1}},
+						NegativeDeltas: []int64{1},
+						ResetHint:      ResetHintNo,
+						Timestamp:      3000,
+					},
+					{
+						Count:          2.5,
+						Schema:         3,
+						PositiveSpans:  []BucketSpan{{Offset: 4, Length: 2}},
+						PositiveCounts: []float64{1.5, 1},
+						ResetHint:      ResetHintGauge,
+						Timestamp:      4000,
+					},
+				},
+			},
+		},
+		Metadata: []MetricMetadata{
+			{Type: MetricTypeCounter, MetricFamilyName: "http_requests"},
+			{Type: MetricTypeHistogram, MetricFamilyName: "request_duration_seconds"},
+		},
+	}
+
+	decoded, err := Unmarshal(Marshal(req))
+	require.NoError(t, err)
+	assert.Equal(t, req, decoded)
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	data := Marshal(&WriteRequest{
+		Timeseries: []TimeSeries{{
+			Labels:  []Label{{Name: "__name__", Value: "up"}},
+			Samples: []Sample{{Value: 1, Timestamp: 1000}},
+		}},
+	})
+
+	_, err := Unmarshal(data[:len(data)-3])
+	assert.Error(t, err)
+
+	_, err = Unmarshal([]byte("not a protobuf message"))
+	assert.Error(t, err)
+
+	req, err := Unmarshal(nil)
+	require.NoError(t, err)
+	assert.Empty(t, req.Timeseries)
+}
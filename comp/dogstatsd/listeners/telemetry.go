@@ -24,6 +24,16 @@ var (
 	tlmUDSPacketsBytes = telemetry.NewCounter("dogstatsd", "uds_packets_bytes",
 		nil, "Dogstatsd UDS packets bytes")
 
+	// Graphite
+	tlmGraphitePackets = telemetry.NewCounter("dogstatsd", "graphite_packets",
+		[]string{"transport", "state"}, "Dogstatsd Graphite lines and datagrams count")
+
+	// Prometheus remote-write
+	tlmRemoteWriteRequests = telemetry.NewCounter("dogstatsd", "remote_write_requests",
+		[]string{"state"}, "Dogstatsd Prometheus remote-write requests count")
+	tlmRemoteWriteSamples = telemetry.NewCounter("dogstatsd", "remote_write_samples",
+		nil, "Dogstatsd metric samples converted from Prometheus remote-write requests")
+
 	tlmListener            = telemetry.NewHistogramNoOp()
 	defaultListenerBuckets = []float64{300, 500, 1000, 1500, 2000, 2500, 3000, 10000, 20000, 50000}
 )
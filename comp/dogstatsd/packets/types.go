@@ -15,6 +15,8 @@ const (
 	UDS
 	// NamedPipe Windows named pipe listner
 	NamedPipe
+	// Graphite plaintext protocol listener
+	Graphite
 )
 
 // Packet represents a statsd packet ready to process,
@@ -0,0 +1,150 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package replay
+
+import (
+	"bytes"
+	"regexp"
+
+	pb "github.com/DataDog/datadog-agent/pkg/proto/pbgo/core"
+)
+
+var (
+	eventPrefix        = []byte("_e{")
+	serviceCheckPrefix = []byte("_sc|")
+	fieldSeparator     = []byte("|")
+	tagSeparator       = []byte(",")
+)
+
+// messageType is the type of a DogStatsD message found in a captured payload
+type messageType int
+
+const (
+	metricMessage messageType = iota
+	eventMessage
+	serviceCheckMessage
+)
+
+// TrafficFilter selects the captured messages to replay or summarize. The zero value
+// keeps all the messages.
+type TrafficFilter struct {
+	// MetricName only keeps the metrics with a name matching it, the events and the
+	// service checks are dropped when it is set.
+	MetricName *regexp.Regexp
+	// Tags only keeps the messages holding all these tags.
+	Tags []string
+	// Pids only keeps the packets sent by these processes.
+	Pids []int32
+}
+
+// IsEmpty returns whether the filter keeps all the messages.
+func (f *TrafficFilter) IsEmpty() bool {
+	return f == nil || (f.MetricName == nil && len(f.Tags) == 0 && len(f.Pids) == 0)
+}
+
+// Filter returns the payload of the captured packet holding only the matching messages,
+// or nil if none of them matches.
+func (f *TrafficFilter) Filter(msg *pb.UnixDogstatsdMsg) []byte {
+	payload := msg.Payload[:msg.PayloadSize]
+	if f.IsEmpty() {
+		return payload
+	}
+	if !f.matchPid(msg.Pid) {
+		return nil
+	}
+	if f.MetricName == nil && len(f.Tags) == 0 {
+		return payload
+	}
+
+	var filtered []byte
+	forEachMessage(payload, func(message []byte) {
+		if !f.matchMessage(message) {
+			return
+		}
+		if filtered != nil {
+			filtered = append(filtered, '\n')
+		}
+		filtered = append(filtered, message...)
+	})
+	return filtered
+}
+
+func (f *TrafficFilter) matchPid(pid int32) bool {
+	if len(f.Pids) == 0 {
+		return true
+	}
+	for _, p := range f.Pids {
+		if p == pid {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *TrafficFilter) matchMessage(message []byte) bool {
+	msgType, name, tags := parseMessage(message)
+	if f.MetricName != nil && (msgType != metricMessage || !f.MetricName.Match(name)) {
+		return false
+	}
+	for _, tag := range f.Tags {
+		if !containsTag(tags, tag) {
+			return false
+		}
+	}
+	return true
+}
+
+func containsTag(tags [][]byte, tag string) bool {
+	for _, t := range tags {
+		if string(t) == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// forEachMessage calls fn on each non-empty message of a DogStatsD payload.
+func forEachMessage(payload []byte, fn func(message []byte)) {
+	for len(payload) > 0 {
+		var message []byte
+		message, payload, _ = bytes.Cut(payload, []byte("\n"))
+		message = bytes.TrimSuffix(message, []byte("\r"))
+		if len(message) > 0 {
+			fn(message)
+		}
+	}
+}
+
+// parseMessage returns the type, the metric name and the tags of a DogStatsD message.
+// It only does what the filters and the summary need, invalid messages are not reported:
+// they are rejected by the server anyway. The name is only returned for the metrics.
+func parseMessage(message []byte) (messageType, []byte, [][]byte) {
+	msgType := metricMessage
+	if bytes.HasPrefix(message, eventPrefix) {
+		msgType = eventMessage
+	} else if bytes.HasPrefix(message, serviceCheckPrefix) {
+		msgType = serviceCheckMessage
+	}
+
+	var name []byte
+	if msgType == metricMessage {
+		name, _, _ = bytes.Cut(message, []byte(":"))
+	}
+
+	var tags [][]byte
+	// the first field is never the tags, it holds the name and the value of the metric,
+	// or the header of the event and service check
+	_, fields, _ := bytes.Cut(message, fieldSeparator)
+	for len(fields) > 0 {
+		var field []byte
+		field, fields, _ = bytes.Cut(fields, fieldSeparator)
+		if len(field) > 0 && field[0] == '#' {
+			tags = bytes.Split(field[1:], tagSeparator)
+			break
+		}
+	}
+	return msgType, name, tags
+}
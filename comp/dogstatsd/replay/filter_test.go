@@ -0,0 +1,69 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package replay
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	pb "github.com/DataDog/datadog-agent/pkg/proto/pbgo/core"
+)
+
+func newCapturedMsg(pid int32, payload string) *pb.UnixDogstatsdMsg {
+	return &pb.UnixDogstatsdMsg{
+		Pid:         pid,
+		Payload:     []byte(payload),
+		PayloadSize: int32(len(payload)),
+	}
+}
+
+const testPayload = "web.requests:1|c|#env:prod,service:web\n" +
+	"web.latency:12|h|@0.5|#env:staging,service:web\n" +
+	"db.queries:3|c\n" +
+	"_e{5,4}:title|text|#env:prod\n" +
+	"_sc|db.check|0|#env:prod,service:db"
+
+func TestFilterEmpty(t *testing.T) {
+	msg := newCapturedMsg(42, testPayload)
+
+	var nilFilter *TrafficFilter
+	assert.Equal(t, []byte(testPayload), nilFilter.Filter(msg))
+	assert.Equal(t, []byte(testPayload), (&TrafficFilter{}).Filter(msg))
+}
+
+func TestFilterMetricName(t *testing.T) {
+	filter := &TrafficFilter{MetricName: regexp.MustCompile(`^web\.`)}
+
+	assert.Equal(t, "web.requests:1|c|#env:prod,service:web\nweb.latency:12|h|@0.5|#env:staging,service:web",
+		string(filter.Filter(newCapturedMsg(42, testPayload))))
+	assert.Nil(t, filter.Filter(newCapturedMsg(42, "db.queries:3|c")))
+}
+
+func TestFilterTags(t *testing.T) {
+	filter := &TrafficFilter{Tags: []string{"env:prod"}}
+	assert.Equal(t, "web.requests:1|c|#env:prod,service:web\n_e{5,4}:title|text|#env:prod\n_sc|db.check|0|#env:prod,service:db",
+		string(filter.Filter(newCapturedMsg(42, testPayload))))
+
+	// all the tags must match
+	filter = &TrafficFilter{Tags: []string{"env:prod", "service:db"}}
+	assert.Equal(t, "_sc|db.check|0|#env:prod,service:db", string(filter.Filter(newCapturedMsg(42, testPayload))))
+
+	// the tags are only read in the tags field
+	filter = &TrafficFilter{Tags: []string{"c"}}
+	assert.Nil(t, filter.Filter(newCapturedMsg(42, "db.queries:3|c")))
+}
+
+func TestFilterPids(t *testing.T) {
+	filter := &TrafficFilter{Pids: []int32{1, 42}}
+	assert.Equal(t, []byte(testPayload), filter.Filter(newCapturedMsg(42, testPayload)))
+	assert.Nil(t, filter.Filter(newCapturedMsg(43, testPayload)))
+
+	filter.MetricName = regexp.MustCompile(`^db\.`)
+	assert.Equal(t, "db.queries:3|c", string(filter.Filter(newCapturedMsg(1, testPayload))))
+	assert.Nil(t, filter.Filter(newCapturedMsg(43, testPayload)))
+}
@@ -32,6 +32,7 @@ type TrafficCaptureReader struct {
 	fuse        chan struct{}
 	offset      uint32
 	mmap        bool
+	speed       float64
 
 	sync.Mutex
 }
@@ -77,9 +78,20 @@ func NewTrafficCaptureReader(path string, depth int, mmap bool) (*TrafficCapture
 		Version:     ver,
 		Traffic:     make(chan *pb.UnixDogstatsdMsg, depth),
 		mmap:        mmap,
+		speed:       1,
 	}, nil
 }
 
+// SetSpeed sets the replay speed multiplier applied to the cadence of the capture, a
+// speed of 2 replays the packets twice as fast as they were captured. A speed of 0
+// replays them as fast as possible. Should be called before Read.
+func (tc *TrafficCaptureReader) SetSpeed(speed float64) {
+	tc.Lock()
+	defer tc.Unlock()
+
+	tc.speed = speed
+}
+
 // Read reads the contents of the traffic capture and writes each packet to a channel
 func (tc *TrafficCaptureReader) Read(ready chan struct{}) {
 	tc.Lock()
@@ -98,6 +110,7 @@ func (tc *TrafficCaptureReader) Read(ready chan struct{}) {
 	} else {
 		tsResolution = time.Nanosecond
 	}
+	speed := tc.speed
 	tc.Unlock()
 
 	last := int64(0)
@@ -118,9 +131,9 @@ func (tc *TrafficCaptureReader) Read(ready chan struct{}) {
 			break
 		}
 
-		if last != 0 {
+		if last != 0 && speed > 0 {
 			if msg.Timestamp > last {
-				util.Wait(tsResolution * time.Duration(msg.Timestamp-last))
+				util.Wait(time.Duration(float64(tsResolution*time.Duration(msg.Timestamp-last)) / speed))
 			}
 		}
 
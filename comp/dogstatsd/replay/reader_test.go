@@ -8,8 +8,10 @@ package replay
 import (
 	"io"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func readerTest(t *testing.T, path string, mmap bool) {
@@ -91,3 +93,24 @@ func TestSeek(t *testing.T) {
 	assert.Equal(t, cnt*i, total)
 
 }
+
+func TestReadFullSpeed(t *testing.T) {
+	tc, err := NewTrafficCaptureReader("resources/test/datadog-capture.dog", 32, false)
+	require.NoError(t, err)
+	defer tc.Close()
+
+	// the capture lasts 13 seconds, it is replayed without waiting between the packets
+	tc.SetSpeed(0)
+	ready := make(chan struct{})
+	start := time.Now()
+	go tc.Read(ready)
+	<-ready
+
+	select {
+	case <-tc.Done:
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "the capture should have been replayed as fast as possible")
+	}
+	assert.Less(t, time.Since(start), 5*time.Second)
+	assert.Len(t, tc.Traffic, 21)
+}
@@ -0,0 +1,250 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package replay
+
+import (
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MetricSummary holds the statistics of a metric found in a capture
+type MetricSummary struct {
+	Name     string
+	Samples  int
+	Contexts int
+}
+
+type metricStats struct {
+	samples  int
+	contexts map[string]struct{}
+}
+
+// CaptureSummary holds the statistics of the traffic found in a capture
+type CaptureSummary struct {
+	Packets       int
+	Bytes         int
+	Samples       int
+	Events        int
+	ServiceChecks int
+	// Duration is the time between the first and the last captured packets
+	Duration time.Duration
+	// PacketsByPid is the number of packets sent by each process
+	PacketsByPid map[int32]int
+
+	metrics map[string]*metricStats
+}
+
+// SummarizeCapture reads all the packets of a capture and returns its statistics,
+// only the messages kept by the filter are taken into account. The packets are read
+// from the start of the capture, the reader must not be replaying at the same time.
+func SummarizeCapture(tc *TrafficCaptureReader, filter *TrafficFilter) (*CaptureSummary, error) {
+	summary := &CaptureSummary{
+		PacketsByPid: map[int32]int{},
+		metrics:      map[string]*metricStats{},
+	}
+
+	tsResolution := time.Nanosecond
+	if tc.Version < minNanoVersion {
+		tsResolution = time.Second
+	}
+
+	tc.Seek(0)
+	var first, last int64
+	for {
+		msg, err := tc.ReadNext()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		payload := filter.Filter(msg)
+		if len(payload) == 0 {
+			continue
+		}
+
+		if summary.Packets == 0 {
+			first = msg.Timestamp
+		}
+		last = msg.Timestamp
+		summary.Packets++
+		summary.Bytes += len(payload)
+		summary.PacketsByPid[msg.Pid]++
+
+		forEachMessage(payload, summary.addMessage)
+	}
+	summary.Duration = tsResolution * time.Duration(last-first)
+
+	return summary, nil
+}
+
+func (s *CaptureSummary) addMessage(message []byte) {
+	msgType, name, tags := parseMessage(message)
+	switch msgType {
+	case eventMessage:
+		s.Events++
+		return
+	case serviceCheckMessage:
+		s.ServiceChecks++
+		return
+	}
+
+	s.Samples++
+	stats, found := s.metrics[string(name)]
+	if !found {
+		stats = &metricStats{contexts: map[string]struct{}{}}
+		s.metrics[string(name)] = stats
+	}
+	stats.samples++
+
+	// the order of the tags doesn't change the context
+	sortedTags := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		sortedTags = append(sortedTags, string(tag))
+	}
+	sort.Strings(sortedTags)
+	stats.contexts[strings.Join(sortedTags, ",")] = struct{}{}
+}
+
+// Metrics returns the number of distinct metric names
+func (s *CaptureSummary) Metrics() int {
+	return len(s.metrics)
+}
+
+// Contexts returns the number of distinct contexts, a context being a metric name and a set of tags
+func (s *CaptureSummary) Contexts() int {
+	contexts := 0
+	for _, stats := range s.metrics {
+		contexts += len(stats.contexts)
+	}
+	return contexts
+}
+
+// TopMetricsByContexts returns the n metrics with the most contexts
+func (s *CaptureSummary) TopMetricsByContexts(n int) []MetricSummary {
+	return s.topMetrics(n, func(a, b MetricSummary) bool {
+		if a.Contexts != b.Contexts {
+			return a.Contexts > b.Contexts
+		}
+		return a.Samples > b.Samples
+	})
+}
+
+// TopMetricsBySamples returns the n metrics with the most samples
+func (s *CaptureSummary) TopMetricsBySamples(n int) []MetricSummary {
+	return s.topMetrics(n, func(a, b MetricSummary) bool {
+		if a.Samples != b.Samples {
+			return a.Samples > b.Samples
+		}
+		return a.Contexts > b.Contexts
+	})
+}
+
+func (s *CaptureSummary) topMetrics(n int, less func(a, b MetricSummary) bool) []MetricSummary {
+	metrics := make([]MetricSummary, 0, len(s.metrics))
+	for name, stats := range s.metrics {
+		metrics = append(metrics, MetricSummary{
+			Name:     name,
+			Samples:  stats.samples,
+			Contexts: len(stats.contexts),
+		})
+	}
+	sort.Slice(metrics, func(i, j int) bool {
+		if less(metrics[i], metrics[j]) {
+			return true
+		}
+		if less(metrics[j], metrics[i]) {
+			return false
+		}
+		// stable output for the ties
+		return metrics[i].Name < metrics[j].Name
+	})
+
+	if n > 0 && len(metrics) > n {
+		metrics = metrics[:n]
+	}
+	return metrics
+}
+
+// MetricDiff holds the differences of a metric between two captures, the statistics
+// of a metric missing from a capture are zero.
+type MetricDiff struct {
+	Name           string
+	SamplesBefore  int
+	SamplesAfter   int
+	ContextsBefore int
+	ContextsAfter  int
+	// NewContexts is the number of contexts only found in the after capture
+	NewContexts int
+	// RemovedContexts is the number of contexts only found in the before capture
+	RemovedContexts int
+}
+
+// DiffSummaries returns the metrics whose contexts or number of samples differ between
+// the before and after captures, the metrics with the most changed contexts first.
+func DiffSummaries(before, after *CaptureSummary) []MetricDiff {
+	var diffs []MetricDiff
+	add := func(name string, b, a *metricStats) {
+		diff := MetricDiff{Name: name}
+		if b != nil {
+			diff.SamplesBefore = b.samples
+			diff.ContextsBefore = len(b.contexts)
+		}
+		if a != nil {
+			diff.SamplesAfter = a.samples
+			diff.ContextsAfter = len(a.contexts)
+			for context := range a.contexts {
+				if b == nil {
+					diff.NewContexts++
+				} else if _, found := b.contexts[context]; !found {
+					diff.NewContexts++
+				}
+			}
+		}
+		if b != nil {
+			for context := range b.contexts {
+				if a == nil {
+					diff.RemovedContexts++
+				} else if _, found := a.contexts[context]; !found {
+					diff.RemovedContexts++
+				}
+			}
+		}
+		if diff.NewContexts > 0 || diff.RemovedContexts > 0 || diff.SamplesBefore != diff.SamplesAfter {
+			diffs = append(diffs, diff)
+		}
+	}
+	for name, stats := range before.metrics {
+		add(name, stats, after.metrics[name])
+	}
+	for name, stats := range after.metrics {
+		if _, found := before.metrics[name]; !found {
+			add(name, nil, stats)
+		}
+	}
+
+	abs := func(n int) int {
+		if n < 0 {
+			return -n
+		}
+		return n
+	}
+	sort.Slice(diffs, func(i, j int) bool {
+		ci, cj := diffs[i].NewContexts+diffs[i].RemovedContexts, diffs[j].NewContexts+diffs[j].RemovedContexts
+		if ci != cj {
+			return ci > cj
+		}
+		si, sj := abs(diffs[i].SamplesAfter-diffs[i].SamplesBefore), abs(diffs[j].SamplesAfter-diffs[j].SamplesBefore)
+		if si != sj {
+			return si > sj
+		}
+		// stable output for the ties
+		return diffs[i].Name < diffs[j].Name
+	})
+	return diffs
+}
@@ -0,0 +1,118 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package replay
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	pb "github.com/DataDog/datadog-agent/pkg/proto/pbgo/core"
+)
+
+// newTestCaptureReader returns a reader of a capture holding the messages, without state
+func newTestCaptureReader(t *testing.T, msgs ...*pb.UnixDogstatsdMsg) *TrafficCaptureReader {
+	var buf bytes.Buffer
+	require.NoError(t, WriteHeader(&buf))
+	for _, msg := range msgs {
+		raw, err := proto.Marshal(msg)
+		require.NoError(t, err)
+		require.NoError(t, binary.Write(&buf, binary.LittleEndian, uint32(len(raw))))
+		buf.Write(raw)
+	}
+	// empty state
+	require.NoError(t, binary.Write(&buf, binary.LittleEndian, uint32(0)))
+
+	return &TrafficCaptureReader{
+		Contents: buf.Bytes(),
+		Version:  int(datadogFileVersion),
+	}
+}
+
+func TestSummarizeCapture(t *testing.T) {
+	first := newCapturedMsg(42, testPayload)
+	first.Timestamp = time.Unix(1700000000, 0).UnixNano()
+	second := newCapturedMsg(43, "web.requests:1|c|#service:web,env:prod\nweb.requests:1|c|#env:dev\nweb.requests:1|c")
+	second.Timestamp = time.Unix(1700000030, 0).UnixNano()
+
+	tc := newTestCaptureReader(t, first, second)
+	summary, err := SummarizeCapture(tc, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, summary.Packets)
+	assert.Equal(t, len(first.Payload)+len(second.Payload), summary.Bytes)
+	assert.Equal(t, 30*time.Second, summary.Duration)
+	assert.Equal(t, map[int32]int{42: 1, 43: 1}, summary.PacketsByPid)
+	assert.Equal(t, 6, summary.Samples)
+	assert.Equal(t, 1, summary.Events)
+	assert.Equal(t, 1, summary.ServiceChecks)
+	assert.Equal(t, 3, summary.Metrics())
+	// the order of the tags doesn't change the context
+	assert.Equal(t, 5, summary.Contexts())
+
+	assert.Equal(t, []MetricSummary{
+		{Name: "web.requests", Samples: 4, Contexts: 3},
+		{Name: "db.queries", Samples: 1, Contexts: 1},
+	}, summary.TopMetricsByContexts(2))
+	assert.Equal(t, []MetricSummary{
+		{Name: "web.requests", Samples: 4, Contexts: 3},
+		{Name: "db.queries", Samples: 1, Contexts: 1},
+		{Name: "web.latency", Samples: 1, Contexts: 1},
+	}, summary.TopMetricsBySamples(0))
+}
+
+func TestSummarizeCaptureFiltered(t *testing.T) {
+	first := newCapturedMsg(42, testPayload)
+	second := newCapturedMsg(43, "web.requests:1|c|#env:dev")
+
+	tc := newTestCaptureReader(t, first, second)
+	summary, err := SummarizeCapture(tc, &TrafficFilter{Tags: []string{"env:prod"}})
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, summary.Packets)
+	assert.Equal(t, map[int32]int{42: 1}, summary.PacketsByPid)
+	assert.Equal(t, 1, summary.Samples)
+	assert.Equal(t, 1, summary.Events)
+	assert.Equal(t, 1, summary.ServiceChecks)
+	assert.Equal(t, []MetricSummary{{Name: "web.requests", Samples: 1, Contexts: 1}}, summary.TopMetricsBySamples(10))
+}
+
+func TestSummarizeCaptureFile(t *testing.T) {
+	tc, err := NewTrafficCaptureReader("resources/test/datadog-capture.dog.zstd", 1, false)
+	require.NoError(t, err)
+	defer tc.Close()
+
+	summary, err := SummarizeCapture(tc, nil)
+	require.NoError(t, err)
+
+	// version 2 captures have a second resolution
+	assert.Equal(t, 21, summary.Packets)
+	assert.Equal(t, 13*time.Second, summary.Duration)
+	assert.Equal(t, []MetricSummary{{Name: "jaime.uds.test", Samples: 21, Contexts: 1}}, summary.TopMetricsByContexts(10))
+}
+
+func TestDiffSummaries(t *testing.T) {
+	before, err := SummarizeCapture(newTestCaptureReader(t,
+		newCapturedMsg(42, "web.requests:1|c|#env:prod\nweb.requests:1|c|#env:dev\ndb.queries:3|c\nweb.latency:12|h"),
+	), nil)
+	require.NoError(t, err)
+	after, err := SummarizeCapture(newTestCaptureReader(t,
+		newCapturedMsg(42, "web.requests:1|c|#env:prod\nweb.requests:1|c|#env:staging,user:1\nweb.requests:1|c|#user:1,env:staging\ndb.queries:3|c\ndb.queries:3|c\nweb.latency:12|h\ncache.hits:1|c"),
+	), nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, []MetricDiff{
+		{Name: "web.requests", SamplesBefore: 2, SamplesAfter: 3, ContextsBefore: 2, ContextsAfter: 2, NewContexts: 1, RemovedContexts: 1},
+		{Name: "cache.hits", SamplesAfter: 1, ContextsAfter: 1, NewContexts: 1},
+		{Name: "db.queries", SamplesBefore: 1, SamplesAfter: 2, ContextsBefore: 1, ContextsAfter: 1},
+	}, DiffSummaries(before, after))
+	assert.Empty(t, DiffSummaries(before, before))
+}
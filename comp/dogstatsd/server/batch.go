@@ -55,6 +55,9 @@ type batcher struct {
 	// the batcher can decide to properly distribute these samples on the available
 	// pipelines.
 	noAggPipelineEnabled bool
+	// maxLateness is how late, in seconds, a sample with a timestamp can be to be sent to
+	// the main pipeline, which aggregates it in the bucket of its timestamp.
+	maxLateness float64
 }
 
 // Use fastrange instead of a modulo for better performance.
@@ -108,6 +111,7 @@ func newBatcher(demux aggregator.DemultiplexerWithAggregator) *batcher {
 		keyGenerator:  ckey.NewKeyGenerator(),
 
 		noAggPipelineEnabled: demux.Options().EnableNoAggregationPipeline,
+		maxLateness:          demux.Options().DogstatsdMaxLateness.Seconds(),
 	}
 }
 
@@ -177,6 +181,16 @@ func (b *batcher) appendLateSample(sample metrics.MetricSample) {
 		return
 	}
 
+	// the samples which are not too late are aggregated in the bucket of their timestamp,
+	// the samples from the future are sent as is. The time samplers send back to the
+	// no-aggregation pipeline the samples whose bucket was flushed in the meantime.
+	if b.maxLateness > 0 {
+		if now := float64(time.Now().Unix()); sample.Timestamp <= now && sample.Timestamp >= now-b.maxLateness {
+			b.appendSample(sample)
+			return
+		}
+	}
+
 	if b.samplesWithTsCount == len(b.samplesWithTs) {
 		b.flushSamplesWithTs()
 	}
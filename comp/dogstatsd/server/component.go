@@ -8,6 +8,8 @@ package server
 
 import (
 	"github.com/DataDog/datadog-agent/pkg/aggregator"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/remoteconfig/state"
 	"github.com/DataDog/datadog-agent/pkg/util/fxutil"
 	"go.uber.org/fx"
 )
@@ -37,6 +39,16 @@ type Component interface {
 
 	// UDPLocalAddr returns the local address of the UDP statsd listener, if enabled.
 	UDPLocalAddr() string
+
+	// SetBlocklist replaces the metric blocklist without restarting the server.
+	SetBlocklist(data []string, matchPrefix bool)
+
+	// SetMappingProfiles replaces the mapper profiles without restarting the server, an empty list disables the mapper.
+	SetMappingProfiles(profiles []config.MappingProfile) error
+
+	// MetricControlUpdateCallback is the remote configuration callback of the METRIC_CONTROL product,
+	// it updates the metric blocklist and the mapper profiles.
+	MetricControlUpdateCallback(updates map[string]state.RawConfig, applyStateCallback func(string, state.ApplyStatus))
 }
 
 // Mock implements mock-specific methods.
@@ -25,8 +25,9 @@ var (
 	jmxTagPrefix         = "jmx_domain:"
 )
 
-// enrichConfig contains static parameters used in various enrichment
-// procedures for metrics, events and service checks.
+// enrichConfig contains the parameters used in various enrichment
+// procedures for metrics, events and service checks. It is never modified,
+// the server replaces it when its blocklist is updated.
 type enrichConfig struct {
 	metricPrefix              string
 	metricPrefixBlacklist     []string
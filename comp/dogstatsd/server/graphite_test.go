@@ -0,0 +1,78 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build test
+
+package server
+
+import (
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/comp/dogstatsd/listeners"
+	"github.com/DataDog/datadog-agent/comp/forwarder/defaultforwarder"
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+func TestGraphiteReceive(t *testing.T) {
+	deps := fulfillDepsWithConfigYaml(t, `
+dogstatsd_mapper_profiles:
+  - name: servers
+    prefix: 'servers.'
+    mappings:
+      - match: "servers.*.cpu.*"
+        name: "system.cpu.$2"
+        tags:
+          host_name: "$1"
+`)
+	port := getAvailableTCPPort(t)
+	cw := deps.Config.(config.ReaderWriter)
+	cw.Set("dogstatsd_port", listeners.RandomPortName)
+	cw.Set("dogstatsd_graphite.enabled", true)
+	cw.Set("dogstatsd_graphite.port", port)
+
+	opts := aggregator.DefaultAgentDemultiplexerOptions()
+	opts.FlushInterval = 10 * time.Millisecond
+	opts.DontStartForwarders = true
+	opts.UseNoopEventPlatformForwarder = true
+	demux := aggregator.InitTestAgentDemultiplexerWithOpts(deps.Log, defaultforwarder.NewOptions(deps.Config, deps.Log, nil), opts)
+	defer demux.Stop(false)
+	requireStart(t, deps.Server, demux)
+	defer deps.Server.Stop()
+
+	var conn net.Conn
+	require.Eventually(t, func() bool {
+		var err error
+		conn, err = net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+		return err == nil
+	}, 2*time.Second, 10*time.Millisecond)
+	defer conn.Close()
+
+	// the lines look like DogStatsD events and service checks, they are parsed as metrics anyway
+	_, err := conn.Write([]byte("servers.web-1.cpu.user 12.5 1700000000\ndisk.used;dc=dc1 42 1700000000\n_sc 1 -1\n"))
+	require.NoError(t, err)
+
+	samples, timedSamples := demux.WaitForNumberOfSamples(3, 0, 2*time.Second)
+	require.Len(t, samples, 3)
+	require.Len(t, timedSamples, 0)
+
+	assert.Equal(t, "system.cpu.user", samples[0].Name)
+	assert.Equal(t, 12.5, samples[0].Value)
+	assert.Equal(t, metrics.GaugeType, samples[0].Mtype)
+	assert.Equal(t, []string{"host_name:web-1"}, samples[0].Tags)
+
+	assert.Equal(t, "disk.used", samples[1].Name)
+	assert.Equal(t, 42.0, samples[1].Value)
+	assert.Equal(t, []string{"dc:dc1"}, samples[1].Tags)
+
+	assert.Equal(t, "_sc", samples[2].Name)
+}
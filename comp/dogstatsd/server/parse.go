@@ -48,6 +48,9 @@ type parser struct {
 
 	// readTimestamps is true if the parser has to read timestamps from messages.
 	readTimestamps bool
+	// readGraphiteTimestamps is true if the parser has to read the timestamps of the
+	// Graphite messages, which are always sent.
+	readGraphiteTimestamps bool
 }
 
 func newParser(cfg config.Reader, float64List *float64ListPool) *parser {
@@ -55,10 +58,11 @@ func newParser(cfg config.Reader, float64List *float64ListPool) *parser {
 	readTimestamps := cfg.GetBool("dogstatsd_no_aggregation_pipeline")
 
 	return &parser{
-		interner:         newStringInterner(stringInternerCacheSize),
-		readTimestamps:   readTimestamps,
-		float64List:      float64List,
-		dsdOriginEnabled: cfg.GetBool("dogstatsd_origin_detection_client"),
+		interner:               newStringInterner(stringInternerCacheSize),
+		readTimestamps:         readTimestamps,
+		readGraphiteTimestamps: readTimestamps && cfg.GetBool("dogstatsd_graphite.use_timestamps"),
+		float64List:            float64List,
+		dsdOriginEnabled:       cfg.GetBool("dogstatsd_origin_detection_client"),
 	}
 }
 
@@ -0,0 +1,76 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"time"
+)
+
+var (
+	graphiteTagSeparator      = []byte(";")
+	graphiteTagValueSeparator = []byte("=")
+)
+
+// parseGraphiteMetricSample parses a line of the Graphite plaintext protocol,
+// `<path> <value> [<timestamp>]`, into a gauge. The path can hold Graphite tags:
+// `<name>;<tag>=<value>;...`. The timestamp is ignored unless the parser reads the
+// Graphite timestamps, -1 meaning the current time.
+func (p *parser) parseGraphiteMetricSample(message []byte) (dogstatsdMetricSample, error) {
+	fields := bytes.Fields(message)
+	if len(fields) < 2 || len(fields) > 3 {
+		return dogstatsdMetricSample{}, fmt.Errorf("invalid graphite message format")
+	}
+
+	rawPath := fields[0]
+	rawName, rawTags, _ := bytes.Cut(rawPath, graphiteTagSeparator)
+	if len(rawName) == 0 {
+		return dogstatsdMetricSample{}, fmt.Errorf("invalid graphite metric path: %q", rawPath)
+	}
+
+	var tags []string
+	if len(rawTags) > 0 {
+		tags = make([]string, 0, bytes.Count(rawTags, graphiteTagSeparator)+1)
+		for len(rawTags) > 0 {
+			var rawTag []byte
+			rawTag, rawTags, _ = bytes.Cut(rawTags, graphiteTagSeparator)
+			key, value, found := bytes.Cut(rawTag, graphiteTagValueSeparator)
+			if !found || len(key) == 0 || len(value) == 0 {
+				return dogstatsdMetricSample{}, fmt.Errorf("invalid graphite tag: %q", rawTag)
+			}
+			tag := make([]byte, 0, len(rawTag))
+			tag = append(append(append(tag, key...), ':'), value...)
+			tags = append(tags, p.interner.LoadOrStore(tag))
+		}
+	}
+
+	value, err := parseFloat64(fields[1])
+	if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+		return dogstatsdMetricSample{}, fmt.Errorf("could not parse graphite metric value %q", fields[1])
+	}
+
+	var timestamp time.Time
+	if len(fields) == 3 {
+		ts, err := parseFloat64(fields[2])
+		if err != nil {
+			return dogstatsdMetricSample{}, fmt.Errorf("could not parse graphite timestamp %q", fields[2])
+		}
+		if p.readGraphiteTimestamps && ts >= 1 {
+			timestamp = time.Unix(int64(ts), 0)
+		}
+	}
+
+	return dogstatsdMetricSample{
+		name:       p.interner.LoadOrStore(rawName),
+		value:      value,
+		metricType: gaugeType,
+		sampleRate: 1,
+		tags:       tags,
+		ts:         timestamp,
+	}, nil
+}
@@ -0,0 +1,93 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/fx"
+
+	"github.com/DataDog/datadog-agent/comp/core/config"
+	"github.com/DataDog/datadog-agent/pkg/util/fxutil"
+)
+
+func parseGraphiteMetricSample(t *testing.T, overrides map[string]any, rawSample []byte) (dogstatsdMetricSample, error) {
+	cfg := fxutil.Test[config.Component](t, fx.Options(
+		config.MockModule,
+		fx.Replace(config.MockParams{Overrides: overrides}),
+	))
+	parser := newParser(cfg, newFloat64ListPool())
+	return parser.parseGraphiteMetricSample(rawSample)
+}
+
+func TestParseGraphite(t *testing.T) {
+	sample, err := parseGraphiteMetricSample(t, map[string]any{}, []byte("servers.web-1.cpu.load 0.75 1700000000"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "servers.web-1.cpu.load", sample.name)
+	assert.InEpsilon(t, 0.75, sample.value, epsilon)
+	assert.Equal(t, gaugeType, sample.metricType)
+	assert.InEpsilon(t, 1.0, sample.sampleRate, epsilon)
+	assert.Empty(t, sample.tags)
+	// the timestamps are ignored by default
+	assert.Zero(t, sample.ts)
+
+	// the timestamp is optional and the fields can be separated by several spaces
+	sample, err = parseGraphiteMetricSample(t, map[string]any{}, []byte("queue.depth\t 12"))
+	require.NoError(t, err)
+	assert.Equal(t, "queue.depth", sample.name)
+	assert.InEpsilon(t, 12.0, sample.value, epsilon)
+}
+
+func TestParseGraphiteTags(t *testing.T) {
+	sample, err := parseGraphiteMetricSample(t, map[string]any{}, []byte("disk.used;datacenter=dc1;server=web-1 42 1700000000"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "disk.used", sample.name)
+	assert.InEpsilon(t, 42.0, sample.value, epsilon)
+	assert.Equal(t, []string{"datacenter:dc1", "server:web-1"}, sample.tags)
+}
+
+func TestParseGraphiteTimestamps(t *testing.T) {
+	overrides := map[string]any{
+		"dogstatsd_graphite.use_timestamps": true,
+		"dogstatsd_no_aggregation_pipeline": true,
+	}
+	sample, err := parseGraphiteMetricSample(t, overrides, []byte("cpu.load 0.75 1700000000"))
+	require.NoError(t, err)
+	assert.Equal(t, time.Unix(1700000000, 0), sample.ts)
+
+	// -1 means now
+	sample, err = parseGraphiteMetricSample(t, overrides, []byte("cpu.load 0.75 -1"))
+	require.NoError(t, err)
+	assert.Zero(t, sample.ts)
+
+	// the timestamps are only read when the no-aggregation pipeline is enabled
+	overrides["dogstatsd_no_aggregation_pipeline"] = false
+	sample, err = parseGraphiteMetricSample(t, overrides, []byte("cpu.load 0.75 1700000000"))
+	require.NoError(t, err)
+	assert.Zero(t, sample.ts)
+}
+
+func TestParseGraphiteErrors(t *testing.T) {
+	for _, message := range []string{
+		"",
+		"cpu.load",
+		"cpu.load 0.75 1700000000 extra",
+		"cpu.load abc 1700000000",
+		"cpu.load nan 1700000000",
+		"cpu.load 0.75 abc",
+		";datacenter=dc1 0.75",
+		"cpu.load;datacenter 0.75",
+		"cpu.load;=dc1 0.75",
+	} {
+		_, err := parseGraphiteMetricSample(t, map[string]any{}, []byte(message))
+		assert.Error(t, err, "message %q", message)
+	}
+}
@@ -0,0 +1,122 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/DataDog/datadog-agent/comp/dogstatsd/mapper"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/remoteconfig/state"
+)
+
+// SetBlocklist replaces the metric blocklist. The workers pick it up with the next message
+// they process, no packet is dropped during the swap.
+func (s *server) SetBlocklist(data []string, matchPrefix bool) {
+	s.reloadLock.Lock()
+	defer s.reloadLock.Unlock()
+
+	conf := *s.enrichConfig.Load()
+	conf.metricBlocklist = newBlocklist(data, matchPrefix)
+	s.enrichConfig.Store(&conf)
+	s.log.Infof("Dogstatsd: the metric blocklist now holds %d entries", len(data))
+}
+
+// SetMappingProfiles replaces the mapper profiles, an empty list disables the mapper.
+// The current profiles are kept if the new ones are invalid.
+func (s *server) SetMappingProfiles(profiles []config.MappingProfile) error {
+	s.reloadLock.Lock()
+	defer s.reloadLock.Unlock()
+
+	if len(profiles) == 0 {
+		s.mapper.Store(nil)
+		return nil
+	}
+	mapperInstance, err := mapper.NewMetricMapper(profiles, s.config.GetInt("dogstatsd_mapper_cache_size"))
+	if err != nil {
+		return err
+	}
+	s.mapper.Store(mapperInstance)
+	s.log.Infof("Dogstatsd: the metric mapper now uses %d profiles", len(profiles))
+	return nil
+}
+
+// metricControlConfig is the content of a METRIC_CONTROL remote configuration,
+// the fields which are not set keep the value of the agent configuration.
+type metricControlConfig struct {
+	MetricBlocklist            *[]string                `json:"metric_blocklist"`
+	MetricBlocklistMatchPrefix *bool                    `json:"metric_blocklist_match_prefix"`
+	MapperProfiles             *[]config.MappingProfile `json:"mapper_profiles"`
+}
+
+// MetricControlUpdateCallback applies the METRIC_CONTROL remote configurations. When several
+// configurations set the same field, the one with the greatest path wins. Removing all the
+// configurations restores the blocklist and the mapper profiles of the agent configuration.
+func (s *server) MetricControlUpdateCallback(updates map[string]state.RawConfig, applyStateCallback func(string, state.ApplyStatus)) {
+	paths := make([]string, 0, len(updates))
+	for path := range updates {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var merged metricControlConfig
+	failed := map[string]bool{}
+	for _, path := range paths {
+		var update metricControlConfig
+		if err := json.Unmarshal(updates[path].Config, &update); err != nil {
+			s.log.Errorf("Dogstatsd: invalid remote configuration %s: %v", path, err)
+			applyStateCallback(path, state.ApplyStatus{State: state.ApplyStateError, Error: err.Error()})
+			failed[path] = true
+			continue
+		}
+		if update.MetricBlocklist != nil {
+			merged.MetricBlocklist = update.MetricBlocklist
+		}
+		if update.MetricBlocklistMatchPrefix != nil {
+			merged.MetricBlocklistMatchPrefix = update.MetricBlocklistMatchPrefix
+		}
+		if update.MapperProfiles != nil {
+			merged.MapperProfiles = update.MapperProfiles
+		}
+	}
+
+	blocklist := s.config.GetStringSlice("statsd_metric_blocklist")
+	if merged.MetricBlocklist != nil {
+		blocklist = *merged.MetricBlocklist
+	}
+	matchPrefix := s.config.GetBool("statsd_metric_blocklist_match_prefix")
+	if merged.MetricBlocklistMatchPrefix != nil {
+		matchPrefix = *merged.MetricBlocklistMatchPrefix
+	}
+	s.SetBlocklist(blocklist, matchPrefix)
+
+	var err error
+	if merged.MapperProfiles != nil {
+		err = s.SetMappingProfiles(*merged.MapperProfiles)
+	} else {
+		var profiles []config.MappingProfile
+		if profiles, err = config.GetDogstatsdMappingProfiles(); err == nil {
+			err = s.SetMappingProfiles(profiles)
+		}
+	}
+	if err != nil {
+		err = fmt.Errorf("could not update the metric mapper: %v", err)
+		s.log.Errorf("Dogstatsd: %v", err)
+	}
+
+	for _, path := range paths {
+		if failed[path] {
+			continue
+		}
+		if err != nil {
+			applyStateCallback(path, state.ApplyStatus{State: state.ApplyStateError, Error: err.Error()})
+		} else {
+			applyStateCallback(path, state.ApplyStatus{State: state.ApplyStateAcknowledged})
+		}
+	}
+}
@@ -0,0 +1,104 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build test
+
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/remoteconfig/state"
+)
+
+func parseNames(t *testing.T, s *server, messages ...string) []string {
+	parser := newParser(s.config, newFloat64ListPool())
+	var names []string
+	for _, message := range messages {
+		samples, err := s.parseMetricMessage([]metrics.MetricSample{}, parser, []byte(message), "", false)
+		require.NoError(t, err)
+		for _, sample := range samples {
+			names = append(names, sample.Name)
+		}
+	}
+	return names
+}
+
+func TestSetBlocklist(t *testing.T) {
+	deps := fulfillDepsWithConfigOverride(t, map[string]interface{}{
+		"statsd_metric_blocklist": []string{"foo"},
+	})
+	s := deps.Server.(*server)
+
+	assert.Equal(t, []string{"bar.count"}, parseNames(t, s, "foo:1|c", "bar.count:1|c"))
+
+	s.SetBlocklist([]string{"bar."}, true)
+	assert.Equal(t, []string{"foo"}, parseNames(t, s, "foo:1|c", "bar.count:1|c"))
+
+	s.SetBlocklist(nil, false)
+	assert.Equal(t, []string{"foo", "bar.count"}, parseNames(t, s, "foo:1|c", "bar.count:1|c"))
+}
+
+func TestSetMappingProfiles(t *testing.T) {
+	deps := fulfillDeps(t)
+	s := deps.Server.(*server)
+
+	err := s.SetMappingProfiles([]config.MappingProfile{{
+		Name:     "test",
+		Prefix:   "test.",
+		Mappings: []config.MetricMapping{{Match: "test.job.*", Name: "test.job"}},
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"test.job"}, parseNames(t, s, "test.job.1:1|c"))
+
+	// invalid profiles keep the current mapper
+	err = s.SetMappingProfiles([]config.MappingProfile{{
+		Name:     "invalid",
+		Prefix:   "test.",
+		Mappings: []config.MetricMapping{{Match: "test.job.*", MatchType: "unknown", Name: "test.job"}},
+	}})
+	assert.Error(t, err)
+	assert.Equal(t, []string{"test.job"}, parseNames(t, s, "test.job.1:1|c"))
+
+	require.NoError(t, s.SetMappingProfiles(nil))
+	assert.Nil(t, s.mapper.Load())
+	assert.Equal(t, []string{"test.job.1"}, parseNames(t, s, "test.job.1:1|c"))
+}
+
+func TestMetricControlUpdateCallback(t *testing.T) {
+	deps := fulfillDepsWithConfigOverride(t, map[string]interface{}{
+		"statsd_metric_blocklist": []string{"foo"},
+	})
+	s := deps.Server.(*server)
+
+	applied := map[string]state.ApplyStatus{}
+	applyStateCallback := func(path string, status state.ApplyStatus) {
+		applied[path] = status
+	}
+
+	s.MetricControlUpdateCallback(map[string]state.RawConfig{
+		"datadog/2/METRIC_CONTROL/blocklist/config": {Config: []byte(`{"metric_blocklist": ["bar.count"]}`)},
+		"datadog/2/METRIC_CONTROL/mapper/config": {Config: []byte(`{
+			"mapper_profiles": [{"name": "test", "prefix": "test.", "mappings": [{"match": "test.job.*", "name": "test.job"}]}]
+		}`)},
+		"datadog/2/METRIC_CONTROL/invalid/config": {Config: []byte(`{"metric_blocklist": "bar.count"}`)},
+	}, applyStateCallback)
+
+	assert.Equal(t, state.ApplyStateAcknowledged, applied["datadog/2/METRIC_CONTROL/blocklist/config"].State)
+	assert.Equal(t, state.ApplyStateAcknowledged, applied["datadog/2/METRIC_CONTROL/mapper/config"].State)
+	assert.Equal(t, state.ApplyStateError, applied["datadog/2/METRIC_CONTROL/invalid/config"].State)
+	assert.NotEmpty(t, applied["datadog/2/METRIC_CONTROL/invalid/config"].Error)
+	assert.Equal(t, []string{"foo", "test.job"}, parseNames(t, s, "foo:1|c", "bar.count:1|c", "test.job.1:1|c"))
+
+	// removing the configurations restores the agent configuration
+	s.MetricControlUpdateCallback(map[string]state.RawConfig{}, applyStateCallback)
+	assert.Nil(t, s.mapper.Load())
+	assert.Equal(t, []string{"bar.count", "test.job.1"}, parseNames(t, s, "foo:1|c", "bar.count:1|c", "test.job.1:1|c"))
+}
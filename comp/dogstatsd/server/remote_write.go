@@ -0,0 +1,44 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package server
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+// handleRemoteWrite enriches the samples of a Prometheus remote-write request like the
+// DogStatsD metrics: the namespace, the blocklist, the host and origin tags and the extra
+// tags are applied, then the samples are sent to the aggregator.
+func (s *server) handleRemoteWrite(samples []metrics.MetricSample, origin string) {
+	conf := *s.enrichConfig.Load()
+
+	s.remoteWriteLock.Lock()
+	defer s.remoteWriteLock.Unlock()
+
+	for _, sample := range samples {
+		if !isExcluded(sample.Name, conf.metricPrefix, conf.metricPrefixBlacklist) {
+			sample.Name = conf.metricPrefix + sample.Name
+		}
+		if conf.metricBlocklist.test(sample.Name) {
+			continue
+		}
+
+		// the samples of a histogram share their tags, which are filtered in place
+		tags := make([]string, len(sample.Tags), len(sample.Tags)+len(s.extraTags))
+		copy(tags, sample.Tags)
+		tags, host, udsOrigin, _, cardinality, _ := extractTagsMetadata(tags, origin, nil, conf)
+
+		sample.Tags = append(tags, s.extraTags...)
+		sample.Host = host
+		sample.OriginFromUDS = udsOrigin
+		sample.Cardinality = cardinality
+		sample.Source = metrics.MetricSourceDogstatsd
+
+		s.Debug.StoreMetricStats(sample)
+		s.remoteWriteBatcher.appendSample(sample)
+	}
+	s.remoteWriteBatcher.flush()
+}
@@ -0,0 +1,86 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build test
+
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/snappy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/comp/dogstatsd/listeners"
+	"github.com/DataDog/datadog-agent/comp/dogstatsd/listeners/remotewrite"
+	"github.com/DataDog/datadog-agent/comp/forwarder/defaultforwarder"
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+func getAvailableTCPPort(t *testing.T) int {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+func TestRemoteWriteReceive(t *testing.T) {
+	port := getAvailableTCPPort(t)
+	deps := fulfillDepsWithConfigOverride(t, map[string]interface{}{
+		"dogstatsd_port":                 listeners.RandomPortName,
+		"dogstatsd_remote_write.enabled": true,
+		"dogstatsd_remote_write.port":    port,
+		"statsd_metric_namespace":        "prom.",
+		"statsd_metric_blocklist":        []string{"prom.blocked"},
+		"dogstatsd_tags":                 []string{"env:test"},
+	})
+
+	opts := aggregator.DefaultAgentDemultiplexerOptions()
+	opts.FlushInterval = 10 * time.Millisecond
+	opts.DontStartForwarders = true
+	opts.UseNoopEventPlatformForwarder = true
+	demux := aggregator.InitTestAgentDemultiplexerWithOpts(deps.Log, defaultforwarder.NewOptions(deps.Config, deps.Log, nil), opts)
+	defer demux.Stop(false)
+	requireStart(t, deps.Server, demux)
+	defer deps.Server.Stop()
+
+	body := snappy.Encode(nil, remotewrite.Marshal(&remotewrite.WriteRequest{
+		Timeseries: []remotewrite.TimeSeries{
+			{
+				Labels:  []remotewrite.Label{{Name: "__name__", Value: "temperature"}, {Name: "host", Value: "sensor-1"}, {Name: "room", Value: "kitchen"}},
+				Samples: []remotewrite.Sample{{Value: 21.5, Timestamp: 1000}},
+			},
+			{
+				Labels:  []remotewrite.Label{{Name: "__name__", Value: "blocked"}},
+				Samples: []remotewrite.Sample{{Value: 1, Timestamp: 1000}},
+			},
+		},
+	}))
+
+	var resp *http.Response
+	require.Eventually(t, func() bool {
+		var err error
+		resp, err = http.Post(fmt.Sprintf("http://127.0.0.1:%d/api/v1/write", port), "application/x-protobuf", bytes.NewReader(body))
+		return err == nil
+	}, 2*time.Second, 10*time.Millisecond)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	samples, timedSamples := demux.WaitForSamples(2 * time.Second)
+	require.Len(t, samples, 1)
+	require.Len(t, timedSamples, 0)
+	assert.Equal(t, "prom.temperature", samples[0].Name)
+	assert.Equal(t, 21.5, samples[0].Value)
+	assert.Equal(t, metrics.GaugeType, samples[0].Mtype)
+	assert.Equal(t, "sensor-1", samples[0].Host)
+	assert.ElementsMatch(t, []string{"room:kitchen", "env:test"}, samples[0].Tags)
+}
@@ -13,6 +13,7 @@ import (
 	"net"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"go.uber.org/fx"
@@ -114,7 +115,7 @@ type server struct {
 	Debug                   serverDebug.Component
 
 	tCapture                replay.Component
-	mapper                  *mapper.MetricMapper
+	mapper                  atomic.Pointer[mapper.MetricMapper]
 	eolTerminationUDP       bool
 	eolTerminationUDS       bool
 	eolTerminationNamedPipe bool
@@ -139,7 +140,16 @@ type server struct {
 	// originTelemetry is true if we want to report telemetry per origin.
 	originTelemetry bool
 
-	enrichConfig enrichConfig
+	// enrichConfig is replaced as a whole when the blocklist is updated at runtime,
+	// the workers load it for every message.
+	enrichConfig atomic.Pointer[enrichConfig]
+	// reloadLock must be held when replacing enrichConfig or the mapper
+	reloadLock sync.Mutex
+
+	// remoteWriteBatcher batches the samples of the Prometheus remote-write requests,
+	// remoteWriteLock must be held when using it.
+	remoteWriteBatcher *batcher
+	remoteWriteLock    sync.Mutex
 }
 
 func initTelemetry(cfg config.Reader, logger logComponent.Component) {
@@ -286,16 +296,16 @@ func newServerCompat(cfg config.Reader, log logComponent.Component, capture repl
 		udsListenerRunning:   false,
 		cachedOriginCounters: make(map[string]cachedOriginCounter),
 		ServerlessMode:       serverless,
-		enrichConfig: enrichConfig{
-			metricPrefix:              metricPrefix,
-			metricPrefixBlacklist:     metricPrefixBlacklist,
-			metricBlocklist:           metricBlocklist,
-			entityIDPrecedenceEnabled: entityIDPrecedenceEnabled,
-			defaultHostname:           defaultHostname,
-			serverlessMode:            serverless,
-			originOptOutEnabled:       cfg.GetBool("dogstatsd_origin_optout_enabled"),
-		},
 	}
+	s.enrichConfig.Store(&enrichConfig{
+		metricPrefix:              metricPrefix,
+		metricPrefixBlacklist:     metricPrefixBlacklist,
+		metricBlocklist:           metricBlocklist,
+		entityIDPrecedenceEnabled: entityIDPrecedenceEnabled,
+		defaultHostname:           defaultHostname,
+		serverlessMode:            serverless,
+		originOptOutEnabled:       cfg.GetBool("dogstatsd_origin_optout_enabled"),
+	})
 	return s
 }
 
@@ -350,6 +360,25 @@ func (s *server) Start(demultiplexer aggregator.Demultiplexer) error {
 		}
 	}
 
+	if s.config.GetBool("dogstatsd_graphite.enabled") {
+		graphiteListener, err := listeners.NewGraphiteListener(packetsChannel, sharedPacketPoolManager, s.config)
+		if err != nil {
+			s.log.Errorf("graphite listener error: %v", err.Error())
+		} else {
+			tmpListeners = append(tmpListeners, graphiteListener)
+		}
+	}
+
+	if s.config.GetBool("dogstatsd_remote_write.enabled") && !s.ServerlessMode {
+		s.remoteWriteBatcher = newBatcher(demultiplexer.(aggregator.DemultiplexerWithAggregator))
+		remoteWriteListener, err := listeners.NewRemoteWriteListener(s.config, s.handleRemoteWrite)
+		if err != nil {
+			s.log.Errorf("remote-write listener error: %v", err.Error())
+		} else {
+			tmpListeners = append(tmpListeners, remoteWriteListener)
+		}
+	}
+
 	if len(tmpListeners) == 0 {
 		return fmt.Errorf("listening on neither udp nor socket, please check your configuration")
 	}
@@ -395,18 +424,11 @@ func (s *server) Start(demultiplexer aggregator.Demultiplexer) error {
 	// map some metric name
 	// ----------------------
 
-	cacheSize := s.config.GetInt("dogstatsd_mapper_cache_size")
-
 	mappings, err := config.GetDogstatsdMappingProfiles()
 	if err != nil {
 		s.log.Warnf("Could not parse mapping profiles: %v", err)
-	} else if len(mappings) != 0 {
-		mapperInstance, err := mapper.NewMetricMapper(mappings, cacheSize)
-		if err != nil {
-			s.log.Warnf("Could not create metric mapper: %v", err)
-		} else {
-			s.mapper = mapperInstance
-		}
+	} else if err := s.SetMappingProfiles(mappings); err != nil {
+		s.log.Warnf("Could not create metric mapper: %v", err)
 	}
 	return nil
 }
@@ -571,8 +593,8 @@ func (s *server) errLog(format string, params ...interface{}) {
 }
 
 // workers are running this function in their goroutine
-func (s *server) parsePackets(batcher *batcher, parser *parser, packets []*packets.Packet, samples metrics.MetricSampleBatch) metrics.MetricSampleBatch {
-	for _, packet := range packets {
+func (s *server) parsePackets(batcher *batcher, parser *parser, packetBatch []*packets.Packet, samples metrics.MetricSampleBatch) metrics.MetricSampleBatch {
+	for _, packet := range packetBatch {
 		s.log.Tracef("Dogstatsd receive: %q", packet.Contents)
 		for {
 			message := nextMessage(&packet.Contents, s.eolEnabled(packet.Source))
@@ -586,6 +608,10 @@ func (s *server) parsePackets(batcher *batcher, parser *parser, packets []*packe
 				s.Statistics.StatEvent(1)
 			}
 			messageType := findMessageType(message)
+			if packet.Source == packets.Graphite {
+				// the Graphite plaintext protocol only carries metrics
+				messageType = metricSampleType
+			}
 
 			switch messageType {
 			case serviceCheckType:
@@ -607,7 +633,11 @@ func (s *server) parsePackets(batcher *batcher, parser *parser, packets []*packe
 
 				samples = samples[0:0]
 
-				samples, err = s.parseMetricMessage(samples, parser, message, packet.Origin, s.originTelemetry)
+				if packet.Source == packets.Graphite {
+					samples, err = s.parseGraphiteMessage(samples, parser, message, packet.Origin, s.originTelemetry)
+				} else {
+					samples, err = s.parseMetricMessage(samples, parser, message, packet.Origin, s.originTelemetry)
+				}
 				if err != nil {
 					s.errLog("Dogstatsd: error parsing metric message '%q': %s", message, err)
 					continue
@@ -683,11 +713,7 @@ func (s *server) getOriginCounter(origin string) (okCnt telemetry.SimpleCounter,
 // is the first part aware of processing a late metric. Also, it may help us having a telemetry of a "late_metrics" type here
 // which we can't do today.
 func (s *server) parseMetricMessage(metricSamples []metrics.MetricSample, parser *parser, message []byte, origin string, originTelemetry bool) ([]metrics.MetricSample, error) {
-	okCnt := tlmProcessedOk
-	errorCnt := tlmProcessedError
-	if origin != "" && originTelemetry {
-		okCnt, errorCnt = s.getOriginCounter(origin)
-	}
+	okCnt, errorCnt := s.processedCounters(origin, originTelemetry)
 
 	sample, err := parser.parseMetricSample(message)
 	if err != nil {
@@ -695,9 +721,35 @@ func (s *server) parseMetricMessage(metricSamples []metrics.MetricSample, parser
 		errorCnt.Inc()
 		return metricSamples, err
 	}
+	return s.mapAndEnrichMetricSample(metricSamples, sample, origin, okCnt), nil
+}
+
+// parseGraphiteMessage is parseMetricMessage for the Graphite plaintext protocol.
+func (s *server) parseGraphiteMessage(metricSamples []metrics.MetricSample, parser *parser, message []byte, origin string, originTelemetry bool) ([]metrics.MetricSample, error) {
+	okCnt, errorCnt := s.processedCounters(origin, originTelemetry)
+
+	sample, err := parser.parseGraphiteMetricSample(message)
+	if err != nil {
+		dogstatsdMetricParseErrors.Add(1)
+		errorCnt.Inc()
+		return metricSamples, err
+	}
+	return s.mapAndEnrichMetricSample(metricSamples, sample, origin, okCnt), nil
+}
+
+// processedCounters returns the telemetry counters of the processed metrics, per origin
+// if originTelemetry is set.
+func (s *server) processedCounters(origin string, originTelemetry bool) (okCnt telemetry.SimpleCounter, errorCnt telemetry.SimpleCounter) {
+	if origin != "" && originTelemetry {
+		return s.getOriginCounter(origin)
+	}
+	return tlmProcessedOk, tlmProcessedError
+}
 
-	if s.mapper != nil {
-		mapResult := s.mapper.Map(sample.name)
+// mapAndEnrichMetricSample applies the mapper to a parsed sample, then enriches it.
+func (s *server) mapAndEnrichMetricSample(metricSamples []metrics.MetricSample, sample dogstatsdMetricSample, origin string, okCnt telemetry.SimpleCounter) []metrics.MetricSample {
+	if metricMapper := s.mapper.Load(); metricMapper != nil {
+		mapResult := metricMapper.Map(sample.name)
 		if mapResult != nil {
 			s.log.Tracef("Dogstatsd mapper: metric mapped from %q to %q with tags %v", sample.name, mapResult.Name, mapResult.Tags)
 			sample.name = mapResult.Name
@@ -705,7 +757,7 @@ func (s *server) parseMetricMessage(metricSamples []metrics.MetricSample, parser
 		}
 	}
 
-	metricSamples = enrichMetricSample(metricSamples, sample, origin, s.enrichConfig)
+	metricSamples = enrichMetricSample(metricSamples, sample, origin, *s.enrichConfig.Load())
 
 	if len(sample.values) > 0 {
 		s.sharedFloat64List.put(sample.values)
@@ -722,7 +774,7 @@ func (s *server) parseMetricMessage(metricSamples []metrics.MetricSample, parser
 		dogstatsdMetricPackets.Add(1)
 		okCnt.Inc()
 	}
-	return metricSamples, nil
+	return metricSamples
 }
 
 func (s *server) parseEventMessage(parser *parser, message []byte, origin string) (*event.Event, error) {
@@ -732,7 +784,7 @@ func (s *server) parseEventMessage(parser *parser, message []byte, origin string
 		tlmProcessed.Inc("events", "error", "")
 		return nil, err
 	}
-	event := enrichEvent(sample, origin, s.enrichConfig)
+	event := enrichEvent(sample, origin, *s.enrichConfig.Load())
 	event.Tags = append(event.Tags, s.extraTags...)
 	tlmProcessed.Inc("events", "ok", "")
 	dogstatsdEventPackets.Add(1)
@@ -746,7 +798,7 @@ func (s *server) parseServiceCheckMessage(parser *parser, message []byte, origin
 		tlmProcessed.Inc("service_checks", "error", "")
 		return nil, err
 	}
-	serviceCheck := enrichServiceCheck(sample, origin, s.enrichConfig)
+	serviceCheck := enrichServiceCheck(sample, origin, *s.enrichConfig.Load())
 	serviceCheck.Tags = append(serviceCheck.Tags, s.extraTags...)
 	dogstatsdServiceCheckPackets.Add(1)
 	tlmProcessed.Inc("service_checks", "ok", "")
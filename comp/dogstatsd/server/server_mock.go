@@ -9,6 +9,8 @@ import (
 	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/aggregator"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/remoteconfig/state"
 )
 
 type serverMock struct {
@@ -47,3 +49,12 @@ func (s *serverMock) UDPLocalAddr() string {
 func (s *serverMock) ServerlessFlush() {}
 
 func (s *serverMock) SetExtraTags(tags []string) {}
+
+func (s *serverMock) SetBlocklist(data []string, matchPrefix bool) {}
+
+func (s *serverMock) SetMappingProfiles(profiles []config.MappingProfile) error {
+	return nil
+}
+
+func (s *serverMock) MetricControlUpdateCallback(updates map[string]state.RawConfig, applyStateCallback func(string, state.ApplyStatus)) {
+}
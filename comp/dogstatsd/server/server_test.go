@@ -419,6 +419,42 @@ func TestUDPReceive(t *testing.T) {
 	}
 }
 
+func TestUDPReceiveLateSamples(t *testing.T) {
+	cfg := make(map[string]interface{})
+
+	cfg["dogstatsd_port"] = listeners.RandomPortName
+	cfg["dogstatsd_no_aggregation_pipeline"] = true // another test may have turned it off
+
+	deps := fulfillDepsWithConfigOverride(t, cfg)
+
+	opts := aggregator.DefaultAgentDemultiplexerOptions()
+	opts.FlushInterval = 10 * time.Millisecond
+	opts.DontStartForwarders = true
+	opts.UseNoopEventPlatformForwarder = true
+	opts.EnableNoAggregationPipeline = true
+	opts.DogstatsdMaxLateness = time.Minute
+
+	demux := aggregator.InitTestAgentDemultiplexerWithOpts(deps.Log, defaultforwarder.NewOptions(deps.Config, deps.Log, nil), opts)
+	defer demux.Stop(false)
+	requireStart(t, deps.Server, demux)
+	defer deps.Server.Stop()
+
+	conn, err := net.Dial("udp", deps.Server.UDPLocalAddr())
+	require.NoError(t, err, "cannot connect to DSD socket")
+	defer conn.Close()
+
+	// the late sample within the window goes to the main pipeline, the older one and
+	// the one from the future to the no-aggregation pipeline
+	now := time.Now().Unix()
+	conn.Write([]byte(fmt.Sprintf("daemon:1|g|T%d\ndaemon:2|g|T%d\ndaemon:3|g|T%d", now-10, now-3600, now+3600)))
+	samples, timedSamples := demux.WaitForNumberOfSamples(1, 2, time.Second*2)
+	require.Len(t, samples, 1)
+	require.Len(t, timedSamples, 2)
+	assert.EqualValues(t, 1.0, samples[0].Value)
+	assert.Equal(t, float64(now-10), samples[0].Timestamp)
+	assert.ElementsMatch(t, []float64{2.0, 3.0}, []float64{timedSamples[0].Value, timedSamples[1].Value})
+}
+
 func TestUDPForward(t *testing.T) {
 	cfg := make(map[string]interface{})
 
@@ -675,7 +711,7 @@ func TestNoMappingsConfig(t *testing.T) {
 	defer demux.Stop(false)
 	requireStart(t, s, demux)
 
-	assert.Nil(t, s.mapper)
+	assert.Nil(t, s.mapper.Load())
 
 	parser := newParser(deps.Config, newFloat64ListPool())
 	samples, err := s.parseMetricMessage(samples, parser, []byte("test.metric:666|g"), "", false)
@@ -236,6 +236,7 @@ func NewDefaultForwarder(config config.Component, log log.Component, options *Op
 	var optionalRemovalPolicy *retry.FileRemovalPolicy
 	storageMaxSize := config.GetInt64("forwarder_storage_max_size_in_bytes")
 	var diskUsageLimit *retry.DiskUsageLimit
+	var storageEncryptionKey string
 
 	// Disk Persistence is a core-only feature for now.
 	if storageMaxSize == 0 {
@@ -262,6 +263,10 @@ func NewDefaultForwarder(config config.Component, log log.Component, options *Op
 
 		diskRatio := config.GetFloat64("forwarder_storage_max_disk_ratio")
 		diskUsageLimit = retry.NewDiskUsageLimit(storagePath, filesystem.NewDisk(), storageMaxSize, diskRatio)
+		storageEncryptionKey = config.GetString("forwarder_storage_encryption_key")
+		if storageEncryptionKey == "" {
+			log.Infof("Retry queue storage on disk is not encrypted")
+		}
 
 	} else {
 		log.Infof("Retry queue storage on disk is disabled because the feature is unavailable for this process.")
@@ -293,6 +298,7 @@ func NewDefaultForwarder(config config.Component, log log.Component, options *Op
 				flushToDiskMemRatio,
 				domainFolderPath,
 				diskUsageLimit,
+				storageEncryptionKey,
 				transactionContainerSort,
 				resolver,
 				pointCountTelemetry)
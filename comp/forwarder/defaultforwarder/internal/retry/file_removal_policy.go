@@ -20,7 +20,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/util/filesystem"
 )
 
-// FileRemovalPolicy handles the removal policy for `.retry` files.
+// FileRemovalPolicy handles the removal policy for `.retry` and `.quarantine` files.
 type FileRemovalPolicy struct {
 	rootPath           string
 	knownDomainFolders map[string]struct{}
@@ -164,7 +164,8 @@ func (p *FileRemovalPolicy) getRetryFiles(folder string) ([]string, error) {
 	}
 	var files []string
 	for _, entry := range entries {
-		if entry.Type().IsRegular() && filepath.Ext(entry.Name()) == retryTransactionsExtension {
+		ext := filepath.Ext(entry.Name())
+		if entry.Type().IsRegular() && (ext == retryTransactionsExtension || ext == quarantinedTransactionsExtension) {
 			files = append(files, path.Join(folder, entry.Name()))
 		}
 	}
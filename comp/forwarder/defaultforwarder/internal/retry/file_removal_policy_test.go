@@ -50,17 +50,20 @@ func TestFileRemovalPolicyOutdatedFiles(t *testing.T) {
 	file1 := createRetryFile(a, domain, "file1")
 	file2 := createRetryFile(a, domain, "file2")
 	file3 := createRetryFile(a, domain, "file3")
+	file4 := createFile(a, domain, "file4"+quarantinedTransactionsExtension)
+	file5 := createFile(a, domain, "file5"+quarantinedTransactionsExtension)
 
 	modTime := time.Now().Add(time.Duration(-3*24) * time.Hour)
 	a.NoError(os.Chtimes(file2, modTime, modTime))
+	a.NoError(os.Chtimes(file4, modTime, modTime))
 
 	modTime = time.Now().Add(time.Duration(-1*24) * time.Hour)
 	a.NoError(os.Chtimes(file3, modTime, modTime))
 
 	pathsRemoved, err := p.RemoveOutdatedFiles()
 	a.NoError(err)
-	assertFilenamesEqual(a, []string{file2}, pathsRemoved)
-	assertFilenamesEqual(a, []string{file1, file3}, getRemainingFiles(a, root))
+	assertFilenamesEqual(a, []string{file2, file4}, pathsRemoved)
+	assertFilenamesEqual(a, []string{file1, file3, file5}, getRemainingFiles(a, root))
 }
 
 func TestFileRemovalPolicyExistingDomain(t *testing.T) {
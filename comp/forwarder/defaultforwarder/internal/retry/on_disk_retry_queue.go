@@ -11,6 +11,7 @@ import (
 	"path"
 	"path/filepath"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/DataDog/datadog-agent/comp/core/log"
@@ -19,11 +20,13 @@ import (
 )
 
 const retryTransactionsExtension = ".retry"
+const quarantinedTransactionsExtension = ".quarantine"
 const retryFileFormat = "2006_01_02__15_04_05_"
 
 type onDiskRetryQueue struct {
 	log                 log.Component
 	serializer          *HTTPTransactionsSerializer
+	encoder             *retryFileEncoder
 	storagePath         string
 	diskUsageLimit      *DiskUsageLimit
 	filenames           []string
@@ -35,6 +38,7 @@ type onDiskRetryQueue struct {
 func newOnDiskRetryQueue(
 	log log.Component,
 	serializer *HTTPTransactionsSerializer,
+	encoder *retryFileEncoder,
 	storagePath string,
 	diskUsageLimit *DiskUsageLimit,
 	telemetry onDiskRetryQueueTelemetry,
@@ -47,6 +51,7 @@ func newOnDiskRetryQueue(
 	storage := &onDiskRetryQueue{
 		log:                 log,
 		serializer:          serializer,
+		encoder:             encoder,
 		storagePath:         storagePath,
 		diskUsageLimit:      diskUsageLimit,
 		telemetry:           telemetry,
@@ -82,6 +87,10 @@ func (s *onDiskRetryQueue) Store(transactions []transaction.Transaction) error {
 	if err != nil {
 		return err
 	}
+	bytes, err = s.encoder.encode(bytes)
+	if err != nil {
+		return err
+	}
 	bufferSize := int64(len(bytes))
 
 	if err := s.makeRoomFor(bufferSize); err != nil {
@@ -120,18 +129,28 @@ func (s *onDiskRetryQueue) ExtractLast() ([]transaction.Transaction, error) {
 	index := len(s.filenames) - 1
 	path := s.filenames[index]
 	bytes, err := os.ReadFile(path)
-
-	// Remove the file even in case of a read failure.
-	if errRemoveFile := s.removeFileAt(index); errRemoveFile != nil {
-		return nil, errRemoveFile
+	if err != nil {
+		// Remove the file even in case of a read failure.
+		if errRemoveFile := s.removeFileAt(index); errRemoveFile != nil {
+			return nil, errRemoveFile
+		}
+		return nil, err
 	}
 
+	transactions, errorsCount, err := s.deserialize(bytes)
 	if err != nil {
+		// The file is kept for investigation but it is never replayed.
+		s.log.Errorf("Cannot read the transactions of the file %v, moving it to quarantine: %v", path, err)
+		if errQuarantine := s.quarantineFileAt(index); errQuarantine != nil {
+			return nil, errQuarantine
+		}
+		s.telemetry.addFilesQuarantinedCount()
+		s.telemetry.setCurrentSizeInBytes(s.GetDiskSpaceUsed())
+		s.telemetry.setFilesCount(s.getFilesCount())
 		return nil, err
 	}
 
-	transactions, errorsCount, err := s.serializer.Deserialize(bytes)
-	if err != nil {
+	if err := s.removeFileAt(index); err != nil {
 		return nil, err
 	}
 	s.telemetry.addDeserializeErrorsCount(errorsCount)
@@ -169,7 +188,7 @@ func (s *onDiskRetryQueue) makeRoomFor(bufferSize int64) error {
 		bytes, err := os.ReadFile(filename)
 		if err != nil {
 			s.log.Errorf("Cannot read the file %v: %v", filename, err)
-		} else if transactions, _, errDeserialize := s.serializer.Deserialize(bytes); errDeserialize == nil {
+		} else if transactions, _, errDeserialize := s.deserialize(bytes); errDeserialize == nil {
 			pointDroppedCount := 0
 			for _, tr := range transactions {
 				pointDroppedCount += tr.GetPointCount()
@@ -193,7 +212,27 @@ func (s *onDiskRetryQueue) onPointDropped(count int) {
 	s.pointCountTelemetry.OnPointDropped(count)
 }
 
+func (s *onDiskRetryQueue) deserialize(bytes []byte) ([]transaction.Transaction, int, error) {
+	bytes, err := s.encoder.decode(bytes)
+	if err != nil {
+		return nil, 0, err
+	}
+	return s.serializer.Deserialize(bytes)
+}
+
 func (s *onDiskRetryQueue) removeFileAt(index int) error {
+	return s.discardFileAt(index, os.Remove)
+}
+
+// quarantineFileAt renames the file so that it is neither reloaded nor counted in the disk usage.
+// Quarantined files are removed with the outdated retry files.
+func (s *onDiskRetryQueue) quarantineFileAt(index int) error {
+	return s.discardFileAt(index, func(filename string) error {
+		return os.Rename(filename, strings.TrimSuffix(filename, retryTransactionsExtension)+quarantinedTransactionsExtension)
+	})
+}
+
+func (s *onDiskRetryQueue) discardFileAt(index int, discard func(filename string) error) error {
 	filename := s.filenames[index]
 
 	// Remove the file from s.filenames also in case of error to not
@@ -205,7 +244,7 @@ func (s *onDiskRetryQueue) removeFileAt(index int) error {
 		return err
 	}
 
-	if err := os.Remove(filename); err != nil {
+	if err := discard(filename); err != nil {
 		return err
 	}
 
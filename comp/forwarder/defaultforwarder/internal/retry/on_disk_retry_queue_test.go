@@ -8,10 +8,14 @@
 package retry
 
 import (
+	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/DataDog/datadog-agent/comp/core/log"
 	"github.com/DataDog/datadog-agent/comp/forwarder/defaultforwarder/transaction"
@@ -27,7 +31,7 @@ func TestOnDiskRetryQueue(t *testing.T) {
 	path := t.TempDir()
 
 	pointDropped := fileStoragePointDroppedCountTelemetry.expvar.Value()
-	q := newTestOnDiskRetryQueue(t, a, path, 1000)
+	q := newTestOnDiskRetryQueue(t, a, path, 1000, &retryFileEncoder{})
 	err := q.Store(createHTTPTransactionCollectionTests("endpoint1", "endpoint2"))
 	a.NoError(err)
 	err = q.Store(createHTTPTransactionCollectionTests("endpoint3", "endpoint4"))
@@ -51,9 +55,9 @@ func TestOnDiskRetryQueueMaxSize(t *testing.T) {
 	a := assert.New(t)
 	path := t.TempDir()
 
-	maxSizeInBytes := int64(100)
+	maxSizeInBytes := int64(200)
 	pointDropped := fileStoragePointDroppedCountTelemetry.expvar.Value()
-	q := newTestOnDiskRetryQueue(t, a, path, maxSizeInBytes)
+	q := newTestOnDiskRetryQueue(t, a, path, maxSizeInBytes, &retryFileEncoder{})
 
 	i := 0
 	err := q.Store(createHTTPTransactionCollectionTests(strconv.Itoa(i)))
@@ -91,11 +95,11 @@ func TestOnDiskRetryQueueReloadExistingRetryFiles(t *testing.T) {
 	a := assert.New(t)
 	path := t.TempDir()
 
-	retryQueue := newTestOnDiskRetryQueue(t, a, path, 1000)
+	retryQueue := newTestOnDiskRetryQueue(t, a, path, 1000, &retryFileEncoder{})
 	err := retryQueue.Store(createHTTPTransactionCollectionTests("endpoint1", "endpoint2"))
 	a.NoError(err)
 
-	newRetryQueue := newTestOnDiskRetryQueue(t, a, path, 1000)
+	newRetryQueue := newTestOnDiskRetryQueue(t, a, path, 1000, &retryFileEncoder{})
 	a.Equal(retryQueue.GetDiskSpaceUsed(), newRetryQueue.GetDiskSpaceUsed())
 	a.Equal(retryQueue.getFilesCount(), newRetryQueue.getFilesCount())
 	transactions, err := newRetryQueue.ExtractLast()
@@ -103,6 +107,118 @@ func TestOnDiskRetryQueueReloadExistingRetryFiles(t *testing.T) {
 	a.Equal([]string{"endpoint1", "endpoint2"}, getEndpointsFromTransactions(transactions))
 }
 
+func TestOnDiskRetryQueueEncryption(t *testing.T) {
+	a := assert.New(t)
+	path := t.TempDir()
+
+	encoder, err := newRetryFileEncoder("secret")
+	a.NoError(err)
+	q := newTestOnDiskRetryQueue(t, a, path, 1000, encoder)
+	err = q.Store(createHTTPTransactionCollectionTests("endpoint1", "endpoint2"))
+	a.NoError(err)
+
+	content, err := os.ReadFile(q.filenames[0])
+	a.NoError(err)
+	a.NotContains(string(content), "endpoint1")
+
+	transactions, err := q.ExtractLast()
+	a.NoError(err)
+	a.Equal([]string{"endpoint1", "endpoint2"}, getEndpointsFromTransactions(transactions))
+}
+
+func TestOnDiskRetryQueueQuarantine(t *testing.T) {
+	encryptedEncoder, err := newRetryFileEncoder("secret")
+	require.NoError(t, err)
+	otherKeyEncoder, err := newRetryFileEncoder("other secret")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name          string
+		writeEncoder  *retryFileEncoder
+		readEncoder   *retryFileEncoder
+		updateContent func([]byte) []byte
+	}{
+		{
+			name:          "corrupted file",
+			writeEncoder:  &retryFileEncoder{},
+			readEncoder:   &retryFileEncoder{},
+			updateContent: func(content []byte) []byte { content[len(content)-1]++; return content },
+		},
+		{
+			name:          "truncated file",
+			writeEncoder:  encryptedEncoder,
+			readEncoder:   encryptedEncoder,
+			updateContent: func(content []byte) []byte { return content[:retryFileHeaderSize-1] },
+		},
+		{
+			name:         "plaintext file with encryption enabled",
+			writeEncoder: &retryFileEncoder{},
+			readEncoder:  encryptedEncoder,
+		},
+		{
+			name:         "encrypted file with encryption disabled",
+			writeEncoder: encryptedEncoder,
+			readEncoder:  &retryFileEncoder{},
+		},
+		{
+			name:         "encrypted file with another key",
+			writeEncoder: encryptedEncoder,
+			readEncoder:  otherKeyEncoder,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := assert.New(t)
+			path := t.TempDir()
+
+			q := newTestOnDiskRetryQueue(t, a, path, 1000, tt.writeEncoder)
+			a.NoError(q.Store(createHTTPTransactionCollectionTests("endpoint1")))
+			filename := q.filenames[0]
+			if tt.updateContent != nil {
+				content, err := os.ReadFile(filename)
+				a.NoError(err)
+				a.NoError(os.WriteFile(filename, tt.updateContent(content), 0600))
+			}
+
+			quarantined := filesQuarantinedCountTelemetry.expvar.Value()
+			q = newTestOnDiskRetryQueue(t, a, path, 1000, tt.readEncoder)
+			transactions, err := q.ExtractLast()
+			a.ErrorIs(err, errCorruptedRetryFile)
+			a.Nil(transactions)
+			a.Equal(quarantined+1, filesQuarantinedCountTelemetry.expvar.Value())
+			a.Equal(0, q.getFilesCount())
+			a.Equal(int64(0), q.GetDiskSpaceUsed())
+
+			a.NoFileExists(filename)
+			a.FileExists(strings.TrimSuffix(filename, retryTransactionsExtension) + quarantinedTransactionsExtension)
+
+			// Quarantined files are not reloaded
+			q = newTestOnDiskRetryQueue(t, a, path, 1000, tt.readEncoder)
+			a.Equal(0, q.getFilesCount())
+		})
+	}
+}
+
+func TestOnDiskRetryQueueLegacyFile(t *testing.T) {
+	a := assert.New(t)
+	path := t.TempDir()
+
+	log := fxutil.Test[log.Component](t, log.MockModule)
+	serializer := NewHTTPTransactionsSerializer(log, resolver.NewSingleDomainResolver(domainName, nil))
+	for _, tr := range createHTTPTransactionCollectionTests("endpoint1") {
+		a.NoError(tr.SerializeTo(log, serializer))
+	}
+	content, err := serializer.GetBytesAndReset()
+	a.NoError(err)
+	a.NoError(os.WriteFile(filepath.Join(path, "legacy"+retryTransactionsExtension), content, 0600))
+
+	q := newTestOnDiskRetryQueue(t, a, path, 1000, &retryFileEncoder{})
+	transactions, err := q.ExtractLast()
+	a.NoError(err)
+	a.Equal([]string{"endpoint1"}, getEndpointsFromTransactions(transactions))
+}
+
 func createHTTPTransactionCollectionTests(endpoints ...string) []transaction.Transaction {
 	var transactions []transaction.Transaction
 
@@ -126,7 +242,7 @@ func getEndpointsFromTransactions(transactions []transaction.Transaction) []stri
 	return endpoints
 }
 
-func newTestOnDiskRetryQueue(t *testing.T, a *assert.Assertions, path string, maxSizeInBytes int64) *onDiskRetryQueue {
+func newTestOnDiskRetryQueue(t *testing.T, a *assert.Assertions, path string, maxSizeInBytes int64, encoder *retryFileEncoder) *onDiskRetryQueue {
 	telemetry := newOnDiskRetryQueueTelemetry("domain")
 	disk := diskUsageRetrieverMock{
 		diskUsage: &filesystem.DiskUsage{
@@ -135,7 +251,7 @@ func newTestOnDiskRetryQueue(t *testing.T, a *assert.Assertions, path string, ma
 		}}
 	diskUsageLimit := NewDiskUsageLimit("", disk, maxSizeInBytes, 1)
 	log := fxutil.Test[log.Component](t, log.MockModule)
-	storage, err := newOnDiskRetryQueue(log, NewHTTPTransactionsSerializer(log, resolver.NewSingleDomainResolver(domainName, nil)), path, diskUsageLimit, telemetry, NewPointCountTelemetryMock())
+	storage, err := newOnDiskRetryQueue(log, NewHTTPTransactionsSerializer(log, resolver.NewSingleDomainResolver(domainName, nil)), encoder, path, diskUsageLimit, telemetry, NewPointCountTelemetryMock())
 	a.NoError(err)
 	return storage
 }
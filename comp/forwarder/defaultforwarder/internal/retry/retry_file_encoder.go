@@ -0,0 +1,134 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package retry
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+)
+
+// A retry file starts with a header made of retryFileMagic, the version of the format, the
+// flags and the SHA-256 checksum of the content following the header.
+//
+// The files written before the header was introduced hold the serialized transactions only.
+// They are told apart as a protobuf message never starts with 'D': it would be the end of a
+// group for the field 8.
+var retryFileMagic = []byte("DDRQ")
+
+const (
+	retryFileFormatVersion = 1
+	retryFileChecksumSize  = sha256.Size
+	retryFileHeaderSize    = 6 + retryFileChecksumSize
+)
+
+const (
+	// retryFileEncrypted is set when the content is encrypted with AES-256-GCM. The content is
+	// the nonce followed by the sealed transactions.
+	retryFileEncrypted byte = 1 << iota
+)
+
+// errCorruptedRetryFile is returned when the content of a retry file cannot be trusted.
+var errCorruptedRetryFile = errors.New("corrupted retry file")
+
+// retryFileEncoder adds a checksum to the retry files and optionally encrypts them.
+type retryFileEncoder struct {
+	// aead is nil when the files are not encrypted
+	aead cipher.AEAD
+}
+
+// newRetryFileEncoder creates a new instance of retryFileEncoder. The files are encrypted when
+// encryptionKey is not empty, the AES-256 key is the SHA-256 hash of encryptionKey.
+func newRetryFileEncoder(encryptionKey string) (*retryFileEncoder, error) {
+	if encryptionKey == "" {
+		return &retryFileEncoder{}, nil
+	}
+
+	key := sha256.Sum256([]byte(encryptionKey))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &retryFileEncoder{aead: aead}, nil
+}
+
+// encode returns the content of a retry file holding the serialized transactions.
+func (e *retryFileEncoder) encode(transactions []byte) ([]byte, error) {
+	header := make([]byte, retryFileHeaderSize)
+	copy(header, retryFileMagic)
+	header[4] = retryFileFormatVersion
+
+	content := transactions
+	if e.aead != nil {
+		header[5] |= retryFileEncrypted
+		nonce := make([]byte, e.aead.NonceSize())
+		if _, err := rand.Read(nonce); err != nil {
+			return nil, err
+		}
+		// The header without the checksum is authenticated so that the flags cannot be updated.
+		content = e.aead.Seal(nonce, nonce, transactions, header[:6])
+	}
+
+	checksum := sha256.Sum256(content)
+	copy(header[6:], checksum[:])
+	return append(header, content...), nil
+}
+
+// decode returns the serialized transactions held by a retry file. It returns an error
+// wrapping errCorruptedRetryFile when the checksum does not match, when the file cannot be
+// decrypted or when the file is not encrypted while the encryption is enabled.
+func (e *retryFileEncoder) decode(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, retryFileMagic) {
+		if e.aead != nil {
+			return nil, fmt.Errorf("%w: the file is not encrypted", errCorruptedRetryFile)
+		}
+		// File written by a previous version of the Agent.
+		return data, nil
+	}
+
+	if len(data) < retryFileHeaderSize {
+		return nil, fmt.Errorf("%w: the header is truncated", errCorruptedRetryFile)
+	}
+	if version := data[4]; version != retryFileFormatVersion {
+		return nil, fmt.Errorf("%w: unsupported format version %d", errCorruptedRetryFile, version)
+	}
+
+	flags := data[5]
+	content := data[retryFileHeaderSize:]
+	checksum := sha256.Sum256(content)
+	if subtle.ConstantTimeCompare(checksum[:], data[6:retryFileHeaderSize]) != 1 {
+		return nil, fmt.Errorf("%w: invalid checksum", errCorruptedRetryFile)
+	}
+
+	if flags&retryFileEncrypted == 0 {
+		if e.aead != nil {
+			return nil, fmt.Errorf("%w: the file is not encrypted", errCorruptedRetryFile)
+		}
+		return content, nil
+	}
+
+	if e.aead == nil {
+		return nil, fmt.Errorf("%w: the file is encrypted and no encryption key is set", errCorruptedRetryFile)
+	}
+	nonceSize := e.aead.NonceSize()
+	if len(content) < nonceSize {
+		return nil, fmt.Errorf("%w: the nonce is truncated", errCorruptedRetryFile)
+	}
+	transactions, err := e.aead.Open(nil, content[:nonceSize], content[nonceSize:], data[:6])
+	if err != nil {
+		return nil, fmt.Errorf("%w: cannot decrypt the file: %v", errCorruptedRetryFile, err)
+	}
+	return transactions, nil
+}
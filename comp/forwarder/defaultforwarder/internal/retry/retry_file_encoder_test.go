@@ -0,0 +1,54 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build test
+
+package retry
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryFileEncoder(t *testing.T) {
+	for _, key := range []string{"", "secret"} {
+		encoder, err := newRetryFileEncoder(key)
+		require.NoError(t, err)
+
+		content := []byte("transactions")
+		data, err := encoder.encode(content)
+		require.NoError(t, err)
+		require.Equal(t, retryFileMagic, data[:len(retryFileMagic)])
+		require.Equal(t, key != "", data[5]&retryFileEncrypted != 0)
+
+		decoded, err := encoder.decode(data)
+		require.NoError(t, err)
+		require.Equal(t, content, decoded)
+	}
+}
+
+func TestRetryFileEncoderTamperedFlags(t *testing.T) {
+	encoder, err := newRetryFileEncoder("secret")
+	require.NoError(t, err)
+	data, err := encoder.encode([]byte("transactions"))
+	require.NoError(t, err)
+
+	// The header is authenticated, updating it is detected even if the checksum is valid.
+	data[5] |= 1 << 7
+	_, err = encoder.decode(data)
+	require.ErrorIs(t, err, errCorruptedRetryFile)
+}
+
+func TestRetryFileEncoderUnsupportedVersion(t *testing.T) {
+	encoder, err := newRetryFileEncoder("")
+	require.NoError(t, err)
+	data, err := encoder.encode([]byte("transactions"))
+	require.NoError(t, err)
+
+	data[4] = retryFileFormatVersion + 1
+	_, err = encoder.decode(data)
+	require.ErrorIs(t, err, errCorruptedRetryFile)
+}
@@ -77,6 +77,7 @@ var (
 	filesCountTelemetry                     *gaugeExpvar
 	startupReloadedRetryFilesCountTelemetry *gaugeExpvar
 	filesRemovedCountTelemetry              *counterExpvar
+	filesQuarantinedCountTelemetry          *counterExpvar
 	fileStoragePointDroppedCountTelemetry   *counterExpvar
 	deserializeErrorsCountTelemetry         *counterExpvar
 	deserializeTransactionsCountTelemetry   *counterExpvar
@@ -185,6 +186,12 @@ func init() {
 		domainTag,
 		"The number of files removed because the disk limit was reached",
 		&fileStorageExpvar)
+	filesQuarantinedCountTelemetry = newCounterExpvar(
+		"file_storage",
+		"files_quarantined_count",
+		domainTag,
+		"The number of files moved to quarantine because their content cannot be trusted",
+		&fileStorageExpvar)
 
 	fileStoragePointDroppedCountTelemetry = newCounterExpvar(
 		"file_storage",
@@ -295,6 +302,10 @@ func (t onDiskRetryQueueTelemetry) addFilesRemovedCount() {
 	filesRemovedCountTelemetry.add(1, t.domainName)
 }
 
+func (t onDiskRetryQueueTelemetry) addFilesQuarantinedCount() {
+	filesQuarantinedCountTelemetry.add(1, t.domainName)
+}
+
 func (t onDiskRetryQueueTelemetry) addPointDroppedCount(count int) {
 	fileStoragePointDroppedCountTelemetry.add(float64(count), t.domainName)
 }
@@ -184,6 +184,7 @@ func newOnDiskRetryQueueTest(t *testing.T, a *assert.Assertions) *onDiskRetryQue
 	q, err := newOnDiskRetryQueue(
 		log,
 		NewHTTPTransactionsSerializer(log, resolver.NewSingleDomainResolver("", nil)),
+		&retryFileEncoder{},
 		path,
 		diskUsageLimit,
 		newOnDiskRetryQueueTelemetry("domain"),
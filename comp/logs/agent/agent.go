@@ -71,6 +71,7 @@ type agent struct {
 	launchers                 *launchers.Launchers
 	health                    *health.Handle
 	diagnosticMessageReceiver *diagnostic.BufferedMessageReceiver
+	metricSubmitter           startstop.StartStoppable
 
 	// started is true if the logs agent is running
 	started *atomic.Bool
@@ -166,6 +167,7 @@ func (a *agent) startPipeline() {
 	starter := startstop.NewStarter(
 		a.destinationsCtx,
 		a.auditor,
+		a.metricSubmitter,
 		a.pipelineProvider,
 		a.diagnosticMessageReceiver,
 		a.launchers,
@@ -183,6 +185,7 @@ func (a *agent) stop(context.Context) error {
 		a.schedulers,
 		a.launchers,
 		a.pipelineProvider,
+		a.metricSubmitter,
 		a.auditor,
 		a.destinationsCtx,
 		a.diagnosticMessageReceiver,
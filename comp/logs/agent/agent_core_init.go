@@ -8,9 +8,12 @@
 package agent
 
 import (
+	"path/filepath"
 	"time"
 
 	"github.com/DataDog/datadog-agent/comp/logs/agent/config"
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
+	"github.com/DataDog/datadog-agent/pkg/collector/check/defaults"
 	pkgConfig "github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/logs/auditor"
 	"github.com/DataDog/datadog-agent/pkg/logs/client"
@@ -22,8 +25,10 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/logs/launchers/journald"
 	"github.com/DataDog/datadog-agent/pkg/logs/launchers/listener"
 	"github.com/DataDog/datadog-agent/pkg/logs/launchers/windowsevent"
+	"github.com/DataDog/datadog-agent/pkg/logs/metricsubmitter"
 	"github.com/DataDog/datadog-agent/pkg/logs/pipeline"
 	"github.com/DataDog/datadog-agent/pkg/logs/schedulers"
+	"github.com/DataDog/datadog-agent/pkg/logs/sender"
 	"github.com/DataDog/datadog-agent/pkg/status/health"
 )
 
@@ -40,9 +45,11 @@ func (a *agent) SetupPipeline(
 	auditor := auditor.New(a.config.GetString("logs_config.run_path"), auditor.DefaultRegistryFilename, auditorTTL, health)
 	destinationsCtx := client.NewDestinationsContext()
 	diagnosticMessageReceiver := diagnostic.NewBufferedMessageReceiver(nil)
+	// metrics extracted from logs are committed to the aggregator at the default check interval
+	metricSubmitter := metricsubmitter.NewSenderSubmitter(aggregator.GetSenderManager(), defaults.DefaultCheckInterval)
 
 	// setup the pipeline provider that provides pairs of processor and sender
-	pipelineProvider := pipeline.NewProvider(config.NumberOfPipelines, auditor, diagnosticMessageReceiver, processingRules, a.endpoints, destinationsCtx)
+	pipelineProvider := pipeline.NewProvider(config.NumberOfPipelines, auditor, diagnosticMessageReceiver, metricSubmitter, processingRules, a.endpoints, destinationsCtx, a.buildDiskQueue())
 
 	// setup the launchers
 	lnchrs := launchers.NewLaunchers(a.sources, pipelineProvider, auditor, a.tracker)
@@ -64,7 +71,26 @@ func (a *agent) SetupPipeline(
 	a.launchers = lnchrs
 	a.health = health
 	a.diagnosticMessageReceiver = diagnosticMessageReceiver
+	a.metricSubmitter = metricSubmitter
+}
 
+// buildDiskQueue builds the queue storing the logs payloads on disk while the intake is unreachable,
+// it returns nil if it is disabled.
+func (a *agent) buildDiskQueue() *sender.DiskQueue {
+	maxSize := a.config.GetInt64("logs_config.disk_queue_max_size_in_bytes")
+	if maxSize <= 0 {
+		return nil
+	}
+	path := a.config.GetString("logs_config.disk_queue_path")
+	if path == "" {
+		path = filepath.Join(a.config.GetString("logs_config.run_path"), "logs_disk_queue")
+	}
+	diskQueue, err := sender.NewDiskQueue(path, maxSize)
+	if err != nil {
+		a.log.Errorf("Could not create the logs disk queue in %s, payloads won't be stored on disk: %v", path, err)
+		return nil
+	}
+	return diskQueue
 }
 
 // buildEndpoints builds endpoints for the logs agent
@@ -15,6 +15,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/logs/diagnostic"
 	"github.com/DataDog/datadog-agent/pkg/logs/launchers"
 	"github.com/DataDog/datadog-agent/pkg/logs/launchers/channel"
+	"github.com/DataDog/datadog-agent/pkg/logs/metricsubmitter"
 	"github.com/DataDog/datadog-agent/pkg/logs/pipeline"
 	"github.com/DataDog/datadog-agent/pkg/logs/schedulers"
 	"github.com/DataDog/datadog-agent/pkg/status/health"
@@ -51,6 +52,7 @@ func (a *agent) SetupPipeline(
 	a.launchers = lnchrs
 	a.health = health
 	a.diagnosticMessageReceiver = diagnosticMessageReceiver
+	a.metricSubmitter = &metricsubmitter.NoopSubmitter{}
 }
 
 // buildEndpoints builds endpoints for the logs agent
@@ -174,11 +174,16 @@ func buildTCPEndpoints(coreConfig pkgConfig.Reader, logsConfig *LogsConfigKeys)
 		main.UseSSL = !logsConfig.devModeNoSSL()
 	}
 
-	additionals := logsConfig.getAdditionalEndpoints()
-	for i := 0; i < len(additionals); i++ {
-		additionals[i].UseSSL = main.UseSSL
-		additionals[i].ProxyAddress = proxyAddress
-		additionals[i].APIKey = configUtils.SanitizeAPIKey(additionals[i].APIKey)
+	var additionals []Endpoint
+	for _, additional := range logsConfig.getAdditionalEndpoints() {
+		if additional.OTLP {
+			log.Warnf("Ignoring the OTLP logs endpoint %s:%d, OTLP endpoints are only supported when logs are sent over HTTP", additional.Host, additional.Port)
+			continue
+		}
+		additional.UseSSL = main.UseSSL
+		additional.ProxyAddress = proxyAddress
+		additional.APIKey = configUtils.SanitizeAPIKey(additional.APIKey)
+		additionals = append(additionals, additional)
 	}
 	return NewEndpoints(main, additionals, useProto, false), nil
 }
@@ -249,7 +254,10 @@ func BuildHTTPEndpointsWithConfig(coreConfig pkgConfig.Reader, logsConfig *LogsC
 
 	additionals := logsConfig.getAdditionalEndpoints()
 	for i := 0; i < len(additionals); i++ {
-		additionals[i].UseSSL = main.UseSSL
+		if !additionals[i].OTLP {
+			// OTLP endpoints are usually collectors running next to the agent, they keep their own SSL setting
+			additionals[i].UseSSL = main.UseSSL
+		}
 		additionals[i].APIKey = configUtils.SanitizeAPIKey(additionals[i].APIKey)
 		additionals[i].UseCompression = main.UseCompression
 		additionals[i].CompressionLevel = main.CompressionLevel
@@ -259,6 +267,9 @@ func BuildHTTPEndpointsWithConfig(coreConfig pkgConfig.Reader, logsConfig *LogsC
 		additionals[i].RecoveryInterval = main.RecoveryInterval
 		additionals[i].RecoveryReset = main.RecoveryReset
 
+		if additionals[i].OTLP {
+			continue
+		}
 		if additionals[i].Version == 0 {
 			additionals[i].Version = main.Version
 		}
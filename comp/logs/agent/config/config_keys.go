@@ -116,8 +116,15 @@ func (l *LogsConfigKeys) useCompression() bool {
 	return l.getConfig().GetBool(l.getConfigKey("use_compression"))
 }
 
+// hasAdditionalEndpoints returns true if additional Datadog endpoints are set,
+// OTLP endpoints are not taken into account since they are only supported over HTTP.
 func (l *LogsConfigKeys) hasAdditionalEndpoints() bool {
-	return len(l.getAdditionalEndpoints()) > 0
+	for _, endpoint := range l.getAdditionalEndpoints() {
+		if !endpoint.OTLP {
+			return true
+		}
+	}
+	return false
 }
 
 // getLogsAPIKey provides the dd api key used by the main logs agent sender.
@@ -244,6 +244,32 @@ func (suite *ConfigTestSuite) TestMultipleTCPEndpointsEnvVar() {
 	suite.Equal(expectedEndpoints, endpoints)
 }
 
+func (suite *ConfigTestSuite) TestOTLPEndpoints() {
+	suite.config.Set("logs_config.additional_endpoints", `[
+		{"host": "otel-collector", "port": 4318, "otlp": true, "use_ssl": false, "is_reliable": false}]`)
+	suite.config.Set("api_key", "123")
+	suite.config.Set("logs_config.logs_dd_url", "agent-http-intake.logs.datadoghq.com:443")
+	suite.config.Set("logs_config.logs_no_ssl", false)
+
+	// OTLP endpoints don't force the use of TCP
+	endpoints, err := BuildEndpoints(suite.config, HTTPConnectivitySuccess, "test-track", "test-proto", "test-source")
+	suite.Nil(err)
+	suite.True(endpoints.UseHTTP)
+	suite.Len(endpoints.Endpoints, 2)
+	otlpEndpoint := endpoints.GetUnReliableEndpoints()[0]
+	suite.True(otlpEndpoint.OTLP)
+	suite.Equal("otel-collector", otlpEndpoint.Host)
+	suite.Equal(4318, otlpEndpoint.Port)
+	suite.False(otlpEndpoint.UseSSL)
+	suite.Equal("", string(otlpEndpoint.TrackType))
+	suite.Equal(endpoints.Main.BackoffMax, otlpEndpoint.BackoffMax)
+
+	// OTLP endpoints are ignored when logs are sent over TCP
+	endpoints, err = buildTCPEndpoints(suite.config, defaultLogsConfigKeys(suite.config))
+	suite.Nil(err)
+	suite.Len(endpoints.Endpoints, 1)
+}
+
 func (suite *ConfigTestSuite) TestMultipleHttpEndpointsInConfig() {
 	suite.config.Set("api_key", "123")
 	suite.config.Set("logs_config.batch_wait", 1)
@@ -37,7 +37,7 @@ type Endpoint struct {
 	APIKey                  string `mapstructure:"api_key" json:"api_key"`
 	Host                    string
 	Port                    int
-	UseSSL                  bool
+	UseSSL                  bool `mapstructure:"use_ssl" json:"use_ssl"`
 	UseCompression          bool `mapstructure:"use_compression" json:"use_compression"`
 	CompressionLevel        int  `mapstructure:"compression_level" json:"compression_level"`
 	ProxyAddress            string
@@ -54,6 +54,9 @@ type Endpoint struct {
 	TrackType IntakeTrackType
 	Protocol  IntakeProtocol
 	Origin    IntakeOrigin
+
+	// OTLP is true if logs are sent to this endpoint as OTLP log records over HTTP
+	OTLP bool `mapstructure:"otlp" json:"otlp"`
 }
 
 // GetStatus returns the endpoint status
@@ -67,7 +70,12 @@ func (e *Endpoint) GetStatus(prefix string, useHTTP bool) string {
 	port := e.Port
 
 	var protocol string
-	if useHTTP {
+	if e.OTLP {
+		protocol = "OTLP/HTTP"
+		if e.UseSSL {
+			protocol = "OTLP/HTTPS"
+		}
+	} else if useHTTP {
 		if e.UseSSL {
 			protocol = "HTTPS"
 			if port == 0 {
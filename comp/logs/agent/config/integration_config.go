@@ -30,6 +30,9 @@ const (
 	UTF16LE string = "utf-16-le"
 	// SHIFTJIS for Shift JIS (Japanese) encoding
 	SHIFTJIS string = "shift-jis"
+
+	// SyslogFormat for syslog messages following RFC5424 or RFC3164
+	SyslogFormat string = "syslog"
 )
 
 // LogsConfig represents a log source config, which can be for instance
@@ -39,20 +42,30 @@ type LogsConfig struct {
 
 	Port        int    // Network
 	IdleTimeout string `mapstructure:"idle_timeout" json:"idle_timeout"` // Network
+	Format      string `mapstructure:"format" json:"format"`             // Network
 	Path        string // File, Journald
 
 	Encoding     string   `mapstructure:"encoding" json:"encoding"`             // File
 	ExcludePaths []string `mapstructure:"exclude_paths" json:"exclude_paths"`   // File
 	TailingMode  string   `mapstructure:"start_position" json:"start_position"` // File
+	// IngestCompressedFiles decompresses the gzip and zstd files, which are read once.
+	IngestCompressedFiles bool `mapstructure:"ingest_compressed_files" json:"ingest_compressed_files"` // File
 
-	ConfigId           string   `mapstructure:"config_id" json:"config_id"`                   // Journald
-	IncludeSystemUnits []string `mapstructure:"include_units" json:"include_units"`           // Journald
-	ExcludeSystemUnits []string `mapstructure:"exclude_units" json:"exclude_units"`           // Journald
-	IncludeUserUnits   []string `mapstructure:"include_user_units" json:"include_user_units"` // Journald
-	ExcludeUserUnits   []string `mapstructure:"exclude_user_units" json:"exclude_user_units"` // Journald
-	IncludeMatches     []string `mapstructure:"include_matches" json:"include_matches"`       // Journald
-	ExcludeMatches     []string `mapstructure:"exclude_matches" json:"exclude_matches"`       // Journald
-	ContainerMode      bool     `mapstructure:"container_mode" json:"container_mode"`         // Journald
+	ConfigId           string     `mapstructure:"config_id" json:"config_id"`                       // Journald
+	IncludeSystemUnits []string   `mapstructure:"include_units" json:"include_units"`               // Journald
+	ExcludeSystemUnits []string   `mapstructure:"exclude_units" json:"exclude_units"`               // Journald
+	IncludeUserUnits   []string   `mapstructure:"include_user_units" json:"include_user_units"`     // Journald
+	ExcludeUserUnits   []string   `mapstructure:"exclude_user_units" json:"exclude_user_units"`     // Journald
+	IncludeMatches     []string   `mapstructure:"include_matches" json:"include_matches"`           // Journald
+	ExcludeMatches     []string   `mapstructure:"exclude_matches" json:"exclude_matches"`           // Journald
+	ContainerMode      bool       `mapstructure:"container_mode" json:"container_mode"`             // Journald
+	MinPriority        string     `mapstructure:"min_priority" json:"min_priority"`                 // Journald
+	IncludeMatchGroups [][]string `mapstructure:"include_match_groups" json:"include_match_groups"` // Journald
+	ExcludeMatchGroups [][]string `mapstructure:"exclude_match_groups" json:"exclude_match_groups"` // Journald
+	IncludeFields      []string   `mapstructure:"include_fields" json:"include_fields"`             // Journald
+	ExcludeFields      []string   `mapstructure:"exclude_fields" json:"exclude_fields"`             // Journald
+	// SyslogIdentifierAsService uses the SYSLOG_IDENTIFIER of the journal entries as service when set.
+	SyslogIdentifierAsService bool `mapstructure:"syslog_identifier_as_service" json:"syslog_identifier_as_service"` // Journald
 
 	Image string // Docker
 	Label string // Docker
@@ -85,6 +98,8 @@ type LogsConfig struct {
 	AutoMultiLine               *bool   `mapstructure:"auto_multi_line_detection" json:"auto_multi_line_detection"`
 	AutoMultiLineSampleSize     int     `mapstructure:"auto_multi_line_sample_size" json:"auto_multi_line_sample_size"`
 	AutoMultiLineMatchThreshold float64 `mapstructure:"auto_multi_line_match_threshold" json:"auto_multi_line_match_threshold"`
+	// AutoMultiLineAggregation aggregates stack traces and multi-line JSON objects without a pattern.
+	AutoMultiLineAggregation *bool `mapstructure:"auto_multi_line_aggregation" json:"auto_multi_line_aggregation"`
 }
 
 // Dump dumps the contents of this struct to a string, for debugging purposes.
@@ -107,14 +122,17 @@ func (c *LogsConfig) Dump(multiline bool) string {
 	case TCPType:
 		fmt.Fprintf(&b, ws("Port: %d,"), c.Port)
 		fmt.Fprintf(&b, ws("IdleTimeout: %#v,"), c.IdleTimeout)
+		fmt.Fprintf(&b, ws("Format: %#v,"), c.Format)
 	case UDPType:
 		fmt.Fprintf(&b, ws("Port: %d,"), c.Port)
 		fmt.Fprintf(&b, ws("IdleTimeout: %#v,"), c.IdleTimeout)
+		fmt.Fprintf(&b, ws("Format: %#v,"), c.Format)
 	case FileType:
 		fmt.Fprintf(&b, ws("Path: %#v,"), c.Path)
 		fmt.Fprintf(&b, ws("Encoding: %#v,"), c.Encoding)
 		fmt.Fprintf(&b, ws("Identifier: %#v,"), c.Identifier)
 		fmt.Fprintf(&b, ws("ExcludePaths: %#v,"), c.ExcludePaths)
+		fmt.Fprintf(&b, ws("IngestCompressedFiles: %t,"), c.IngestCompressedFiles)
 		fmt.Fprintf(&b, ws("TailingMode: %#v,"), c.TailingMode)
 	case DockerType, ContainerdType:
 		fmt.Fprintf(&b, ws("Image: %#v,"), c.Image)
@@ -128,6 +146,12 @@ func (c *LogsConfig) Dump(multiline bool) string {
 		fmt.Fprintf(&b, ws("IncludeUserUnits: %#v,"), c.IncludeUserUnits)
 		fmt.Fprintf(&b, ws("ExcludeUserUnits: %#v,"), c.ExcludeUserUnits)
 		fmt.Fprintf(&b, ws("ContainerMode: %t,"), c.ContainerMode)
+		fmt.Fprintf(&b, ws("MinPriority: %#v,"), c.MinPriority)
+		fmt.Fprintf(&b, ws("IncludeMatchGroups: %#v,"), c.IncludeMatchGroups)
+		fmt.Fprintf(&b, ws("ExcludeMatchGroups: %#v,"), c.ExcludeMatchGroups)
+		fmt.Fprintf(&b, ws("IncludeFields: %#v,"), c.IncludeFields)
+		fmt.Fprintf(&b, ws("ExcludeFields: %#v,"), c.ExcludeFields)
+		fmt.Fprintf(&b, ws("SyslogIdentifierAsService: %t,"), c.SyslogIdentifierAsService)
 	case WindowsEventType:
 		fmt.Fprintf(&b, ws("ChannelPath: %#v,"), c.ChannelPath)
 		fmt.Fprintf(&b, ws("Query: %#v,"), c.Query)
@@ -148,7 +172,12 @@ func (c *LogsConfig) Dump(multiline bool) string {
 		fmt.Fprint(&b, ws("AutoMultiLine: nil,"))
 	}
 	fmt.Fprintf(&b, ws("AutoMultiLineSampleSize: %d,"), c.AutoMultiLineSampleSize)
-	fmt.Fprintf(&b, ws("AutoMultiLineMatchThreshold: %f}"), c.AutoMultiLineMatchThreshold)
+	fmt.Fprintf(&b, ws("AutoMultiLineMatchThreshold: %f,"), c.AutoMultiLineMatchThreshold)
+	if c.AutoMultiLineAggregation != nil {
+		fmt.Fprintf(&b, ws("AutoMultiLineAggregation: %t}"), *c.AutoMultiLineAggregation)
+	} else {
+		fmt.Fprint(&b, ws("AutoMultiLineAggregation: nil}"))
+	}
 	return b.String()
 }
 
@@ -214,13 +243,29 @@ func (c *LogsConfig) Validate() error {
 	case c.Type == UDPType && c.Port == 0:
 		return fmt.Errorf("udp source must have a port")
 	}
-	err := ValidateProcessingRules(c.ProcessingRules)
+	err := c.validateFormat()
+	if err != nil {
+		return err
+	}
+	err = ValidateProcessingRules(c.ProcessingRules)
 	if err != nil {
 		return err
 	}
 	return CompileProcessingRules(c.ProcessingRules)
 }
 
+func (c *LogsConfig) validateFormat() error {
+	switch {
+	case c.Format == "":
+		return nil
+	case c.Format != SyslogFormat:
+		return fmt.Errorf("invalid format '%v', only '%v' is supported", c.Format, SyslogFormat)
+	case c.Type != TCPType && c.Type != UDPType:
+		return fmt.Errorf("format is only supported by tcp and udp sources")
+	}
+	return nil
+}
+
 func (c *LogsConfig) validateTailingMode() error {
 	mode, found := TailingModeFromString(c.TailingMode)
 	if !found && c.TailingMode != "" {
@@ -242,6 +287,16 @@ func (c *LogsConfig) AutoMultiLineEnabled(coreConfig pkgConfig.Reader) bool {
 	return coreConfig.GetBool("logs_config.auto_multi_line_detection")
 }
 
+// AutoMultiLineAggregationEnabled determines whether the aggregation of stack traces and multi-line
+// JSON objects is enabled for this config, considering both the agent-wide
+// logs_config.auto_multi_line_aggregation and any config for this particular log source.
+func (c *LogsConfig) AutoMultiLineAggregationEnabled(coreConfig pkgConfig.Reader) bool {
+	if c.AutoMultiLineAggregation != nil {
+		return *c.AutoMultiLineAggregation
+	}
+	return coreConfig.GetBool("logs_config.auto_multi_line_aggregation")
+}
+
 // ContainsWildcard returns true if the path contains any wildcard character
 func ContainsWildcard(path string) bool {
 	return strings.ContainsAny(path, "*?[")
@@ -23,6 +23,8 @@ func TestValidateShouldSucceedWithValidConfigs(t *testing.T) {
 		{Type: FileType, Path: "/var/log/foo.log"},
 		{Type: TCPType, Port: 1234},
 		{Type: UDPType, Port: 5678},
+		{Type: TCPType, Port: 1234, Format: SyslogFormat},
+		{Type: UDPType, Port: 5678, Format: SyslogFormat},
 		{Type: DockerType},
 		{Type: JournaldType, ProcessingRules: []*ProcessingRule{{Name: "foo", Type: ExcludeAtMatch, Pattern: ".*"}}},
 	}
@@ -39,6 +41,8 @@ func TestValidateShouldFailWithInvalidConfigs(t *testing.T) {
 		{Type: FileType},
 		{Type: TCPType},
 		{Type: UDPType},
+		{Type: TCPType, Port: 1234, Format: "foo"},
+		{Type: FileType, Path: "/var/log/foo.log", Format: SyslogFormat},
 		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Name: "foo"}}},
 		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Name: "foo", Type: "bar"}}},
 		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Name: "foo", Type: ExcludeAtMatch}}},
@@ -86,6 +90,26 @@ func TestAutoMultilineEnabled(t *testing.T) {
 
 }
 
+func TestAutoMultilineAggregationEnabled(t *testing.T) {
+	mockConfig := fxutil.Test[config.Component](t, fx.Options(
+		config.MockModule,
+	)).(config.Mock)
+
+	decode := func(cfg string) *LogsConfig {
+		lc := LogsConfig{}
+		json.Unmarshal([]byte(cfg), &lc)
+		return &lc
+	}
+
+	mockConfig.Set("logs_config.auto_multi_line_aggregation", false)
+	assert.False(t, decode(`{}`).AutoMultiLineAggregationEnabled(mockConfig))
+	assert.True(t, decode(`{"auto_multi_line_aggregation":true}`).AutoMultiLineAggregationEnabled(mockConfig))
+
+	mockConfig.Set("logs_config.auto_multi_line_aggregation", true)
+	assert.True(t, decode(`{}`).AutoMultiLineAggregationEnabled(mockConfig))
+	assert.False(t, decode(`{"auto_multi_line_aggregation":false}`).AutoMultiLineAggregationEnabled(mockConfig))
+}
+
 func TestConfigDump(t *testing.T) {
 	config := LogsConfig{Type: FileType, Path: "/var/log/foo.log"}
 	dump := config.Dump(true)
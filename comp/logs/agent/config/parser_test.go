@@ -39,6 +39,13 @@ func TestParseJSONWithValidFormatShouldSucceed(t *testing.T) {
 	rule := config.ProcessingRules[0]
 	assert.Equal(t, "multi_line", rule.Type)
 	assert.Equal(t, "numbers", rule.Name)
+
+	// as set by the com.datadoghq.ad.logs container label
+	configs, err = ParseJSON([]byte(`[{"source":"java","service":"any_service","auto_multi_line_aggregation":true}]`))
+	assert.Nil(t, err)
+	config = configs[0]
+	assert.NotNil(t, config.AutoMultiLineAggregation)
+	assert.True(t, *config.AutoMultiLineAggregation)
 }
 
 func TestParseJSONWithInvalidFormatShouldFail(t *testing.T) {
@@ -105,6 +112,30 @@ logs:
 	assert.Equal(t, "^[0-9]+$", rule.Pattern)
 }
 
+func TestParseYAMLWithJournaldFilters(t *testing.T) {
+	data := []byte(`
+logs:
+  - type: journald
+    min_priority: warning
+    include_match_groups:
+      - [_SYSTEMD_UNIT=nginx.service, PRIORITY=3]
+      - [_COMM=sshd]
+    exclude_fields: [_PID]
+    syslog_identifier_as_service: true
+`)
+
+	configs, err := ParseYAML(data)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(configs))
+
+	config := configs[0]
+	assert.Equal(t, JournaldType, config.Type)
+	assert.Equal(t, "warning", config.MinPriority)
+	assert.Equal(t, [][]string{{"_SYSTEMD_UNIT=nginx.service", "PRIORITY=3"}, {"_COMM=sshd"}}, config.IncludeMatchGroups)
+	assert.Equal(t, []string{"_PID"}, config.ExcludeFields)
+	assert.True(t, config.SyslogIdentifierAsService)
+}
+
 func TestParseYAMLWithInvalidFormatShouldFail(t *testing.T) {
 	invalidFormats := []string{`
 foo:
@@ -8,6 +8,7 @@ package config
 import (
 	"fmt"
 	"regexp"
+	"strings"
 )
 
 // Processing rule types
@@ -16,6 +17,35 @@ const (
 	IncludeAtMatch = "include_at_match"
 	MaskSequences  = "mask_sequences"
 	MultiLine      = "multi_line"
+
+	// Structured rule types, applied on a field of JSON formatted log lines
+	ExcludeAtFieldMatch = "exclude_at_field_match"
+	IncludeAtFieldMatch = "include_at_field_match"
+	MaskFieldSequences  = "mask_field_sequences"
+	RemapField          = "remap_field"
+	ExtractField        = "extract_field"
+
+	// Volume reduction rule types, applied on the log lines matching the pattern
+	Sample    = "sample"
+	RateLimit = "rate_limit"
+
+	// ExtractMetric submits a metric for the log lines matching the pattern
+	ExtractMetric = "extract_metric"
+)
+
+// Metric types an extract_metric rule can submit
+const (
+	MetricTypeCount        = "count"
+	MetricTypeDistribution = "distribution"
+)
+
+// Prefix of a rate_limit key referring to a tag of the log source
+const rateLimitTagKeyPrefix = "tag:"
+
+// Attributes an extract_field rule can target
+const (
+	ExtractTargetStatus  = "status"
+	ExtractTargetService = "service"
 )
 
 // ProcessingRule defines an exclusion or a masking rule to
@@ -25,9 +55,31 @@ type ProcessingRule struct {
 	Name               string
 	ReplacePlaceholder string `mapstructure:"replace_placeholder" json:"replace_placeholder"`
 	Pattern            string
+	// Field is the dot-separated path of the JSON attribute targeted by a structured rule
+	Field string
+	// Target is the destination of remap_field and extract_field rules
+	Target string
+	// SampleRate is the percentage of matching lines kept by a sample rule
+	SampleRate float64 `mapstructure:"sample_rate" json:"sample_rate"`
+	// MaxPerSecond is the number of matching lines kept each second by a rate_limit rule
+	MaxPerSecond int `mapstructure:"max_per_second" json:"max_per_second"`
+	// Key splits the lines of a rate_limit rule into separately limited groups, it is either
+	// a template referring to the pattern capture groups, such as ${1}, or a tag key prefixed by "tag:"
+	Key string
+	// MetricName is the name of the metric submitted by an extract_metric rule
+	MetricName string `mapstructure:"metric_name" json:"metric_name"`
+	// MetricType is either count or distribution
+	MetricType string `mapstructure:"metric_type" json:"metric_type"`
+	// MetricValue is a template referring to the pattern capture groups, such as ${1},
+	// which expands to the value of the metric, counts default to 1
+	MetricValue string `mapstructure:"metric_value" json:"metric_value"`
+	// MetricTags are templates referring to the pattern capture groups, which expand to
+	// tags added to the metric along with the tags of the log source
+	MetricTags []string `mapstructure:"metric_tags" json:"metric_tags"`
 	// TODO: should be moved out
 	Regex       *regexp.Regexp
 	Placeholder []byte
+	Sampler     *LineSampler
 }
 
 // ValidateProcessingRules validates the rules and raises an error if one is misconfigured.
@@ -35,6 +87,9 @@ type ProcessingRule struct {
 // - a valid name
 // - a valid type
 // - a valid pattern that compiles
+// Structured rules must also have a field and, for remap_field and extract_field, a target.
+// Sample and rate_limit rules must also have a valid rate.
+// Extract_metric rules must also have a metric name, a metric type and, for distributions, a value.
 func ValidateProcessingRules(rules []*ProcessingRule) error {
 	for _, rule := range rules {
 		if rule.Name == "" {
@@ -44,6 +99,38 @@ func ValidateProcessingRules(rules []*ProcessingRule) error {
 		switch rule.Type {
 		case ExcludeAtMatch, IncludeAtMatch, MaskSequences, MultiLine:
 			break
+		case Sample:
+			if rule.SampleRate <= 0 || rule.SampleRate > 100 {
+				return fmt.Errorf("sample_rate must be greater than 0 and lower than or equal to 100 for processing rule: %s", rule.Name)
+			}
+		case RateLimit:
+			if rule.MaxPerSecond <= 0 {
+				return fmt.Errorf("max_per_second must be greater than 0 for processing rule: %s", rule.Name)
+			}
+			if rule.Key == rateLimitTagKeyPrefix {
+				return fmt.Errorf("invalid key %q for processing rule: %s", rule.Key, rule.Name)
+			}
+		case ExtractMetric:
+			if rule.MetricName == "" {
+				return fmt.Errorf("metric_name must be set for processing rule: %s", rule.Name)
+			}
+			switch rule.MetricType {
+			case MetricTypeCount:
+			case MetricTypeDistribution:
+				if rule.MetricValue == "" {
+					return fmt.Errorf("metric_value must be set for distributions in processing rule: %s", rule.Name)
+				}
+			default:
+				return fmt.Errorf("metric_type must be one of %s, %s for processing rule: %s", MetricTypeCount, MetricTypeDistribution, rule.Name)
+			}
+		case ExcludeAtFieldMatch, IncludeAtFieldMatch, MaskFieldSequences, RemapField, ExtractField:
+			if err := validateFieldRule(rule); err != nil {
+				return err
+			}
+			if rule.Type == RemapField || rule.Type == ExtractField {
+				// these rules don't need a pattern
+				continue
+			}
 		case "":
 			return fmt.Errorf("type must be set for processing rule `%s`", rule.Name)
 		default:
@@ -61,19 +148,86 @@ func ValidateProcessingRules(rules []*ProcessingRule) error {
 	return nil
 }
 
+// validateFieldRule validates the field and target of a structured rule.
+func validateFieldRule(rule *ProcessingRule) error {
+	if !isValidFieldPath(rule.Field) {
+		return fmt.Errorf("invalid field %q for processing rule: %s", rule.Field, rule.Name)
+	}
+	switch rule.Type {
+	case RemapField:
+		if !isValidFieldPath(rule.Target) || rule.Target == rule.Field {
+			return fmt.Errorf("invalid target %q for processing rule: %s", rule.Target, rule.Name)
+		}
+	case ExtractField:
+		if rule.Target != ExtractTargetStatus && rule.Target != ExtractTargetService {
+			return fmt.Errorf("target must be one of %s, %s for processing rule: %s", ExtractTargetStatus, ExtractTargetService, rule.Name)
+		}
+	}
+	return nil
+}
+
+// isValidFieldPath returns true if path is a dot-separated path without empty segments.
+func isValidFieldPath(path string) bool {
+	if path == "" {
+		return false
+	}
+	for _, key := range strings.Split(path, ".") {
+		if key == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// IsFieldRule returns true if the rule is applied on a JSON attribute rather than on the whole log line.
+func (r *ProcessingRule) IsFieldRule() bool {
+	switch r.Type {
+	case ExcludeAtFieldMatch, IncludeAtFieldMatch, MaskFieldSequences, RemapField, ExtractField:
+		return true
+	}
+	return false
+}
+
+// SamplerKey returns the key used by the sampler of the rule for a line matching
+// the rule pattern, given the submatch indexes of the match and the tags of the line.
+func (r *ProcessingRule) SamplerKey(content []byte, match []int, tags []string) string {
+	if r.Key == "" {
+		return ""
+	}
+	if strings.HasPrefix(r.Key, rateLimitTagKeyPrefix) {
+		prefix := r.Key[len(rateLimitTagKeyPrefix):] + ":"
+		for _, tag := range tags {
+			if strings.HasPrefix(tag, prefix) {
+				return tag[len(prefix):]
+			}
+		}
+		return ""
+	}
+	return string(r.Regex.Expand(nil, []byte(r.Key), content, match))
+}
+
 // CompileProcessingRules compiles all processing rule regular expressions.
 func CompileProcessingRules(rules []*ProcessingRule) error {
 	for _, rule := range rules {
+		if rule.Type == RemapField || rule.Type == ExtractField {
+			continue
+		}
 		re, err := regexp.Compile(rule.Pattern)
 		if err != nil {
 			return err
 		}
 		switch rule.Type {
-		case ExcludeAtMatch, IncludeAtMatch:
+		case ExcludeAtMatch, IncludeAtMatch, ExcludeAtFieldMatch, IncludeAtFieldMatch, ExtractMetric:
 			rule.Regex = re
-		case MaskSequences:
+		case MaskSequences, MaskFieldSequences:
 			rule.Regex = re
 			rule.Placeholder = []byte(rule.ReplacePlaceholder)
+		case Sample:
+			rule.Regex = re
+			rule.Sampler = NewSampleLineSampler(rule.SampleRate)
+		case RateLimit:
+			rule.Regex = re
+			rule.Sampler = NewRateLimitLineSampler(rule.MaxPerSecond)
 		case MultiLine:
 			rule.Regex, err = regexp.Compile("^" + rule.Pattern)
 			if err != nil {
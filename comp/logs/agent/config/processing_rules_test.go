@@ -33,3 +33,87 @@ func TestCompileShouldFailWithInvalidRules(t *testing.T) {
 		assert.Nil(t, rule.Regex)
 	}
 }
+
+func TestValidateShouldSucceedWithValidFieldRules(t *testing.T) {
+	rules := []*ProcessingRule{
+		{Name: "exclude_debug", Type: ExcludeAtFieldMatch, Field: "level", Pattern: "^debug$"},
+		{Name: "include_errors", Type: IncludeAtFieldMatch, Field: "log.level", Pattern: "error"},
+		{Name: "mask_email", Type: MaskFieldSequences, Field: "user.email", Pattern: ".+", ReplacePlaceholder: "[masked]"},
+		{Name: "rename_msg", Type: RemapField, Field: "msg", Target: "message"},
+		{Name: "status", Type: ExtractField, Field: "level", Target: ExtractTargetStatus},
+		{Name: "service", Type: ExtractField, Field: "app.name", Target: ExtractTargetService},
+	}
+	assert.Nil(t, ValidateProcessingRules(rules))
+	assert.Nil(t, CompileProcessingRules(rules))
+	assert.True(t, rules[0].Regex.MatchString("debug"))
+	assert.Equal(t, []byte("[masked]"), rules[2].Placeholder)
+	assert.Nil(t, rules[3].Regex)
+	for _, rule := range rules {
+		assert.True(t, rule.IsFieldRule())
+	}
+}
+
+func TestValidateShouldFailWithInvalidFieldRules(t *testing.T) {
+	invalidRules := []*ProcessingRule{
+		{Name: "no_field", Type: ExcludeAtFieldMatch, Pattern: "debug"},
+		{Name: "empty_segment", Type: ExcludeAtFieldMatch, Field: "user..email", Pattern: "debug"},
+		{Name: "no_pattern", Type: IncludeAtFieldMatch, Field: "level"},
+		{Name: "invalid_pattern", Type: MaskFieldSequences, Field: "level", Pattern: "(?=abf)"},
+		{Name: "no_target", Type: RemapField, Field: "msg"},
+		{Name: "same_target", Type: RemapField, Field: "msg", Target: "msg"},
+		{Name: "invalid_target", Type: ExtractField, Field: "level", Target: "host"},
+	}
+
+	for _, rule := range invalidRules {
+		assert.NotNil(t, ValidateProcessingRules([]*ProcessingRule{rule}), rule.Name)
+	}
+}
+
+func TestSamplingRules(t *testing.T) {
+	rules := []*ProcessingRule{
+		{Name: "sample", Type: Sample, Pattern: "health", SampleRate: 10},
+		{Name: "rate_limit", Type: RateLimit, Pattern: "user=(\\w+)", MaxPerSecond: 5, Key: "${1}"},
+	}
+	assert.Nil(t, ValidateProcessingRules(rules))
+	assert.Nil(t, CompileProcessingRules(rules))
+	assert.NotNil(t, rules[0].Sampler)
+	assert.NotNil(t, rules[1].Sampler)
+
+	content := []byte("login user=bob")
+	match := rules[1].Regex.FindSubmatchIndex(content)
+	assert.Equal(t, "bob", rules[1].SamplerKey(content, match, nil))
+
+	rules[1].Key = "tag:env"
+	assert.Equal(t, "prod", rules[1].SamplerKey(content, match, []string{"service:web", "env:prod"}))
+	assert.Equal(t, "", rules[1].SamplerKey(content, match, []string{"service:web"}))
+
+	invalidRules := []*ProcessingRule{
+		{Name: "no_rate", Type: Sample, Pattern: "health"},
+		{Name: "rate_too_high", Type: Sample, Pattern: "health", SampleRate: 101},
+		{Name: "no_limit", Type: RateLimit, Pattern: "health"},
+		{Name: "empty_tag_key", Type: RateLimit, Pattern: "health", MaxPerSecond: 1, Key: "tag:"},
+	}
+	for _, rule := range invalidRules {
+		assert.NotNil(t, ValidateProcessingRules([]*ProcessingRule{rule}), rule.Name)
+	}
+}
+
+func TestExtractMetricRules(t *testing.T) {
+	rules := []*ProcessingRule{
+		{Name: "count", Type: ExtractMetric, Pattern: "GET", MetricName: "requests", MetricType: MetricTypeCount},
+		{Name: "distribution", Type: ExtractMetric, Pattern: "took (\\d+)ms", MetricName: "latency", MetricType: MetricTypeDistribution, MetricValue: "${1}"},
+	}
+	assert.Nil(t, ValidateProcessingRules(rules))
+	assert.Nil(t, CompileProcessingRules(rules))
+	assert.NotNil(t, rules[1].Regex)
+
+	invalidRules := []*ProcessingRule{
+		{Name: "no_name", Type: ExtractMetric, Pattern: "GET", MetricType: MetricTypeCount},
+		{Name: "no_type", Type: ExtractMetric, Pattern: "GET", MetricName: "requests"},
+		{Name: "invalid_type", Type: ExtractMetric, Pattern: "GET", MetricName: "requests", MetricType: "gauge"},
+		{Name: "no_value", Type: ExtractMetric, Pattern: "GET", MetricName: "latency", MetricType: MetricTypeDistribution},
+	}
+	for _, rule := range invalidRules {
+		assert.NotNil(t, ValidateProcessingRules([]*ProcessingRule{rule}), rule.Name)
+	}
+}
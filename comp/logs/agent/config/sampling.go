@@ -0,0 +1,103 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package config
+
+import (
+	"sync"
+	"time"
+)
+
+// maxSamplerKeys caps the number of keys a LineSampler keeps track of,
+// lines with a new key are accounted together once the cap is reached.
+const maxSamplerKeys = 1000
+
+// overflowSamplerKey is the key used once maxSamplerKeys is reached.
+const overflowSamplerKey = "\x00overflow"
+
+// LineSampler decides which lines matching a sample or rate_limit rule are kept.
+// It is shared by all the pipelines applying the rule.
+type LineSampler struct {
+	mu sync.Mutex
+	// keepRatio is the ratio of lines kept by a sample rule
+	keepRatio float64
+	credit    float64
+	// maxPerSecond is the number of lines kept each second by a rate_limit rule
+	maxPerSecond int
+	windows      map[string]*samplerWindow
+}
+
+type samplerWindow struct {
+	start int64
+	count int
+}
+
+// NewSampleLineSampler returns a LineSampler keeping sampleRate percent of the lines.
+func NewSampleLineSampler(sampleRate float64) *LineSampler {
+	return &LineSampler{
+		keepRatio: sampleRate / 100,
+		// always keep the first line
+		credit: 1,
+	}
+}
+
+// NewRateLimitLineSampler returns a LineSampler keeping at most maxPerSecond lines per second and per key.
+func NewRateLimitLineSampler(maxPerSecond int) *LineSampler {
+	return &LineSampler{
+		maxPerSecond: maxPerSecond,
+		windows:      make(map[string]*samplerWindow),
+	}
+}
+
+// Keep returns true if a line with the given key, seen at now, must be kept.
+// The key is ignored by sample rules.
+func (s *LineSampler) Keep(key string, now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.windows == nil {
+		if s.credit >= 1 {
+			s.credit--
+			s.credit += s.keepRatio
+			return true
+		}
+		s.credit += s.keepRatio
+		return false
+	}
+
+	second := now.Unix()
+	window, exists := s.windows[key]
+	if !exists {
+		if len(s.windows) >= maxSamplerKeys {
+			s.expireWindows(second)
+		}
+		if len(s.windows) >= maxSamplerKeys {
+			key = overflowSamplerKey
+			window = s.windows[key]
+		}
+		if window == nil {
+			window = &samplerWindow{start: second}
+			s.windows[key] = window
+		}
+	}
+	if window.start != second {
+		window.start = second
+		window.count = 0
+	}
+	if window.count >= s.maxPerSecond {
+		return false
+	}
+	window.count++
+	return true
+}
+
+// expireWindows removes the windows which didn't see any line during the current second.
+func (s *LineSampler) expireWindows(second int64) {
+	for key, window := range s.windows {
+		if window.start != second {
+			delete(s.windows, key)
+		}
+	}
+}
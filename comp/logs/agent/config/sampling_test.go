@@ -0,0 +1,67 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package config
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSampleLineSampler(t *testing.T) {
+	sampler := NewSampleLineSampler(25)
+	now := time.Now()
+
+	var kept []int
+	for i := 0; i < 12; i++ {
+		if sampler.Keep("", now) {
+			kept = append(kept, i)
+		}
+	}
+	assert.Equal(t, []int{0, 4, 8}, kept)
+
+	sampler = NewSampleLineSampler(100)
+	for i := 0; i < 10; i++ {
+		assert.True(t, sampler.Keep("", now))
+	}
+}
+
+func TestRateLimitLineSampler(t *testing.T) {
+	sampler := NewRateLimitLineSampler(2)
+	now := time.Unix(1000, 0)
+
+	assert.True(t, sampler.Keep("", now))
+	assert.True(t, sampler.Keep("", now.Add(500*time.Millisecond)))
+	assert.False(t, sampler.Keep("", now.Add(900*time.Millisecond)))
+
+	// keys are limited separately
+	assert.True(t, sampler.Keep("a", now))
+	assert.True(t, sampler.Keep("a", now))
+	assert.False(t, sampler.Keep("a", now))
+
+	// the limit is reset every second
+	assert.True(t, sampler.Keep("", now.Add(time.Second)))
+	assert.True(t, sampler.Keep("a", now.Add(time.Second)))
+}
+
+func TestRateLimitLineSamplerKeyOverflow(t *testing.T) {
+	sampler := NewRateLimitLineSampler(1)
+	now := time.Unix(1000, 0)
+
+	for i := 0; i < maxSamplerKeys; i++ {
+		assert.True(t, sampler.Keep(strconv.Itoa(i), now))
+	}
+	// new keys share the overflow window
+	assert.True(t, sampler.Keep("new", now))
+	assert.False(t, sampler.Keep("other", now))
+	assert.Len(t, sampler.windows, maxSamplerKeys+1)
+
+	// stale windows are expired to make room for new keys
+	assert.True(t, sampler.Keep("new", now.Add(time.Second)))
+	assert.Len(t, sampler.windows, 1)
+}
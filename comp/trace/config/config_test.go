@@ -579,6 +579,9 @@ func TestDefaultConfig(t *testing.T) {
 	// assert that some sane defaults are set
 	assert.Equal(t, "localhost", cfg.ReceiverHost)
 	assert.Equal(t, 8126, cfg.ReceiverPort)
+	assert.False(t, cfg.ZipkinReceiverEnabled)
+	assert.False(t, cfg.JaegerReceiverEnabled)
+	assert.Equal(t, &traceconfig.OTLPExporter{}, cfg.OTLPExporter)
 
 	assert.Equal(t, "localhost", cfg.StatsdHost)
 	assert.Equal(t, 8125, cfg.StatsdPort)
@@ -655,6 +658,10 @@ func TestFullYamlConfig(t *testing.T) {
 	assert.Equal(t, "test", cfg.DefaultEnv)
 	assert.Equal(t, 123, cfg.ConnectionLimit)
 	assert.Equal(t, 18126, cfg.ReceiverPort)
+	assert.True(t, cfg.ZipkinReceiverEnabled)
+	assert.True(t, cfg.JaegerReceiverEnabled)
+	assert.Equal(t, "http://tempo.local:4318/v1/traces", cfg.OTLPExporter.Endpoint)
+	assert.Equal(t, map[string]string{"x-scope-orgid": "team-a"}, cfg.OTLPExporter.Headers)
 	assert.Equal(t, 0.5, cfg.ExtraSampleRate)
 	assert.Equal(t, 5.0, cfg.TargetTPS)
 	assert.Equal(t, 50.0, cfg.MaxEPS)
@@ -681,6 +688,18 @@ func TestFullYamlConfig(t *testing.T) {
 		{Host: "https://my2.endpoint.eu", APIKey: "apikey5", NoProxy: noProxy},
 	}, cfg.Endpoints)
 
+	assert.True(t, cfg.TailSamplingEnabled)
+	assert.Equal(t, []string{"checkout"}, cfg.TailSamplingServices)
+	assert.Equal(t, 30*time.Second, cfg.TailSamplingDecisionWait)
+	assert.EqualValues(t, 1000000, cfg.TailSamplingMaxMemory)
+	assert.False(t, cfg.TailSamplingKeepErrors)
+	assert.Equal(t, 0.95, cfg.TailSamplingLatencyPercentile)
+	assert.ElementsMatch(t, []*traceconfig.Tag{{K: "customer.tier", V: "premium"}, {K: "debug"}}, cfg.TailSamplingKeepTags)
+	assert.Equal(t, 0.25, cfg.TailSamplingRate)
+
+	assert.Equal(t, []string{"tenant", "region"}, cfg.StatsCustomTags)
+	assert.Equal(t, 500, cfg.StatsCustomTagsMaxCardinality)
+
 	assert.ElementsMatch(t, []*traceconfig.Tag{{K: "env", V: "prod"}, {K: "db", V: "mongodb"}}, cfg.RequireTags)
 	assert.ElementsMatch(t, []*traceconfig.Tag{{K: "outcome", V: "success"}, {K: "bad-key", V: "bad-value"}}, cfg.RejectTags)
 	assert.ElementsMatch(t, []*traceconfig.TagRegex{{K: "type", V: regexp.MustCompile("^internal$")}}, cfg.RequireTagsRegex)
@@ -707,6 +726,27 @@ func TestFullYamlConfig(t *testing.T) {
 		},
 	}, cfg.ReplaceTags)
 
+	assert.Equal(t, []*traceconfig.SpanRule{
+		{
+			Name:   "^redis\\.command$",
+			Tags:   []string{"db.instance"},
+			Action: traceconfig.SpanRuleDrop,
+			NameRe: regexp.MustCompile(`^redis\.command$`),
+		},
+		{
+			Service:   "^web$",
+			Action:    traceconfig.SpanRuleRenameTag,
+			Key:       "old",
+			NewKey:    "new",
+			ServiceRe: regexp.MustCompile(`^web$`),
+		},
+		{
+			Action:    traceconfig.SpanRuleTruncateTag,
+			Key:       "*",
+			MaxLength: 1024,
+		},
+	}, cfg.SpanRules)
+
 	assert.EqualValues(t, []string{"/health", "/500"}, cfg.Ignore["resource"])
 
 	o := cfg.Obfuscation
@@ -1210,6 +1250,51 @@ func TestLoadEnv(t *testing.T) {
 		assert.Contains(t, cfg.ReplaceTags, rule2)
 	})
 
+	env = "DD_APM_SPAN_RULES"
+	t.Run(env, func(t *testing.T) {
+		t.Setenv(env, `[{"name":"^redis.command$", "action":"drop"}, {"action":"truncate_tag","key":"*","max_length":100}]`)
+
+		c := fxutil.Test[Component](t, fx.Options(
+			corecomp.MockModule,
+			fx.Replace(corecomp.MockParams{
+				Params:      corecomp.Params{ConfFilePath: "./testdata/full.yaml"},
+				SetupConfig: true,
+			}),
+			MockModule,
+		))
+
+		cfg := c.Object()
+
+		assert.NotNil(t, cfg)
+		rules := []*config.SpanRule{
+			{Name: "^redis.command$", Action: config.SpanRuleDrop},
+			{Action: config.SpanRuleTruncateTag, Key: "*", MaxLength: 100},
+		}
+		assert.NoError(t, config.CompileSpanRules(rules))
+		assert.Equal(t, rules, cfg.SpanRules)
+	})
+
+	env = "DD_APM_OTLP_EXPORTER_HEADERS"
+	t.Run(env, func(t *testing.T) {
+		t.Setenv("DD_APM_OTLP_EXPORTER_ENDPOINT", "https://tempo.example.com/v1/traces")
+		t.Setenv(env, `{"Authorization":"Basic dGVtcG8=","X-Scope-OrgID":"team-b"}`)
+
+		c := fxutil.Test[Component](t, fx.Options(
+			corecomp.MockModule,
+			fx.Replace(corecomp.MockParams{
+				Params:      corecomp.Params{ConfFilePath: "./testdata/full.yaml"},
+				SetupConfig: true,
+			}),
+			MockModule,
+		))
+
+		cfg := c.Object()
+
+		assert.NotNil(t, cfg)
+		assert.Equal(t, "https://tempo.example.com/v1/traces", cfg.OTLPExporter.Endpoint)
+		assert.Equal(t, map[string]string{"Authorization": "Basic dGVtcG8=", "X-Scope-OrgID": "team-b"}, cfg.OTLPExporter.Headers)
+	})
+
 	env = "DD_APM_FILTER_TAGS_REQUIRE"
 	t.Run(env, func(t *testing.T) {
 		t.Setenv(env, `important1 important2:value1`)
@@ -108,7 +108,7 @@ func prepareConfig(c corecompcfg.Component) (*config.AgentConfig, error) {
 		client, err := remote.NewGRPCClient(
 			rcClientName,
 			version.AgentVersion,
-			[]data.Product{data.ProductAPMSampling, data.ProductAgentConfig},
+			[]data.Product{data.ProductAPMSampling, data.ProductAgentConfig, data.ProductAPMSpanRules},
 			rcClientPollInterval,
 		)
 		if err != nil {
@@ -226,6 +226,12 @@ func applyDatadogConfig(c *config.AgentConfig, core corecompcfg.Component) error
 	if core.IsSet("apm_config.peer_tags") {
 		c.PeerTags = core.GetStringSlice("apm_config.peer_tags")
 	}
+	if core.IsSet("apm_config.stats_custom_tags") {
+		c.StatsCustomTags = core.GetStringSlice("apm_config.stats_custom_tags")
+	}
+	if core.IsSet("apm_config.stats_custom_tags_max_cardinality") {
+		c.StatsCustomTagsMaxCardinality = core.GetInt("apm_config.stats_custom_tags_max_cardinality")
+	}
 	if core.IsSet("apm_config.extra_sample_rate") {
 		c.ExtraSampleRate = core.GetFloat64("apm_config.extra_sample_rate")
 	}
@@ -251,6 +257,37 @@ func applyDatadogConfig(c *config.AgentConfig, core corecompcfg.Component) error
 		c.RareSamplerCardinality = core.GetInt("apm_config.rare_sampler.cardinality")
 	}
 
+	if core.IsSet("apm_config.tail_sampling.enabled") {
+		c.TailSamplingEnabled = core.GetBool("apm_config.tail_sampling.enabled")
+	}
+	if core.IsSet("apm_config.tail_sampling.services") {
+		c.TailSamplingServices = core.GetStringSlice("apm_config.tail_sampling.services")
+	}
+	if core.IsSet("apm_config.tail_sampling.decision_wait") {
+		c.TailSamplingDecisionWait = core.GetDuration("apm_config.tail_sampling.decision_wait")
+	}
+	if core.IsSet("apm_config.tail_sampling.max_memory") {
+		c.TailSamplingMaxMemory = core.GetInt64("apm_config.tail_sampling.max_memory")
+	}
+	if core.IsSet("apm_config.tail_sampling.keep_errors") {
+		c.TailSamplingKeepErrors = core.GetBool("apm_config.tail_sampling.keep_errors")
+	}
+	if core.IsSet("apm_config.tail_sampling.latency_percentile") {
+		c.TailSamplingLatencyPercentile = core.GetFloat64("apm_config.tail_sampling.latency_percentile")
+	}
+	if core.IsSet("apm_config.tail_sampling.keep_tags") {
+		for _, tag := range core.GetStringSlice("apm_config.tail_sampling.keep_tags") {
+			c.TailSamplingKeepTags = append(c.TailSamplingKeepTags, splitTag(tag))
+		}
+	}
+	if core.IsSet("apm_config.tail_sampling.sample_rate") {
+		c.TailSamplingRate = core.GetFloat64("apm_config.tail_sampling.sample_rate")
+	}
+	if c.TailSamplingLatencyPercentile < 0 || c.TailSamplingLatencyPercentile >= 1 {
+		log.Warnf("Invalid apm_config.tail_sampling.latency_percentile %f, it must be between 0 and 1. Disabling the latency policy.", c.TailSamplingLatencyPercentile)
+		c.TailSamplingLatencyPercentile = 0
+	}
+
 	if core.IsSet("apm_config.max_remote_traces_per_second") {
 		c.MaxRemoteTPS = core.GetFloat64("apm_config.max_remote_traces_per_second")
 	}
@@ -287,6 +324,8 @@ func applyDatadogConfig(c *config.AgentConfig, core corecompcfg.Component) error
 	} else {
 		c.DecoderTimeout = 1000
 	}
+	c.ZipkinReceiverEnabled = core.GetBool("apm_config.zipkin_receiver_enabled")
+	c.JaegerReceiverEnabled = core.GetBool("apm_config.jaeger_receiver_enabled")
 
 	if k := "apm_config.replace_tags"; core.IsSet(k) {
 		rt := make([]*config.ReplaceRule, 0)
@@ -301,6 +340,18 @@ func applyDatadogConfig(c *config.AgentConfig, core corecompcfg.Component) error
 		}
 	}
 
+	if k := "apm_config.span_rules"; core.IsSet(k) {
+		rules := make([]*config.SpanRule, 0)
+		if err := coreconfig.Datadog.UnmarshalKey(k, &rules); err != nil {
+			log.Errorf("Bad format for %q it should be a list of rules of the form '{\"name\": \"pattern\", \"action\": \"drop\"}', error: %v", k, err)
+		} else {
+			if err := config.CompileSpanRules(rules); err != nil {
+				return fmt.Errorf("span_rules: %s", err)
+			}
+			c.SpanRules = rules
+		}
+	}
+
 	if core.IsSet("bind_host") || core.IsSet("apm_config.apm_non_local_traffic") {
 		if core.IsSet("bind_host") {
 			host := core.GetString("bind_host")
@@ -335,6 +386,14 @@ func applyDatadogConfig(c *config.AgentConfig, core corecompcfg.Component) error
 		SpanNameAsResourceName: core.GetBool("otlp_config.traces.span_name_as_resource_name"),
 		ProbabilisticSampling:  core.GetFloat64("otlp_config.traces.probabilistic_sampler.sampling_percentage"),
 	}
+	if k := "apm_config.otlp_exporter.endpoint"; core.IsSet(k) {
+		endpoint := core.GetString(k)
+		if u, err := url.Parse(endpoint); err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("%s: invalid URL %q, it should be of the form http://host:4318/v1/traces", k, endpoint)
+		}
+		c.OTLPExporter.Endpoint = endpoint
+		c.OTLPExporter.Headers = core.GetStringMapString("apm_config.otlp_exporter.headers")
+	}
 
 	if core.GetBool("apm_config.telemetry.enabled") {
 		c.TelemetryConfig.Enabled = true
@@ -133,6 +133,8 @@ var (
 		nil, "Count the number of dogstatsd contexts in the aggregator")
 	tlmDogstatsdContextsByMtype = telemetry.NewGauge("aggregator", "dogstatsd_contexts_by_mtype",
 		[]string{"metric_type"}, "Count the number of dogstatsd contexts in the aggregator, by metric type")
+	tlmDogstatsdLateSamplesDropped = telemetry.NewCounter("aggregator", "dogstatsd_late_samples_dropped",
+		nil, "Count the number of dogstatsd samples dropped because their bucket had already been flushed")
 
 	// Hold series to be added to aggregated series on each flush
 	recurrentSeries     metrics.Series
@@ -827,6 +829,16 @@ func (agg *BufferedAggregator) updateChecksTelemetry() {
 	t.Flush()
 }
 
+// countChecksCardinality adds the contexts of the check samplers to accumulator.
+func (agg *BufferedAggregator) countChecksCardinality(accumulator *cardinalityAccumulator) {
+	agg.mu.Lock()
+	defer agg.mu.Unlock()
+
+	for _, sampler := range agg.checkSamplers {
+		sampler.countCardinality(accumulator)
+	}
+}
+
 // deregisterSampler is an item sent internally by the aggregator to
 // signal that the sender will no longer will be used for a given
 // checkid.ID.
@@ -0,0 +1,149 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package aggregator
+
+import (
+	"errors"
+	"sort"
+	"strings"
+)
+
+// CardinalityReport holds the metric names with the most contexts live in the aggregator,
+// for DogStatsD (the TimeSamplers) and for the checks (the CheckSamplers).
+type CardinalityReport struct {
+	Dogstatsd CardinalityStats `json:"dogstatsd"`
+	Checks    CardinalityStats `json:"checks"`
+}
+
+// CardinalityStats holds the context counts of a set of samplers.
+type CardinalityStats struct {
+	// Contexts is the total number of contexts, all metric names included
+	Contexts int `json:"contexts"`
+	// MetricNames is the number of distinct metric names
+	MetricNames int `json:"metric_names"`
+	// Metrics are the metric names with the most contexts, in decreasing order
+	Metrics []MetricCardinality `json:"metrics"`
+}
+
+// MetricCardinality holds the number of contexts of a metric name.
+type MetricCardinality struct {
+	Name     string `json:"name"`
+	Contexts int    `json:"contexts"`
+	// TagKeys are the tag keys of the metric with the most distinct values, in decreasing order
+	TagKeys []TagKeyCardinality `json:"tag_keys"`
+}
+
+// TagKeyCardinality holds the number of distinct values of a tag key.
+type TagKeyCardinality struct {
+	Key    string `json:"key"`
+	Values int    `json:"values"`
+}
+
+// cardinalityAccumulator counts the contexts of one or several context resolvers.
+//
+// It is not thread safe, the samplers fill it one after the other in their own goroutine.
+type cardinalityAccumulator struct {
+	contexts int
+	byName   map[string]*metricCardinalityAccumulator
+}
+
+type metricCardinalityAccumulator struct {
+	contexts int
+	// tagValues holds the distinct values of each tag key, tags without a value are counted
+	// as a key with an empty value
+	tagValues map[string]map[string]struct{}
+}
+
+func newCardinalityAccumulator() *cardinalityAccumulator {
+	return &cardinalityAccumulator{byName: map[string]*metricCardinalityAccumulator{}}
+}
+
+func (a *cardinalityAccumulator) add(cx *Context) {
+	a.contexts++
+
+	m, found := a.byName[cx.Name]
+	if !found {
+		m = &metricCardinalityAccumulator{tagValues: map[string]map[string]struct{}{}}
+		a.byName[cx.Name] = m
+	}
+	m.contexts++
+
+	cx.Tags().ForEach(func(tag string) {
+		key, value := tag, ""
+		if i := strings.IndexByte(tag, ':'); i >= 0 {
+			key, value = tag[:i], tag[i+1:]
+		}
+		values, found := m.tagValues[key]
+		if !found {
+			values = map[string]struct{}{}
+			m.tagValues[key] = values
+		}
+		values[value] = struct{}{}
+	})
+}
+
+// stats returns the topMetrics metric names with the most contexts, along with their
+// topTagKeys tag keys with the most distinct values. Ties are sorted by name.
+func (a *cardinalityAccumulator) stats(topMetrics, topTagKeys int) CardinalityStats {
+	stats := CardinalityStats{
+		Contexts:    a.contexts,
+		MetricNames: len(a.byName),
+		Metrics:     make([]MetricCardinality, 0, len(a.byName)),
+	}
+
+	for name, m := range a.byName {
+		stats.Metrics = append(stats.Metrics, MetricCardinality{Name: name, Contexts: m.contexts})
+	}
+	sort.Slice(stats.Metrics, func(i, j int) bool {
+		if stats.Metrics[i].Contexts != stats.Metrics[j].Contexts {
+			return stats.Metrics[i].Contexts > stats.Metrics[j].Contexts
+		}
+		return stats.Metrics[i].Name < stats.Metrics[j].Name
+	})
+	if len(stats.Metrics) > topMetrics {
+		stats.Metrics = stats.Metrics[:topMetrics]
+	}
+
+	for i := range stats.Metrics {
+		tagValues := a.byName[stats.Metrics[i].Name].tagValues
+		tagKeys := make([]TagKeyCardinality, 0, len(tagValues))
+		for key, values := range tagValues {
+			tagKeys = append(tagKeys, TagKeyCardinality{Key: key, Values: len(values)})
+		}
+		sort.Slice(tagKeys, func(i, j int) bool {
+			if tagKeys[i].Values != tagKeys[j].Values {
+				return tagKeys[i].Values > tagKeys[j].Values
+			}
+			return tagKeys[i].Key < tagKeys[j].Key
+		})
+		if len(tagKeys) > topTagKeys {
+			tagKeys = tagKeys[:topTagKeys]
+		}
+		stats.Metrics[i].TagKeys = tagKeys
+	}
+
+	return stats
+}
+
+// cardinalityTrigger asks a timeSamplerWorker to count the contexts of its TimeSampler.
+// A message is sent on blockChan when it is done.
+type cardinalityTrigger struct {
+	accumulator *cardinalityAccumulator
+	blockChan   chan struct{}
+}
+
+// GetCardinalityReport returns the cardinality report of the running agent demultiplexer,
+// see AgentDemultiplexer.CardinalityReport.
+func GetCardinalityReport(topMetrics, topTagKeys int) (CardinalityReport, error) {
+	demultiplexerInstanceMu.Lock()
+	demux, ok := demultiplexerInstance.(*AgentDemultiplexer)
+	demultiplexerInstanceMu.Unlock()
+
+	if !ok {
+		return CardinalityReport{}, errors.New("the aggregator is not running")
+	}
+	return demux.CardinalityReport(topMetrics, topTagKeys)
+}
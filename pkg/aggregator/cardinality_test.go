@@ -0,0 +1,121 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build test
+
+package aggregator
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/comp/core/config"
+	"github.com/DataDog/datadog-agent/comp/core/log"
+	"github.com/DataDog/datadog-agent/comp/forwarder/defaultforwarder"
+	checkid "github.com/DataDog/datadog-agent/pkg/collector/check/id"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/util/fxutil"
+)
+
+func TestCardinalityStats(t *testing.T) {
+	sampler := testTimeSampler()
+	for i := 0; i < 10; i++ {
+		sampler.sample(&metrics.MetricSample{
+			Name:       "http.requests",
+			Value:      1,
+			Mtype:      metrics.CounterType,
+			Tags:       []string{"env:prod", fmt.Sprintf("pod_name:web-%d", i), fmt.Sprintf("status:%d", 200+i%2), "canary"},
+			SampleRate: 1,
+		}, 12345.0)
+	}
+	for i := 0; i < 3; i++ {
+		sampler.sample(&metrics.MetricSample{
+			Name:       "queue.depth",
+			Value:      1,
+			Mtype:      metrics.GaugeType,
+			Tags:       []string{fmt.Sprintf("queue:%d", i)},
+			SampleRate: 1,
+		}, 12345.0)
+	}
+	sampler.sample(&metrics.MetricSample{Name: "uptime", Value: 1, Mtype: metrics.GaugeType, SampleRate: 1}, 12345.0)
+
+	accumulator := newCardinalityAccumulator()
+	sampler.countCardinality(accumulator)
+
+	stats := accumulator.stats(2, 3)
+	assert.Equal(t, 14, stats.Contexts)
+	assert.Equal(t, 3, stats.MetricNames)
+	assert.Equal(t, []MetricCardinality{
+		{
+			Name:     "http.requests",
+			Contexts: 10,
+			TagKeys: []TagKeyCardinality{
+				{Key: "pod_name", Values: 10},
+				{Key: "status", Values: 2},
+				{Key: "canary", Values: 1},
+			},
+		},
+		{
+			Name:     "queue.depth",
+			Contexts: 3,
+			TagKeys:  []TagKeyCardinality{{Key: "queue", Values: 3}},
+		},
+	}, stats.Metrics)
+}
+
+func TestCardinalityReport(t *testing.T) {
+	opts := demuxTestOptions()
+	deps := fxutil.Test[AggregatorTestDeps](t, defaultforwarder.MockModule, config.MockModule, log.MockModule)
+	demux := InitAndStartAgentDemultiplexerForTest(deps, opts, "")
+
+	demux.AggregateSamples(0, testDemuxSamples(t))
+
+	sender, err := demux.GetSender(checkid.ID("cardinality_check"))
+	require.NoError(t, err)
+	sender.Gauge("check.metric", 1, "", []string{"instance:a"})
+	sender.Gauge("check.metric", 1, "", []string{"instance:b"})
+
+	require.Eventually(t, func() bool {
+		report, err := GetCardinalityReport(10, 10)
+		require.NoError(t, err)
+		return report.Dogstatsd.Contexts == 3 && report.Checks.Contexts == 2
+	}, 5*time.Second, 10*time.Millisecond)
+
+	report, err := GetCardinalityReport(1, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []MetricCardinality{{Name: "check.metric", Contexts: 2, TagKeys: []TagKeyCardinality{{Key: "instance", Values: 2}}}}, report.Checks.Metrics)
+	assert.Equal(t, 3, report.Dogstatsd.MetricNames)
+	assert.Len(t, report.Dogstatsd.Metrics, 1)
+
+	demux.Stop(false)
+	_, err = GetCardinalityReport(1, 1)
+	assert.Error(t, err)
+}
+
+func TestCardinalityReportBusySampler(t *testing.T) {
+	opts := demuxTestOptions()
+	deps := fxutil.Test[AggregatorTestDeps](t, defaultforwarder.MockModule, config.MockModule, log.MockModule)
+	demux := InitAndStartAgentDemultiplexerForTest(deps, opts, "")
+	defer demux.Stop(false)
+
+	defer func(timeout time.Duration) { cardinalityReportTimeout = timeout }(cardinalityReportTimeout)
+	cardinalityReportTimeout = 50 * time.Millisecond
+
+	// the worker is blocked until blockChan is read
+	busy := cardinalityTrigger{accumulator: newCardinalityAccumulator(), blockChan: make(chan struct{})}
+	demux.statsd.workers[0].cardinalityChan <- busy
+
+	// the report doesn't wait indefinitely for the worker
+	_, err := GetCardinalityReport(1, 1)
+	assert.Error(t, err)
+
+	<-busy.blockChan
+	_, err = GetCardinalityReport(1, 1)
+	assert.NoError(t, err)
+}
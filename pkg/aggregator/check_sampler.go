@@ -195,6 +195,11 @@ func (cs *CheckSampler) flush() (metrics.Series, metrics.SketchSeriesList) {
 	return series, sketches
 }
 
+// countCardinality adds the contexts of the sampler to accumulator.
+func (cs *CheckSampler) countCardinality(accumulator *cardinalityAccumulator) {
+	cs.contextResolver.countCardinality(accumulator)
+}
+
 func (cs *CheckSampler) release() {
 	cs.contextResolver.release()
 }
@@ -10,7 +10,9 @@ import (
 
 	"github.com/DataDog/datadog-agent/pkg/aggregator/ckey"
 	"github.com/DataDog/datadog-agent/pkg/aggregator/internal/limiter"
+	"github.com/DataDog/datadog-agent/pkg/aggregator/internal/tag_values_limiter"
 	"github.com/DataDog/datadog-agent/pkg/aggregator/internal/tags"
+	"github.com/DataDog/datadog-agent/pkg/aggregator/internal/tags_filter"
 	"github.com/DataDog/datadog-agent/pkg/aggregator/internal/tags_limiter"
 	"github.com/DataDog/datadog-agent/pkg/metrics"
 	"github.com/DataDog/datadog-agent/pkg/tagset"
@@ -47,6 +49,9 @@ type contextResolver struct {
 	metricBuffer    *tagset.HashingTagsAccumulator
 	contextsLimiter *limiter.Limiter
 	tagsLimiter     *tags_limiter.Limiter
+	tagsFilter      *tags_filter.Filter
+
+	tagValuesLimiter *tag_values_limiter.Limiter
 }
 
 // generateContextKey generates the contextKey associated with the context of the metricSample
@@ -54,7 +59,7 @@ func (cr *contextResolver) generateContextKey(metricSampleContext metrics.Metric
 	return cr.keyGenerator.GenerateWithTags2(metricSampleContext.GetName(), metricSampleContext.GetHost(), cr.taggerBuffer, cr.metricBuffer)
 }
 
-func newContextResolver(cache *tags.Store, contextsLimiter *limiter.Limiter, tagsLimiter *tags_limiter.Limiter) *contextResolver {
+func newContextResolver(cache *tags.Store, contextsLimiter *limiter.Limiter, tagsLimiter *tags_limiter.Limiter, tagsFilter *tags_filter.Filter, tagValuesLimiter *tag_values_limiter.Limiter) *contextResolver {
 	return &contextResolver{
 		contextsByKey:   make(map[ckey.ContextKey]*Context),
 		countsByMtype:   make([]uint64, metrics.NumMetricTypes),
@@ -64,6 +69,9 @@ func newContextResolver(cache *tags.Store, contextsLimiter *limiter.Limiter, tag
 		metricBuffer:    tagset.NewHashingTagsAccumulator(),
 		contextsLimiter: contextsLimiter,
 		tagsLimiter:     tagsLimiter,
+		tagsFilter:      tagsFilter,
+
+		tagValuesLimiter: tagValuesLimiter,
 	}
 }
 
@@ -72,6 +80,8 @@ func (cr *contextResolver) trackContext(metricSampleContext metrics.MetricSample
 	metricSampleContext.GetTags(cr.taggerBuffer, cr.metricBuffer) // tags here are not sorted and can contain duplicates
 	defer cr.taggerBuffer.Reset()
 	defer cr.metricBuffer.Reset()
+	cr.tagsFilter.Apply(metricSampleContext.GetName(), cr.taggerBuffer, cr.metricBuffer)
+	cr.tagValuesLimiter.Apply(metricSampleContext.GetName(), cr.taggerBuffer, cr.metricBuffer)
 
 	contextKey, taggerKey, metricKey := cr.generateContextKey(metricSampleContext) // the generator will remove duplicates (and doesn't mind the order)
 
@@ -123,6 +133,13 @@ func (cr *contextResolver) remove(expiredContextKey ckey.ContextKey) {
 	}
 }
 
+// countCardinality adds the tracked contexts to accumulator.
+func (cr *contextResolver) countCardinality(accumulator *cardinalityAccumulator) {
+	for _, cx := range cr.contextsByKey {
+		accumulator.add(cx)
+	}
+}
+
 func (cr *contextResolver) release() {
 	for _, c := range cr.contextsByKey {
 		c.release()
@@ -131,6 +148,7 @@ func (cr *contextResolver) release() {
 
 func (cr *contextResolver) removeOverLimit(keep func(ckey.ContextKey) bool) {
 	cr.contextsLimiter.ExpireEntries()
+	cr.tagValuesLimiter.ExpireEntries()
 
 	for key, cx := range cr.contextsByKey {
 		if cr.contextsLimiter.IsOverLimit(cx.taggerTags.Tags()) && (keep == nil || !keep(key)) {
@@ -172,6 +190,7 @@ func (c *contextResolver) sendOriginTelemetry(timestamp float64, series metrics.
 func (c *contextResolver) sendLimiterTelemetry(timestamp float64, series metrics.SerieSink, hostname string, constTags []string) {
 	c.contextsLimiter.SendTelemetry(timestamp, series, hostname, constTags)
 	c.tagsLimiter.SendTelemetry(timestamp, series, hostname, constTags)
+	c.tagValuesLimiter.SendTelemetry(timestamp, series, hostname, constTags)
 }
 
 // timestampContextResolver allows tracking and expiring contexts based on time.
@@ -180,9 +199,9 @@ type timestampContextResolver struct {
 	lastSeenByKey map[ckey.ContextKey]float64
 }
 
-func newTimestampContextResolver(cache *tags.Store, contextsLimiter *limiter.Limiter, tagsLimiter *tags_limiter.Limiter) *timestampContextResolver {
+func newTimestampContextResolver(cache *tags.Store, contextsLimiter *limiter.Limiter, tagsLimiter *tags_limiter.Limiter, tagsFilter *tags_filter.Filter, tagValuesLimiter *tag_values_limiter.Limiter) *timestampContextResolver {
 	return &timestampContextResolver{
-		resolver:      newContextResolver(cache, contextsLimiter, tagsLimiter),
+		resolver:      newContextResolver(cache, contextsLimiter, tagsLimiter, tagsFilter, tagValuesLimiter),
 		lastSeenByKey: make(map[ckey.ContextKey]float64),
 	}
 }
@@ -233,6 +252,10 @@ func (cr *timestampContextResolver) expireContexts(expireTimestamp float64, keep
 	cr.resolver.removeOverLimit(keep)
 }
 
+func (cr *timestampContextResolver) countCardinality(accumulator *cardinalityAccumulator) {
+	cr.resolver.countCardinality(accumulator)
+}
+
 func (cr *timestampContextResolver) sendOriginTelemetry(timestamp float64, series metrics.SerieSink, hostname string, tags []string) {
 	cr.resolver.sendOriginTelemetry(timestamp, series, hostname, tags)
 }
@@ -252,7 +275,7 @@ type countBasedContextResolver struct {
 
 func newCountBasedContextResolver(expireCountInterval int, cache *tags.Store) *countBasedContextResolver {
 	return &countBasedContextResolver{
-		resolver:            newContextResolver(cache, nil, nil),
+		resolver:            newContextResolver(cache, nil, nil, nil, nil),
 		expireCountByKey:    make(map[ckey.ContextKey]int64),
 		expireCount:         0,
 		expireCountInterval: int64(expireCountInterval),
@@ -285,6 +308,10 @@ func (cr *countBasedContextResolver) expireContexts() []ckey.ContextKey {
 	return keys
 }
 
+func (cr *countBasedContextResolver) countCardinality(accumulator *cardinalityAccumulator) {
+	cr.resolver.countCardinality(accumulator)
+}
+
 func (cr *countBasedContextResolver) release() {
 	cr.resolver.release()
 }
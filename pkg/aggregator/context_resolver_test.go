@@ -17,8 +17,11 @@ import (
 
 	"github.com/DataDog/datadog-agent/pkg/aggregator/ckey"
 	"github.com/DataDog/datadog-agent/pkg/aggregator/internal/limiter"
+	"github.com/DataDog/datadog-agent/pkg/aggregator/internal/tag_values_limiter"
 	"github.com/DataDog/datadog-agent/pkg/aggregator/internal/tags"
+	"github.com/DataDog/datadog-agent/pkg/aggregator/internal/tags_filter"
 	"github.com/DataDog/datadog-agent/pkg/aggregator/internal/tags_limiter"
+	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/metrics"
 	"github.com/DataDog/datadog-agent/pkg/tagset"
 )
@@ -78,7 +81,7 @@ func testTrackContext(t *testing.T, store *tags.Store) {
 		SampleRate: 1,
 	}
 
-	contextResolver := newContextResolver(store, nil, nil)
+	contextResolver := newContextResolver(store, nil, nil, nil, nil)
 
 	// Track the 2 contexts
 	contextKey1, _ := contextResolver.trackContext(&mSample1)
@@ -123,7 +126,7 @@ func testExpireContexts(t *testing.T, store *tags.Store) {
 		Tags:       []string{"foo", "bar", "baz"},
 		SampleRate: 1,
 	}
-	contextResolver := newTimestampContextResolver(store, nil, nil)
+	contextResolver := newTimestampContextResolver(store, nil, nil, nil, nil)
 
 	// Track the 2 contexts
 	contextKey1, _ := contextResolver.trackContext(&mSample1, 4)
@@ -165,7 +168,7 @@ func testExpireContextsWithKeep(t *testing.T, store *tags.Store) {
 		Tags:       []string{"foo", "bar", "baz"},
 		SampleRate: 1,
 	}
-	contextResolver := newTimestampContextResolver(store, nil, nil)
+	contextResolver := newTimestampContextResolver(store, nil, nil, nil, nil)
 
 	// Track the 2 contexts
 	contextKey1, _ := contextResolver.trackContext(&mSample1, 4)
@@ -242,7 +245,7 @@ func TestCountBasedExpireContexts(t *testing.T) {
 }
 
 func testTagDeduplication(t *testing.T, store *tags.Store) {
-	resolver := newContextResolver(store, nil, nil)
+	resolver := newContextResolver(store, nil, nil, nil, nil)
 
 	ckey, _ := resolver.trackContext(&metrics.MetricSample{
 		Name: "foo",
@@ -279,8 +282,52 @@ func (s *mockSample) GetTags(tb, mb tagset.TagsAccumulator) {
 	mb.Append(s.metricTags...)
 }
 
+func TestTagsFilter(t *testing.T) {
+	f := tags_filter.New([]config.MetricTagFilter{{MetricName: "foo", Action: "exclude", Tags: []string{"pod_name", "container_id"}}})
+	r := newContextResolver(tags.NewStore(true, "test"), nil, nil, f, nil)
+
+	key1, ok := r.trackContext(&mockSample{"foo", []string{"pod_name:a", "kube_namespace:ns"}, []string{"env:prod", "container_id:1"}})
+	require.True(t, ok)
+	key2, ok := r.trackContext(&mockSample{"foo", []string{"pod_name:b", "kube_namespace:ns"}, []string{"env:prod", "container_id:2"}})
+	require.True(t, ok)
+	key3, ok := r.trackContext(&mockSample{"bar", []string{"pod_name:b", "kube_namespace:ns"}, []string{"env:prod", "container_id:2"}})
+	require.True(t, ok)
+
+	// the samples of foo are aggregated across pods and containers
+	assert.Equal(t, key1, key2)
+	assert.NotEqual(t, key1, key3)
+	assert.Equal(t, 2, r.length())
+
+	context, _ := r.get(key1)
+	assert.ElementsMatch(t, []string{"kube_namespace:ns", "env:prod"}, context.Tags().UnsafeToReadOnlySliceString())
+	context, _ = r.get(key3)
+	assert.ElementsMatch(t, []string{"pod_name:b", "kube_namespace:ns", "env:prod", "container_id:2"}, context.Tags().UnsafeToReadOnlySliceString())
+}
+
+func TestTagValuesLimiter(t *testing.T) {
+	l := tag_values_limiter.New(2, []string{"user_id"}, "other", 1)
+	r := newContextResolver(tags.NewStore(true, "test"), nil, nil, nil, l)
+
+	key1, ok := r.trackContext(&mockSample{"foo", nil, []string{"user_id:1", "env:prod"}})
+	require.True(t, ok)
+	key2, ok := r.trackContext(&mockSample{"foo", nil, []string{"user_id:2", "env:prod"}})
+	require.True(t, ok)
+	key3, ok := r.trackContext(&mockSample{"foo", nil, []string{"user_id:3", "env:prod"}})
+	require.True(t, ok)
+	key4, ok := r.trackContext(&mockSample{"foo", nil, []string{"user_id:4", "env:prod"}})
+	require.True(t, ok)
+
+	// the samples over the limit are aggregated in a single context
+	assert.NotEqual(t, key1, key2)
+	assert.Equal(t, key3, key4)
+	assert.Equal(t, 3, r.length())
+
+	context, _ := r.get(key3)
+	assert.ElementsMatch(t, []string{"user_id:other", "env:prod"}, context.Tags().UnsafeToReadOnlySliceString())
+}
+
 func TestOriginTelemetry(t *testing.T) {
-	r := newContextResolver(tags.NewStore(true, "test"), nil, nil)
+	r := newContextResolver(tags.NewStore(true, "test"), nil, nil, nil, nil)
 	r.trackContext(&mockSample{"foo", []string{"foo"}, []string{"ook"}})
 	r.trackContext(&mockSample{"foo", []string{"foo"}, []string{"eek"}})
 	r.trackContext(&mockSample{"foo", []string{"bar"}, []string{"ook"}})
@@ -314,7 +361,7 @@ func TestOriginTelemetry(t *testing.T) {
 func TestLimiterTelemetry(t *testing.T) {
 	l := limiter.New(2, "pod", []string{"pod", "srv"})
 	tl := tags_limiter.New(4)
-	r := newContextResolver(tags.NewStore(true, "test"), l, tl)
+	r := newContextResolver(tags.NewStore(true, "test"), l, tl, nil, nil)
 	r.trackContext(&mockSample{"foo", []string{"pod:foo", "srv:foo"}, []string{"pod:bar"}})
 	r.trackContext(&mockSample{"foo", []string{"pod:foo", "srv:foo"}, []string{"srv:bar"}})
 	r.trackContext(&mockSample{"bar", []string{"pod:foo", "srv:foo"}, []string{"srv:bar"}})
@@ -374,7 +421,7 @@ func TestLimiterTelemetry(t *testing.T) {
 func TestTimestampContextResolverLimit(t *testing.T) {
 	store := tags.NewStore(true, "")
 	limiter := limiter.New(1, "pod", []string{})
-	r := newTimestampContextResolver(store, limiter, nil)
+	r := newTimestampContextResolver(store, limiter, nil, nil, nil)
 
 	r.trackContext(&mockSample{"foo", []string{"pod:foo", "srv:foo"}, []string{"pod:bar"}}, 42)
 	r.trackContext(&mockSample{"foo", []string{"pod:foo", "srv:foo"}, []string{"srv:bar"}}, 42)
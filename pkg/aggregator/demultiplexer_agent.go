@@ -6,6 +6,7 @@
 package aggregator
 
 import (
+	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -14,7 +15,9 @@ import (
 	"github.com/DataDog/datadog-agent/comp/forwarder/defaultforwarder"
 	forwarder "github.com/DataDog/datadog-agent/comp/forwarder/defaultforwarder"
 	"github.com/DataDog/datadog-agent/pkg/aggregator/internal/limiter"
+	"github.com/DataDog/datadog-agent/pkg/aggregator/internal/tag_values_limiter"
 	"github.com/DataDog/datadog-agent/pkg/aggregator/internal/tags"
+	"github.com/DataDog/datadog-agent/pkg/aggregator/internal/tags_filter"
 	"github.com/DataDog/datadog-agent/pkg/aggregator/internal/tags_limiter"
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/epforwarder"
@@ -75,6 +78,11 @@ type AgentDemultiplexerOptions struct {
 
 	UseDogstatsdContextLimiter bool
 	DogstatsdMaxMetricsTags    int
+
+	// DogstatsdMaxLateness is how late a DogStatsD sample with a timestamp can be to be
+	// aggregated in the bucket of its timestamp instead of being sent without aggregation.
+	// The TimeSamplers keep their buckets open that long before flushing them, 0 disables it.
+	DogstatsdMaxLateness time.Duration
 }
 
 // DefaultAgentDemultiplexerOptions returns the default options to initialize an AgentDemultiplexer.
@@ -188,35 +196,45 @@ func initAgentDemultiplexer(log log.Component, sharedForwarder forwarder.Forward
 	bufferSize := config.Datadog.GetInt("aggregator_buffer_size")
 	metricSamplePool := metrics.NewMetricSamplePool(MetricSamplePoolBatchSize)
 
+	var noAggWorker *noAggregationStreamWorker
+	var noAggSerializer serializer.MetricSerializer
+	if options.EnableNoAggregationPipeline {
+		noAggSerializer = serializer.NewSerializer(sharedForwarder, orchestratorForwarder)
+		noAggWorker = newNoAggregationStreamWorker(
+			config.Datadog.GetInt("dogstatsd_no_aggregation_pipeline_batch_size"),
+			metricSamplePool,
+			noAggSerializer,
+			agg.flushAndSerializeInParallel,
+		)
+	}
+
 	_, statsdPipelinesCount := GetDogStatsDWorkerAndPipelineCount()
 	log.Debug("the Demultiplexer will use", statsdPipelinesCount, "pipelines")
 
 	statsdWorkers := make([]*timeSamplerWorker, statsdPipelinesCount)
+	tagsFilter := tags_filter.FromConfig()
 
 	for i := 0; i < statsdPipelinesCount; i++ {
 		// the sampler
 		tagsStore := tags.NewStore(config.Datadog.GetBool("aggregator_use_tags_store"), fmt.Sprintf("timesampler #%d", i))
 		tagsLimiter := tags_limiter.New(options.DogstatsdMaxMetricsTags)
 		contextsLimiter := limiter.FromConfig(statsdPipelinesCount, options.UseDogstatsdContextLimiter)
+		var tagValuesLimiter *tag_values_limiter.Limiter
+		if options.UseDogstatsdContextLimiter {
+			tagValuesLimiter = tag_values_limiter.FromConfig(statsdPipelinesCount)
+		}
 
-		statsdSampler := NewTimeSampler(TimeSamplerID(i), bucketSize, tagsStore, contextsLimiter, tagsLimiter, agg.hostname)
+		statsdSampler := NewTimeSampler(TimeSamplerID(i), bucketSize, tagsStore, contextsLimiter, tagsLimiter, tagsFilter, tagValuesLimiter, agg.hostname)
+		statsdSampler.maxLateness = int64(options.DogstatsdMaxLateness / time.Second)
 
 		// its worker (process loop + flush/serialization mechanism)
 
 		statsdWorkers[i] = newTimeSamplerWorker(statsdSampler, options.FlushInterval,
 			bufferSize, metricSamplePool, agg.flushAndSerializeInParallel, tagsStore)
-	}
-
-	var noAggWorker *noAggregationStreamWorker
-	var noAggSerializer serializer.MetricSerializer
-	if options.EnableNoAggregationPipeline {
-		noAggSerializer = serializer.NewSerializer(sharedForwarder, orchestratorForwarder)
-		noAggWorker = newNoAggregationStreamWorker(
-			config.Datadog.GetInt("dogstatsd_no_aggregation_pipeline_batch_size"),
-			metricSamplePool,
-			noAggSerializer,
-			agg.flushAndSerializeInParallel,
-		)
+		if noAggWorker != nil {
+			// the samples arriving after their bucket was flushed are sent as is
+			statsdWorkers[i].lateSamples = noAggWorker.addSamples
+		}
 	}
 
 	// --
@@ -527,6 +545,55 @@ func (d *AgentDemultiplexer) flushToSerializer(start time.Time, waitForSerialize
 	aggregatorNumberOfFlush.Add(1)
 }
 
+// cardinalityReportTimeout is how long CardinalityReport waits for each sampler to count its contexts.
+var cardinalityReportTimeout = 5 * time.Second
+
+// CardinalityReport returns the topMetrics metric names with the most contexts in the
+// TimeSamplers and in the CheckSamplers, along with, for each of them, the topTagKeys
+// tag keys with the most distinct values.
+//
+// The samplers count their contexts one after the other, each of them stops processing
+// samples while it is counting. An error is returned if a sampler doesn't count its
+// contexts within cardinalityReportTimeout, for instance because it is busy flushing.
+func (d *AgentDemultiplexer) CardinalityReport(topMetrics, topTagKeys int) (CardinalityReport, error) {
+	// the lock is not held while waiting for the samplers, a flush needs it
+	d.m.Lock()
+	agg := d.aggregator
+	workers := append([]*timeSamplerWorker(nil), d.statsd.workers...)
+	d.m.Unlock()
+
+	if agg == nil {
+		return CardinalityReport{}, errors.New("the aggregator is stopped")
+	}
+
+	dogstatsd := newCardinalityAccumulator()
+	for i, worker := range workers {
+		t := cardinalityTrigger{
+			accumulator: dogstatsd,
+			// buffered so that a worker answering after the timeout doesn't block
+			blockChan: make(chan struct{}, 1),
+		}
+		select {
+		case worker.cardinalityChan <- t:
+		case <-time.After(cardinalityReportTimeout):
+			return CardinalityReport{}, fmt.Errorf("the time sampler %d is busy, try again later", i)
+		}
+		select {
+		case <-t.blockChan:
+		case <-time.After(cardinalityReportTimeout):
+			return CardinalityReport{}, fmt.Errorf("the time sampler %d did not count its contexts in time, try again later", i)
+		}
+	}
+
+	checks := newCardinalityAccumulator()
+	agg.countChecksCardinality(checks)
+
+	return CardinalityReport{
+		Dogstatsd: dogstatsd.stats(topMetrics, topTagKeys),
+		Checks:    checks.stats(topMetrics, topTagKeys),
+	}, nil
+}
+
 // GetEventsAndServiceChecksChannels returneds underlying events and service checks channels.
 func (d *AgentDemultiplexer) GetEventsAndServiceChecksChannels() (chan []*event.Event, chan []*servicecheck.ServiceCheck) {
 	return d.aggregator.GetBufferedChannels()
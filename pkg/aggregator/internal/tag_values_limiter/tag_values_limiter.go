@@ -0,0 +1,202 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package tag_values_limiter caps the number of distinct values of the tag keys of each metric.
+// Once a metric has reached the limit for a tag key, its samples with other values for this key
+// are kept but their value is replaced by a placeholder, so that they are aggregated in a single
+// context.
+package tag_values_limiter
+
+import (
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/tagset"
+	"github.com/DataDog/datadog-agent/pkg/telemetry"
+)
+
+var tlmRewrittenTags = telemetry.NewCounter("aggregator", "tag_values_limiter_rewritten_tags",
+	[]string{"tag_key"}, "Count of tags whose value was replaced by the tag values limiter")
+
+type keyEntry struct {
+	// values maps the values allowed for the key to the expireCount when they were seen last
+	values map[string]int
+	// placeholderTag is the tag replacing the values over the limit
+	placeholderTag string
+	// rewritten is the number of tags rewritten since the last telemetry
+	rewritten uint64
+}
+
+// Limiter tracks the distinct values of the tag keys of each metric name, and replaces
+// the values over the limit by a placeholder.
+//
+// A nil *Limiter is valid and does nothing. Not thread safe.
+type Limiter struct {
+	limit       int
+	placeholder string
+	// keys are the limited tag keys, all the keys are limited if it is nil
+	keys map[string]struct{}
+
+	entries map[string]map[string]*keyEntry // metric name -> tag key -> entry
+
+	// current holds the entries of the metric being processed by Apply, rewriteFunc is
+	// bound once to avoid an allocation for every sample
+	current     map[string]*keyEntry
+	rewriteFunc func(tag string) string
+
+	// expireCount ensures the eventual removal of the values which are not used anymore,
+	// which makes room for new values.
+	expireCount         int
+	expireCountInterval int
+}
+
+// FromConfig returns a Limiter built from the dogstatsd_tag_values_limiter settings,
+// or nil if the limiter is disabled. The contexts of a metric are spread over the
+// pipelineCount pipelines, each of them gets an equal share of the configured limit.
+func FromConfig(pipelineCount int) *Limiter {
+	limit := config.Datadog.GetInt("dogstatsd_tag_values_limiter.limit")
+	if limit > 0 && pipelineCount > 0 {
+		limit = limit / pipelineCount
+		if limit == 0 {
+			// the limiter must not be disabled by the division
+			limit = 1
+		}
+	}
+	return New(
+		limit,
+		config.Datadog.GetStringSlice("dogstatsd_tag_values_limiter.tag_keys"),
+		config.Datadog.GetString("dogstatsd_tag_values_limiter.placeholder"),
+		config.Datadog.GetInt("dogstatsd_tag_values_limiter.entry_timeout"),
+	)
+}
+
+// New returns a limiter allowing limit distinct values for each key of tagKeys, or for every key
+// if tagKeys is empty. A value not seen for expireCountInterval calls to ExpireEntries no longer
+// counts toward the limit. If limit is zero or less, the limiter is disabled.
+func New(limit int, tagKeys []string, placeholder string, expireCountInterval int) *Limiter {
+	if limit <= 0 {
+		return nil
+	}
+
+	var keys map[string]struct{}
+	if len(tagKeys) > 0 {
+		keys = make(map[string]struct{}, len(tagKeys))
+		for _, key := range tagKeys {
+			keys[strings.TrimSuffix(key, ":")] = struct{}{}
+		}
+	}
+
+	l := &Limiter{
+		limit:               limit,
+		placeholder:         placeholder,
+		keys:                keys,
+		entries:             map[string]map[string]*keyEntry{},
+		expireCountInterval: expireCountInterval,
+	}
+	l.rewriteFunc = l.rewrite
+	return l
+}
+
+// Apply replaces the values of taggerTags and metricTags which are over the limit for the metric name.
+func (l *Limiter) Apply(name string, taggerTags, metricTags *tagset.HashingTagsAccumulator) {
+	if l == nil {
+		return
+	}
+
+	l.current = l.entries[name]
+	if l.current == nil {
+		l.current = map[string]*keyEntry{}
+		l.entries[name] = l.current
+	}
+
+	taggerTags.Rewrite(l.rewriteFunc)
+	metricTags.Rewrite(l.rewriteFunc)
+	l.current = nil
+}
+
+// rewrite returns the tag, or the placeholder tag of its key if the current metric
+// already has too many values for this key.
+func (l *Limiter) rewrite(tag string) string {
+	i := strings.IndexByte(tag, ':')
+	if i < 0 {
+		return tag
+	}
+	key, value := tag[:i], tag[i+1:]
+	if l.keys != nil {
+		if _, limited := l.keys[key]; !limited {
+			return tag
+		}
+	}
+
+	e := l.current[key]
+	if e == nil {
+		e = &keyEntry{
+			values:         map[string]int{},
+			placeholderTag: key + ":" + l.placeholder,
+		}
+		l.current[key] = e
+	}
+
+	if _, found := e.values[value]; found || len(e.values) < l.limit {
+		e.values[value] = l.expireCount
+		return tag
+	}
+	if tag == e.placeholderTag {
+		return tag
+	}
+
+	e.rewritten++
+	tlmRewrittenTags.Inc(key)
+	return e.placeholderTag
+}
+
+// ExpireEntries is called once per flush cycle to forget the values which have not been seen recently.
+func (l *Limiter) ExpireEntries() {
+	if l == nil {
+		return
+	}
+
+	l.expireCount++
+	tooOld := l.expireCount - l.expireCountInterval
+	for name, byKey := range l.entries {
+		for key, e := range byKey {
+			for value, lastExpireCount := range e.values {
+				if lastExpireCount < tooOld {
+					delete(e.values, value)
+				}
+			}
+			if len(e.values) == 0 && e.rewritten == 0 {
+				delete(byKey, key)
+			}
+		}
+		if len(byKey) == 0 {
+			delete(l.entries, name)
+		}
+	}
+}
+
+// SendTelemetry appends the number of rewritten tags, by metric name and tag key, to the series sink.
+func (l *Limiter) SendTelemetry(timestamp float64, series metrics.SerieSink, hostname string, constTags []string) {
+	if l == nil {
+		return
+	}
+
+	for name, byKey := range l.entries {
+		for key, e := range byKey {
+			if e.rewritten == 0 {
+				continue
+			}
+			series.Append(&metrics.Serie{
+				Name:   "datadog.agent.aggregator.dogstatsd_tag_values_limiter.rewritten_tags",
+				Host:   hostname,
+				Tags:   tagset.NewCompositeTags(constTags, []string{"metric_name:" + name, "tag_key:" + key}),
+				MType:  metrics.APICountType,
+				Points: []metrics.Point{{Ts: timestamp, Value: float64(e.rewritten)}},
+			})
+			e.rewritten = 0
+		}
+	}
+}
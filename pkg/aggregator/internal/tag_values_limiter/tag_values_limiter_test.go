@@ -0,0 +1,101 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package tag_values_limiter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/tagset"
+)
+
+func apply(l *Limiter, name string, metricTags ...string) []string {
+	tb := tagset.NewHashingTagsAccumulatorWithTags(metricTags)
+	l.Apply(name, tagset.NewHashingTagsAccumulator(), tb)
+	return tb.Get()
+}
+
+func TestNewDisabled(t *testing.T) {
+	assert.Nil(t, New(0, []string{"user_id"}, "other", 1))
+
+	var l *Limiter
+	assert.Equal(t, []string{"user_id:1"}, apply(l, "foo", "user_id:1"))
+	l.ExpireEntries()
+}
+
+func TestFromConfig(t *testing.T) {
+	m := config.Mock(t)
+	assert.Nil(t, FromConfig(2))
+
+	// the limit is shared by the pipelines
+	m.Set("dogstatsd_tag_values_limiter.limit", 100)
+	assert.Equal(t, 100, FromConfig(1).limit)
+	assert.Equal(t, 25, FromConfig(4).limit)
+	assert.Equal(t, 1, FromConfig(200).limit)
+}
+
+func TestApply(t *testing.T) {
+	l := New(2, []string{"user_id", "session_id:"}, "other", 1)
+
+	assert.Equal(t, []string{"user_id:1", "env:prod"}, apply(l, "foo", "user_id:1", "env:prod"))
+	assert.Equal(t, []string{"user_id:2", "env:dev"}, apply(l, "foo", "user_id:2", "env:dev"))
+	assert.Equal(t, []string{"user_id:other", "env:staging"}, apply(l, "foo", "user_id:3", "env:staging"))
+	// known values are still allowed
+	assert.Equal(t, []string{"user_id:1"}, apply(l, "foo", "user_id:1"))
+	// the placeholder is not counted as a rewrite
+	assert.Equal(t, []string{"user_id:other"}, apply(l, "foo", "user_id:other"))
+	// limits are per metric and per key
+	assert.Equal(t, []string{"user_id:3", "session_id:3"}, apply(l, "bar", "user_id:3", "session_id:3"))
+
+	// tagger tags are limited as well
+	taggerTags := tagset.NewHashingTagsAccumulatorWithTags([]string{"user_id:4"})
+	l.Apply("foo", taggerTags, tagset.NewHashingTagsAccumulator())
+	assert.Equal(t, []string{"user_id:other"}, taggerTags.Get())
+	assert.Equal(t, tagset.NewHashingTagsAccumulatorWithTags([]string{"user_id:other"}).Hashes(), taggerTags.Hashes())
+}
+
+func TestApplyAllKeys(t *testing.T) {
+	l := New(1, nil, "overflow", 1)
+
+	assert.Equal(t, []string{"env:prod", "debug"}, apply(l, "foo", "env:prod", "debug"))
+	assert.Equal(t, []string{"env:overflow", "debug"}, apply(l, "foo", "env:dev", "debug"))
+}
+
+func TestExpireEntries(t *testing.T) {
+	l := New(1, nil, "other", 1)
+
+	assert.Equal(t, []string{"user_id:1"}, apply(l, "foo", "user_id:1"))
+	l.ExpireEntries()
+	assert.Equal(t, []string{"user_id:other"}, apply(l, "foo", "user_id:2"))
+
+	// user_id:1 was not seen for a whole interval
+	l.ExpireEntries()
+	l.ExpireEntries()
+	assert.Equal(t, []string{"user_id:2"}, apply(l, "foo", "user_id:2"))
+}
+
+func TestSendTelemetry(t *testing.T) {
+	l := New(1, nil, "other", 1)
+	apply(l, "foo", "user_id:1")
+	apply(l, "foo", "user_id:2")
+	apply(l, "foo", "user_id:3")
+
+	sink := metrics.Series{}
+	l.SendTelemetry(1000, &sink, "host", []string{"sampler_id:0"})
+	require.Len(t, sink, 1)
+	assert.Equal(t, "datadog.agent.aggregator.dogstatsd_tag_values_limiter.rewritten_tags", sink[0].Name)
+	assert.Equal(t, tagset.NewCompositeTags([]string{"sampler_id:0"}, []string{"metric_name:foo", "tag_key:user_id"}), sink[0].Tags)
+	assert.Equal(t, []metrics.Point{{Ts: 1000, Value: 2}}, sink[0].Points)
+
+	// the counts are reset
+	sink = metrics.Series{}
+	l.SendTelemetry(1010, &sink, "host", nil)
+	assert.Empty(t, sink)
+}
@@ -0,0 +1,107 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package tags_filter removes tag keys from the metrics before their context is computed,
+// so that the series are aggregated across the removed dimensions.
+package tags_filter
+
+import (
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/tagset"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	// ActionExclude removes the listed tag keys
+	ActionExclude = "exclude"
+	// ActionInclude removes all the tag keys but the listed ones
+	ActionInclude = "include"
+)
+
+type rule struct {
+	include bool
+	keys    map[string]struct{}
+}
+
+// keep returns true if tag must be kept by the rule.
+func (r *rule) keep(tag string) bool {
+	key := tag
+	if i := strings.IndexByte(tag, ':'); i >= 0 {
+		key = tag[:i]
+	}
+	_, listed := r.keys[key]
+	return listed == r.include
+}
+
+// Filter applies per metric name rules to the tags of the metrics.
+//
+// A nil *Filter is valid and does nothing. Once built, a Filter is
+// read-only and can be shared between samplers.
+type Filter struct {
+	rules map[string]*rule
+}
+
+// FromConfig returns a Filter built from dogstatsd_metric_tag_filterlist, or nil if it is empty.
+func FromConfig() *Filter {
+	filterlist, err := config.GetDogstatsdMetricTagFilterlist()
+	if err != nil {
+		log.Errorf("Ignoring the metric tag filters: %v", err)
+		return nil
+	}
+	return New(filterlist)
+}
+
+// New returns a Filter applying filterlist, or nil if it is empty. Invalid
+// filters are ignored and filters applying to the same metric are merged.
+func New(filterlist []config.MetricTagFilter) *Filter {
+	rules := map[string]*rule{}
+	for _, filter := range filterlist {
+		if filter.MetricName == "" || len(filter.Tags) == 0 {
+			log.Warnf("Ignoring the metric tag filter %+v: a metric name and tags are required", filter)
+			continue
+		}
+		var include bool
+		switch strings.ToLower(filter.Action) {
+		case ActionExclude, "":
+			include = false
+		case ActionInclude:
+			include = true
+		default:
+			log.Warnf("Ignoring the metric tag filter of %s: invalid action %q, expected %q or %q", filter.MetricName, filter.Action, ActionExclude, ActionInclude)
+			continue
+		}
+
+		r, found := rules[filter.MetricName]
+		if !found {
+			r = &rule{include: include, keys: map[string]struct{}{}}
+			rules[filter.MetricName] = r
+		} else if r.include != include {
+			log.Warnf("Ignoring a metric tag filter of %s: its action conflicts with a previous filter of the same metric", filter.MetricName)
+			continue
+		}
+		for _, key := range filter.Tags {
+			r.keys[key] = struct{}{}
+		}
+	}
+	if len(rules) == 0 {
+		return nil
+	}
+	return &Filter{rules: rules}
+}
+
+// Apply removes from taggerTags and metricTags the tags filtered out for the metric name.
+func (f *Filter) Apply(name string, taggerTags, metricTags *tagset.HashingTagsAccumulator) {
+	if f == nil {
+		return
+	}
+	r, found := f.rules[name]
+	if !found {
+		return
+	}
+	taggerTags.Retain(r.keep)
+	metricTags.Retain(r.keep)
+}
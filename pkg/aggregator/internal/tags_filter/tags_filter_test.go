@@ -0,0 +1,58 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package tags_filter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/tagset"
+)
+
+func TestNewIgnoresInvalidFilters(t *testing.T) {
+	assert.Nil(t, New(nil))
+	assert.Nil(t, New([]config.MetricTagFilter{
+		{MetricName: "", Tags: []string{"pod_name"}},
+		{MetricName: "http.requests"},
+		{MetricName: "http.requests", Action: "drop", Tags: []string{"pod_name"}},
+	}))
+}
+
+func TestApply(t *testing.T) {
+	f := New([]config.MetricTagFilter{
+		{MetricName: "http.requests", Action: "exclude", Tags: []string{"pod_name"}},
+		{MetricName: "http.requests", Tags: []string{"container_id", "debug"}},
+		{MetricName: "queue.depth", Action: "include", Tags: []string{"queue", "env"}},
+		// conflicts with the exclude filter
+		{MetricName: "http.requests", Action: "include", Tags: []string{"env"}},
+	})
+
+	taggerTags := tagset.NewHashingTagsAccumulatorWithTags([]string{"pod_name:web-1", "container_id:abc", "kube_namespace:default"})
+	metricTags := tagset.NewHashingTagsAccumulatorWithTags([]string{"env:prod", "debug", "endpoint:/login"})
+	f.Apply("http.requests", taggerTags, metricTags)
+	assert.Equal(t, []string{"kube_namespace:default"}, taggerTags.Get())
+	assert.Equal(t, []string{"env:prod", "endpoint:/login"}, metricTags.Get())
+
+	taggerTags = tagset.NewHashingTagsAccumulatorWithTags([]string{"pod_name:worker-1"})
+	metricTags = tagset.NewHashingTagsAccumulatorWithTags([]string{"env:prod", "queue:jobs", "worker:12"})
+	f.Apply("queue.depth", taggerTags, metricTags)
+	assert.Empty(t, taggerTags.Get())
+	assert.Equal(t, []string{"env:prod", "queue:jobs"}, metricTags.Get())
+
+	// other metrics are left untouched
+	metricTags = tagset.NewHashingTagsAccumulatorWithTags([]string{"pod_name:web-1"})
+	f.Apply("http.latency", tagset.NewHashingTagsAccumulator(), metricTags)
+	assert.Equal(t, []string{"pod_name:web-1"}, metricTags.Get())
+}
+
+func TestApplyNilFilter(t *testing.T) {
+	var f *Filter
+	metricTags := tagset.NewHashingTagsAccumulatorWithTags([]string{"pod_name:web-1"})
+	f.Apply("http.requests", tagset.NewHashingTagsAccumulator(), metricTags)
+	assert.Equal(t, []string{"pod_name:web-1"}, metricTags.Get())
+}
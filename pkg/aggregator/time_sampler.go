@@ -10,7 +10,9 @@ import (
 
 	"github.com/DataDog/datadog-agent/pkg/aggregator/ckey"
 	"github.com/DataDog/datadog-agent/pkg/aggregator/internal/limiter"
+	"github.com/DataDog/datadog-agent/pkg/aggregator/internal/tag_values_limiter"
 	"github.com/DataDog/datadog-agent/pkg/aggregator/internal/tags"
+	"github.com/DataDog/datadog-agent/pkg/aggregator/internal/tags_filter"
 	"github.com/DataDog/datadog-agent/pkg/aggregator/internal/tags_limiter"
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/metrics"
@@ -40,11 +42,16 @@ type TimeSampler struct {
 	// since we start running more than one with the demultiplexer introduction
 	id TimeSamplerID
 
+	// maxLateness is the number of seconds the buckets are kept open after their end,
+	// so that the late samples with a timestamp are aggregated in them. 0 keeps the
+	// historical behavior: the buckets are flushed as soon as they end.
+	maxLateness int64
+
 	hostname string
 }
 
 // NewTimeSampler returns a newly initialized TimeSampler
-func NewTimeSampler(id TimeSamplerID, interval int64, cache *tags.Store, contextsLimiter *limiter.Limiter, tagsLimiter *tags_limiter.Limiter, hostname string) *TimeSampler {
+func NewTimeSampler(id TimeSamplerID, interval int64, cache *tags.Store, contextsLimiter *limiter.Limiter, tagsLimiter *tags_limiter.Limiter, tagsFilter *tags_filter.Filter, tagValuesLimiter *tag_values_limiter.Limiter, hostname string) *TimeSampler {
 	if interval == 0 {
 		interval = bucketSize
 	}
@@ -53,7 +60,7 @@ func NewTimeSampler(id TimeSamplerID, interval int64, cache *tags.Store, context
 
 	s := &TimeSampler{
 		interval:                    interval,
-		contextResolver:             newTimestampContextResolver(cache, contextsLimiter, tagsLimiter),
+		contextResolver:             newTimestampContextResolver(cache, contextsLimiter, tagsLimiter, tagsFilter, tagValuesLimiter),
 		metricsByTimestamp:          map[int64]metrics.ContextMetrics{},
 		counterLastSampledByContext: map[ckey.ContextKey]float64{},
 		sketchMap:                   make(sketchMap),
@@ -72,20 +79,34 @@ func (s *TimeSampler) isBucketStillOpen(bucketStartTimestamp, timestamp int64) b
 	return bucketStartTimestamp+s.interval > timestamp
 }
 
+// isTooLate returns true when the bucket of a sample with a timestamp has already been flushed.
+// The samples without timestamp are never too late.
+func (s *TimeSampler) isTooLate(metricSample *metrics.MetricSample) bool {
+	if s.maxLateness <= 0 || s.lastCutOffTime <= 0 || metricSample.Timestamp <= 0 {
+		return false
+	}
+	return !s.isBucketStillOpen(s.calculateBucketStart(metricSample.Timestamp), s.lastCutOffTime)
+}
+
 func (s *TimeSampler) sample(metricSample *metrics.MetricSample, timestamp float64) {
 	// use the timestamp provided in the sample if any
 	if metricSample.Timestamp > 0 {
 		timestamp = metricSample.Timestamp
 	}
 
+	if s.isTooLate(metricSample) {
+		// the bucket has already been flushed, the sample would overwrite its points
+		tlmDogstatsdLateSamplesDropped.Inc()
+		return
+	}
+	bucketStart := s.calculateBucketStart(timestamp)
+
 	// Keep track of the context
 	contextKey, ok := s.contextResolver.trackContext(metricSample, timestamp)
 	if !ok {
 		return
 	}
 
-	bucketStart := s.calculateBucketStart(timestamp)
-
 	switch metricSample.Mtype {
 	case metrics.DistributionType:
 		s.sketchMap.insert(bucketStart, contextKey, metricSample.Value, metricSample.SampleRate)
@@ -96,8 +117,8 @@ func (s *TimeSampler) sample(metricSample *metrics.MetricSample, timestamp float
 			bucketMetrics = metrics.MakeContextMetrics()
 			s.metricsByTimestamp[bucketStart] = bucketMetrics
 		}
-		// Update LastSampled timestamp for counters
-		if metricSample.Mtype == metrics.CounterType {
+		// Update LastSampled timestamp for counters, a late sample doesn't move it back
+		if metricSample.Mtype == metrics.CounterType && timestamp > s.counterLastSampledByContext[contextKey] {
 			s.counterLastSampledByContext[contextKey] = timestamp
 		}
 
@@ -217,14 +238,14 @@ func (s *TimeSampler) flushSketches(cutoffTime int64, sketchesSink metrics.Sketc
 }
 
 func (s *TimeSampler) flush(timestamp float64, series metrics.SerieSink, sketches metrics.SketchesSink) {
-	// Compute a limit timestamp
-	cutoffTime := s.calculateBucketStart(timestamp)
+	// Compute a limit timestamp, the buckets are kept open for maxLateness seconds
+	cutoffTime := s.calculateBucketStart(timestamp - float64(s.maxLateness))
 
 	s.flushSeries(cutoffTime, series)
 	s.flushSketches(cutoffTime, sketches)
 
-	// expiring contexts
-	s.contextResolver.expireContexts(timestamp-config.Datadog.GetFloat64("dogstatsd_context_expiry_seconds"),
+	// expiring contexts, the contexts of the open buckets are kept
+	s.contextResolver.expireContexts(timestamp-float64(s.maxLateness)-config.Datadog.GetFloat64("dogstatsd_context_expiry_seconds"),
 		func(k ckey.ContextKey) bool {
 			_, ok := s.counterLastSampledByContext[k]
 			return ok
@@ -310,3 +331,8 @@ func (s *TimeSampler) sendTelemetry(timestamp float64, series metrics.SerieSink)
 		s.contextResolver.sendLimiterTelemetry(timestamp, series, s.hostname, tags)
 	}
 }
+
+// countCardinality adds the contexts of the sampler to accumulator.
+func (s *TimeSampler) countCardinality(accumulator *cardinalityAccumulator) {
+	s.contextResolver.countCardinality(accumulator)
+}
@@ -12,6 +12,7 @@ import (
 	"math"
 	"sort"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -20,6 +21,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/aggregator/internal/limiter"
 	"github.com/DataDog/datadog-agent/pkg/aggregator/internal/tags"
 	"github.com/DataDog/datadog-agent/pkg/aggregator/internal/tags_limiter"
+	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/metrics"
 	"github.com/DataDog/datadog-agent/pkg/tagset"
 	"github.com/DataDog/opentelemetry-mapping-go/pkg/quantile"
@@ -35,7 +37,7 @@ func generateSerieContextKey(serie *metrics.Serie) ckey.ContextKey {
 }
 
 func testTimeSampler() *TimeSampler {
-	sampler := NewTimeSampler(TimeSamplerID(0), 10, tags.NewStore(false, "test"), nil, nil, "host")
+	sampler := NewTimeSampler(TimeSamplerID(0), 10, tags.NewStore(false, "test"), nil, nil, nil, nil, "host")
 	return sampler
 }
 
@@ -284,6 +286,91 @@ func TestCounterExpirySeconds(t *testing.T) {
 	testWithTagsStore(t, testCounterExpirySeconds)
 }
 
+func testLateSampling(t *testing.T, store *tags.Store) {
+	sampler := testTimeSampler()
+	sampler.maxLateness = 30
+
+	mSample := metrics.MetricSample{
+		Name:       "my.metric.name",
+		Value:      1,
+		Mtype:      metrics.CountType,
+		Tags:       []string{"foo", "bar"},
+		SampleRate: 1,
+	}
+	sampler.sample(&mSample, 12345.0)
+
+	// the bucket is kept open for maxLateness seconds after its end
+	series, _ := flushSerie(sampler, 12360.0)
+	assert.Len(t, series, 0)
+
+	// so a late sample is aggregated in it
+	lateSample := mSample
+	lateSample.Timestamp = 12347.0
+	sampler.sample(&lateSample, 12365.0)
+	sampler.sample(&mSample, 12365.0)
+
+	series, _ = flushSerie(sampler, 12380.0)
+	expectedSerie := &metrics.Serie{
+		Name:     "my.metric.name",
+		Tags:     tagset.CompositeTagsFromSlice([]string{"foo", "bar"}),
+		Points:   []metrics.Point{{Ts: 12340.0, Value: 2}},
+		MType:    metrics.APICountType,
+		Interval: 10,
+	}
+	if assert.Equal(t, 1, len(series)) {
+		metrics.AssertSerieEqual(t, expectedSerie, series[0])
+	}
+
+	// the bucket has been flushed, a sample arriving later is dropped
+	lateSample.Timestamp = 12348.0
+	sampler.sample(&lateSample, 12385.0)
+
+	series, _ = flushSerie(sampler, 12400.0)
+	expectedSerie.Points = []metrics.Point{{Ts: 12360.0, Value: 1}}
+	if assert.Equal(t, 1, len(series)) {
+		metrics.AssertSerieEqual(t, expectedSerie, series[0])
+	}
+	assert.Len(t, sampler.metricsByTimestamp, 0)
+}
+
+func TestLateSampling(t *testing.T) {
+	testWithTagsStore(t, testLateSampling)
+}
+
+func TestTimeSamplerWorkerLateSamples(t *testing.T) {
+	sampler := testTimeSampler()
+	sampler.maxLateness = 30
+	worker := newTimeSamplerWorker(sampler, time.Second, 10, metrics.NewMetricSamplePool(16), NewFlushAndSerializeInParallel(config.Datadog), tags.NewStore(false, "test"))
+	var late metrics.MetricSampleBatch
+	worker.lateSamples = func(samples metrics.MetricSampleBatch) {
+		late = append(late, samples...)
+	}
+
+	// the buckets ending before 12370 are flushed
+	series, _ := flushSerie(sampler, 12400.0)
+	assert.Len(t, series, 0)
+
+	newSample := func(value, timestamp float64) metrics.MetricSample {
+		return metrics.MetricSample{Name: "my.metric.name", Value: value, Mtype: metrics.GaugeType, SampleRate: 1, Timestamp: timestamp}
+	}
+	worker.sampleBatch([]metrics.MetricSample{
+		// at the edge of the last flushed bucket
+		newSample(1, 12369.5),
+		// in the first bucket still open
+		newSample(2, 12370.0),
+	})
+
+	// the sample of the flushed bucket is handed back instead of being dropped
+	if assert.Len(t, late, 1) {
+		assert.Equal(t, 1.0, late[0].Value)
+		assert.Equal(t, 12369.5, late[0].Timestamp)
+	}
+	series, _ = flushSerie(sampler, 12420.0)
+	if assert.Len(t, series, 1) {
+		assert.Equal(t, []metrics.Point{{Ts: 12370.0, Value: 2}}, series[0].Points)
+	}
+}
+
 func testSketch(t *testing.T, store *tags.Store) {
 	const (
 		defaultBucketSize = 10
@@ -516,7 +603,7 @@ func TestBucketSamplingWithSketchAndSeries(t *testing.T) {
 }
 
 func benchmarkTimeSampler(b *testing.B, store *tags.Store) {
-	sampler := NewTimeSampler(TimeSamplerID(0), 10, store, nil, nil, "host")
+	sampler := NewTimeSampler(TimeSamplerID(0), 10, store, nil, nil, nil, nil, "host")
 
 	sample := metrics.MetricSample{
 		Name:       "my.metric.name",
@@ -556,7 +643,7 @@ func BenchmarkTimeSamplerWithLimiter(b *testing.B) {
 		store := tags.NewStore(false, "test")
 		limiter := limiter.New(limit, "pod", []string{"pod"})
 		tagsLimiter := tags_limiter.New(5)
-		sampler := NewTimeSampler(TimeSamplerID(0), 10, store, limiter, tagsLimiter, "host")
+		sampler := NewTimeSampler(TimeSamplerID(0), 10, store, limiter, tagsLimiter, nil, nil, "host")
 
 		b.Run(fmt.Sprintf("limit=%d", limit), func(b *testing.B) {
 			for n := 0; n < b.N; n++ {
@@ -34,11 +34,17 @@ type timeSamplerWorker struct {
 	samplesChan chan []metrics.MetricSample
 	// use this chan to trigger a flush of the time sampler
 	flushChan chan flushTrigger
+	// use this chan to count the contexts of the time sampler
+	cardinalityChan chan cardinalityTrigger
 	// use this chan to stop the timeSamplerWorker
 	stopChan chan struct{}
 
 	// tagsStore shard used to store tag slices for this worker
 	tagsStore *tags.Store
+
+	// lateSamples receives the samples whose bucket has already been flushed, they are
+	// dropped by the TimeSampler when it is nil.
+	lateSamples func(metrics.MetricSampleBatch)
 }
 
 func newTimeSamplerWorker(sampler *TimeSampler, flushInterval time.Duration, bufferSize int,
@@ -56,6 +62,8 @@ func newTimeSamplerWorker(sampler *TimeSampler, flushInterval time.Duration, buf
 		stopChan:    make(chan struct{}),
 		flushChan:   make(chan flushTrigger),
 
+		cardinalityChan: make(chan cardinalityTrigger),
+
 		tagsStore: tagsStore,
 	}
 }
@@ -74,18 +82,38 @@ func (w *timeSamplerWorker) run() {
 		case ms := <-w.samplesChan:
 			aggregatorDogstatsdMetricSample.Add(int64(len(ms)))
 			tlmProcessed.Add(float64(len(ms)), "dogstatsd_metrics")
-			t := timeNowNano()
-			for i := 0; i < len(ms); i++ {
-				w.sampler.sample(&ms[i], t)
-			}
+			w.sampleBatch(ms)
 			w.metricSamplePool.PutBatch(ms)
 		case trigger := <-w.flushChan:
 			w.triggerFlush(trigger)
 			w.tagsStore.Shrink()
+		case trigger := <-w.cardinalityChan:
+			w.sampler.countCardinality(trigger.accumulator)
+			trigger.blockChan <- struct{}{}
 		}
 	}
 }
 
+// sampleBatch processes a batch of samples. The samples too late to be aggregated in their
+// bucket are handed to lateSamples in a new batch.
+func (w *timeSamplerWorker) sampleBatch(ms []metrics.MetricSample) {
+	var late metrics.MetricSampleBatch
+	t := timeNowNano()
+	for i := 0; i < len(ms); i++ {
+		if w.lateSamples != nil && w.sampler.isTooLate(&ms[i]) {
+			if late == nil {
+				late = w.metricSamplePool.GetBatch()[:0]
+			}
+			late = append(late, ms[i])
+			continue
+		}
+		w.sampler.sample(&ms[i], t)
+	}
+	if len(late) > 0 {
+		w.lateSamples(late)
+	}
+}
+
 func (w *timeSamplerWorker) stop() {
 	w.stopChan <- struct{}{}
 }
@@ -74,12 +74,16 @@ func setupAPM(config Config) {
 	config.BindEnvAndSetDefault("apm_config.remote_tagger", true, "DD_APM_REMOTE_TAGGER")                                                     //nolint:errcheck
 	config.BindEnvAndSetDefault("apm_config.peer_service_aggregation", false, "DD_APM_PEER_SERVICE_AGGREGATION")                              //nolint:errcheck
 	config.BindEnvAndSetDefault("apm_config.compute_stats_by_span_kind", false, "DD_APM_COMPUTE_STATS_BY_SPAN_KIND")                          //nolint:errcheck
+	config.BindEnvAndSetDefault("apm_config.zipkin_receiver_enabled", false, "DD_APM_ZIPKIN_RECEIVER_ENABLED")
+	config.BindEnvAndSetDefault("apm_config.jaeger_receiver_enabled", false, "DD_APM_JAEGER_RECEIVER_ENABLED")
 	config.BindEnvAndSetDefault("apm_config.instrumentation.enabled", false, "DD_APM_INSTRUMENTATION_ENABLED")
 	config.BindEnvAndSetDefault("apm_config.instrumentation.enabled_namespaces", []string{}, "DD_APM_INSTRUMENTATION_ENABLED_NAMESPACES")
 	config.BindEnvAndSetDefault("apm_config.instrumentation.disabled_namespaces", []string{}, "DD_APM_INSTRUMENTATION_DISABLED_NAMESPACES")
 	config.BindEnv("apm_config.instrumentation.lib_versions", "DD_APM_INSTRUMENTATION_LIB_VERSIONS")
 
 	config.BindEnv("apm_config.max_catalog_services", "DD_APM_MAX_CATALOG_SERVICES")
+	config.BindEnv("apm_config.otlp_exporter.endpoint", "DD_APM_OTLP_EXPORTER_ENDPOINT")
+	config.BindEnv("apm_config.otlp_exporter.headers", "DD_APM_OTLP_EXPORTER_HEADERS")
 	config.BindEnv("apm_config.receiver_timeout", "DD_APM_RECEIVER_TIMEOUT")
 	config.BindEnv("apm_config.max_payload_size", "DD_APM_MAX_PAYLOAD_SIZE")
 	config.BindEnv("apm_config.trace_buffer", "DD_APM_TRACE_BUFFER")
@@ -93,6 +97,14 @@ func setupAPM(config Config) {
 	config.BindEnv("apm_config.enable_rare_sampler", "DD_APM_ENABLE_RARE_SAMPLER")
 	config.BindEnv("apm_config.disable_rare_sampler", "DD_APM_DISABLE_RARE_SAMPLER") //Deprecated
 	config.BindEnv("apm_config.max_remote_traces_per_second", "DD_APM_MAX_REMOTE_TPS")
+	config.BindEnv("apm_config.tail_sampling.enabled", "DD_APM_TAIL_SAMPLING_ENABLED")
+	config.BindEnv("apm_config.tail_sampling.services", "DD_APM_TAIL_SAMPLING_SERVICES")
+	config.BindEnv("apm_config.tail_sampling.decision_wait", "DD_APM_TAIL_SAMPLING_DECISION_WAIT")
+	config.BindEnv("apm_config.tail_sampling.max_memory", "DD_APM_TAIL_SAMPLING_MAX_MEMORY")
+	config.BindEnv("apm_config.tail_sampling.keep_errors", "DD_APM_TAIL_SAMPLING_KEEP_ERRORS")
+	config.BindEnv("apm_config.tail_sampling.latency_percentile", "DD_APM_TAIL_SAMPLING_LATENCY_PERCENTILE")
+	config.BindEnv("apm_config.tail_sampling.keep_tags", "DD_APM_TAIL_SAMPLING_KEEP_TAGS")
+	config.BindEnv("apm_config.tail_sampling.sample_rate", "DD_APM_TAIL_SAMPLING_SAMPLE_RATE")
 
 	config.BindEnv("apm_config.max_memory", "DD_APM_MAX_MEMORY")
 	config.BindEnv("apm_config.max_cpu_percent", "DD_APM_MAX_CPU_PERCENT")
@@ -106,6 +118,7 @@ func setupAPM(config Config) {
 	config.BindEnv("apm_config.profiling_additional_endpoints", "DD_APM_PROFILING_ADDITIONAL_ENDPOINTS")
 	config.BindEnv("apm_config.additional_endpoints", "DD_APM_ADDITIONAL_ENDPOINTS")
 	config.BindEnv("apm_config.replace_tags", "DD_APM_REPLACE_TAGS")
+	config.BindEnv("apm_config.span_rules", "DD_APM_SPAN_RULES")
 	config.BindEnv("apm_config.analyzed_spans", "DD_APM_ANALYZED_SPANS")
 	config.BindEnv("apm_config.ignore_resources", "DD_APM_IGNORE_RESOURCES", "DD_IGNORE_RESOURCE")
 	config.BindEnv("apm_config.receiver_socket", "DD_APM_RECEIVER_SOCKET")
@@ -170,6 +183,22 @@ func setupAPM(config Config) {
 		return out
 	})
 
+	config.SetEnvKeyTransformer("apm_config.span_rules", func(in string) interface{} {
+		var out []map[string]interface{}
+		if err := json.Unmarshal([]byte(in), &out); err != nil {
+			log.Warnf(`"apm_config.span_rules" can not be parsed: %v`, err)
+		}
+		return out
+	})
+
+	config.SetEnvKeyTransformer("apm_config.otlp_exporter.headers", func(in string) interface{} {
+		var out map[string]string
+		if err := json.Unmarshal([]byte(in), &out); err != nil {
+			log.Warnf(`"apm_config.otlp_exporter.headers" can not be parsed: %v`, err)
+		}
+		return out
+	})
+
 	config.SetEnvKeyTransformer("apm_config.analyzed_spans", func(in string) interface{} {
 		out, err := parseAnalyzedSpans(in)
 		if err != nil {
@@ -186,6 +215,16 @@ func setupAPM(config Config) {
 		}
 		return out
 	})
+
+	config.BindEnv("apm_config.stats_custom_tags", "DD_APM_STATS_CUSTOM_TAGS")
+	config.SetEnvKeyTransformer("apm_config.stats_custom_tags", func(in string) interface{} {
+		var out []string
+		if err := json.Unmarshal([]byte(in), &out); err != nil {
+			log.Warnf(`"apm_config.stats_custom_tags" can not be parsed: %v`, err)
+		}
+		return out
+	})
+	config.BindEnv("apm_config.stats_custom_tags_max_cardinality", "DD_APM_STATS_CUSTOM_TAGS_MAX_CARDINALITY")
 }
 
 func parseKVList(key string) func(string) interface{} {
@@ -189,6 +189,13 @@ type MetricMapping struct {
 	Tags      map[string]string `mapstructure:"tags" json:"tags" yaml:"tags"`
 }
 
+// MetricTagFilter represents the tag keys removed from, or kept on, the DogStatsD metrics with a given name
+type MetricTagFilter struct {
+	MetricName string   `mapstructure:"metric_name" json:"metric_name" yaml:"metric_name"`
+	Action     string   `mapstructure:"action" json:"action" yaml:"action"`
+	Tags       []string `mapstructure:"tags" json:"tags" yaml:"tags"`
+}
+
 // Endpoint represent a datadog endpoint
 type Endpoint struct {
 	Site   string `mapstructure:"site" json:"site" yaml:"site"`
@@ -304,6 +311,7 @@ func InitConfig(config Config) {
 	// Remote config products
 	config.BindEnvAndSetDefault("remote_configuration.apm_sampling.enabled", true)
 	config.BindEnvAndSetDefault("remote_configuration.agent_integrations.enabled", false)
+	config.BindEnvAndSetDefault("remote_configuration.dogstatsd.enabled", false)
 
 	// Auto exit configuration
 	config.BindEnvAndSetDefault("auto_exit.validation_period", 60)
@@ -452,6 +460,13 @@ func InitConfig(config Config) {
 	config.BindEnvAndSetDefault("enable_events_stream_payload_serialization", true)
 	config.BindEnvAndSetDefault("enable_sketch_stream_payload_serialization", true)
 	config.BindEnvAndSetDefault("enable_json_stream_shared_compressor_buffers", true)
+	// Serializer: compression of the payloads, an empty kind uses the compression the agent was built with
+	config.BindEnvAndSetDefault("serializer_compression.kind", "")
+	config.BindEnvAndSetDefault("serializer_compression.level", 0)
+	for _, payload := range []string{"series", "sketches", "events", "service_checks", "metadata", "process", "orchestrator"} {
+		config.BindEnvAndSetDefault("serializer_compression."+payload+".kind", "")
+		config.BindEnvAndSetDefault("serializer_compression."+payload+".level", 0)
+	}
 
 	// Warning: do not change the following values. Your payloads will get dropped by Datadog's intake.
 	config.BindEnvAndSetDefault("serializer_max_payload_size", 2*megaByte+megaByte/2)
@@ -489,6 +504,7 @@ func InitConfig(config Config) {
 	config.BindEnvAndSetDefault("forwarder_outdated_file_in_days", 10)
 	config.BindEnvAndSetDefault("forwarder_flush_to_disk_mem_ratio", 0.5)
 	config.BindEnvAndSetDefault("forwarder_storage_max_size_in_bytes", 0)                // 0 means disabled. This is a BETA feature.
+	config.BindEnvAndSetDefault("forwarder_storage_encryption_key", "")                  // empty means the transactions are stored in plaintext
 	config.BindEnvAndSetDefault("forwarder_storage_max_disk_ratio", 0.80)                // Do not store transactions on disk when the disk usage exceeds 80% of the disk capacity. Use 80% as some applications do not behave well when the disk space is very small.
 	config.BindEnvAndSetDefault("forwarder_retry_queue_capacity_time_interval_sec", 900) // 15 mins
 
@@ -553,6 +569,9 @@ func InitConfig(config Config) {
 	// How many metrics maximum in payloads sent by the no-aggregation pipeline to the intake.
 	config.BindEnvAndSetDefault("dogstatsd_no_aggregation_pipeline_batch_size", 2048)
 	config.BindEnvAndSetDefault("dogstatsd_max_metrics_tags", 0) // 0 = disabled.
+	// How late, in seconds, a sample with a timestamp can be to be aggregated in the bucket of its
+	// timestamp instead of going through the no-aggregation pipeline. 0 = disabled.
+	config.BindEnvAndSetDefault("dogstatsd_max_lateness", 0)
 
 	// To enable the following feature, GODEBUG must contain `madvdontneed=1`
 	config.BindEnvAndSetDefault("dogstatsd_mem_based_rate_limiter.enabled", false)
@@ -574,6 +593,22 @@ func InitConfig(config Config) {
 	config.BindEnvAndSetDefault("dogstatsd_context_limiter.bytes_per_context", 1500)
 	config.BindEnvAndSetDefault("dogstatsd_context_limiter.cgroup_memory_ratio", 0.0)
 
+	config.BindEnvAndSetDefault("dogstatsd_tag_values_limiter.limit", 0) // 0 = disabled.
+	config.BindEnvAndSetDefault("dogstatsd_tag_values_limiter.tag_keys", []string{})
+	config.BindEnvAndSetDefault("dogstatsd_tag_values_limiter.placeholder", "other")
+	config.BindEnvAndSetDefault("dogstatsd_tag_values_limiter.entry_timeout", 20) // number of flush intervals
+
+	// Graphite plaintext protocol listener, TCP and UDP
+	config.BindEnvAndSetDefault("dogstatsd_graphite.enabled", false)
+	config.BindEnvAndSetDefault("dogstatsd_graphite.port", 2003)
+	config.BindEnvAndSetDefault("dogstatsd_graphite.use_timestamps", false)
+
+	// Prometheus remote-write listener
+	config.BindEnvAndSetDefault("dogstatsd_remote_write.enabled", false)
+	config.BindEnvAndSetDefault("dogstatsd_remote_write.port", 9201)
+	config.BindEnvAndSetDefault("dogstatsd_remote_write.max_request_size", 32*1024*1024) // decompressed, in bytes
+	config.BindEnvAndSetDefault("dogstatsd_remote_write.series_expiry", 600)             // in seconds
+
 	config.BindEnv("dogstatsd_mapper_profiles")
 	config.SetEnvKeyTransformer("dogstatsd_mapper_profiles", func(in string) interface{} {
 		var mappings []MappingProfile
@@ -583,6 +618,15 @@ func InitConfig(config Config) {
 		return mappings
 	})
 
+	config.BindEnv("dogstatsd_metric_tag_filterlist")
+	config.SetEnvKeyTransformer("dogstatsd_metric_tag_filterlist", func(in string) interface{} {
+		var filterlist []MetricTagFilter
+		if err := json.Unmarshal([]byte(in), &filterlist); err != nil {
+			log.Errorf(`"dogstatsd_metric_tag_filterlist" can not be parsed: %v`, err)
+		}
+		return filterlist
+	})
+
 	config.BindEnvAndSetDefault("statsd_forward_host", "")
 	config.BindEnvAndSetDefault("statsd_forward_port", 0)
 	config.BindEnvAndSetDefault("statsd_metric_namespace", "")
@@ -936,6 +980,10 @@ func InitConfig(config Config) {
 	config.BindEnvAndSetDefault("logs_config.dev_mode_use_proto", true)
 	config.BindEnvAndSetDefault("logs_config.dd_url_443", "agent-443-intake.logs.datadoghq.com")
 	config.BindEnvAndSetDefault("logs_config.stop_grace_period", 30)
+	// maximum disk space used to store the logs payloads while the intake is unreachable, 0 disables it
+	config.BindEnvAndSetDefault("logs_config.disk_queue_max_size_in_bytes", 0)
+	// defaults to a "logs_disk_queue" directory in logs_config.run_path
+	config.BindEnvAndSetDefault("logs_config.disk_queue_path", "")
 	// maximum time that the unix tailer will hold a log file open after it has been rotated
 	config.BindEnvAndSetDefault("logs_config.close_timeout", 60)
 	// maximum time that the windows tailer will hold a log file open, while waiting for
@@ -947,6 +995,8 @@ func InitConfig(config Config) {
 	config.BindEnvAndSetDefault("logs_config.auto_multi_line_default_sample_size", 500)
 	config.BindEnvAndSetDefault("logs_config.auto_multi_line_default_match_timeout", 30) // Seconds
 	config.BindEnvAndSetDefault("logs_config.auto_multi_line_default_match_threshold", 0.48)
+	// aggregates stack traces and multi-line JSON objects, takes precedence over auto_multi_line_detection
+	config.BindEnvAndSetDefault("logs_config.auto_multi_line_aggregation", false)
 
 	// If true, the agent looks for container logs in the location used by podman, rather
 	// than docker.  This is a temporary configuration parameter to support podman logs until
@@ -1926,6 +1976,22 @@ func getDogstatsdMappingProfilesConfig(config Config) ([]MappingProfile, error)
 	return mappings, nil
 }
 
+// GetDogstatsdMetricTagFilterlist returns the tag filters applied to DogStatsD metrics before aggregation
+func GetDogstatsdMetricTagFilterlist() ([]MetricTagFilter, error) {
+	return getDogstatsdMetricTagFilterlistConfig(Datadog)
+}
+
+func getDogstatsdMetricTagFilterlistConfig(config Config) ([]MetricTagFilter, error) {
+	var filterlist []MetricTagFilter
+	if config.IsSet("dogstatsd_metric_tag_filterlist") {
+		err := config.UnmarshalKey("dogstatsd_metric_tag_filterlist", &filterlist)
+		if err != nil {
+			return []MetricTagFilter{}, log.Errorf("Could not parse dogstatsd_metric_tag_filterlist: %v", err)
+		}
+	}
+	return filterlist, nil
+}
+
 // IsCLCRunner returns whether the Agent is in cluster check runner mode
 func IsCLCRunner() bool {
 	if !Datadog.GetBool("clc_runner_enabled") {
@@ -1971,6 +2037,20 @@ func GetBindHostFromConfig(cfg Reader) string {
 	return "localhost"
 }
 
+// GetSerializerCompression returns the compression kind and level configured for a kind of
+// payload, the settings of the payload override the ones of all the payloads
+func GetSerializerCompression(cfg Reader, payload string) (kind string, level int) {
+	kind = cfg.GetString("serializer_compression.kind")
+	if payloadKind := cfg.GetString("serializer_compression." + payload + ".kind"); payloadKind != "" {
+		kind = payloadKind
+	}
+	level = cfg.GetInt("serializer_compression.level")
+	if payloadLevel := cfg.GetInt("serializer_compression." + payload + ".level"); payloadLevel != 0 {
+		level = payloadLevel
+	}
+	return kind, level
+}
+
 // GetValidHostAliases validates host aliases set in `host_aliases` variable and returns
 // only valid ones.
 func GetValidHostAliases(_ context.Context) ([]string, error) {
@@ -618,6 +618,34 @@ func TestDogstatsdMappingProfilesEnv(t *testing.T) {
 	assert.Equal(t, mappings, expected)
 }
 
+func TestDogstatsdMetricTagFilterlist(t *testing.T) {
+	datadogYaml := `
+dogstatsd_metric_tag_filterlist:
+  - metric_name: "http.requests"
+    action: "exclude"
+    tags: ["pod_name", "container_id"]
+  - metric_name: "queue.depth"
+    action: "include"
+    tags: ["queue"]
+`
+	testConfig := SetupConfFromYAML(datadogYaml)
+
+	filterlist, err := getDogstatsdMetricTagFilterlistConfig(testConfig)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []MetricTagFilter{
+		{MetricName: "http.requests", Action: "exclude", Tags: []string{"pod_name", "container_id"}},
+		{MetricName: "queue.depth", Action: "include", Tags: []string{"queue"}},
+	}, filterlist)
+}
+
+func TestDogstatsdMetricTagFilterlistEnv(t *testing.T) {
+	t.Setenv("DD_DOGSTATSD_METRIC_TAG_FILTERLIST", `[{"metric_name":"http.requests","action":"exclude","tags":["pod_name"]}]`)
+	filterlist, err := GetDogstatsdMetricTagFilterlist()
+	assert.NoError(t, err)
+	assert.Equal(t, []MetricTagFilter{{MetricName: "http.requests", Action: "exclude", Tags: []string{"pod_name"}}}, filterlist)
+}
+
 func TestGetValidHostAliasesWithConfig(t *testing.T) {
 	config := SetupConfFromYAML(`host_aliases: ["foo", "-bar"]`)
 	assert.EqualValues(t, getValidHostAliasesWithConfig(config), []string{"foo"})
@@ -908,6 +936,28 @@ func TestIsRemoteConfigEnabled(t *testing.T) {
 	require.False(t, IsRemoteConfigEnabled(testConfig))
 }
 
+func TestGetSerializerCompression(t *testing.T) {
+	testConfig := SetupConfFromYAML(`
+serializer_compression:
+  kind: zstd
+  level: 3
+  orchestrator:
+    kind: zlib
+  process:
+    level: 6
+`)
+
+	kind, level := GetSerializerCompression(testConfig, "series")
+	assert.Equal(t, "zstd", kind)
+	assert.Equal(t, 3, level)
+	kind, level = GetSerializerCompression(testConfig, "orchestrator")
+	assert.Equal(t, "zlib", kind)
+	assert.Equal(t, 3, level)
+	kind, level = GetSerializerCompression(testConfig, "process")
+	assert.Equal(t, "zstd", kind)
+	assert.Equal(t, 6, level)
+}
+
 func TestLanguageDetectionSettings(t *testing.T) {
 	testConfig := SetupConfFromYAML("")
 	require.False(t, testConfig.GetBool("language_detection.enabled"))
@@ -19,6 +19,8 @@ const (
 	ProductCWSProfile Product = "CWS_SECURITY_PROFILES"
 	// ProductAPMTracing is the apm tracing product
 	ProductAPMTracing Product = "APM_TRACING"
+	// ProductAPMSpanRules is to receive the rules the trace-agent applies to the spans
+	ProductAPMSpanRules Product = "APM_SPAN_RULES"
 	// ProductTesting1 is a testing product
 	ProductTesting1 Product = "TESTING1"
 	// ProductAgentTask is to receive agent task instruction, like a flare
@@ -27,6 +29,8 @@ const (
 	ProductAgentConfig = "AGENT_CONFIG"
 	// ProductAgentIntegrations is to receive integrations to schedule
 	ProductAgentIntegrations = "AGENT_INTEGRATIONS"
+	// ProductMetricControl is to receive the dogstatsd metric blocklist and mapper profiles
+	ProductMetricControl Product = "METRIC_CONTROL"
 )
 
 // ProductListToString converts a product list to string list
@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
@@ -29,6 +30,10 @@ const defaultCleanupPeriod = 300 * time.Second
 // latest version of the API used by the auditor to retrieve the registry from disk.
 const registryAPIVersion = 2
 
+// ArchiveIdentifierPrefix prefixes the identifiers of the compressed files, which are read once.
+// Their entries don't expire: an archive still on disk after the TTL would be sent again.
+const ArchiveIdentifierPrefix = "archive:"
+
 // Registry holds a list of offsets.
 type Registry interface {
 	GetOffset(identifier string) string
@@ -214,12 +219,15 @@ func (a *RegistryAuditor) recoverRegistry() map[string]*RegistryEntry {
 	return r
 }
 
-// cleanupRegistry removes expired entries from the registry
+// cleanupRegistry removes expired entries from the registry, except the entries of compressed files
 func (a *RegistryAuditor) cleanupRegistry() {
 	a.registryMutex.Lock()
 	defer a.registryMutex.Unlock()
 	expireBefore := time.Now().UTC().Add(-a.entryTTL)
 	for path, entry := range a.registry {
+		if strings.HasPrefix(path, ArchiveIdentifierPrefix) {
+			continue
+		}
 		if entry.LastUpdated.Before(expireBefore) {
 			delete(a.registry, path)
 		}
@@ -112,6 +112,19 @@ func (suite *AuditorTestSuite) TestAuditorCleansupRegistry() {
 	suite.Equal("43", suite.a.registry[otherpath].Offset)
 }
 
+func (suite *AuditorTestSuite) TestAuditorKeepsArchiveEntries() {
+	suite.a.registry = make(map[string]*RegistryEntry)
+	archive := ArchiveIdentifierPrefix + "13-abcdef"
+	suite.a.registry[archive] = &RegistryEntry{
+		LastUpdated: time.Date(2006, time.January, 12, 1, 1, 1, 1, time.UTC),
+		Offset:      "13",
+	}
+
+	suite.a.cleanupRegistry()
+	suite.Equal(1, len(suite.a.registry))
+	suite.Equal("13", suite.a.registry[archive].Offset)
+}
+
 func TestScannerTestSuite(t *testing.T) {
 	suite.Run(t, new(AuditorTestSuite))
 }
@@ -37,6 +37,9 @@ const (
 	ProtobufContentType = "application/x-protobuf"
 )
 
+// otlpLogsPath is the path of the OTLP/HTTP logs receiver.
+const otlpLogsPath = "/v1/logs"
+
 // HTTP errors.
 var (
 	errClient = errors.New("client error")
@@ -377,7 +380,9 @@ func buildURL(endpoint config.Endpoint) string {
 		Scheme: scheme,
 		Host:   address,
 	}
-	if endpoint.Version == config.EPIntakeVersion2 && endpoint.TrackType != "" {
+	if endpoint.OTLP {
+		url.Path = otlpLogsPath
+	} else if endpoint.Version == config.EPIntakeVersion2 && endpoint.TrackType != "" {
 		url.Path = fmt.Sprintf("/api/v2/%s", endpoint.TrackType)
 	} else {
 		url.Path = "/v1/input"
@@ -57,6 +57,16 @@ func TestBuildURLShouldReturnAddressForVersion2(t *testing.T) {
 	assert.Equal(t, "http://foo/api/v2/test-track", url)
 }
 
+func TestBuildURLShouldReturnOTLPPath(t *testing.T) {
+	url := buildURL(config.Endpoint{
+		Host:   "foo",
+		Port:   4318,
+		UseSSL: false,
+		OTLP:   true,
+	})
+	assert.Equal(t, "http://foo:4318/v1/logs", url)
+}
+
 func TestDestinationSend200(t *testing.T) {
 	server := NewTestServer(200)
 	input := make(chan *message.Payload)
@@ -0,0 +1,114 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package otlp implements a destination sending logs as OTLP log records over HTTP.
+package otlp
+
+import (
+	"bytes"
+	"compress/gzip"
+
+	"github.com/DataDog/datadog-agent/comp/logs/agent/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/client"
+	"github.com/DataDog/datadog-agent/pkg/logs/client/http"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/logs/metrics"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// Destination sends payloads to an OTLP/HTTP logs receiver.
+// The messages of each payload are encoded as an OTLP export request before being sent
+// by an HTTP destination, which provides the same retry and backoff behavior as the
+// Datadog endpoints.
+type Destination struct {
+	destination      *http.Destination
+	host             string
+	useCompression   bool
+	compressionLevel int
+
+	// encode is replaced in tests.
+	encode func(payload *message.Payload) (*message.Payload, error)
+}
+
+// NewDestination returns a new Destination.
+func NewDestination(endpoint config.Endpoint,
+	destinationsContext *client.DestinationsContext,
+	maxConcurrentBackgroundSends int,
+	shouldRetry bool,
+	telemetryName string) *Destination {
+	d := &Destination{
+		destination:      http.NewDestination(endpoint, http.ProtobufContentType, destinationsContext, maxConcurrentBackgroundSends, shouldRetry, telemetryName),
+		host:             endpoint.Host,
+		useCompression:   endpoint.UseCompression,
+		compressionLevel: endpoint.CompressionLevel,
+	}
+	d.encode = d.encodePayload
+	return d
+}
+
+// Start starts reading the input channel
+func (d *Destination) Start(input chan *message.Payload, output chan *message.Payload, isRetrying chan bool) (stopChan <-chan struct{}) {
+	encoded := make(chan *message.Payload)
+	stopChan = d.destination.Start(encoded, output, isRetrying)
+	go d.run(input, encoded, output)
+	return stopChan
+}
+
+func (d *Destination) run(input chan *message.Payload, encoded chan *message.Payload, output chan *message.Payload) {
+	defer close(encoded)
+	for payload := range input {
+		otlpPayload, err := d.encode(payload)
+		if err != nil {
+			log.Warnf("Could not encode %d logs as OTLP log records, dropping them: %v", len(payload.Messages), err)
+			metrics.DestinationLogsDropped.Add(d.host, int64(len(payload.Messages)))
+			metrics.TlmLogsDropped.Add(float64(len(payload.Messages)), d.host)
+			// the payload is still acknowledged so that the auditor commits the
+			// offsets of its messages and keeps advancing
+			output <- payload
+			continue
+		}
+		encoded <- otlpPayload
+	}
+}
+
+// encodePayload returns a payload holding the messages of the given payload encoded as an OTLP export request.
+func (d *Destination) encodePayload(payload *message.Payload) (*message.Payload, error) {
+	request, err := toExportRequest(payload.Messages).MarshalProto()
+	if err != nil {
+		return nil, err
+	}
+	otlpPayload := &message.Payload{
+		Messages:      payload.Messages,
+		Encoded:       request,
+		UnencodedSize: len(request),
+	}
+	if d.useCompression {
+		compressed, err := compress(request, d.compressionLevel)
+		if err != nil {
+			return nil, err
+		}
+		otlpPayload.Encoded = compressed
+		otlpPayload.Encoding = "gzip"
+	}
+	return otlpPayload, nil
+}
+
+func compress(payload []byte, level int) ([]byte, error) {
+	if level < gzip.NoCompression || level > gzip.BestCompression {
+		level = gzip.DefaultCompression
+	}
+	var compressed bytes.Buffer
+	writer, err := gzip.NewWriterLevel(&compressed, level)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := writer.Write(payload); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return compressed.Bytes(), nil
+}
@@ -0,0 +1,209 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package otlp
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	nethttp "net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/plog/plogotlp"
+
+	"github.com/DataDog/datadog-agent/comp/logs/agent/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/client"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/logs/metrics"
+	"github.com/DataDog/datadog-agent/pkg/logs/sources"
+)
+
+type receivedRequest struct {
+	path        string
+	contentType string
+	logs        plog.Logs
+}
+
+// newTestCollector returns an OTLP/HTTP stand-in forwarding the decoded requests to requests.
+func newTestCollector(t *testing.T, statusCodes ...int) (*httptest.Server, chan receivedRequest) {
+	requests := make(chan receivedRequest, 10)
+	calls := 0
+	server := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		statusCode := nethttp.StatusOK
+		if calls < len(statusCodes) {
+			statusCode = statusCodes[calls]
+		}
+		calls++
+
+		var body io.Reader = r.Body
+		if r.Header.Get("Content-Encoding") == "gzip" {
+			reader, err := gzip.NewReader(r.Body)
+			require.NoError(t, err)
+			body = reader
+		}
+		content, err := io.ReadAll(body)
+		require.NoError(t, err)
+		request := plogotlp.NewExportRequest()
+		require.NoError(t, request.UnmarshalProto(content))
+
+		w.WriteHeader(statusCode)
+		if statusCode == nethttp.StatusOK {
+			requests <- receivedRequest{path: r.URL.Path, contentType: r.Header.Get("Content-Type"), logs: request.Logs()}
+		}
+	}))
+	return server, requests
+}
+
+func newTestEndpoint(server *httptest.Server) config.Endpoint {
+	address := strings.TrimPrefix(server.URL, "http://")
+	host, rawPort, _ := strings.Cut(address, ":")
+	port, _ := strconv.Atoi(rawPort)
+	return config.Endpoint{
+		Host:             host,
+		Port:             port,
+		OTLP:             true,
+		BackoffFactor:    1,
+		BackoffBase:      1,
+		BackoffMax:       10,
+		RecoveryInterval: 1,
+	}
+}
+
+func newTestPayload() *message.Payload {
+	source := sources.NewLogSource("", &config.LogsConfig{})
+	return &message.Payload{
+		Messages: []*message.Message{
+			message.NewMessageWithSource([]byte(`{"message":"hello","status":"error","timestamp":1700000000000,"hostname":"host1","service":"web","ddsource":"nginx","ddtags":"env:prod"}`), "", source, 0),
+			message.NewMessageWithSource([]byte(`{"message":"world","status":"info","timestamp":1700000001000,"hostname":"host1","service":"web","ddsource":"nginx","ddtags":"env:prod"}`), "", source, 0),
+			message.NewMessageWithSource([]byte(`{"message":"other","status":"warn","timestamp":1700000002000,"hostname":"host1","service":"db"}`), "", source, 0),
+		},
+		Encoded: []byte("ignored"),
+	}
+}
+
+func TestDestinationSendsOTLPLogs(t *testing.T) {
+	server, requests := newTestCollector(t)
+	defer server.Close()
+
+	destinationsCtx := client.NewDestinationsContext()
+	destinationsCtx.Start()
+	defer destinationsCtx.Stop()
+
+	input := make(chan *message.Payload)
+	output := make(chan *message.Payload)
+	destination := NewDestination(newTestEndpoint(server), destinationsCtx, 1, true, "")
+	stop := destination.Start(input, output, nil)
+
+	payload := newTestPayload()
+	input <- payload
+	sent := <-output
+	assert.Equal(t, payload.Messages, sent.Messages)
+
+	request := <-requests
+	assert.Equal(t, "/v1/logs", request.path)
+	assert.Equal(t, "application/x-protobuf", request.contentType)
+
+	logs := request.logs
+	require.Equal(t, 2, logs.ResourceLogs().Len())
+	assert.Equal(t, 3, logs.LogRecordCount())
+
+	web := logs.ResourceLogs().At(0)
+	hostname, _ := web.Resource().Attributes().Get("host.name")
+	assert.Equal(t, "host1", hostname.Str())
+	service, _ := web.Resource().Attributes().Get("service.name")
+	assert.Equal(t, "web", service.Str())
+
+	record := web.ScopeLogs().At(0).LogRecords().At(0)
+	assert.Equal(t, "hello", record.Body().Str())
+	assert.Equal(t, "error", record.SeverityText())
+	assert.Equal(t, plog.SeverityNumberError, record.SeverityNumber())
+	assert.Equal(t, int64(1700000000000), record.Timestamp().AsTime().UnixMilli())
+	source, _ := record.Attributes().Get("ddsource")
+	assert.Equal(t, "nginx", source.Str())
+	tags, _ := record.Attributes().Get("ddtags")
+	assert.Equal(t, "env:prod", tags.Str())
+
+	db := logs.ResourceLogs().At(1)
+	service, _ = db.Resource().Attributes().Get("service.name")
+	assert.Equal(t, "db", service.Str())
+	assert.Equal(t, plog.SeverityNumberWarn, db.ScopeLogs().At(0).LogRecords().At(0).SeverityNumber())
+
+	close(input)
+	<-stop
+}
+
+func TestDestinationRetriesWithCompression(t *testing.T) {
+	server, requests := newTestCollector(t, nethttp.StatusServiceUnavailable)
+	defer server.Close()
+
+	destinationsCtx := client.NewDestinationsContext()
+	destinationsCtx.Start()
+	defer destinationsCtx.Stop()
+
+	endpoint := newTestEndpoint(server)
+	endpoint.UseCompression = true
+	endpoint.CompressionLevel = 6
+	input := make(chan *message.Payload)
+	output := make(chan *message.Payload)
+	isRetrying := make(chan bool, 2)
+	destination := NewDestination(endpoint, destinationsCtx, 1, true, "")
+	stop := destination.Start(input, output, isRetrying)
+
+	input <- newTestPayload()
+	assert.True(t, <-isRetrying)
+	<-output
+	assert.False(t, <-isRetrying)
+	assert.Equal(t, 3, (<-requests).logs.LogRecordCount())
+
+	close(input)
+	<-stop
+}
+
+func TestDestinationAcknowledgesPayloadsFailingToEncode(t *testing.T) {
+	server, requests := newTestCollector(t)
+	defer server.Close()
+
+	destinationsCtx := client.NewDestinationsContext()
+	destinationsCtx.Start()
+	defer destinationsCtx.Stop()
+
+	endpoint := newTestEndpoint(server)
+	input := make(chan *message.Payload)
+	output := make(chan *message.Payload)
+	destination := NewDestination(endpoint, destinationsCtx, 1, true, "")
+	destination.encode = func(*message.Payload) (*message.Payload, error) {
+		return nil, errors.New("encoding failure")
+	}
+	stop := destination.Start(input, output, nil)
+
+	payload := newTestPayload()
+	input <- payload
+	// the payload is acknowledged without being sent
+	assert.Equal(t, payload, <-output)
+	assert.Empty(t, requests)
+	assert.Equal(t, "3", metrics.DestinationLogsDropped.Get(endpoint.Host).String())
+
+	close(input)
+	<-stop
+}
+
+func TestDecodeRawContent(t *testing.T) {
+	source := sources.NewLogSource("", &config.LogsConfig{Service: "web", Source: "nginx", Tags: []string{"env:prod"}})
+	msg := message.NewMessageWithSource([]byte("plain text"), message.StatusWarning, source, 0)
+
+	l := decode(msg)
+	assert.Equal(t, "plain text", l.Message)
+	assert.Equal(t, message.StatusWarning, l.Status)
+	assert.Equal(t, "web", l.Service)
+	assert.Equal(t, "nginx", l.Source)
+	assert.Equal(t, "env:prod", l.Tags)
+}
@@ -0,0 +1,116 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package otlp
+
+import (
+	"encoding/json"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/plog/plogotlp"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/version"
+)
+
+const (
+	scopeName = "datadog-agent/logs"
+
+	// attributes holding the Datadog source and tags of a log record
+	sourceAttribute = "ddsource"
+	tagsAttribute   = "ddtags"
+)
+
+// jsonLog is the JSON representation of a message encoded by the logs processor
+// for the HTTP endpoints.
+type jsonLog struct {
+	Message   string `json:"message"`
+	Status    string `json:"status"`
+	Timestamp int64  `json:"timestamp"`
+	Hostname  string `json:"hostname"`
+	Service   string `json:"service"`
+	Source    string `json:"ddsource"`
+	Tags      string `json:"ddtags"`
+}
+
+var statusSeverityNumbers = map[string]plog.SeverityNumber{
+	message.StatusEmergency: plog.SeverityNumberFatal3,
+	message.StatusAlert:     plog.SeverityNumberFatal2,
+	message.StatusCritical:  plog.SeverityNumberFatal,
+	message.StatusError:     plog.SeverityNumberError,
+	message.StatusWarning:   plog.SeverityNumberWarn,
+	message.StatusNotice:    plog.SeverityNumberInfo2,
+	message.StatusInfo:      plog.SeverityNumberInfo,
+	message.StatusDebug:     plog.SeverityNumberDebug,
+}
+
+// resourceKey identifies the resource a log record belongs to.
+type resourceKey struct {
+	hostname string
+	service  string
+}
+
+// toExportRequest converts the messages to an OTLP export request, the log records
+// are grouped by hostname and service which become resource attributes.
+func toExportRequest(messages []*message.Message) plogotlp.ExportRequest {
+	logs := plog.NewLogs()
+	scopeLogs := make(map[resourceKey]plog.ScopeLogs)
+	now := pcommon.NewTimestampFromTime(time.Now())
+
+	for _, msg := range messages {
+		l := decode(msg)
+
+		key := resourceKey{hostname: l.Hostname, service: l.Service}
+		records, exists := scopeLogs[key]
+		if !exists {
+			resourceLogs := logs.ResourceLogs().AppendEmpty()
+			if l.Hostname != "" {
+				resourceLogs.Resource().Attributes().PutStr("host.name", l.Hostname)
+			}
+			if l.Service != "" {
+				resourceLogs.Resource().Attributes().PutStr("service.name", l.Service)
+			}
+			records = resourceLogs.ScopeLogs().AppendEmpty()
+			records.Scope().SetName(scopeName)
+			records.Scope().SetVersion(version.AgentVersion)
+			scopeLogs[key] = records
+		}
+
+		record := records.LogRecords().AppendEmpty()
+		record.Body().SetStr(l.Message)
+		record.SetObservedTimestamp(now)
+		if l.Timestamp != 0 {
+			record.SetTimestamp(pcommon.NewTimestampFromTime(time.UnixMilli(l.Timestamp)))
+		}
+		record.SetSeverityText(l.Status)
+		record.SetSeverityNumber(statusSeverityNumbers[l.Status])
+		if l.Source != "" {
+			record.Attributes().PutStr(sourceAttribute, l.Source)
+		}
+		if l.Tags != "" {
+			record.Attributes().PutStr(tagsAttribute, l.Tags)
+		}
+	}
+	return plogotlp.NewExportRequestFromLogs(logs)
+}
+
+// decode returns the fields of a message encoded by the JSON encoder, the content
+// is used as is with the metadata of the message if it is not JSON encoded.
+func decode(msg *message.Message) jsonLog {
+	var l jsonLog
+	if err := json.Unmarshal(msg.Content, &l); err == nil {
+		return l
+	}
+	return jsonLog{
+		Message:  string(msg.Content),
+		Status:   msg.GetStatus(),
+		Hostname: msg.GetHostname(),
+		Service:  msg.Origin.Service(),
+		Source:   msg.Origin.Source(),
+		Tags:     msg.Origin.TagsToString(),
+	}
+}
@@ -0,0 +1,153 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package dryrun runs sample log lines through the decoder and the processor of the logs agent,
+// without sending them, to check the effect of a logs configuration and of its processing rules.
+package dryrun
+
+import (
+	"io"
+
+	"github.com/DataDog/datadog-agent/comp/logs/agent/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/diagnostic"
+	"github.com/DataDog/datadog-agent/pkg/logs/internal/decoder"
+	"github.com/DataDog/datadog-agent/pkg/logs/internal/framer"
+	"github.com/DataDog/datadog-agent/pkg/logs/internal/parsers/noop"
+	"github.com/DataDog/datadog-agent/pkg/logs/internal/parsers/syslog"
+	"github.com/DataDog/datadog-agent/pkg/logs/internal/processor"
+	"github.com/DataDog/datadog-agent/pkg/logs/internal/status"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/logs/sources"
+)
+
+// readSize is the size of the chunks of the sample given to the decoder.
+const readSize = 4096
+
+// Result is the outcome of the processing of a decoded message of the sample.
+type Result struct {
+	// Content is the content of the message once redacted, or as decoded if it was dropped
+	Content string   `json:"content"`
+	Status  string   `json:"status"`
+	Service string   `json:"service,omitempty"`
+	Source  string   `json:"source,omitempty"`
+	Tags    []string `json:"tags,omitempty"`
+	// Dropped is true if a processing rule kept the message from being sent
+	Dropped bool `json:"dropped"`
+	// MatchedRules are the rules which dropped or modified the message, in order
+	MatchedRules []MatchedRule `json:"matched_rules,omitempty"`
+	// Metrics are the metrics extracted from the message
+	Metrics []Metric `json:"metrics,omitempty"`
+}
+
+// MatchedRule is a processing rule which dropped or modified a message.
+type MatchedRule struct {
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	Dropped bool   `json:"dropped"`
+}
+
+// Metric is a metric extracted from a message by an extract_metric rule.
+type Metric struct {
+	Name  string   `json:"name"`
+	Type  string   `json:"type"`
+	Value float64  `json:"value"`
+	Tags  []string `json:"tags,omitempty"`
+}
+
+// Run decodes the sample the way a tailer of cfg would, multi-line aggregation included,
+// and applies the global processing rules and the rules of cfg to every decoded message.
+// cfg must be validated beforehand so that its rules are compiled.
+func Run(cfg *config.LogsConfig, globalRules []*config.ProcessingRule, sample io.Reader) ([]Result, error) {
+	source := sources.NewLogSource("dry-run", cfg)
+	d := buildDecoder(source)
+	submitter := &metricsCollector{}
+	p := processor.New(nil, nil, globalRules, nil, &diagnostic.NoopMessageReceiver{}, submitter)
+
+	readErr := make(chan error, 1)
+	go func() {
+		readErr <- feed(d, sample)
+	}()
+	d.Start()
+
+	var results []Result
+	for output := range d.OutputChan {
+		if len(output.Content) == 0 {
+			continue
+		}
+		msg := message.NewMessageWithSource(output.Content, output.Status, source, output.IngestionTimestamp)
+		if len(output.ParsingExtra.Tags) > 0 {
+			msg.Origin.SetTags(output.ParsingExtra.Tags)
+		}
+		decoded := string(msg.Content)
+
+		submitter.metrics = nil
+		shouldProcess, content, matches := p.Check(msg)
+		result := Result{
+			Content: string(content),
+			Status:  msg.GetStatus(),
+			Service: msg.Origin.Service(),
+			Source:  msg.Origin.Source(),
+			Tags:    append([]string{}, msg.Origin.Tags()...),
+			Dropped: !shouldProcess,
+			Metrics: submitter.metrics,
+		}
+		if result.Dropped {
+			result.Content = decoded
+		}
+		for _, match := range matches {
+			result.MatchedRules = append(result.MatchedRules, MatchedRule{
+				Name:    match.Rule.Name,
+				Type:    match.Rule.Type,
+				Dropped: match.Dropped,
+			})
+		}
+		results = append(results, result)
+	}
+	if err := <-readErr; err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// buildDecoder returns a decoder matching the format of the source, like the tailers do.
+func buildDecoder(source *sources.LogSource) *decoder.Decoder {
+	if source.Config.Format == config.SyslogFormat {
+		return decoder.NewDecoderWithFraming(sources.NewReplaceableSource(source), syslog.New(), framer.SyslogStream, nil, status.NewInfoRegistry())
+	}
+	return decoder.InitializeDecoder(sources.NewReplaceableSource(source), noop.New(), status.NewInfoRegistry())
+}
+
+// feed sends the sample to the decoder and stops it, which flushes the pending lines.
+func feed(d *decoder.Decoder, sample io.Reader) error {
+	defer d.Stop()
+	for {
+		buf := make([]byte, readSize)
+		n, err := sample.Read(buf)
+		if n > 0 {
+			d.InputChan <- decoder.NewInput(buf[:n])
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// metricsCollector keeps the metrics extracted from the message being checked.
+type metricsCollector struct {
+	metrics []Metric
+}
+
+// Count collects a count metric
+func (c *metricsCollector) Count(metric string, value float64, tags []string) {
+	c.metrics = append(c.metrics, Metric{Name: metric, Type: config.MetricTypeCount, Value: value, Tags: tags})
+}
+
+// Distribution collects a distribution metric
+func (c *metricsCollector) Distribution(metric string, value float64, tags []string) {
+	c.metrics = append(c.metrics, Metric{Name: metric, Type: config.MetricTypeDistribution, Value: value, Tags: tags})
+}
@@ -0,0 +1,96 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package dryrun
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/comp/logs/agent/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+func parseConfig(t *testing.T, content string) *config.LogsConfig {
+	configs, err := config.ParseYAML([]byte(content))
+	require.NoError(t, err)
+	require.Len(t, configs, 1)
+	require.NoError(t, configs[0].Validate())
+	return configs[0]
+}
+
+func TestRun(t *testing.T) {
+	cfg := parseConfig(t, `
+logs:
+  - type: file
+    path: /var/log/app.log
+    service: app
+    source: python
+    tags: ["env:prod"]
+    log_processing_rules:
+      - type: multi_line
+        name: new_log_start_with_date
+        pattern: \d{4}-\d{2}-\d{2}
+      - type: mask_sequences
+        name: mask_tokens
+        pattern: token=\w+
+        replace_placeholder: "token=[redacted]"
+      - type: exclude_at_match
+        name: exclude_debug
+        pattern: DEBUG
+`)
+	global := []*config.ProcessingRule{{Type: config.ExtractMetric, Name: "count_errors", Pattern: "ERROR", MetricName: "app.errors", MetricType: config.MetricTypeCount}}
+	require.NoError(t, config.CompileProcessingRules(global))
+
+	sample := strings.Join([]string{
+		"2023-03-10 12:00:00 INFO user logged in token=abc123",
+		"2023-03-10 12:00:01 DEBUG cache miss",
+		"2023-03-10 12:00:02 ERROR request failed",
+		"Traceback (most recent call last):",
+		`  File "app.py", line 1, in <module>`,
+		"",
+	}, "\n")
+
+	results, err := Run(cfg, global, strings.NewReader(sample))
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+
+	assert.Equal(t, "2023-03-10 12:00:00 INFO user logged in token=[redacted]", results[0].Content)
+	assert.False(t, results[0].Dropped)
+	assert.Equal(t, "app", results[0].Service)
+	assert.Equal(t, "python", results[0].Source)
+	assert.Equal(t, message.StatusInfo, results[0].Status)
+	assert.Equal(t, []string{"env:prod"}, results[0].Tags)
+	assert.Equal(t, []MatchedRule{{Name: "mask_tokens", Type: config.MaskSequences}}, results[0].MatchedRules)
+
+	assert.Equal(t, "2023-03-10 12:00:01 DEBUG cache miss", results[1].Content)
+	assert.True(t, results[1].Dropped)
+	assert.Equal(t, []MatchedRule{{Name: "exclude_debug", Type: config.ExcludeAtMatch, Dropped: true}}, results[1].MatchedRules)
+
+	assert.Equal(t, "2023-03-10 12:00:02 ERROR request failed\\nTraceback (most recent call last):\\n  File \"app.py\", line 1, in <module>", results[2].Content)
+	assert.False(t, results[2].Dropped)
+	assert.Equal(t, []MatchedRule{{Name: "count_errors", Type: config.ExtractMetric}}, results[2].MatchedRules)
+	assert.Equal(t, []Metric{{Name: "app.errors", Type: config.MetricTypeCount, Value: 1, Tags: []string{"env:prod", "service:app", "source:python"}}}, results[2].Metrics)
+}
+
+func TestRunSyslogFormat(t *testing.T) {
+	cfg := parseConfig(t, `
+logs:
+  - type: tcp
+    port: 10514
+    format: syslog
+`)
+	results, err := Run(cfg, nil, strings.NewReader("<34>Oct 11 22:14:15 mymachine su[123]: 'su root' failed\n"))
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+
+	assert.Equal(t, message.StatusCritical, results[0].Status)
+	assert.Contains(t, results[0].Content, `"message":"'su root' failed"`)
+	assert.Contains(t, results[0].Tags, "syslog_appname:su")
+	assert.Empty(t, results[0].MatchedRules)
+}
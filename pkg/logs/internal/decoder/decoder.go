@@ -110,6 +110,12 @@ func NewDecoderWithFraming(source *sources.ReplaceableSource, parser parsers.Par
 			lineHandler = lh
 		}
 	}
+	if lineHandler == nil && source.Config().AutoMultiLineAggregationEnabled(pkgConfig.Datadog) {
+		log.Infof("Auto multi line aggregation enabled")
+		lh := NewHeuristicMultiLineHandler(outputFn, config.AggregationTimeout(pkgConfig.Datadog), lineLimit)
+		syncSourceInfo(source, lh)
+		lineHandler = lh
+	}
 	if lineHandler == nil {
 		if source.Config().AutoMultiLineEnabled(pkgConfig.Datadog) {
 			log.Infof("Auto multi line log detection enabled")
@@ -341,3 +341,19 @@ func TestDecoderWithMultilineKubernetes(t *testing.T) {
 	assert.Equal(t, message.StatusError, output.Status)
 	assert.Equal(t, "2019-06-06T16:35:55.930852913Z", output.ParsingExtra.Timestamp)
 }
+
+func TestDecoderWithAutoMultiLineAggregation(t *testing.T) {
+	aggregate := true
+	source := sources.NewLogSource("config", &config.LogsConfig{AutoMultiLineAggregation: &aggregate})
+	d := InitializeDecoderForTest(source, noop.New())
+	d.Start()
+
+	d.InputChan <- NewInput([]byte("Exception in thread \"main\" java.lang.NullPointerException\n\tat com.example.Main.main(Main.java:12)\nnext line\n"))
+
+	output := <-d.OutputChan
+	assert.Equal(t, `Exception in thread "main" java.lang.NullPointerException\n`+"\tat com.example.Main.main(Main.java:12)", string(output.Content))
+
+	d.Stop()
+	output = <-d.OutputChan
+	assert.Equal(t, "next line", string(output.Content))
+}
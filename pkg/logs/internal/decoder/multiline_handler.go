@@ -19,11 +19,16 @@ import (
 // the multi_line handler is used with auto_multi_line_detection enabled.
 const linesCombinedTelemetryMetricName = "datadog.logs_agent.auto_multi_line_lines_combined"
 
+// newContentMatcher decides whether a line is the first line of a new message.
+type newContentMatcher interface {
+	Match(content []byte) bool
+}
+
 // MultiLineHandler makes sure that multiple lines from a same content
 // are properly put together.
 type MultiLineHandler struct {
 	outputFn          func(*message.Message)
-	newContentRe      *regexp.Regexp
+	newContentRe      newContentMatcher
 	buffer            *bytes.Buffer
 	flushTimeout      time.Duration
 	flushTimer        *time.Timer
@@ -40,6 +45,10 @@ type MultiLineHandler struct {
 
 // NewMultiLineHandler returns a new MultiLineHandler.
 func NewMultiLineHandler(outputFn func(*message.Message), newContentRe *regexp.Regexp, flushTimeout time.Duration, lineLimit int, telemetryEnabled bool) *MultiLineHandler {
+	return newMultiLineHandler(outputFn, newContentRe, flushTimeout, lineLimit, telemetryEnabled)
+}
+
+func newMultiLineHandler(outputFn func(*message.Message), newContentRe newContentMatcher, flushTimeout time.Duration, lineLimit int, telemetryEnabled bool) *MultiLineHandler {
 	return &MultiLineHandler{
 		outputFn:          outputFn,
 		newContentRe:      newContentRe,
@@ -0,0 +1,141 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package decoder
+
+import (
+	"bytes"
+	"regexp"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+// NewHeuristicMultiLineHandler returns a MultiLineHandler aggregating the lines of stack traces
+// (Java, Python, Go, .NET, Node.js) and of JSON objects spread over several lines,
+// any other line starts a new message.
+func NewHeuristicMultiLineHandler(outputFn func(*message.Message), flushTimeout time.Duration, lineLimit int) *MultiLineHandler {
+	return newMultiLineHandler(outputFn, &heuristicMatcher{}, flushTimeout, lineLimit, true)
+}
+
+var (
+	// lines following the first line of a stack trace which are not indented
+	stackTraceContinuations = []*regexp.Regexp{
+		regexp.MustCompile(`^Caused by: `),
+		regexp.MustCompile(`^Suppressed: `),
+		// an exception logged after the message, like 'java.lang.IllegalStateException: boom'
+		regexp.MustCompile(`^([a-zA-Z_$][\w$]*\.)+[\w$]*(Exception|Error|Throwable)(: |$)`),
+		// a python exception chained to the previous one
+		regexp.MustCompile(`^(During handling of the above exception, another exception occurred:|The above exception was the direct cause of the following exception:)$`),
+	}
+	goPanicStart         = regexp.MustCompile(`^(panic: |fatal error: )`)
+	goPanicContinuations = regexp.MustCompile(`^(goroutine \d+ \[|created by |\[signal |exit status \d+$|[\w./*()\-\[\]{}]+\(.*\)$)`)
+	pythonTracebackStart = []byte("Traceback (most recent call last):")
+)
+
+// heuristicMatcher recognizes the lines that continue a message, it is stateful
+// as some continuations depend on the previous lines.
+type heuristicMatcher struct {
+	// jsonDepth is the number of JSON objects and arrays opened by the previous lines and not closed yet
+	jsonDepth int
+	// inPythonTraceback is true while the frames of a python traceback are read
+	inPythonTraceback bool
+	// inGoPanic is true while the goroutines dumped by a go panic are read
+	inGoPanic bool
+}
+
+// Match returns true if the line is the first line of a new message.
+func (m *heuristicMatcher) Match(content []byte) bool {
+	if m.jsonDepth > 0 {
+		if isJSONContinuation(content) {
+			m.jsonDepth = jsonDepth(content, m.jsonDepth)
+			return false
+		}
+		// the JSON object won't be closed
+		m.jsonDepth = 0
+	}
+
+	if len(bytes.TrimSpace(content)) == 0 {
+		return false
+	}
+
+	if m.inPythonTraceback {
+		if !isIndented(content) {
+			// the exception ends the traceback
+			m.inPythonTraceback = false
+		}
+		return false
+	}
+	if bytes.HasPrefix(content, pythonTracebackStart) {
+		// the traceback is logged after the message
+		m.inPythonTraceback = true
+		return false
+	}
+
+	if m.inGoPanic {
+		if isIndented(content) || goPanicContinuations.Match(content) {
+			return false
+		}
+		m.inGoPanic = false
+	}
+	if goPanicStart.Match(content) {
+		m.inGoPanic = true
+		return true
+	}
+
+	if isIndented(content) {
+		// stack frames, like '\tat com.example.Main.main(Main.java:12)' or '    at Object.<anonymous> (/app/index.js:1:7)'
+		return false
+	}
+	for _, re := range stackTraceContinuations {
+		if re.Match(content) {
+			return false
+		}
+	}
+
+	if content[0] == '{' {
+		m.jsonDepth = jsonDepth(content, 0)
+	}
+	return true
+}
+
+func isIndented(content []byte) bool {
+	return len(content) > 0 && (content[0] == ' ' || content[0] == '\t')
+}
+
+// isJSONContinuation returns true if the line can be part of a pretty-printed JSON document.
+func isJSONContinuation(content []byte) bool {
+	if len(content) == 0 || isIndented(content) {
+		return true
+	}
+	switch content[0] {
+	case '}', ']', '{', '[', '"':
+		return true
+	}
+	return false
+}
+
+// jsonDepth returns the depth of the JSON document after reading the line,
+// the strings of a JSON document can't span several lines.
+func jsonDepth(content []byte, depth int) int {
+	inString := false
+	for i := 0; i < len(content); i++ {
+		switch c := content[i]; {
+		case inString && c == '\\':
+			i++
+		case c == '"':
+			inString = !inString
+		case inString:
+		case c == '{' || c == '[':
+			depth++
+		case c == '}' || c == ']':
+			depth--
+		}
+	}
+	if depth < 0 {
+		return 0
+	}
+	return depth
+}
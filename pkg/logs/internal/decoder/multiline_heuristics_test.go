@@ -0,0 +1,163 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package decoder
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func aggregateWithHeuristics(lines []string) []string {
+	outputFn, outputChan := lineHandlerChans()
+	h := NewHeuristicMultiLineHandler(outputFn, time.Minute, 10000)
+	messages := []string{}
+	collect := func() {
+		for {
+			select {
+			case msg := <-outputChan:
+				messages = append(messages, strings.ReplaceAll(string(msg.Content), `\n`, "\n"))
+			default:
+				return
+			}
+		}
+	}
+	for _, line := range lines {
+		h.process(getDummyMessageWithLF(line))
+		collect()
+	}
+	h.flush()
+	collect()
+	return messages
+}
+
+func TestHeuristicMultiLineHandler(t *testing.T) {
+	tests := []struct {
+		name     string
+		lines    []string
+		expected []string
+	}{
+		{
+			name:     "single lines",
+			lines:    []string{"first", "second", "[INFO] third [unbalanced", "fourth"},
+			expected: []string{"first", "second", "[INFO] third [unbalanced", "fourth"},
+		},
+		{
+			name: "java",
+			lines: []string{
+				"2023-03-10 12:00:00 ERROR Request failed",
+				"java.lang.IllegalStateException: boom",
+				"\tat com.example.Service.handle(Service.java:42)",
+				"\tat com.example.Main.main(Main.java:12)",
+				"Caused by: java.io.IOException: disk full",
+				"\tat com.example.Store.write(Store.java:7)",
+				"\t... 2 more",
+				"2023-03-10 12:00:01 INFO Next request",
+			},
+			expected: []string{
+				"2023-03-10 12:00:00 ERROR Request failed\njava.lang.IllegalStateException: boom\n\tat com.example.Service.handle(Service.java:42)\n\tat com.example.Main.main(Main.java:12)\nCaused by: java.io.IOException: disk full\n\tat com.example.Store.write(Store.java:7)\n\t... 2 more",
+				"2023-03-10 12:00:01 INFO Next request",
+			},
+		},
+		{
+			name: "python",
+			lines: []string{
+				"ERROR:root:Request failed",
+				"Traceback (most recent call last):",
+				`  File "/app/main.py", line 3, in <module>`,
+				"    foo()",
+				"KeyError: 'a'",
+				"",
+				"During handling of the above exception, another exception occurred:",
+				"",
+				"Traceback (most recent call last):",
+				`  File "/app/main.py", line 5, in <module>`,
+				"ValueError: bad value",
+				"INFO:root:Next request",
+			},
+			expected: []string{
+				"ERROR:root:Request failed\nTraceback (most recent call last):\n  File \"/app/main.py\", line 3, in <module>\n    foo()\nKeyError: 'a'\n\nDuring handling of the above exception, another exception occurred:\n\nTraceback (most recent call last):\n  File \"/app/main.py\", line 5, in <module>\nValueError: bad value",
+				"INFO:root:Next request",
+			},
+		},
+		{
+			name: "go",
+			lines: []string{
+				"starting",
+				"panic: runtime error: invalid memory address or nil pointer dereference",
+				"[signal SIGSEGV: segmentation violation code=0x1 addr=0x0 pc=0x47f1d4]",
+				"",
+				"goroutine 1 [running]:",
+				"main.(*server).handle(0x0, {0x4b6f40, 0xc000012345})",
+				"\t/app/main.go:12 +0x14",
+				"main.main()",
+				"\t/app/main.go:20 +0x1d",
+				"exit status 2",
+				"restarting",
+			},
+			expected: []string{
+				"starting",
+				"panic: runtime error: invalid memory address or nil pointer dereference\n[signal SIGSEGV: segmentation violation code=0x1 addr=0x0 pc=0x47f1d4]\n\ngoroutine 1 [running]:\nmain.(*server).handle(0x0, {0x4b6f40, 0xc000012345})\n\t/app/main.go:12 +0x14\nmain.main()\n\t/app/main.go:20 +0x1d\nexit status 2",
+				"restarting",
+			},
+		},
+		{
+			name: "dotnet",
+			lines: []string{
+				"Unhandled exception. System.InvalidOperationException: Operation failed",
+				" ---> System.Exception: Inner failure",
+				"   at Program.Inner() in /app/Program.cs:line 10",
+				"   --- End of inner exception stack trace ---",
+				"   at Program.Main() in /app/Program.cs:line 5",
+				"Done",
+			},
+			expected: []string{
+				"Unhandled exception. System.InvalidOperationException: Operation failed\n ---> System.Exception: Inner failure\n   at Program.Inner() in /app/Program.cs:line 10\n   --- End of inner exception stack trace ---\n   at Program.Main() in /app/Program.cs:line 5",
+				"Done",
+			},
+		},
+		{
+			name: "node",
+			lines: []string{
+				"Error: boom",
+				"    at Object.<anonymous> (/app/index.js:1:7)",
+				"    at Module._compile (node:internal/modules/cjs/loader:1105:14)",
+				"listening on 8080",
+			},
+			expected: []string{
+				"Error: boom\n    at Object.<anonymous> (/app/index.js:1:7)\n    at Module._compile (node:internal/modules/cjs/loader:1105:14)",
+				"listening on 8080",
+			},
+		},
+		{
+			name: "json",
+			lines: []string{
+				`{"single": "line"}`,
+				`{`,
+				`  "message": "multi {line",`,
+				`  "nested": {`,
+				`    "list": [1, 2]`,
+				`  }`,
+				`}`,
+				`{"unterminated": [`,
+				`not json`,
+			},
+			expected: []string{
+				`{"single": "line"}`,
+				"{\n  \"message\": \"multi {line\",\n  \"nested\": {\n    \"list\": [1, 2]\n  }\n}",
+				`{"unterminated": [`,
+				`not json`,
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, aggregateWithHeuristics(test.lines))
+		})
+	}
+}
@@ -42,6 +42,11 @@ const (
 	// headers are included in the log frame.  The size in those headers is not
 	// consulted.  The result does not include the trailing newlines.
 	DockerStream
+
+	// Syslog messages over a stream, either octet-counted ('<length> <message>')
+	// or newline-terminated as described in RFC6587.  The framing is detected for
+	// each message.
+	SyslogStream
 )
 
 // Framer gets chunks of bytes (via Process(..)) and uses an
@@ -101,6 +106,8 @@ func NewFramer(
 		matcher = &dockerStreamMatcher{contentLenLimit}
 	case NoFraming:
 		matcher = &noFramingMatcher{}
+	case SyslogStream:
+		matcher = &syslogStreamMatcher{newLineMatcher: oneByteNewLineMatcher{contentLenLimit}}
 	default:
 		panic(fmt.Sprintf("unknown framing %d", framing))
 	}
@@ -147,6 +154,12 @@ func (fr *Framer) Process(input *message.Message) {
 		buf := fr.buffer.Bytes()[framed:]
 
 		content, rawDataLen := fr.matcher.FindFrame(buf, seen-framed)
+		if content == nil && rawDataLen > 0 {
+			// the matcher discarded these bytes
+			framed += rawDataLen
+			seen = framed
+			continue
+		}
 		if content == nil {
 			// if the matcher was asked to match more than contentLenLimit,
 			// chop off contentLenLimit raw bytes and output them
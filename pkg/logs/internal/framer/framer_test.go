@@ -214,6 +214,18 @@ func TestLineBreaking(t *testing.T) {
 			t.Run(fmt.Sprintf("%d-byte chunks", size), test(framing, chunk(input, size), lines, lens))
 		}
 	})
+
+	t.Run("SyslogStream", func(t *testing.T) {
+		// octet-counted messages can contain newlines and be mixed with newline-terminated messages
+		input := []byte("10 <13>line\n1" + "<13>line 2\n" + "11 <13>line 3\n" + "2021 is not a length\n")
+		lines := []string{"<13>line\n1", "<13>line 2", "<13>line 3\n", "2021 is not a length"}
+		lens := []int{13, 11, 14, 21}
+		framing := SyslogStream
+		t.Run("one chunk", test(framing, chunk(input, len(input)), lines, lens))
+		for size := 0; size < 20; size++ {
+			t.Run(fmt.Sprintf("%d-byte chunks", size), test(framing, chunk(input, size), lines, lens))
+		}
+	})
 }
 
 func TestContentLenLimit(t *testing.T) {
@@ -268,6 +280,37 @@ func TestContentLenLimit(t *testing.T) {
 	})
 }
 
+func TestSyslogStreamContentLenLimit(t *testing.T) {
+	// two octet-counted messages of 33 bytes, followed by messages within the limit
+	input := []byte("30 <13>" + strings.Repeat("a", 26) + "30 <13>" + strings.Repeat("b", 26) + "6 <13>ok" + "line\n")
+	lines := []string{"<13>aaaaaaaaa", "<13>bbbbbbbbb", "<13>ok", "line"}
+	lens := []int{16, 16, 8, 5}
+	test := func(size int) func(*testing.T) {
+		return func(t *testing.T) {
+			gotContent := []string{}
+			gotLens := []int{}
+			outputFn := func(msg *message.Message, rawDataLen int) {
+				gotContent = append(gotContent, string(msg.Content))
+				gotLens = append(gotLens, rawDataLen)
+			}
+			fr := NewFramer(outputFn, SyslogStream, 16)
+			for i := 0; i < len(input); i += size {
+				end := i + size
+				if end > len(input) {
+					end = len(input)
+				}
+				fr.Process(&message.Message{Content: input[i:end]})
+			}
+			require.Equal(t, lines, gotContent)
+			require.Equal(t, lens, gotLens)
+		}
+	}
+	t.Run("one chunk", test(len(input)))
+	for size := 1; size < 20; size++ {
+		t.Run(fmt.Sprintf("%d-byte chunks", size), test(size))
+	}
+}
+
 func TestLineBreakIncomingData(t *testing.T) {
 	outputFn, outputChan := framerOutput()
 	framer := NewFramer(outputFn, UTF8Newline, contentLenLimit)
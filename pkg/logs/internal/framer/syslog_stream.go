@@ -0,0 +1,90 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package framer
+
+// maxOctetCountDigits is the maximum number of digits of the length prefix of
+// an octet-counted syslog message, longer prefixes are not considered as lengths.
+const maxOctetCountDigits = 9
+
+// syslogStreamMatcher matches syslog messages sent over a stream, see
+// https://datatracker.ietf.org/doc/html/rfc6587#section-3.4.
+//
+// Messages starting with a decimal length followed by a space and the '<' opening
+// the syslog priority use octet-counting, the frame is then the given number of bytes
+// following the space.  Other messages are newline-terminated.
+//
+// Octet-counted messages longer than the content length limit are truncated to it, and
+// the rest of the message is discarded.
+type syslogStreamMatcher struct {
+	newLineMatcher oneByteNewLineMatcher
+	// discard is the number of bytes left to discard from a truncated message.
+	discard int
+}
+
+// FindFrame implements EndLineMatcher#FindFrame.
+func (s *syslogStreamMatcher) FindFrame(buf []byte, seen int) ([]byte, int) {
+	if s.discard > 0 {
+		n := s.discard
+		if n > len(buf) {
+			n = len(buf)
+		}
+		s.discard -= n
+		return nil, n
+	}
+	length, headerLen, ok := s.octetCount(buf)
+	if !ok {
+		return s.newLineMatcher.FindFrame(buf, seen)
+	}
+	if headerLen == 0 {
+		// the length prefix is not complete yet
+		return nil, 0
+	}
+	if limit := s.newLineMatcher.contentLenLimit; headerLen+length > limit {
+		// the frame is truncated as soon as the framer would chop off its content
+		if len(buf) < limit {
+			return nil, 0
+		}
+		s.discard = headerLen + length - limit
+		if headerLen > limit {
+			headerLen = limit
+		}
+		return buf[headerLen:limit], limit
+	}
+	if len(buf) < headerLen+length {
+		return nil, 0
+	}
+	return buf[headerLen : headerLen+length], headerLen + length
+}
+
+// octetCount parses the length prefix at the beginning of buf.  It returns false if buf
+// does not start with a length prefix, and a header length of 0 if more bytes are needed
+// to decide.
+func (s *syslogStreamMatcher) octetCount(buf []byte) (length int, headerLen int, ok bool) {
+	// RFC6587 does not allow a leading zero
+	if len(buf) == 0 || buf[0] < '1' || buf[0] > '9' {
+		return 0, 0, false
+	}
+	for i, b := range buf {
+		switch {
+		case b >= '0' && b <= '9':
+			if i >= maxOctetCountDigits {
+				return 0, 0, false
+			}
+			length = length*10 + int(b-'0')
+		case b == ' ':
+			if i+1 == len(buf) {
+				return 0, 0, true
+			}
+			if buf[i+1] != '<' {
+				return 0, 0, false
+			}
+			return length, i + 1, true
+		default:
+			return 0, 0, false
+		}
+	}
+	return 0, 0, true
+}
@@ -0,0 +1,88 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package syslog
+
+import (
+	"bytes"
+	"time"
+)
+
+// parseRFC3164 parses the part of a RFC3164 message following the priority:
+// 'TIMESTAMP HOSTNAME TAG[PID]: MSG', where the hostname and the tag are optional,
+// see https://datatracker.ietf.org/doc/html/rfc3164#section-4.1.
+// Messages without a valid timestamp are kept whole, as advised by the RFC.
+func (e *entry) parseRFC3164(content []byte, now time.Time) {
+	content, ok := e.parseRFC3164Timestamp(content, now)
+	if !ok {
+		e.msg = content
+		return
+	}
+
+	// the hostname is omitted by some senders, in which case the first word is the tag
+	if end := bytes.IndexByte(content, ' '); end > 0 && !isTag(content[:end]) {
+		e.hostname = string(content[:end])
+		content = content[end+1:]
+	}
+	e.msg = e.parseTag(content)
+}
+
+// parseRFC3164Timestamp parses the 'Mmm dd hh:mm:ss' timestamp, a RFC3339 timestamp is accepted
+// as well as many senders use it.  It returns the content following the timestamp.
+func (e *entry) parseRFC3164Timestamp(content []byte, now time.Time) ([]byte, bool) {
+	if len(content) > len(time.Stamp) && content[len(time.Stamp)] == ' ' {
+		if timestamp, err := time.ParseInLocation(time.Stamp, string(content[:len(time.Stamp)]), now.Location()); err == nil {
+			// the year is not part of the timestamp, messages from the end of
+			// last year can be received at the beginning of the current one
+			timestamp = time.Date(now.Year(), timestamp.Month(), timestamp.Day(), timestamp.Hour(), timestamp.Minute(), timestamp.Second(), 0, now.Location())
+			if timestamp.After(now.AddDate(0, 1, 0)) {
+				timestamp = timestamp.AddDate(-1, 0, 0)
+			}
+			e.timestamp = timestamp
+			return content[len(time.Stamp)+1:], true
+		}
+	}
+	if end := bytes.IndexByte(content, ' '); end > 0 {
+		if timestamp, err := time.Parse(time.RFC3339Nano, string(content[:end])); err == nil {
+			e.timestamp = timestamp
+			return content[end+1:], true
+		}
+	}
+	return content, false
+}
+
+// isTag returns true if word is a tag followed by its delimiter, like 'su:' or 'su[123]:'.
+func isTag(word []byte) bool {
+	return bytes.HasSuffix(word, []byte(":")) || bytes.IndexByte(word, '[') > 0
+}
+
+// parseTag parses the optional 'TAG[PID]:' prefix of the message and returns the message.
+func (e *entry) parseTag(content []byte) []byte {
+	end := bytes.IndexAny(content, ":[ ")
+	if end < 1 {
+		return content
+	}
+	tag := string(content[:end])
+	rest := content[end:]
+
+	var procID string
+	if rest[0] == '[' {
+		pidEnd := bytes.IndexByte(rest, ']')
+		if pidEnd < 0 {
+			return content
+		}
+		procID = string(rest[1:pidEnd])
+		rest = rest[pidEnd+1:]
+		rest = bytes.TrimPrefix(rest, []byte(":"))
+	} else if rest[0] == ':' {
+		rest = rest[1:]
+	} else {
+		// the first word isn't followed by a delimiter, it is part of the message
+		return content
+	}
+	e.appName = tag
+	e.procID = procID
+	return bytes.TrimPrefix(rest, []byte(" "))
+}
@@ -0,0 +1,117 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package syslog
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// nilValue is used by RFC5424 for the fields that are not provided.
+const nilValue = "-"
+
+// bom is the UTF-8 byte order mark which may prefix RFC5424 messages.
+var bom = []byte{0xEF, 0xBB, 0xBF}
+
+// parseRFC5424 parses the part of a RFC5424 message following the version:
+// 'TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA [MSG]'
+// see https://datatracker.ietf.org/doc/html/rfc5424#section-6.
+func (e *entry) parseRFC5424(content []byte) error {
+	var fields [5]string
+	for i := range fields {
+		end := bytes.IndexByte(content, ' ')
+		if end < 1 {
+			return errors.New("truncated syslog header")
+		}
+		if value := string(content[:end]); value != nilValue {
+			fields[i] = value
+		}
+		content = content[end+1:]
+	}
+
+	if fields[0] != "" {
+		timestamp, err := time.Parse(time.RFC3339Nano, fields[0])
+		if err != nil {
+			return fmt.Errorf("invalid syslog timestamp: %v", err)
+		}
+		e.timestamp = timestamp
+	}
+	e.hostname, e.appName, e.procID, e.msgID = fields[1], fields[2], fields[3], fields[4]
+
+	content, err := e.parseStructuredData(content)
+	if err != nil {
+		return err
+	}
+	if len(content) > 0 {
+		if content[0] != ' ' {
+			return errors.New("invalid syslog structured data")
+		}
+		content = bytes.TrimPrefix(content[1:], bom)
+	}
+	e.msg = content
+	return nil
+}
+
+// parseStructuredData parses the '[SD-ID PARAM-NAME="PARAM-VALUE" ...]' elements,
+// it returns the remaining content.
+func (e *entry) parseStructuredData(content []byte) ([]byte, error) {
+	if len(content) > 0 && content[0] == '-' {
+		return content[1:], nil
+	}
+	if len(content) == 0 || content[0] != '[' {
+		return nil, errors.New("invalid syslog structured data")
+	}
+	e.structuredData = make(map[string]map[string]string)
+	for len(content) > 0 && content[0] == '[' {
+		end := bytes.IndexAny(content, " ]")
+		if end < 2 {
+			return nil, errors.New("invalid syslog structured data element")
+		}
+		params := make(map[string]string)
+		e.structuredData[string(content[1:end])] = params
+		content = content[end:]
+
+		for len(content) > 0 && content[0] == ' ' {
+			nameEnd := bytes.IndexByte(content, '=')
+			if nameEnd < 2 || len(content) < nameEnd+2 || content[nameEnd+1] != '"' {
+				return nil, errors.New("invalid syslog structured data parameter")
+			}
+			name := string(content[1:nameEnd])
+			value, rest, err := parseParamValue(content[nameEnd+2:])
+			if err != nil {
+				return nil, err
+			}
+			params[name] = value
+			content = rest
+		}
+		if len(content) == 0 || content[0] != ']' {
+			return nil, errors.New("unterminated syslog structured data element")
+		}
+		content = content[1:]
+	}
+	return content, nil
+}
+
+// parseParamValue parses a parameter value up to its closing quote, unescaping '"', '\' and ']'.
+func parseParamValue(content []byte) (string, []byte, error) {
+	var value []byte
+	for i := 0; i < len(content); i++ {
+		switch content[i] {
+		case '\\':
+			if i+1 < len(content) && (content[i+1] == '"' || content[i+1] == '\\' || content[i+1] == ']') {
+				i++
+			}
+			value = append(value, content[i])
+		case '"':
+			return string(value), content[i+1:], nil
+		default:
+			value = append(value, content[i])
+		}
+	}
+	return "", nil, errors.New("unterminated syslog structured data parameter value")
+}
@@ -0,0 +1,228 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package syslog implements a parser for syslog messages following RFC5424 or RFC3164.
+package syslog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/internal/parsers"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+// New creates a new parser that parses syslog messages.
+//
+// Both the RFC5424 format, for example:
+// `<165>1 2003-10-11T22:14:15.003Z mymachine.example.com evntslog - ID47 [exampleSDID@32473 iut="3"] An application event`
+// and the legacy RFC3164 format, for example:
+// `<34>Oct 11 22:14:15 mymachine su[123]: 'su root' failed for lonvick on /dev/pts/8`
+// are supported.
+//
+// The content of the parsed message is a JSON object holding the syslog message in its
+// "message" attribute and the header fields in its "syslog" attribute, the status of the
+// message is set from the severity.
+func New() parsers.Parser {
+	return &syslogFormat{now: time.Now}
+}
+
+type syslogFormat struct {
+	// now is used to infer the year of RFC3164 timestamps
+	now func() time.Time
+}
+
+// Parse implements Parser#Parse
+func (p *syslogFormat) Parse(msg *message.Message) (*message.Message, error) {
+	e, err := parse(bytes.TrimRight(msg.Content, "\r\n"), p.now())
+	if err != nil {
+		return &message.Message{
+			Content:            msg.Content,
+			Origin:             msg.Origin,
+			Status:             message.StatusInfo,
+			IngestionTimestamp: msg.IngestionTimestamp,
+		}, err
+	}
+	content, err := json.Marshal(e.payload())
+	if err != nil {
+		return &message.Message{
+			Content:            e.msg,
+			Origin:             msg.Origin,
+			Status:             e.status(),
+			IngestionTimestamp: msg.IngestionTimestamp,
+		}, err
+	}
+	return &message.Message{
+		Content:            content,
+		Origin:             msg.Origin,
+		Status:             e.status(),
+		IngestionTimestamp: msg.IngestionTimestamp,
+		ParsingExtra: message.ParsingExtra{
+			Timestamp: e.formatTimestamp(),
+			Tags:      e.tags(),
+		},
+	}, nil
+}
+
+// SupportsPartialLine implements Parser#SupportsPartialLine
+func (p *syslogFormat) SupportsPartialLine() bool {
+	return false
+}
+
+// entry holds the fields of a syslog message, missing fields are left empty.
+type entry struct {
+	facility       int
+	severity       int
+	version        int
+	timestamp      time.Time
+	hostname       string
+	appName        string
+	procID         string
+	msgID          string
+	structuredData map[string]map[string]string
+	msg            []byte
+}
+
+type attributes struct {
+	Timestamp      string                       `json:"timestamp,omitempty"`
+	Hostname       string                       `json:"hostname,omitempty"`
+	AppName        string                       `json:"appname,omitempty"`
+	ProcID         string                       `json:"procid,omitempty"`
+	MsgID          string                       `json:"msgid,omitempty"`
+	Facility       int                          `json:"facility"`
+	Severity       int                          `json:"severity"`
+	Version        int                          `json:"version,omitempty"`
+	StructuredData map[string]map[string]string `json:"structured_data,omitempty"`
+}
+
+type payload struct {
+	Message string     `json:"message"`
+	Syslog  attributes `json:"syslog"`
+}
+
+func (e *entry) payload() payload {
+	return payload{
+		Message: string(e.msg),
+		Syslog: attributes{
+			Timestamp:      e.formatTimestamp(),
+			Hostname:       e.hostname,
+			AppName:        e.appName,
+			ProcID:         e.procID,
+			MsgID:          e.msgID,
+			Facility:       e.facility,
+			Severity:       e.severity,
+			Version:        e.version,
+			StructuredData: e.structuredData,
+		},
+	}
+}
+
+func (e *entry) formatTimestamp() string {
+	if e.timestamp.IsZero() {
+		return ""
+	}
+	return e.timestamp.Format(time.RFC3339Nano)
+}
+
+// severityStatusMapping represents the 1:1 mapping between syslog severities and statuses.
+var severityStatusMapping = []string{
+	message.StatusEmergency,
+	message.StatusAlert,
+	message.StatusCritical,
+	message.StatusError,
+	message.StatusWarning,
+	message.StatusNotice,
+	message.StatusInfo,
+	message.StatusDebug,
+}
+
+func (e *entry) status() string {
+	return severityStatusMapping[e.severity]
+}
+
+// facilityNames are the names of the facilities defined by RFC5424, indexed by their code.
+var facilityNames = []string{
+	"kern", "user", "mail", "daemon", "auth", "syslog", "lpr", "news",
+	"uucp", "cron", "authpriv", "ftp", "ntp", "security", "console", "solaris-cron",
+	"local0", "local1", "local2", "local3", "local4", "local5", "local6", "local7",
+}
+
+func (e *entry) tags() []string {
+	tags := []string{"syslog_facility:" + facilityNames[e.facility]}
+	if e.hostname != "" {
+		tags = append(tags, "syslog_hostname:"+e.hostname)
+	}
+	if e.appName != "" {
+		tags = append(tags, "syslog_appname:"+e.appName)
+	}
+	return tags
+}
+
+// parse parses a syslog message, the format is detected from the header.
+func parse(content []byte, now time.Time) (*entry, error) {
+	e := &entry{}
+	rest, err := e.parsePriority(content)
+	if err != nil {
+		return nil, err
+	}
+	if version, afterVersion, ok := parseVersion(rest); ok {
+		e.version = version
+		err = e.parseRFC5424(afterVersion)
+	} else {
+		e.parseRFC3164(rest, now)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// parsePriority parses the '<PRI>' header shared by both formats.
+func (e *entry) parsePriority(content []byte) ([]byte, error) {
+	if len(content) == 0 || content[0] != '<' {
+		return nil, errors.New("missing syslog priority")
+	}
+	end := bytes.IndexByte(content, '>')
+	if end < 2 || end > 4 {
+		return nil, errors.New("invalid syslog priority")
+	}
+	priority, ok := parseDigits(content[1:end])
+	if !ok || priority > 191 {
+		return nil, errors.New("invalid syslog priority")
+	}
+	e.facility = priority / 8
+	e.severity = priority % 8
+	return content[end+1:], nil
+}
+
+// parseVersion returns the RFC5424 version following the priority, if any.
+func parseVersion(content []byte) (int, []byte, bool) {
+	end := bytes.IndexByte(content, ' ')
+	if end < 1 || end > 3 {
+		return 0, nil, false
+	}
+	version, ok := parseDigits(content[:end])
+	if !ok || version == 0 {
+		return 0, nil, false
+	}
+	return version, content[end+1:], true
+}
+
+// parseDigits parses a positive decimal number.
+func parseDigits(digits []byte) (int, bool) {
+	if len(digits) == 0 {
+		return 0, false
+	}
+	n := 0
+	for _, b := range digits {
+		if b < '0' || b > '9' {
+			return 0, false
+		}
+		n = n*10 + int(b-'0')
+	}
+	return n, true
+}
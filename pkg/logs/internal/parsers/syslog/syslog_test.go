@@ -0,0 +1,136 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package syslog
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+var now = time.Date(2023, time.March, 10, 12, 0, 0, 0, time.UTC)
+
+func parseLine(t *testing.T, line string) (*message.Message, payload) {
+	parser := &syslogFormat{now: func() time.Time { return now }}
+	msg, err := parser.Parse(&message.Message{Content: []byte(line)})
+	require.NoError(t, err)
+	var p payload
+	require.NoError(t, json.Unmarshal(msg.Content, &p))
+	return msg, p
+}
+
+func TestParseRFC5424(t *testing.T) {
+	msg, p := parseLine(t, `<165>1 2003-10-11T22:14:15.003Z mymachine.example.com evntslog 1234 ID47 [exampleSDID@32473 iut="3" eventSource="App\"lication\]"][examplePriority@32473 class="high"] `+"\xEF\xBB\xBF"+`An application event`+"\n")
+
+	assert.Equal(t, message.StatusNotice, msg.Status)
+	assert.Equal(t, "2003-10-11T22:14:15.003Z", msg.ParsingExtra.Timestamp)
+	assert.Equal(t, []string{"syslog_facility:local4", "syslog_hostname:mymachine.example.com", "syslog_appname:evntslog"}, msg.ParsingExtra.Tags)
+	assert.Equal(t, payload{
+		Message: "An application event",
+		Syslog: attributes{
+			Timestamp: "2003-10-11T22:14:15.003Z",
+			Hostname:  "mymachine.example.com",
+			AppName:   "evntslog",
+			ProcID:    "1234",
+			MsgID:     "ID47",
+			Facility:  20,
+			Severity:  5,
+			Version:   1,
+			StructuredData: map[string]map[string]string{
+				"exampleSDID@32473":     {"iut": "3", "eventSource": `App"lication]`},
+				"examplePriority@32473": {"class": "high"},
+			},
+		},
+	}, p)
+}
+
+func TestParseRFC5424NilValues(t *testing.T) {
+	msg, p := parseLine(t, `<11>1 - - - - - -`)
+
+	assert.Equal(t, message.StatusError, msg.Status)
+	assert.Equal(t, "", msg.ParsingExtra.Timestamp)
+	assert.Equal(t, []string{"syslog_facility:user"}, msg.ParsingExtra.Tags)
+	assert.Equal(t, payload{Syslog: attributes{Facility: 1, Severity: 3, Version: 1}}, p)
+}
+
+func TestParseRFC3164(t *testing.T) {
+	msg, p := parseLine(t, `<34>Oct 11 22:14:15 mymachine su[123]: 'su root' failed for lonvick on /dev/pts/8`)
+
+	assert.Equal(t, message.StatusCritical, msg.Status)
+	assert.Equal(t, payload{
+		Message: "'su root' failed for lonvick on /dev/pts/8",
+		Syslog: attributes{
+			Timestamp: "2022-10-11T22:14:15Z",
+			Hostname:  "mymachine",
+			AppName:   "su",
+			ProcID:    "123",
+			Facility:  4,
+			Severity:  2,
+		},
+	}, p)
+
+	_, p = parseLine(t, `<13>Mar  1 08:00:00 myhost kernel: eth0 link up`)
+	assert.Equal(t, "2023-03-01T08:00:00Z", p.Syslog.Timestamp)
+	assert.Equal(t, "myhost", p.Syslog.Hostname)
+	assert.Equal(t, "kernel", p.Syslog.AppName)
+	assert.Equal(t, "eth0 link up", p.Message)
+}
+
+func TestParseRFC3164Variants(t *testing.T) {
+	// without hostname
+	_, p := parseLine(t, `<13>Mar 10 08:00:00 sshd[42]: session opened`)
+	assert.Equal(t, "", p.Syslog.Hostname)
+	assert.Equal(t, "sshd", p.Syslog.AppName)
+	assert.Equal(t, "42", p.Syslog.ProcID)
+	assert.Equal(t, "session opened", p.Message)
+
+	// without tag
+	_, p = parseLine(t, `<13>Mar 10 08:00:00 router1 interface Gi0/1 changed state to up`)
+	assert.Equal(t, "router1", p.Syslog.Hostname)
+	assert.Equal(t, "", p.Syslog.AppName)
+	assert.Equal(t, "interface Gi0/1 changed state to up", p.Message)
+
+	// RFC3339 timestamp
+	_, p = parseLine(t, `<13>2023-03-10T08:00:00+01:00 router1 app: hello`)
+	assert.Equal(t, "2023-03-10T08:00:00+01:00", p.Syslog.Timestamp)
+	assert.Equal(t, "router1", p.Syslog.Hostname)
+	assert.Equal(t, "hello", p.Message)
+
+	// invalid timestamp, the whole content is the message
+	msg, p := parseLine(t, `<189>123: *Mar  1 00:00:00.123: %SYS-5-CONFIG_I: Configured`)
+	assert.Equal(t, message.StatusNotice, msg.Status)
+	assert.Equal(t, "", p.Syslog.Timestamp)
+	assert.Equal(t, "123: *Mar  1 00:00:00.123: %SYS-5-CONFIG_I: Configured", p.Message)
+}
+
+func TestParseRFC3164PreviousYear(t *testing.T) {
+	_, p := parseLine(t, `<13>Dec 31 23:59:59 myhost app: last year`)
+	assert.Equal(t, "2022-12-31T23:59:59Z", p.Syslog.Timestamp)
+}
+
+func TestParseInvalidMessages(t *testing.T) {
+	for _, line := range []string{
+		"",
+		"no priority",
+		"<>1 - - - - - -",
+		"<192>Oct 11 22:14:15 host app: invalid priority",
+		"<13>1 2003-10-11T22:14:15.003Z host",
+		"<13>1 not-a-date host app - - - message",
+		`<13>1 - host app - - [id name="value" message`,
+		`<13>1 - host app - - [id name=value] message`,
+		`<13>1 - host app - - [id]message`,
+	} {
+		msg, err := New().Parse(&message.Message{Content: []byte(line)})
+		assert.Error(t, err, line)
+		assert.Equal(t, line, string(msg.Content))
+		assert.Equal(t, message.StatusInfo, msg.Status)
+	}
+}
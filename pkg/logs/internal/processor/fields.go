@@ -0,0 +1,303 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package processor
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/comp/logs/agent/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// jsonObject is a decoded JSON object which keeps the order of its keys, so that
+// the attributes rules do not modify are encoded again as they were received.
+type jsonObject struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func newJSONObject() *jsonObject {
+	return &jsonObject{values: make(map[string]interface{})}
+}
+
+// get returns the value of key.
+func (o *jsonObject) get(key string) (interface{}, bool) {
+	value, ok := o.values[key]
+	return value, ok
+}
+
+// set sets the value of key, new keys are added after the existing ones.
+func (o *jsonObject) set(key string, value interface{}) {
+	if _, exists := o.values[key]; !exists {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = value
+}
+
+// remove deletes key, it returns false if the key does not exist.
+func (o *jsonObject) remove(key string) bool {
+	if _, exists := o.values[key]; !exists {
+		return false
+	}
+	delete(o.values, key)
+	for i, k := range o.keys {
+		if k == key {
+			o.keys = append(o.keys[:i], o.keys[i+1:]...)
+			break
+		}
+	}
+	return true
+}
+
+// MarshalJSON implements json.Marshaler.
+func (o *jsonObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, err := marshalJSON(key)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(k)
+		buf.WriteByte(':')
+		v, err := marshalJSON(o.values[key])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// marshalJSON encodes v without escaping the HTML characters, unlike json.Marshal,
+// so that the values rules do not modify are left untouched.
+func marshalJSON(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte{'\n'}), nil
+}
+
+// decodeJSONValue decodes the next value of decoder, objects are decoded as *jsonObject.
+func decodeJSONValue(decoder *json.Decoder) (interface{}, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch token {
+	case json.Delim('{'):
+		object := newJSONObject()
+		for decoder.More() {
+			key, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeJSONValue(decoder)
+			if err != nil {
+				return nil, err
+			}
+			object.set(key.(string), value)
+		}
+		// closing delimiter
+		_, err := decoder.Token()
+		return object, err
+	case json.Delim('['):
+		array := []interface{}{}
+		for decoder.More() {
+			value, err := decodeJSONValue(decoder)
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, value)
+		}
+		_, err := decoder.Token()
+		return array, err
+	default:
+		return token, nil
+	}
+}
+
+// jsonFields holds the decoded attributes of a JSON formatted log line
+// so that consecutive structured rules only decode and encode it once.
+type jsonFields struct {
+	raw    []byte
+	fields *jsonObject
+	dirty  bool
+}
+
+// newJSONFields decodes content, the fields are nil if content is not a JSON object.
+func newJSONFields(content []byte) *jsonFields {
+	f := &jsonFields{raw: content}
+	trimmed := bytes.TrimSpace(content)
+	if len(trimmed) == 0 || trimmed[0] != '{' {
+		return f
+	}
+	decoder := json.NewDecoder(bytes.NewReader(trimmed))
+	decoder.UseNumber()
+	value, err := decodeJSONValue(decoder)
+	if err != nil {
+		return f
+	}
+	// make sure there is nothing left after the object
+	if _, err := decoder.Token(); err != io.EOF {
+		return f
+	}
+	f.fields = value.(*jsonObject)
+	return f
+}
+
+// bytes returns the content of the log line, encoded again if any attribute was modified.
+func (f *jsonFields) bytes() []byte {
+	if !f.dirty {
+		return f.raw
+	}
+	content, err := marshalJSON(f.fields)
+	if err != nil {
+		log.Debugf("unable to encode the processed log line: %v", err)
+		return f.raw
+	}
+	return content
+}
+
+// get returns the value at path.
+func (f *jsonFields) get(path string) (interface{}, bool) {
+	if f.fields == nil {
+		return nil, false
+	}
+	keys := strings.Split(path, ".")
+	current := f.fields
+	for _, key := range keys[:len(keys)-1] {
+		child, _ := current.get(key)
+		object, ok := child.(*jsonObject)
+		if !ok {
+			return nil, false
+		}
+		current = object
+	}
+	return current.get(keys[len(keys)-1])
+}
+
+// set sets the value at path, creating intermediate objects when needed.
+// It returns false if an intermediate attribute exists and is not an object.
+func (f *jsonFields) set(path string, value interface{}) bool {
+	if f.fields == nil {
+		return false
+	}
+	keys := strings.Split(path, ".")
+	current := f.fields
+	for _, key := range keys[:len(keys)-1] {
+		child, exists := current.get(key)
+		if !exists {
+			child = newJSONObject()
+			current.set(key, child)
+		}
+		object, ok := child.(*jsonObject)
+		if !ok {
+			return false
+		}
+		current = object
+	}
+	current.set(keys[len(keys)-1], value)
+	f.dirty = true
+	return true
+}
+
+// remove deletes the value at path.
+func (f *jsonFields) remove(path string) {
+	if f.fields == nil {
+		return
+	}
+	keys := strings.Split(path, ".")
+	current := f.fields
+	for _, key := range keys[:len(keys)-1] {
+		child, _ := current.get(key)
+		object, ok := child.(*jsonObject)
+		if !ok {
+			return
+		}
+		current = object
+	}
+	if current.remove(keys[len(keys)-1]) {
+		f.dirty = true
+	}
+}
+
+// fieldValueToString returns the representation of a JSON value
+// structured rules match against.
+func fieldValueToString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	case nil:
+		return "null"
+	default:
+		b, err := marshalJSON(v)
+		if err != nil {
+			return ""
+		}
+		return string(b)
+	}
+}
+
+// applyFieldRule applies a structured rule on the fields of the message,
+// it returns false if the message must be dropped.
+func applyFieldRule(msg *message.Message, rule *config.ProcessingRule, fields *jsonFields) bool {
+	value, found := fields.get(rule.Field)
+	switch rule.Type {
+	case config.ExcludeAtFieldMatch:
+		if found && rule.Regex.MatchString(fieldValueToString(value)) {
+			return false
+		}
+	case config.IncludeAtFieldMatch:
+		if !found || !rule.Regex.MatchString(fieldValueToString(value)) {
+			return false
+		}
+	case config.MaskFieldSequences:
+		if !found {
+			break
+		}
+		switch value.(type) {
+		case string, json.Number:
+			original := fieldValueToString(value)
+			masked := string(rule.Regex.ReplaceAll([]byte(original), rule.Placeholder))
+			if masked != original {
+				fields.set(rule.Field, masked)
+			}
+		}
+	case config.RemapField:
+		if found && fields.set(rule.Target, value) {
+			fields.remove(rule.Field)
+		}
+	case config.ExtractField:
+		if !found {
+			break
+		}
+		switch rule.Target {
+		case config.ExtractTargetStatus:
+			msg.Status = fieldValueToString(value)
+		case config.ExtractTargetService:
+			// the extracted service overrides the one of the source configuration
+			msg.Origin.ForceService(fieldValueToString(value))
+		}
+	}
+	return true
+}
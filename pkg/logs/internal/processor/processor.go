@@ -6,8 +6,11 @@
 package processor
 
 import (
+	"bytes"
 	"context"
+	"strconv"
 	"sync"
+	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 
@@ -15,6 +18,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/logs/diagnostic"
 	"github.com/DataDog/datadog-agent/pkg/logs/message"
 	"github.com/DataDog/datadog-agent/pkg/logs/metrics"
+	"github.com/DataDog/datadog-agent/pkg/logs/metricsubmitter"
 )
 
 // A Processor updates messages from an inputChan and pushes
@@ -26,11 +30,12 @@ type Processor struct {
 	encoder                   Encoder
 	done                      chan struct{}
 	diagnosticMessageReceiver diagnostic.MessageReceiver
+	metricSubmitter           metricsubmitter.Submitter
 	mu                        sync.Mutex
 }
 
 // New returns an initialized Processor.
-func New(inputChan, outputChan chan *message.Message, processingRules []*config.ProcessingRule, encoder Encoder, diagnosticMessageReceiver diagnostic.MessageReceiver) *Processor {
+func New(inputChan, outputChan chan *message.Message, processingRules []*config.ProcessingRule, encoder Encoder, diagnosticMessageReceiver diagnostic.MessageReceiver, metricSubmitter metricsubmitter.Submitter) *Processor {
 	return &Processor{
 		inputChan:                 inputChan,
 		outputChan:                outputChan,
@@ -38,6 +43,7 @@ func New(inputChan, outputChan chan *message.Message, processingRules []*config.
 		encoder:                   encoder,
 		done:                      make(chan struct{}),
 		diagnosticMessageReceiver: diagnosticMessageReceiver,
+		metricSubmitter:           metricSubmitter,
 	}
 }
 
@@ -104,24 +110,140 @@ func (p *Processor) processMessage(msg *message.Message) {
 	}
 }
 
+// RuleMatch is a processing rule which dropped or modified a message.
+type RuleMatch struct {
+	Rule    *config.ProcessingRule
+	Dropped bool
+}
+
+// Check applies the processing rules to the message without encoding nor forwarding it,
+// it returns whether the message would be sent, its redacted content and the rules which matched it.
+func (p *Processor) Check(msg *message.Message) (bool, []byte, []RuleMatch) {
+	var matches []RuleMatch
+	shouldProcess, content := p.applyRules(msg, func(rule *config.ProcessingRule, dropped bool) {
+		matches = append(matches, RuleMatch{Rule: rule, Dropped: dropped})
+	})
+	return shouldProcess, content, matches
+}
+
 // applyRedactingRules returns given a message if we should process it or not,
 // and a copy of the message with some fields redacted, depending on config
 func (p *Processor) applyRedactingRules(msg *message.Message) (bool, []byte) {
+	return p.applyRules(msg, nil)
+}
+
+// applyRules implements applyRedactingRules, onMatch is called, when not nil,
+// for every rule which drops or modifies the message.
+func (p *Processor) applyRules(msg *message.Message, onMatch func(rule *config.ProcessingRule, dropped bool)) (bool, []byte) {
+	report := func(rule *config.ProcessingRule, dropped bool) {
+		if onMatch != nil {
+			onMatch(rule, dropped)
+		}
+	}
 	content := msg.Content
+	// fields is only decoded when a structured rule is met, and encoded
+	// back into content before the next regex based rule
+	var fields *jsonFields
 	rules := append(p.processingRules, msg.Origin.LogSource.Config.ProcessingRules...)
 	for _, rule := range rules {
+		if rule.IsFieldRule() {
+			if fields == nil {
+				fields = newJSONFields(content)
+			}
+			if onMatch == nil {
+				if !applyFieldRule(msg, rule, fields) {
+					return false, nil
+				}
+				continue
+			}
+			// the output is only compared when checking the rules, to not slow down the pipelines
+			before, status, service := string(fields.bytes()), msg.Status, msg.Origin.Service()
+			if !applyFieldRule(msg, rule, fields) {
+				report(rule, true)
+				return false, nil
+			}
+			if string(fields.bytes()) != before || msg.Status != status || msg.Origin.Service() != service {
+				report(rule, false)
+			}
+			continue
+		}
+		if fields != nil {
+			content = fields.bytes()
+			fields = nil
+		}
 		switch rule.Type {
 		case config.ExcludeAtMatch:
 			if rule.Regex.Match(content) {
+				report(rule, true)
 				return false, nil
 			}
 		case config.IncludeAtMatch:
 			if !rule.Regex.Match(content) {
+				report(rule, true)
 				return false, nil
 			}
 		case config.MaskSequences:
-			content = rule.Regex.ReplaceAll(content, rule.Placeholder)
+			masked := rule.Regex.ReplaceAll(content, rule.Placeholder)
+			if onMatch != nil && !bytes.Equal(masked, content) {
+				report(rule, false)
+			}
+			content = masked
+		case config.Sample, config.RateLimit:
+			match := rule.Regex.FindSubmatchIndex(content)
+			if match == nil {
+				break
+			}
+			key := rule.SamplerKey(content, match, msg.Origin.Tags())
+			if !rule.Sampler.Keep(key, time.Now()) {
+				metrics.LogsSampledOut.Add(rule.Name, 1)
+				metrics.TlmLogsSampledOut.Inc(rule.Name)
+				report(rule, true)
+				return false, nil
+			}
+		case config.ExtractMetric:
+			if match := rule.Regex.FindSubmatchIndex(content); match != nil {
+				p.submitExtractedMetric(msg, rule, content, match)
+				report(rule, false)
+			}
 		}
 	}
+	if fields != nil {
+		content = fields.bytes()
+	}
 	return true, content
 }
+
+// submitExtractedMetric submits the metric of an extract_metric rule for a matching line,
+// tagged with the tags of the log source.
+func (p *Processor) submitExtractedMetric(msg *message.Message, rule *config.ProcessingRule, content []byte, match []int) {
+	value := 1.0
+	if rule.MetricValue != "" {
+		rawValue := rule.Regex.Expand(nil, []byte(rule.MetricValue), content, match)
+		var err error
+		value, err = strconv.ParseFloat(string(rawValue), 64)
+		if err != nil {
+			log.Debugf("Unable to extract a value for metric %s from %q: %v", rule.MetricName, rawValue, err)
+			return
+		}
+	}
+
+	tags := append([]string{}, msg.Origin.Tags()...)
+	if service := msg.Origin.Service(); service != "" {
+		tags = append(tags, "service:"+service)
+	}
+	if source := msg.Origin.Source(); source != "" {
+		tags = append(tags, "source:"+source)
+	}
+	for _, tag := range rule.MetricTags {
+		if expanded := rule.Regex.Expand(nil, []byte(tag), content, match); len(expanded) > 0 {
+			tags = append(tags, string(expanded))
+		}
+	}
+
+	switch rule.MetricType {
+	case config.MetricTypeCount:
+		p.metricSubmitter.Count(rule.MetricName, value, tags)
+	case config.MetricTypeDistribution:
+		p.metricSubmitter.Distribution(rule.MetricName, value, tags)
+	}
+}
@@ -140,6 +140,222 @@ func TestTruncate(t *testing.T) {
 	assert.Equal(t, []byte("hello"), redactedMessage)
 }
 
+func TestFieldExclusion(t *testing.T) {
+	p := &Processor{processingRules: []*config.ProcessingRule{newFieldProcessingRule("exclude_at_field_match", "level", "", "", "^debug$")}}
+	source := sources.LogSource{Config: &config.LogsConfig{}}
+
+	shouldProcess, redactedMessage := p.applyRedactingRules(newMessage([]byte(`{"level":"info","message":"debug"}`), &source, ""))
+	assert.Equal(t, true, shouldProcess)
+	assert.Equal(t, []byte(`{"level":"info","message":"debug"}`), redactedMessage)
+
+	shouldProcess, _ = p.applyRedactingRules(newMessage([]byte(`{"level":"debug","message":"hello"}`), &source, ""))
+	assert.Equal(t, false, shouldProcess)
+
+	// missing field and non JSON lines are not excluded
+	shouldProcess, _ = p.applyRedactingRules(newMessage([]byte(`{"message":"debug"}`), &source, ""))
+	assert.Equal(t, true, shouldProcess)
+	shouldProcess, redactedMessage = p.applyRedactingRules(newMessage([]byte(`level=debug`), &source, ""))
+	assert.Equal(t, true, shouldProcess)
+	assert.Equal(t, []byte(`level=debug`), redactedMessage)
+
+	p = &Processor{processingRules: []*config.ProcessingRule{newFieldProcessingRule("exclude_at_field_match", "http.status", "", "", "^2\\d\\d$")}}
+	shouldProcess, _ = p.applyRedactingRules(newMessage([]byte(`{"http":{"status":200}}`), &source, ""))
+	assert.Equal(t, false, shouldProcess)
+	shouldProcess, _ = p.applyRedactingRules(newMessage([]byte(`{"http":{"status":500}}`), &source, ""))
+	assert.Equal(t, true, shouldProcess)
+}
+
+func TestFieldInclusion(t *testing.T) {
+	p := &Processor{}
+	source := newFieldSource("include_at_field_match", "level", "", "", "^(error|warn)$")
+
+	shouldProcess, redactedMessage := p.applyRedactingRules(newMessage([]byte(`{"level":"error"}`), &source, ""))
+	assert.Equal(t, true, shouldProcess)
+	assert.Equal(t, []byte(`{"level":"error"}`), redactedMessage)
+
+	shouldProcess, redactedMessage = p.applyRedactingRules(newMessage([]byte(`{"level":"info","message":"error"}`), &source, ""))
+	assert.Equal(t, false, shouldProcess)
+	assert.Nil(t, redactedMessage)
+
+	shouldProcess, _ = p.applyRedactingRules(newMessage([]byte(`{"message":"error"}`), &source, ""))
+	assert.Equal(t, false, shouldProcess)
+
+	shouldProcess, _ = p.applyRedactingRules(newMessage([]byte(`error`), &source, ""))
+	assert.Equal(t, false, shouldProcess)
+}
+
+func TestFieldMask(t *testing.T) {
+	p := &Processor{}
+	source := newFieldSource("mask_field_sequences", "user.email", "", "[masked_email]", ".+")
+
+	shouldProcess, redactedMessage := p.applyRedactingRules(newMessage([]byte(`{"user":{"email":"bob@datadoghq.com","name":"bob"},"message":"bob@datadoghq.com logged in"}`), &source, ""))
+	assert.Equal(t, true, shouldProcess)
+	assert.Equal(t, []byte(`{"user":{"email":"[masked_email]","name":"bob"},"message":"bob@datadoghq.com logged in"}`), redactedMessage)
+
+	// the line is left untouched when there is nothing to mask
+	shouldProcess, redactedMessage = p.applyRedactingRules(newMessage([]byte(`{"user": {"name": "bob"}}`), &source, ""))
+	assert.Equal(t, true, shouldProcess)
+	assert.Equal(t, []byte(`{"user": {"name": "bob"}}`), redactedMessage)
+
+	source = newFieldSource("mask_field_sequences", "card", "", "${1}[masked]", "^(\\d{4})\\d+")
+	_, redactedMessage = p.applyRedactingRules(newMessage([]byte(`{"card":4323124312341234}`), &source, ""))
+	assert.Equal(t, []byte(`{"card":"4323[masked]"}`), redactedMessage)
+}
+
+func TestFieldRulesKeepUnmodifiedFields(t *testing.T) {
+	p := &Processor{}
+	source := newFieldSource("mask_field_sequences", "token", "", "[masked]", ".+")
+
+	// the other fields keep their order and their characters are not escaped
+	_, redactedMessage := p.applyRedactingRules(newMessage([]byte(`{"zone":"eu","token":"abc","query":"a<b && c>d","tags":[{"z":1,"a":2}]}`), &source, ""))
+	assert.Equal(t, []byte(`{"zone":"eu","token":"[masked]","query":"a<b && c>d","tags":[{"z":1,"a":2}]}`), redactedMessage)
+}
+
+func TestFieldRemap(t *testing.T) {
+	p := &Processor{}
+	source := newFieldSource("remap_field", "attrs.msg", "message", "", "")
+
+	shouldProcess, redactedMessage := p.applyRedactingRules(newMessage([]byte(`{"attrs":{"msg":"hello","id":12345678901234567890}}`), &source, ""))
+	assert.Equal(t, true, shouldProcess)
+	assert.Equal(t, []byte(`{"attrs":{"id":12345678901234567890},"message":"hello"}`), redactedMessage)
+
+	source = newFieldSource("remap_field", "msg", "log.message", "", "")
+	_, redactedMessage = p.applyRedactingRules(newMessage([]byte(`{"msg":"hello","log":"not an object"}`), &source, ""))
+	assert.Equal(t, []byte(`{"msg":"hello","log":"not an object"}`), redactedMessage)
+}
+
+func TestFieldExtract(t *testing.T) {
+	p := &Processor{}
+	source := sources.LogSource{Config: &config.LogsConfig{ProcessingRules: []*config.ProcessingRule{
+		newFieldProcessingRule("extract_field", "level", "status", "", ""),
+		newFieldProcessingRule("extract_field", "app", "service", "", ""),
+	}}}
+
+	msg := newMessage([]byte(`{"level":"error","app":"billing"}`), &source, "")
+	shouldProcess, redactedMessage := p.applyRedactingRules(msg)
+	assert.Equal(t, true, shouldProcess)
+	assert.Equal(t, []byte(`{"level":"error","app":"billing"}`), redactedMessage)
+	assert.Equal(t, "error", msg.GetStatus())
+	assert.Equal(t, "billing", msg.Origin.Service())
+
+	msg = newMessage([]byte(`{"message":"hello"}`), &source, "")
+	p.applyRedactingRules(msg)
+	assert.Equal(t, "info", msg.GetStatus())
+	assert.Equal(t, "", msg.Origin.Service())
+
+	// the extracted service overrides the service of the source
+	source.Config.Service = "configured"
+	msg = newMessage([]byte(`{"app":"billing"}`), &source, "")
+	p.applyRedactingRules(msg)
+	assert.Equal(t, "billing", msg.Origin.Service())
+
+	msg = newMessage([]byte(`{"message":"hello"}`), &source, "")
+	p.applyRedactingRules(msg)
+	assert.Equal(t, "configured", msg.Origin.Service())
+}
+
+func TestFieldAndRegexRules(t *testing.T) {
+	p := &Processor{processingRules: []*config.ProcessingRule{
+		newFieldProcessingRule("remap_field", "secret", "password", "", ""),
+		newProcessingRule("mask_sequences", "[masked]", "hunter2"),
+		newFieldProcessingRule("exclude_at_field_match", "user", "", "", "^bob$"),
+	}}
+	source := sources.LogSource{Config: &config.LogsConfig{}}
+
+	shouldProcess, redactedMessage := p.applyRedactingRules(newMessage([]byte(`{"secret":"hunter2","user":"alice"}`), &source, ""))
+	assert.Equal(t, true, shouldProcess)
+	assert.Equal(t, []byte(`{"user":"alice","password":"[masked]"}`), redactedMessage)
+
+	shouldProcess, _ = p.applyRedactingRules(newMessage([]byte(`{"secret":"hunter2","user":"bob"}`), &source, ""))
+	assert.Equal(t, false, shouldProcess)
+}
+
+func TestSampling(t *testing.T) {
+	rule := newProcessingRule("sample", "", "GET /health")
+	rule.Sampler = config.NewSampleLineSampler(50)
+	p := &Processor{processingRules: []*config.ProcessingRule{rule}}
+	source := sources.LogSource{Config: &config.LogsConfig{}}
+
+	kept := 0
+	for i := 0; i < 10; i++ {
+		if shouldProcess, _ := p.applyRedactingRules(newMessage([]byte("GET /health 200"), &source, "")); shouldProcess {
+			kept++
+		}
+	}
+	assert.Equal(t, 5, kept)
+
+	// lines not matching the pattern are never sampled
+	for i := 0; i < 10; i++ {
+		shouldProcess, _ := p.applyRedactingRules(newMessage([]byte("GET /users 200"), &source, ""))
+		assert.Equal(t, true, shouldProcess)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	rule := newProcessingRule("rate_limit", "", "user=(\\w+)")
+	rule.Key = "${1}"
+	rule.Sampler = config.NewRateLimitLineSampler(1)
+	p := &Processor{}
+	source := sources.LogSource{Config: &config.LogsConfig{ProcessingRules: []*config.ProcessingRule{rule}}}
+
+	shouldProcess, _ := p.applyRedactingRules(newMessage([]byte("login user=bob"), &source, ""))
+	assert.Equal(t, true, shouldProcess)
+	shouldProcess, _ = p.applyRedactingRules(newMessage([]byte("login user=alice"), &source, ""))
+	assert.Equal(t, true, shouldProcess)
+	shouldProcess, _ = p.applyRedactingRules(newMessage([]byte("logout user=bob"), &source, ""))
+	assert.Equal(t, false, shouldProcess)
+}
+
+type extractedMetric struct {
+	metricType string
+	name       string
+	value      float64
+	tags       []string
+}
+
+type fakeMetricSubmitter struct {
+	metrics []extractedMetric
+}
+
+func (f *fakeMetricSubmitter) Count(metric string, value float64, tags []string) {
+	f.metrics = append(f.metrics, extractedMetric{"count", metric, value, tags})
+}
+
+func (f *fakeMetricSubmitter) Distribution(metric string, value float64, tags []string) {
+	f.metrics = append(f.metrics, extractedMetric{"distribution", metric, value, tags})
+}
+
+func TestExtractMetric(t *testing.T) {
+	countRule := newProcessingRule("extract_metric", "", "\\s(\\d{3})\\s")
+	countRule.MetricName = "nginx.requests"
+	countRule.MetricType = "count"
+	countRule.MetricTags = []string{"status_code:${1}"}
+	latencyRule := newProcessingRule("extract_metric", "", "took (?P<latency>[\\d.]+)s")
+	latencyRule.MetricName = "nginx.latency"
+	latencyRule.MetricType = "distribution"
+	latencyRule.MetricValue = "${latency}"
+
+	submitter := &fakeMetricSubmitter{}
+	p := &Processor{
+		processingRules: []*config.ProcessingRule{countRule, latencyRule, newProcessingRule("exclude_at_match", "", "GET")},
+		metricSubmitter: submitter,
+	}
+	source := sources.LogSource{Config: &config.LogsConfig{Service: "nginx", Tags: []string{"env:prod"}}}
+
+	// metrics are extracted before the line is excluded
+	shouldProcess, _ := p.applyRedactingRules(newMessage([]byte("GET /index.html 200 took 0.25s"), &source, ""))
+	assert.Equal(t, false, shouldProcess)
+	shouldProcess, _ = p.applyRedactingRules(newMessage([]byte("POST /login 503 took abcs"), &source, ""))
+	assert.Equal(t, true, shouldProcess)
+	p.applyRedactingRules(newMessage([]byte("starting"), &source, ""))
+
+	assert.Equal(t, []extractedMetric{
+		{"count", "nginx.requests", 1, []string{"env:prod", "service:nginx", "status_code:200"}},
+		{"distribution", "nginx.latency", 0.25, []string{"env:prod", "service:nginx"}},
+		{"count", "nginx.requests", 1, []string{"env:prod", "service:nginx", "status_code:503"}},
+	}, submitter.metrics)
+}
+
 func newProcessingRule(ruleType, replacePlaceholder, pattern string) *config.ProcessingRule {
 	return &config.ProcessingRule{
 		Type:               ruleType,
@@ -151,6 +367,56 @@ func newProcessingRule(ruleType, replacePlaceholder, pattern string) *config.Pro
 	}
 }
 
+func TestCheckReportsMatchedRules(t *testing.T) {
+	maskRule := newProcessingRule("mask_sequences", "[masked]", "password=\\w+")
+	maskRule.Name = "mask_password"
+	excludeRule := newProcessingRule("exclude_at_match", "", "healthcheck")
+	excludeRule.Name = "exclude_healthchecks"
+	statusRule := newFieldProcessingRule("extract_field", "level", "status", "", "")
+	statusRule.Name = "extract_level"
+	p := &Processor{processingRules: []*config.ProcessingRule{maskRule, excludeRule}}
+	source := sources.LogSource{Config: &config.LogsConfig{ProcessingRules: []*config.ProcessingRule{statusRule}}}
+
+	shouldProcess, content, matches := p.Check(newMessage([]byte("login password=secret"), &source, ""))
+	assert.True(t, shouldProcess)
+	assert.Equal(t, "login [masked]", string(content))
+	assert.Equal(t, []RuleMatch{{Rule: maskRule}}, matches)
+
+	shouldProcess, _, matches = p.Check(newMessage([]byte("GET /healthcheck password=secret"), &source, ""))
+	assert.False(t, shouldProcess)
+	assert.Equal(t, []RuleMatch{{Rule: maskRule}, {Rule: excludeRule, Dropped: true}}, matches)
+
+	msg := newMessage([]byte(`{"level":"warn","message":"disk full"}`), &source, "")
+	shouldProcess, _, matches = p.Check(msg)
+	assert.True(t, shouldProcess)
+	assert.Equal(t, message.StatusWarning, msg.GetStatus())
+	assert.Equal(t, []RuleMatch{{Rule: statusRule}}, matches)
+
+	// rules without effect are not reported
+	_, _, matches = p.Check(newMessage([]byte("nothing to see"), &source, ""))
+	assert.Empty(t, matches)
+}
+
+func newFieldProcessingRule(ruleType, field, target, replacePlaceholder, pattern string) *config.ProcessingRule {
+	rule := &config.ProcessingRule{
+		Type:               ruleType,
+		Name:               "test",
+		Field:              field,
+		Target:             target,
+		ReplacePlaceholder: replacePlaceholder,
+		Placeholder:        []byte(replacePlaceholder),
+		Pattern:            pattern,
+	}
+	if pattern != "" {
+		rule.Regex = regexp.MustCompile(pattern)
+	}
+	return rule
+}
+
+func newFieldSource(ruleType, field, target, replacePlaceholder, pattern string) sources.LogSource {
+	return sources.LogSource{Config: &config.LogsConfig{ProcessingRules: []*config.ProcessingRule{newFieldProcessingRule(ruleType, field, target, replacePlaceholder, pattern)}}}
+}
+
 func newSource(ruleType, replacePlaceholder, pattern string) sources.LogSource {
 	return sources.LogSource{Config: &config.LogsConfig{ProcessingRules: []*config.ProcessingRule{newProcessingRule(ruleType, replacePlaceholder, pattern)}}}
 }
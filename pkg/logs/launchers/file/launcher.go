@@ -6,6 +6,7 @@
 package file
 
 import (
+	"os"
 	"regexp"
 	"time"
 
@@ -47,6 +48,18 @@ type Launcher struct {
 	// Feature flag defaulting to false, use `logs_config.validate_pod_container_id`.
 	validatePodContainerID bool
 	scanPeriod             time.Duration
+	// archiveStats are the size and modification time of the compressed files at the previous scan,
+	// an archive is only read once they stopped changing.
+	archiveStats map[string]archiveStat
+	// readArchives are the size and modification time of the compressed files read until their end,
+	// by scan key. Their tailers are stopped and they are not read again unless they change.
+	readArchives map[string]archiveStat
+}
+
+// archiveStat is the size and modification time of a compressed file.
+type archiveStat struct {
+	size    int64
+	modTime time.Time
 }
 
 // NewLauncher returns a new launcher.
@@ -71,6 +84,8 @@ func NewLauncher(tailingLimit int, tailerSleepDuration time.Duration, validatePo
 		stop:                   make(chan struct{}),
 		validatePodContainerID: validatePodContainerID,
 		scanPeriod:             scanPeriod,
+		archiveStats:           make(map[string]archiveStat),
+		readArchives:           make(map[string]archiveStat),
 	}
 }
 
@@ -146,6 +161,10 @@ func (s *Launcher) scan() {
 		scanKey := file.GetScanKey()
 		tailer, isTailed := s.tailers.Get(scanKey)
 		if isTailed && tailer.IsFinished() {
+			if file.IsCompressed() {
+				// the archive was read, its tailer no longer counts towards the tailing limit
+				s.setArchiveRead(file)
+			}
 			// skip this tailer as it must be stopped
 			continue
 		}
@@ -157,6 +176,11 @@ func (s *Launcher) scan() {
 				continue
 			}
 			if didRotate {
+				if file.IsCompressed() && !s.isArchiveStable(file) {
+					// keep the previous tailer until the new archive is completely written
+					filesTailed[scanKey] = true
+					continue
+				}
 				// restart tailer because of file-rotation on file
 				succeeded := s.restartTailerAfterFileRotation(tailer, file)
 				if !succeeded {
@@ -201,6 +225,26 @@ func (s *Launcher) scan() {
 	}
 	log.Debugf("After starting new tailers, there are %d tailers running. Limit is %d.\n", tailersLen, s.tailingLimit)
 
+	// forget the compressed files which are gone
+	if len(s.archiveStats) > 0 || len(s.readArchives) > 0 {
+		paths := make(map[string]bool, len(files))
+		scanKeys := make(map[string]bool, len(files))
+		for _, file := range files {
+			paths[file.Path] = true
+			scanKeys[file.GetScanKey()] = true
+		}
+		for path := range s.archiveStats {
+			if !paths[path] {
+				delete(s.archiveStats, path)
+			}
+		}
+		for scanKey := range s.readArchives {
+			if !scanKeys[scanKey] {
+				delete(s.readArchives, scanKey)
+			}
+		}
+	}
+
 	// Check how many file handles the Agent process has open and log a warning if the process is coming close to the OS file limit
 	fileStats, err := util.GetProcessFileStats()
 	if err == nil {
@@ -269,6 +313,16 @@ func (s *Launcher) startNewTailer(file *tailer.File, m config.TailingMode) bool
 		return false
 	}
 
+	if file.IsCompressed() {
+		if s.wasArchiveRead(file) {
+			return false
+		}
+		if !s.isArchiveStable(file) {
+			log.Debugf("Waiting for %s to be completely written before reading it", file.Path)
+			return false
+		}
+	}
+
 	tailer := s.createTailer(file, s.pipelineProvider.NextPipelineChan())
 
 	var offset int64
@@ -291,6 +345,42 @@ func (s *Launcher) startNewTailer(file *tailer.File, m config.TailingMode) bool
 	return true
 }
 
+// isArchiveStable returns true if the size and modification time of the compressed file did not change
+// since the previous call. An archive which is still being written changes identifier as it grows,
+// reading it before it is complete would send its content again once it is.
+func (s *Launcher) isArchiveStable(file *tailer.File) bool {
+	info, err := os.Stat(file.Path)
+	if err != nil {
+		delete(s.archiveStats, file.Path)
+		return false
+	}
+	current := archiveStat{size: info.Size(), modTime: info.ModTime()}
+	previous, seen := s.archiveStats[file.Path]
+	s.archiveStats[file.Path] = current
+	return seen && previous.size == current.size && previous.modTime.Equal(current.modTime)
+}
+
+// setArchiveRead records that the compressed file was read until its end.
+func (s *Launcher) setArchiveRead(file *tailer.File) {
+	if info, err := os.Stat(file.Path); err == nil {
+		s.readArchives[file.GetScanKey()] = archiveStat{size: info.Size(), modTime: info.ModTime()}
+	}
+}
+
+// wasArchiveRead returns true if the compressed file was read until its end and did not change since.
+func (s *Launcher) wasArchiveRead(file *tailer.File) bool {
+	read, ok := s.readArchives[file.GetScanKey()]
+	if !ok {
+		return false
+	}
+	info, err := os.Stat(file.Path)
+	if err == nil && read.size == info.Size() && read.modTime.Equal(info.ModTime()) {
+		return true
+	}
+	delete(s.readArchives, file.GetScanKey())
+	return false
+}
+
 // handleTailingModeChange determines the tailing behaviour when the tailing mode for a given file has its
 // configuration change. Two case may happen we can switch from "end" to "beginning" (1) and from "beginning" to
 // "end" (2). If the tailing mode is set to forceEnd or forceBeginning it will remain unchanged.
@@ -325,8 +415,18 @@ func (s *Launcher) restartTailerAfterFileRotation(tailer *tailer.Tailer, file *t
 	log.Info("Log rotation happened to ", file.Path)
 	tailer.StopAfterFileRotation()
 	tailer = s.createRotatedTailer(tailer, file, tailer.GetDetectedPattern())
-	// force reading file from beginning since it has been log-rotated
-	err := tailer.StartFromBeginning()
+	var err error
+	if file.IsCompressed() {
+		// the new archive may have been read under another name
+		offset, whence, positionErr := Position(s.registry, tailer.Identifier(), config.Beginning)
+		if positionErr != nil {
+			log.Warnf("Could not recover offset for file with path %v: %v", file.Path, positionErr)
+		}
+		err = tailer.Start(offset, whence)
+	} else {
+		// force reading file from beginning since it has been log-rotated
+		err = tailer.StartFromBeginning()
+	}
 	if err != nil {
 		log.Warn(err)
 		return false
@@ -8,8 +8,11 @@
 package file
 
 import (
+	"compress/gzip"
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -18,6 +21,7 @@ import (
 
 	"github.com/DataDog/datadog-agent/comp/logs/agent/config"
 	pkgConfig "github.com/DataDog/datadog-agent/pkg/config"
+	logsauditor "github.com/DataDog/datadog-agent/pkg/logs/auditor"
 	auditor "github.com/DataDog/datadog-agent/pkg/logs/auditor/mock"
 	"github.com/DataDog/datadog-agent/pkg/logs/internal/util"
 	"github.com/DataDog/datadog-agent/pkg/logs/launchers"
@@ -30,6 +34,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/logs/tailers"
 	filetailer "github.com/DataDog/datadog-agent/pkg/logs/tailers/file"
 	tailer "github.com/DataDog/datadog-agent/pkg/logs/tailers/file"
+	"github.com/DataDog/datadog-agent/pkg/status/health"
 )
 
 type LauncherTestSuite struct {
@@ -665,3 +670,83 @@ func TestLauncherFileDetectionSingleScan(t *testing.T) {
 func getScanKey(path string, source *sources.LogSource) string {
 	return filetailer.NewFile(path, source, false).GetScanKey()
 }
+
+func TestLauncherReadsCompressedFileOnce(t *testing.T) {
+	testDir := t.TempDir()
+	runPath := t.TempDir()
+	path := fmt.Sprintf("%s/app.log.1.gz", testDir)
+	writeGzip := func(content string) {
+		f, err := os.Create(path)
+		assert.Nil(t, err)
+		w := gzip.NewWriter(f)
+		_, err = w.Write([]byte(content))
+		assert.Nil(t, err)
+		assert.Nil(t, w.Close())
+		assert.Nil(t, f.Close())
+	}
+
+	source := sources.NewLogSource("", &config.LogsConfig{Type: config.FileType, Path: fmt.Sprintf("%s/*.gz", testDir), TailingMode: "beginning", IngestCompressedFiles: true})
+	status.Clear()
+	status.InitStatus(pkgConfig.Datadog, util.CreateSources([]*sources.LogSource{source}))
+	defer status.Clear()
+
+	// start returns a launcher using a registry whose entries are all expired on start
+	start := func() (*Launcher, *logsauditor.RegistryAuditor, chan *message.Message) {
+		registry := logsauditor.New(runPath, logsauditor.DefaultRegistryFilename, time.Nanosecond, health.RegisterLiveness("fake"))
+		registry.Start()
+		launcher := NewLauncher(2, 20*time.Millisecond, false, 10*time.Second, "by_name")
+		launcher.pipelineProvider = mock.NewMockProvider()
+		launcher.registry = registry
+		return launcher, registry, launcher.pipelineProvider.NextPipelineChan()
+	}
+	launcher, registry, outputChan := start()
+
+	// the archive is not read while it is being written
+	writeGzip("first\n")
+	launcher.addSource(source)
+	assert.Equal(t, 0, launcher.tailers.Count())
+	writeGzip("first\nsecond\n")
+	launcher.scan()
+	assert.Equal(t, 0, launcher.tailers.Count())
+
+	// the archive did not change since the previous scan
+	launcher.scan()
+	assert.Equal(t, 1, launcher.tailers.Count())
+	first, second := <-outputChan, <-outputChan
+	assert.Equal(t, "first", string(first.Content))
+	assert.Equal(t, "second", string(second.Content))
+	identifier := second.Origin.Identifier
+	assert.True(t, strings.HasPrefix(identifier, logsauditor.ArchiveIdentifierPrefix))
+	registry.Channel() <- &message.Payload{Messages: []*message.Message{first, second}}
+	launcher.cleanup()
+	registry.Stop()
+
+	// the progress of the archive is not removed with the expired entries
+	launcher, registry, outputChan = start()
+	defer registry.Stop()
+	assert.Equal(t, strconv.Itoa(len("first\nsecond\n")), registry.GetOffset(identifier))
+	launcher.addSource(source)
+	launcher.scan()
+	assert.Equal(t, 1, launcher.tailers.Count())
+	select {
+	case msg := <-outputChan:
+		assert.Fail(t, "the archive was read again", string(msg.Content))
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// the tailer is stopped once the archive is read, and is not started again
+	assert.Eventually(t, func() bool {
+		launcher.scan()
+		return launcher.tailers.Count() == 0
+	}, time.Second, 10*time.Millisecond)
+	launcher.scan()
+	assert.Equal(t, 0, launcher.tailers.Count())
+
+	// unless the archive changes
+	writeGzip("third\n")
+	launcher.scan()
+	launcher.scan()
+	assert.Equal(t, 1, launcher.tailers.Count())
+	assert.Equal(t, "third", string((<-outputChan).Content))
+	launcher.cleanup()
+}
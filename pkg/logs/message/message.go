@@ -45,6 +45,8 @@ type ParsingExtra struct {
 	// Used by docker parsers to transmit an offset.
 	Timestamp string
 	IsPartial bool
+	// Used by the syslog parser to transmit the tags extracted from the header.
+	Tags []string
 }
 
 // ServerlessExtra ships extra information from logs processing in serverless envs.
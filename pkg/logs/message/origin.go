@@ -19,6 +19,8 @@ type Origin struct {
 	service    string
 	source     string
 	tags       []string
+	// forceService is true when service takes precedence over the service of the configuration
+	forceService bool
 }
 
 // NewOrigin returns a new Origin
@@ -109,10 +111,16 @@ func (o *Origin) SetService(service string) {
 	o.service = service
 }
 
+// ForceService sets the service of the origin, overriding the service of the configuration.
+func (o *Origin) ForceService(service string) {
+	o.service = service
+	o.forceService = true
+}
+
 // Service returns the service of the configuration if set or the service of the message,
 // if none are defined, returns an empty string by default.
 func (o *Origin) Service() string {
-	if o.LogSource.Config.Service != "" {
+	if o.LogSource.Config.Service != "" && !o.forceService {
 		return o.LogSource.Config.Service
 	}
 	return o.service
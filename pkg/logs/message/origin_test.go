@@ -107,3 +107,12 @@ func TestDefaultServiceValueIsServiceFromConfig(t *testing.T) {
 	origin.SetService("bar")
 	assert.Equal(t, "bar", origin.Service())
 }
+
+func TestForcedServiceOverridesServiceFromConfig(t *testing.T) {
+	cfg := &config.LogsConfig{Service: "foo"}
+	source := sources.NewLogSource("", cfg)
+	origin := NewOrigin(source)
+
+	origin.ForceService("bar")
+	assert.Equal(t, "bar", origin.Service())
+}
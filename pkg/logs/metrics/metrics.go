@@ -40,6 +40,11 @@ var (
 	// TlmLogsDropped is the total number of logs dropped per Destination
 	TlmLogsDropped = telemetry.NewCounter("logs", "dropped",
 		[]string{"destination"}, "Total number of logs dropped per Destination")
+	// LogsSampledOut is the total number of logs dropped by sample and rate_limit processing rules per rule
+	LogsSampledOut = expvar.Map{}
+	// TlmLogsSampledOut is the total number of logs dropped by sample and rate_limit processing rules per rule
+	TlmLogsSampledOut = telemetry.NewCounter("logs", "sampled_out",
+		[]string{"rule"}, "Total number of logs dropped by sample and rate_limit processing rules per rule")
 	// BytesSent is the total number of sent bytes before encoding if any
 	BytesSent = expvar.Int{}
 	// TlmBytesSent is the total number of sent bytes before encoding if any
@@ -70,6 +75,7 @@ func init() {
 	LogsExpvars.Set("LogsSent", &LogsSent)
 	LogsExpvars.Set("DestinationErrors", &DestinationErrors)
 	LogsExpvars.Set("DestinationLogsDropped", &DestinationLogsDropped)
+	LogsExpvars.Set("LogsSampledOut", &LogsSampledOut)
 	LogsExpvars.Set("BytesSent", &BytesSent)
 	LogsExpvars.Set("EncodedBytesSent", &EncodedBytesSent)
 	LogsExpvars.Set("SenderLatency", &SenderLatency)
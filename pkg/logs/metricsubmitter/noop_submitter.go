@@ -0,0 +1,25 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package metricsubmitter
+
+// NoopSubmitter for cases where extracting metrics from logs is unsupported or not needed (serverless, tests)
+type NoopSubmitter struct{}
+
+// Count does nothing with the metric
+func (n *NoopSubmitter) Count(metric string, value float64, tags []string) {
+}
+
+// Distribution does nothing with the metric
+func (n *NoopSubmitter) Distribution(metric string, value float64, tags []string) {
+}
+
+// Start does nothing
+func (n *NoopSubmitter) Start() {
+}
+
+// Stop does nothing
+func (n *NoopSubmitter) Stop() {
+}
@@ -0,0 +1,121 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package metricsubmitter
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator/sender"
+	checkid "github.com/DataDog/datadog-agent/pkg/collector/check/id"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// senderID identifies the sender used by the logs agent in the aggregator,
+// its metrics are committed independently of the checks and of the default sender.
+const senderID checkid.ID = "logs-agent-extracted-metrics"
+
+// SenderSubmitter submits the extracted metrics to the aggregator through a sender,
+// the same way checks do, and commits them periodically.
+type SenderSubmitter struct {
+	senderManager  sender.SenderManager
+	commitInterval time.Duration
+
+	// sender is read without locking on the hot path, mu only guards its
+	// creation and release.
+	sender  atomic.Pointer[sender.Sender]
+	mu      sync.Mutex
+	stopped bool
+
+	stopOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// NewSenderSubmitter returns a new SenderSubmitter committing the metrics every commitInterval.
+func NewSenderSubmitter(senderManager sender.SenderManager, commitInterval time.Duration) *SenderSubmitter {
+	return &SenderSubmitter{
+		senderManager:  senderManager,
+		commitInterval: commitInterval,
+		stop:           make(chan struct{}),
+		done:           make(chan struct{}),
+	}
+}
+
+// Start starts committing the metrics periodically.
+func (s *SenderSubmitter) Start() {
+	go s.run()
+}
+
+// Stop commits the remaining metrics and releases the sender. It can be called several times.
+func (s *SenderSubmitter) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.stop)
+		<-s.done
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.stopped = true
+		if sender := s.sender.Swap(nil); sender != nil {
+			(*sender).Commit()
+			s.senderManager.DestroySender(senderID)
+		}
+	})
+}
+
+func (s *SenderSubmitter) run() {
+	defer close(s.done)
+	ticker := time.NewTicker(s.commitInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			if sender := s.sender.Load(); sender != nil {
+				(*sender).Commit()
+			}
+		}
+	}
+}
+
+// getSender returns the sender, it is created on first use since the
+// aggregator may be initialized after the logs agent.
+func (s *SenderSubmitter) getSender() sender.Sender {
+	if sender := s.sender.Load(); sender != nil {
+		return *sender
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if sender := s.sender.Load(); sender != nil {
+		return *sender
+	}
+	if s.stopped {
+		return nil
+	}
+	sender, err := s.senderManager.GetSender(senderID)
+	if err != nil {
+		log.Debugf("Unable to get a sender for the metrics extracted from logs: %v", err)
+		return nil
+	}
+	s.sender.Store(&sender)
+	return sender
+}
+
+// Count submits a count.
+func (s *SenderSubmitter) Count(metric string, value float64, tags []string) {
+	if sender := s.getSender(); sender != nil {
+		sender.Count(metric, value, "", tags)
+	}
+}
+
+// Distribution submits a distribution.
+func (s *SenderSubmitter) Distribution(metric string, value float64, tags []string) {
+	if sender := s.getSender(); sender != nil {
+		sender.Distribution(metric, value, "", tags)
+	}
+}
@@ -0,0 +1,40 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package metricsubmitter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator/mocksender"
+)
+
+func TestSenderSubmitter(t *testing.T) {
+	mockSender := mocksender.NewMockSender(senderID)
+	mockSender.SetupAcceptAll()
+
+	submitter := NewSenderSubmitter(mockSender.GetSenderManager(), 10*time.Millisecond)
+	submitter.Start()
+
+	submitter.Count("http.requests", 1, []string{"status:200"})
+	submitter.Distribution("http.latency", 0.25, []string{"status:200"})
+
+	mockSender.AssertCalled(t, "Count", "http.requests", 1.0, "", []string{"status:200"})
+	mockSender.AssertCalled(t, "Distribution", "http.latency", 0.25, "", []string{"status:200"})
+
+	// the remaining metrics are committed on stop
+	submitter.Stop()
+	mockSender.AssertCalled(t, "Commit")
+	assert.Nil(t, submitter.sender.Load())
+
+	// stopping again is a no-op, and no sender is created once stopped
+	submitter.Stop()
+	submitter.Count("http.requests", 1, []string{"status:200"})
+	assert.Nil(t, submitter.sender.Load())
+	mockSender.AssertNumberOfCalls(t, "Count", 1)
+}
@@ -0,0 +1,13 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package metricsubmitter submits the metrics extracted from log lines by the logs pipelines.
+package metricsubmitter
+
+// Submitter submits the metrics extracted from log lines.
+type Submitter interface {
+	Count(metric string, value float64, tags []string)
+	Distribution(metric string, value float64, tags []string)
+}
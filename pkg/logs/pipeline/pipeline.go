@@ -12,10 +12,12 @@ import (
 	"github.com/DataDog/datadog-agent/comp/logs/agent/config"
 	"github.com/DataDog/datadog-agent/pkg/logs/client"
 	"github.com/DataDog/datadog-agent/pkg/logs/client/http"
+	"github.com/DataDog/datadog-agent/pkg/logs/client/otlp"
 	"github.com/DataDog/datadog-agent/pkg/logs/client/tcp"
 	"github.com/DataDog/datadog-agent/pkg/logs/diagnostic"
 	"github.com/DataDog/datadog-agent/pkg/logs/internal/processor"
 	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/logs/metricsubmitter"
 	"github.com/DataDog/datadog-agent/pkg/logs/sender"
 )
 
@@ -33,7 +35,9 @@ func NewPipeline(outputChan chan *message.Payload,
 	processingRules []*config.ProcessingRule,
 	endpoints *config.Endpoints,
 	destinationsContext *client.DestinationsContext,
+	diskQueue *sender.DiskQueue,
 	diagnosticMessageReceiver diagnostic.MessageReceiver,
+	metricSubmitter metricsubmitter.Submitter,
 	serverless bool,
 	pipelineID int) *Pipeline {
 
@@ -57,10 +61,10 @@ func NewPipeline(outputChan chan *message.Payload,
 	}
 
 	strategy := getStrategy(strategyInput, senderInput, flushChan, endpoints, serverless, pipelineID)
-	logsSender = sender.NewSender(senderInput, outputChan, mainDestinations, config.DestinationPayloadChanSize)
+	logsSender = sender.NewSender(senderInput, outputChan, mainDestinations, config.DestinationPayloadChanSize, diskQueue)
 
 	inputChan := make(chan *message.Message, config.ChanSize)
-	processor := processor.New(inputChan, strategyInput, processingRules, encoder, diagnosticMessageReceiver)
+	processor := processor.New(inputChan, strategyInput, processingRules, encoder, diagnosticMessageReceiver, metricSubmitter)
 
 	return &Pipeline{
 		InputChan: inputChan,
@@ -98,11 +102,11 @@ func getDestinations(endpoints *config.Endpoints, destinationsContext *client.De
 	if endpoints.UseHTTP {
 		for i, endpoint := range endpoints.GetReliableEndpoints() {
 			telemetryName := fmt.Sprintf("logs_%d_reliable_%d", pipelineID, i)
-			reliable = append(reliable, http.NewDestination(endpoint, http.JSONContentType, destinationsContext, endpoints.BatchMaxConcurrentSend, true, telemetryName))
+			reliable = append(reliable, newHTTPDestination(endpoint, destinationsContext, endpoints.BatchMaxConcurrentSend, true, telemetryName))
 		}
 		for i, endpoint := range endpoints.GetUnReliableEndpoints() {
 			telemetryName := fmt.Sprintf("logs_%d_unreliable_%d", pipelineID, i)
-			additionals = append(additionals, http.NewDestination(endpoint, http.JSONContentType, destinationsContext, endpoints.BatchMaxConcurrentSend, false, telemetryName))
+			additionals = append(additionals, newHTTPDestination(endpoint, destinationsContext, endpoints.BatchMaxConcurrentSend, false, telemetryName))
 		}
 		return client.NewDestinations(reliable, additionals)
 	}
@@ -115,6 +119,14 @@ func getDestinations(endpoints *config.Endpoints, destinationsContext *client.De
 	return client.NewDestinations(reliable, additionals)
 }
 
+// newHTTPDestination returns an OTLP destination for OTLP endpoints and a Datadog HTTP destination otherwise.
+func newHTTPDestination(endpoint config.Endpoint, destinationsContext *client.DestinationsContext, maxConcurrentBackgroundSends int, shouldRetry bool, telemetryName string) client.Destination {
+	if endpoint.OTLP {
+		return otlp.NewDestination(endpoint, destinationsContext, maxConcurrentBackgroundSends, shouldRetry, telemetryName)
+	}
+	return http.NewDestination(endpoint, http.JSONContentType, destinationsContext, maxConcurrentBackgroundSends, shouldRetry, telemetryName)
+}
+
 func getStrategy(inputChan chan *message.Message, outputChan chan *message.Payload, flushChan chan struct{}, endpoints *config.Endpoints, serverless bool, pipelineID int) sender.Strategy {
 	if endpoints.UseHTTP || serverless {
 		encoder := sender.IdentityContentType
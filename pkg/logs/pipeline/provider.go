@@ -16,6 +16,8 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/logs/auditor"
 	"github.com/DataDog/datadog-agent/pkg/logs/client"
 	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/logs/metricsubmitter"
+	"github.com/DataDog/datadog-agent/pkg/logs/sender"
 	"github.com/DataDog/datadog-agent/pkg/util/startstop"
 )
 
@@ -33,6 +35,7 @@ type provider struct {
 	numberOfPipelines         int
 	auditor                   auditor.Auditor
 	diagnosticMessageReceiver diagnostic.MessageReceiver
+	metricSubmitter           metricsubmitter.Submitter
 	outputChan                chan *message.Payload
 	processingRules           []*config.ProcessingRule
 	endpoints                 *config.Endpoints
@@ -40,18 +43,19 @@ type provider struct {
 	pipelines            []*Pipeline
 	currentPipelineIndex *atomic.Uint32
 	destinationsContext  *client.DestinationsContext
+	diskQueue            *sender.DiskQueue
 
 	serverless bool
 }
 
-// NewProvider returns a new Provider
-func NewProvider(numberOfPipelines int, auditor auditor.Auditor, diagnosticMessageReceiver diagnostic.MessageReceiver, processingRules []*config.ProcessingRule, endpoints *config.Endpoints, destinationsContext *client.DestinationsContext) Provider {
-	return newProvider(numberOfPipelines, auditor, diagnosticMessageReceiver, processingRules, endpoints, destinationsContext, false)
+// NewProvider returns a new Provider, diskQueue is optional and shared by all the pipelines
+func NewProvider(numberOfPipelines int, auditor auditor.Auditor, diagnosticMessageReceiver diagnostic.MessageReceiver, metricSubmitter metricsubmitter.Submitter, processingRules []*config.ProcessingRule, endpoints *config.Endpoints, destinationsContext *client.DestinationsContext, diskQueue *sender.DiskQueue) Provider {
+	return newProvider(numberOfPipelines, auditor, diagnosticMessageReceiver, metricSubmitter, processingRules, endpoints, destinationsContext, diskQueue, false)
 }
 
 // NewServerlessProvider returns a new Provider in serverless mode
 func NewServerlessProvider(numberOfPipelines int, auditor auditor.Auditor, processingRules []*config.ProcessingRule, endpoints *config.Endpoints, destinationsContext *client.DestinationsContext) Provider {
-	return newProvider(numberOfPipelines, auditor, &diagnostic.NoopMessageReceiver{}, processingRules, endpoints, destinationsContext, true)
+	return newProvider(numberOfPipelines, auditor, &diagnostic.NoopMessageReceiver{}, &metricsubmitter.NoopSubmitter{}, processingRules, endpoints, destinationsContext, nil, true)
 }
 
 // NewMockProvider creates a new provider that will not provide any pipelines.
@@ -59,16 +63,18 @@ func NewMockProvider() Provider {
 	return &provider{}
 }
 
-func newProvider(numberOfPipelines int, auditor auditor.Auditor, diagnosticMessageReceiver diagnostic.MessageReceiver, processingRules []*config.ProcessingRule, endpoints *config.Endpoints, destinationsContext *client.DestinationsContext, serverless bool) Provider {
+func newProvider(numberOfPipelines int, auditor auditor.Auditor, diagnosticMessageReceiver diagnostic.MessageReceiver, metricSubmitter metricsubmitter.Submitter, processingRules []*config.ProcessingRule, endpoints *config.Endpoints, destinationsContext *client.DestinationsContext, diskQueue *sender.DiskQueue, serverless bool) Provider {
 	return &provider{
 		numberOfPipelines:         numberOfPipelines,
 		auditor:                   auditor,
 		diagnosticMessageReceiver: diagnosticMessageReceiver,
+		metricSubmitter:           metricSubmitter,
 		processingRules:           processingRules,
 		endpoints:                 endpoints,
 		pipelines:                 []*Pipeline{},
 		currentPipelineIndex:      atomic.NewUint32(0),
 		destinationsContext:       destinationsContext,
+		diskQueue:                 diskQueue,
 		serverless:                serverless,
 	}
 }
@@ -79,7 +85,7 @@ func (p *provider) Start() {
 	p.outputChan = p.auditor.Channel()
 
 	for i := 0; i < p.numberOfPipelines; i++ {
-		pipeline := NewPipeline(p.outputChan, p.processingRules, p.endpoints, p.destinationsContext, p.diagnosticMessageReceiver, p.serverless, i)
+		pipeline := NewPipeline(p.outputChan, p.processingRules, p.endpoints, p.destinationsContext, p.diskQueue, p.diagnosticMessageReceiver, p.metricSubmitter, p.serverless, i)
 		pipeline.Start()
 		p.pipelines = append(p.pipelines, pipeline)
 	}
@@ -0,0 +1,271 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package sender
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/comp/logs/agent/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/logs/sources"
+	"github.com/DataDog/datadog-agent/pkg/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	diskQueueFileExtension = ".payload"
+	// files are written under a temporary name first so that partially written files are never loaded
+	diskQueueTmpFileExtension = ".tmp"
+)
+
+var (
+	tlmDiskQueuePayloadsStored  = telemetry.NewCounter("logs_sender", "disk_queue_payloads_stored", []string{}, "Payloads stored in the disk queue")
+	tlmDiskQueuePayloadsDropped = telemetry.NewCounter("logs_sender", "disk_queue_payloads_dropped", []string{}, "Payloads removed from the disk queue to respect its maximum size")
+	tlmDiskQueueSize            = telemetry.NewGauge("logs_sender", "disk_queue_size_bytes", []string{}, "Size of the payloads stored in the disk queue")
+)
+
+// storedPayload is the representation of a payload on disk.
+type storedPayload struct {
+	Messages      []storedMessage
+	Encoded       []byte
+	Encoding      string
+	UnencodedSize int
+}
+
+type storedMessage struct {
+	Content            []byte
+	Status             string
+	IngestionTimestamp int64
+}
+
+// storedPayloadOrigin is the origin of the messages extracted from the disk queue.
+// The offsets of these messages were committed when they were stored,
+// an empty identifier keeps the auditor from tracking them again.
+var storedPayloadOrigin = message.NewOrigin(sources.NewLogSource("disk_queue", &config.LogsConfig{}))
+
+// DiskQueue stores payloads on disk, oldest first, while the reliable destinations are unavailable.
+// It is shared by the senders of all the pipelines and survives restarts.
+// Once the maximum size is reached, the oldest payloads are removed to make room for new ones.
+// Each payload is leased to a single sender at a time so that it is not sent by several pipelines.
+type DiskQueue struct {
+	mu                 sync.Mutex
+	path               string
+	maxSizeInBytes     int64
+	filenames          []string
+	leased             map[string]struct{}
+	currentSizeInBytes int64
+	sequence           uint64
+}
+
+// NewDiskQueue returns a new DiskQueue storing payloads in path, the payloads
+// stored in path by a previous run are loaded.
+func NewDiskQueue(path string, maxSizeInBytes int64) (*DiskQueue, error) {
+	if err := os.MkdirAll(path, 0700); err != nil {
+		return nil, err
+	}
+	q := &DiskQueue{
+		path:           path,
+		maxSizeInBytes: maxSizeInBytes,
+		leased:         make(map[string]struct{}),
+	}
+	if err := q.reloadExistingFiles(); err != nil {
+		return nil, err
+	}
+	if len(q.filenames) > 0 {
+		log.Infof("Loaded %d logs payloads from the disk queue %s", len(q.filenames), path)
+	}
+	return q, nil
+}
+
+// Store writes the payload to disk, removing the oldest payloads if needed.
+func (q *DiskQueue) Store(payload *message.Payload) error {
+	stored := storedPayload{
+		Messages:      make([]storedMessage, 0, len(payload.Messages)),
+		Encoded:       payload.Encoded,
+		Encoding:      payload.Encoding,
+		UnencodedSize: payload.UnencodedSize,
+	}
+	for _, msg := range payload.Messages {
+		stored.Messages = append(stored.Messages, storedMessage{
+			Content:            msg.Content,
+			Status:             msg.GetStatus(),
+			IngestionTimestamp: msg.IngestionTimestamp,
+		})
+	}
+	var buffer bytes.Buffer
+	if err := gob.NewEncoder(&buffer).Encode(&stored); err != nil {
+		return err
+	}
+	size := int64(buffer.Len())
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if err := q.makeRoomFor(size); err != nil {
+		return err
+	}
+
+	q.sequence++
+	filename := filepath.Join(q.path, fmt.Sprintf("%020d_%06d%s", time.Now().UnixNano(), q.sequence%1000000, diskQueueFileExtension))
+	tmpFilename := filename + diskQueueTmpFileExtension
+	if err := os.WriteFile(tmpFilename, buffer.Bytes(), 0600); err != nil {
+		_ = os.Remove(tmpFilename)
+		return err
+	}
+	if err := os.Rename(tmpFilename, filename); err != nil {
+		_ = os.Remove(tmpFilename)
+		return err
+	}
+
+	q.filenames = append(q.filenames, filename)
+	q.currentSizeInBytes += size
+	tlmDiskQueuePayloadsStored.Inc()
+	tlmDiskQueueSize.Set(float64(q.currentSizeInBytes))
+	return nil
+}
+
+// Next leases the oldest payload of the queue that is not leased yet and returns it with its identifier,
+// which must be given to Remove once the payload is sent, or to Release if it is not.
+// It returns nil if there is no such payload.
+func (q *DiskQueue) Next() (*message.Payload, string, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	index := -1
+	for i, filename := range q.filenames {
+		if _, leased := q.leased[filename]; !leased {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return nil, "", nil
+	}
+	filename := q.filenames[index]
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		// remove the file to not fail on the next call
+		q.removeAt(index)
+		return nil, "", err
+	}
+
+	var stored storedPayload
+	if err := gob.NewDecoder(bytes.NewReader(content)).Decode(&stored); err != nil {
+		q.removeAt(index)
+		return nil, "", fmt.Errorf("invalid payload in %s: %v", filename, err)
+	}
+	payload := &message.Payload{
+		Messages:      make([]*message.Message, 0, len(stored.Messages)),
+		Encoded:       stored.Encoded,
+		Encoding:      stored.Encoding,
+		UnencodedSize: stored.UnencodedSize,
+	}
+	for _, msg := range stored.Messages {
+		payload.Messages = append(payload.Messages, message.NewMessage(msg.Content, storedPayloadOrigin, msg.Status, msg.IngestionTimestamp))
+	}
+	q.leased[filename] = struct{}{}
+	return payload, filename, nil
+}
+
+// Remove removes the payload returned by Next from the queue,
+// it does nothing if the payload was already removed to make room for new ones.
+func (q *DiskQueue) Remove(id string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	delete(q.leased, id)
+	for i, filename := range q.filenames {
+		if filename == id {
+			q.removeAt(i)
+			return
+		}
+	}
+}
+
+// Release gives back the payload returned by Next without removing it,
+// it is returned again by a later call to Next.
+func (q *DiskQueue) Release(id string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delete(q.leased, id)
+}
+
+// Len returns the number of payloads in the queue.
+func (q *DiskQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.filenames)
+}
+
+// SizeInBytes returns the size of the payloads stored on disk.
+func (q *DiskQueue) SizeInBytes() int64 {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.currentSizeInBytes
+}
+
+func (q *DiskQueue) makeRoomFor(size int64) error {
+	if size > q.maxSizeInBytes {
+		return fmt.Errorf("the payload is too big. Current:%v Maximum:%v", size, q.maxSizeInBytes)
+	}
+	for len(q.filenames) > 0 && q.currentSizeInBytes+size > q.maxSizeInBytes {
+		log.Warnf("Maximum disk space for logs payloads is reached. Removing %s", q.filenames[0])
+		q.removeAt(0)
+		tlmDiskQueuePayloadsDropped.Inc()
+	}
+	return nil
+}
+
+func (q *DiskQueue) removeAt(index int) {
+	filename := q.filenames[index]
+	q.filenames = append(q.filenames[:index], q.filenames[index+1:]...)
+	delete(q.leased, filename)
+
+	if info, err := os.Stat(filename); err == nil {
+		q.currentSizeInBytes -= info.Size()
+	}
+	if err := os.Remove(filename); err != nil && !os.IsNotExist(err) {
+		log.Warnf("Could not remove %s from the disk queue: %v", filename, err)
+	}
+	tlmDiskQueueSize.Set(float64(q.currentSizeInBytes))
+}
+
+func (q *DiskQueue) reloadExistingFiles() error {
+	entries, err := os.ReadDir(q.path)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		filename := filepath.Join(q.path, entry.Name())
+		switch filepath.Ext(entry.Name()) {
+		case diskQueueFileExtension:
+			info, err := entry.Info()
+			if err != nil {
+				log.Warnf("Can't get file info of %s: %v", filename, err)
+				continue
+			}
+			q.currentSizeInBytes += info.Size()
+			q.filenames = append(q.filenames, filename)
+		case diskQueueTmpFileExtension:
+			// the agent stopped while writing this file
+			_ = os.Remove(filename)
+		}
+	}
+	// the file names start with the creation time
+	sort.Strings(q.filenames)
+	tlmDiskQueueSize.Set(float64(q.currentSizeInBytes))
+	return nil
+}
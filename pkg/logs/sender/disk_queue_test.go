@@ -0,0 +1,168 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package sender
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/comp/logs/agent/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/logs/sources"
+)
+
+func newDiskQueuePayload(content string) *message.Payload {
+	source := sources.NewLogSource("", &config.LogsConfig{})
+	return &message.Payload{
+		Messages:      []*message.Message{message.NewMessageWithSource([]byte(content), message.StatusWarning, source, 42)},
+		Encoded:       []byte(content),
+		Encoding:      "identity",
+		UnencodedSize: len(content),
+	}
+}
+
+func extractNext(q *DiskQueue) (*message.Payload, error) {
+	payload, id, err := q.Next()
+	if payload != nil {
+		q.Remove(id)
+	}
+	return payload, err
+}
+
+func TestDiskQueueStoreAndExtract(t *testing.T) {
+	q, err := NewDiskQueue(t.TempDir(), 1024*1024)
+	require.NoError(t, err)
+
+	payload, _, err := q.Next()
+	assert.NoError(t, err)
+	assert.Nil(t, payload)
+
+	require.NoError(t, q.Store(newDiskQueuePayload("first")))
+	require.NoError(t, q.Store(newDiskQueuePayload("second")))
+	assert.Equal(t, 2, q.Len())
+	assert.True(t, q.SizeInBytes() > 0)
+
+	payload, err = extractNext(q)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("first"), payload.Encoded)
+	assert.Equal(t, "identity", payload.Encoding)
+	assert.Equal(t, 5, payload.UnencodedSize)
+	require.Len(t, payload.Messages, 1)
+	assert.Equal(t, []byte("first"), payload.Messages[0].Content)
+	assert.Equal(t, message.StatusWarning, payload.Messages[0].GetStatus())
+	assert.Equal(t, int64(42), payload.Messages[0].IngestionTimestamp)
+	// the offsets of the stored messages must not be tracked again
+	assert.Equal(t, "", payload.Messages[0].Origin.Identifier)
+
+	// the leased payload stays in the queue until it is removed
+	payload, id, err := q.Next()
+	require.NoError(t, err)
+	assert.Equal(t, []byte("second"), payload.Encoded)
+	assert.Equal(t, 1, q.Len())
+	q.Remove(id)
+	q.Remove(id)
+
+	assert.Equal(t, 0, q.Len())
+	assert.Equal(t, int64(0), q.SizeInBytes())
+}
+
+func TestDiskQueueLeasesPayloadsOnce(t *testing.T) {
+	q, err := NewDiskQueue(t.TempDir(), 1024*1024)
+	require.NoError(t, err)
+	require.NoError(t, q.Store(newDiskQueuePayload("first")))
+	require.NoError(t, q.Store(newDiskQueuePayload("second")))
+
+	first, firstID, err := q.Next()
+	require.NoError(t, err)
+	assert.Equal(t, []byte("first"), first.Encoded)
+
+	// a leased payload is not returned to another sender
+	second, secondID, err := q.Next()
+	require.NoError(t, err)
+	assert.Equal(t, []byte("second"), second.Encoded)
+	payload, _, err := q.Next()
+	require.NoError(t, err)
+	assert.Nil(t, payload)
+
+	// a released payload can be leased again
+	q.Release(firstID)
+	first, firstID, err = q.Next()
+	require.NoError(t, err)
+	assert.Equal(t, []byte("first"), first.Encoded)
+
+	// payloads are removed in any order
+	q.Remove(secondID)
+	assert.Equal(t, 1, q.Len())
+	q.Remove(firstID)
+	assert.Equal(t, 0, q.Len())
+}
+
+func TestDiskQueueReloadsExistingPayloads(t *testing.T) {
+	path := t.TempDir()
+	q, err := NewDiskQueue(path, 1024*1024)
+	require.NoError(t, err)
+	require.NoError(t, q.Store(newDiskQueuePayload("first")))
+	require.NoError(t, q.Store(newDiskQueuePayload("second")))
+
+	// a file which was being written when the agent stopped
+	tmpFile := filepath.Join(path, "00000000000000000000_000000.payload.tmp")
+	require.NoError(t, os.WriteFile(tmpFile, []byte("partial"), 0600))
+
+	q, err = NewDiskQueue(path, 1024*1024)
+	require.NoError(t, err)
+	assert.Equal(t, 2, q.Len())
+	assert.NoFileExists(t, tmpFile)
+
+	payload, err := extractNext(q)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("first"), payload.Encoded)
+	payload, err = extractNext(q)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("second"), payload.Encoded)
+}
+
+func TestDiskQueueRemovesOldestPayloadsWhenFull(t *testing.T) {
+	path := t.TempDir()
+	q, err := NewDiskQueue(path, 1024*1024)
+	require.NoError(t, err)
+	require.NoError(t, q.Store(newDiskQueuePayload("first")))
+	payloadSize := q.SizeInBytes()
+
+	q, err = NewDiskQueue(path, 2*payloadSize+payloadSize/2)
+	require.NoError(t, err)
+	require.NoError(t, q.Store(newDiskQueuePayload("secnd")))
+	require.NoError(t, q.Store(newDiskQueuePayload("third")))
+	assert.Equal(t, 2, q.Len())
+
+	payload, err := extractNext(q)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("secnd"), payload.Encoded)
+
+	// a payload bigger than the queue is rejected
+	q, err = NewDiskQueue(t.TempDir(), 10)
+	require.NoError(t, err)
+	assert.Error(t, q.Store(newDiskQueuePayload("too big")))
+	assert.Equal(t, 0, q.Len())
+}
+
+func TestDiskQueueSkipsInvalidPayloads(t *testing.T) {
+	path := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(path, "00000000000000000000_000000.payload"), []byte("invalid"), 0600))
+
+	q, err := NewDiskQueue(path, 1024*1024)
+	require.NoError(t, err)
+	require.NoError(t, q.Store(newDiskQueuePayload("valid")))
+
+	_, err = extractNext(q)
+	assert.Error(t, err)
+	payload, err := extractNext(q)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("valid"), payload.Encoded)
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/logs/client"
 	"github.com/DataDog/datadog-agent/pkg/logs/message"
 	"github.com/DataDog/datadog-agent/pkg/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
 var (
@@ -27,20 +28,29 @@ var (
 // one reliable destination is also sending logs. However they do not update
 // the auditor or block the pipeline if they fail. There will always be at
 // least 1 reliable destination (the main destination).
+//
+// When a disk queue is given, the payloads are stored on disk instead of
+// blocking the pipeline while all the reliable destinations are in an error
+// state, and sent once a reliable destination recovers.
 type Sender struct {
 	inputChan    chan *message.Payload
 	outputChan   chan *message.Payload
 	destinations *client.Destinations
+	diskQueue    *DiskQueue
 	done         chan struct{}
 	bufferSize   int
 }
 
-// NewSender returns a new sender.
-func NewSender(inputChan chan *message.Payload, outputChan chan *message.Payload, destinations *client.Destinations, bufferSize int) *Sender {
+// diskQueueRetryInterval is the delay between two attempts to send the payloads of the disk queue.
+const diskQueueRetryInterval = 100 * time.Millisecond
+
+// NewSender returns a new sender, diskQueue is optional.
+func NewSender(inputChan chan *message.Payload, outputChan chan *message.Payload, destinations *client.Destinations, bufferSize int, diskQueue *DiskQueue) *Sender {
 	return &Sender{
 		inputChan:    inputChan,
 		outputChan:   outputChan,
 		destinations: destinations,
+		diskQueue:    diskQueue,
 		done:         make(chan struct{}),
 		bufferSize:   bufferSize,
 	}
@@ -64,57 +74,165 @@ func (s *Sender) run() {
 	sink := additionalDestinationsSink(s.bufferSize)
 	unreliableDestinations := buildDestinationSenders(s.destinations.Unreliable, sink, s.bufferSize)
 
-	for payload := range s.inputChan {
-		var startInUse = time.Now()
+	if s.diskQueue != nil {
+		s.runWithDiskQueue(reliableDestinations, unreliableDestinations)
+	} else {
+		for payload := range s.inputChan {
+			var startInUse = time.Now()
 
-		sent := false
-		for !sent {
-			for _, destSender := range reliableDestinations {
-				if destSender.Send(payload) {
-					sent = true
-				}
-			}
+			s.blockingSend(payload, reliableDestinations)
+			bufferToFailingDestinations(payload, reliableDestinations)
+			sendToUnreliableDestinations(payload, unreliableDestinations)
+
+			inUse := float64(time.Since(startInUse) / time.Millisecond)
+			tlmSendWaitTime.Add(inUse)
+		}
+	}
+
+	// Cleanup the destinations
+	for _, destSender := range reliableDestinations {
+		destSender.Stop()
+	}
+	for _, destSender := range unreliableDestinations {
+		destSender.Stop()
+	}
+	close(sink)
+	s.done <- struct{}{}
+}
 
-			if !sent {
-				// Throttle the poll loop while waiting for a send to succeed
-				// This will only happen when all reliable destinations
-				// are blocked so logs have no where to go.
-				time.Sleep(100 * time.Millisecond)
+// runWithDiskQueue sends the payloads until inputChan is closed, storing them in the disk queue
+// when no reliable destination accepts them. The payloads of the disk queue are sent first,
+// new payloads are stored meanwhile to preserve the ordering.
+func (s *Sender) runWithDiskQueue(reliableDestinations []*DestinationSender, unreliableDestinations []*DestinationSender) {
+	// stored is the payload of the disk queue leased by this sender, it is removed from the disk queue once sent
+	var stored *message.Payload
+	var storedID string
+	defer func() {
+		if stored != nil {
+			s.diskQueue.Release(storedID)
+		}
+	}()
+
+	for {
+		if stored == nil {
+			stored, storedID = s.nextStoredPayload()
+		}
+
+		if stored == nil {
+			payload, isOpen := <-s.inputChan
+			if !isOpen {
+				return
+			}
+			var startInUse = time.Now()
+			if trySend(payload, reliableDestinations) {
+				bufferToFailingDestinations(payload, reliableDestinations)
+			} else {
+				s.store(payload, reliableDestinations)
 			}
+			sendToUnreliableDestinations(payload, unreliableDestinations)
+			inUse := float64(time.Since(startInUse) / time.Millisecond)
+			tlmSendWaitTime.Add(inUse)
+			continue
 		}
 
-		for i, destSender := range reliableDestinations {
-			// If an endpoint is stuck in the previous step, try to buffer the payloads if we have room to mitigate
-			// loss on intermittent failures.
-			if !destSender.lastSendSucceeded {
-				if !destSender.NonBlockingSend(payload) {
-					tlmPayloadsDropped.Inc("true", strconv.Itoa(i))
-					tlmMessagesDropped.Add(float64(len(payload.Messages)), "true", strconv.Itoa(i))
-				}
+		// new payloads are not delayed by the payloads of the disk queue
+		select {
+		case payload, isOpen := <-s.inputChan:
+			if !isOpen {
+				return
 			}
+			s.store(payload, reliableDestinations)
+			sendToUnreliableDestinations(payload, unreliableDestinations)
+			continue
+		default:
 		}
 
-		// Attempt to send to unreliable destinations
-		for i, destSender := range unreliableDestinations {
-			if !destSender.NonBlockingSend(payload) {
-				tlmPayloadsDropped.Inc("false", strconv.Itoa(i))
-				tlmMessagesDropped.Add(float64(len(payload.Messages)), "false", strconv.Itoa(i))
+		if trySend(stored, reliableDestinations) {
+			bufferToFailingDestinations(stored, reliableDestinations)
+			s.diskQueue.Remove(storedID)
+			stored = nil
+			continue
+		}
+
+		// all the reliable destinations are still in an error state
+		select {
+		case payload, isOpen := <-s.inputChan:
+			if !isOpen {
+				return
 			}
+			s.store(payload, reliableDestinations)
+			sendToUnreliableDestinations(payload, unreliableDestinations)
+		case <-time.After(diskQueueRetryInterval):
 		}
+	}
+}
 
-		inUse := float64(time.Since(startInUse) / time.Millisecond)
-		tlmSendWaitTime.Add(inUse)
+// nextStoredPayload leases the oldest payload of the disk queue not leased by another sender, nil if there is none.
+func (s *Sender) nextStoredPayload() (*message.Payload, string) {
+	for {
+		payload, id, err := s.diskQueue.Next()
+		if err == nil {
+			return payload, id
+		}
+		log.Warnf("Could not read logs payload from the disk queue: %v", err)
 	}
+}
 
-	// Cleanup the destinations
+// store stores the payload in the disk queue. A stored payload is considered as sent: it is forwarded
+// to the output so that the auditor commits the offsets of its messages, which are not read again on restart.
+// If the payload can't be stored, it is sent as if there were no disk queue.
+func (s *Sender) store(payload *message.Payload, reliableDestinations []*DestinationSender) {
+	if err := s.diskQueue.Store(payload); err != nil {
+		log.Warnf("Could not store logs payload in the disk queue: %v", err)
+		s.blockingSend(payload, reliableDestinations)
+		bufferToFailingDestinations(payload, reliableDestinations)
+		return
+	}
+	s.outputChan <- payload
+}
+
+// blockingSend sends the payload to the reliable destinations, blocking until at least one accepts it.
+func (s *Sender) blockingSend(payload *message.Payload, reliableDestinations []*DestinationSender) {
+	for !trySend(payload, reliableDestinations) {
+		// Throttle the poll loop while waiting for a send to succeed
+		// This will only happen when all reliable destinations
+		// are blocked so logs have no where to go.
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// trySend sends the payload to the reliable destinations, returns false if none accepted it.
+func trySend(payload *message.Payload, reliableDestinations []*DestinationSender) bool {
+	sent := false
 	for _, destSender := range reliableDestinations {
-		destSender.Stop()
+		if destSender.Send(payload) {
+			sent = true
+		}
 	}
-	for _, destSender := range unreliableDestinations {
-		destSender.Stop()
+	return sent
+}
+
+func bufferToFailingDestinations(payload *message.Payload, reliableDestinations []*DestinationSender) {
+	for i, destSender := range reliableDestinations {
+		// If an endpoint is stuck in the previous step, try to buffer the payloads if we have room to mitigate
+		// loss on intermittent failures.
+		if !destSender.lastSendSucceeded {
+			if !destSender.NonBlockingSend(payload) {
+				tlmPayloadsDropped.Inc("true", strconv.Itoa(i))
+				tlmMessagesDropped.Add(float64(len(payload.Messages)), "true", strconv.Itoa(i))
+			}
+		}
+	}
+}
+
+func sendToUnreliableDestinations(payload *message.Payload, unreliableDestinations []*DestinationSender) {
+	// Attempt to send to unreliable destinations
+	for i, destSender := range unreliableDestinations {
+		if !destSender.NonBlockingSend(payload) {
+			tlmPayloadsDropped.Inc("false", strconv.Itoa(i))
+			tlmMessagesDropped.Add(float64(len(payload.Messages)), "false", strconv.Itoa(i))
+		}
 	}
-	close(sink)
-	s.done <- struct{}{}
 }
 
 // Drains the output channel from destinations that don't update the auditor.
@@ -6,9 +6,12 @@
 package sender
 
 import (
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/DataDog/datadog-agent/comp/logs/agent/config"
 	"github.com/DataDog/datadog-agent/pkg/logs/client"
@@ -42,7 +45,7 @@ func TestSender(t *testing.T) {
 	destination := tcp.AddrToDestination(l.Addr(), destinationsCtx)
 	destinations := client.NewDestinations([]client.Destination{destination}, nil)
 
-	sender := NewSender(input, output, destinations, 0)
+	sender := NewSender(input, output, destinations, 0, nil)
 	sender.Start()
 
 	expectedMessage := newMessage([]byte("fake line"), source, "")
@@ -68,7 +71,7 @@ func TestSenderSingleDestination(t *testing.T) {
 
 	destinations := client.NewDestinations([]client.Destination{server.Destination}, nil)
 
-	sender := NewSender(input, output, destinations, 10)
+	sender := NewSender(input, output, destinations, 10, nil)
 	sender.Start()
 
 	input <- &message.Payload{}
@@ -96,7 +99,7 @@ func TestSenderDualReliableDestination(t *testing.T) {
 
 	destinations := client.NewDestinations([]client.Destination{server1.Destination, server2.Destination}, nil)
 
-	sender := NewSender(input, output, destinations, 10)
+	sender := NewSender(input, output, destinations, 10, nil)
 	sender.Start()
 
 	input <- &message.Payload{}
@@ -129,7 +132,7 @@ func TestSenderUnreliableAdditionalDestination(t *testing.T) {
 
 	destinations := client.NewDestinations([]client.Destination{server1.Destination}, []client.Destination{server2.Destination})
 
-	sender := NewSender(input, output, destinations, 10)
+	sender := NewSender(input, output, destinations, 10, nil)
 	sender.Start()
 
 	input <- &message.Payload{}
@@ -160,7 +163,7 @@ func TestSenderUnreliableStopsWhenMainFails(t *testing.T) {
 
 	destinations := client.NewDestinations([]client.Destination{reliableServer.Destination}, []client.Destination{unreliableServer.Destination})
 
-	sender := NewSender(input, output, destinations, 10)
+	sender := NewSender(input, output, destinations, 10, nil)
 	sender.Start()
 
 	input <- &message.Payload{}
@@ -207,7 +210,7 @@ func TestSenderReliableContinuseWhenOneFails(t *testing.T) {
 
 	destinations := client.NewDestinations([]client.Destination{reliableServer1.Destination, reliableServer2.Destination}, nil)
 
-	sender := NewSender(input, output, destinations, 10)
+	sender := NewSender(input, output, destinations, 10, nil)
 	sender.Start()
 
 	input <- &message.Payload{}
@@ -251,7 +254,7 @@ func TestSenderReliableWhenOneFailsAndRecovers(t *testing.T) {
 
 	destinations := client.NewDestinations([]client.Destination{reliableServer1.Destination, reliableServer2.Destination}, nil)
 
-	sender := NewSender(input, output, destinations, 10)
+	sender := NewSender(input, output, destinations, 10, nil)
 	sender.Start()
 
 	input <- &message.Payload{}
@@ -301,3 +304,95 @@ func TestSenderReliableWhenOneFailsAndRecovers(t *testing.T) {
 	reliableServer2.Stop()
 	sender.Stop()
 }
+
+func TestSenderStoresPayloadsOnDiskWhenReliableFails(t *testing.T) {
+	input := make(chan *message.Payload, 1)
+	output := make(chan *message.Payload, 1)
+
+	respondChan := make(chan int)
+	server := http.NewTestServerWithOptions(500, 0, true, respondChan)
+
+	diskQueue, err := NewDiskQueue(t.TempDir(), 1024*1024)
+	assert.NoError(t, err)
+
+	destinations := client.NewDestinations([]client.Destination{server.Destination}, nil)
+
+	sender := NewSender(input, output, destinations, 10, diskQueue)
+	sender.Start()
+
+	source := sources.NewLogSource("", &config.LogsConfig{})
+	input <- newMessage([]byte("first"), source, message.StatusInfo)
+
+	<-respondChan // let it respond 500 once
+	<-respondChan // its in a loop now, the destination is marked as retrying
+
+	// the payload is stored on disk instead of blocking the pipeline
+	stored := newMessage([]byte("stored"), source, message.StatusError)
+	input <- stored
+	assert.Equal(t, stored, <-output)
+	assert.Equal(t, 1, diskQueue.Len())
+
+	// Recover the server
+	server.ChangeStatus(200)
+	// Drain any retries
+	for {
+		if (<-respondChan) == 200 {
+			break
+		}
+	}
+	<-output // get the log line that was stuck
+
+	// the stored payload is sent once the destination recovered, then removed from the disk
+	<-respondChan
+	replayed := <-output
+	assert.Equal(t, []byte("stored"), replayed.Encoded)
+	assert.Equal(t, []byte("stored"), replayed.Messages[0].Content)
+	assert.Equal(t, message.StatusError, replayed.Messages[0].GetStatus())
+
+	server.Stop()
+	sender.Stop()
+	assert.Equal(t, 0, diskQueue.Len())
+}
+
+func TestSendersSharingDiskQueueSendStoredPayloadsOnce(t *testing.T) {
+	diskQueue, err := NewDiskQueue(t.TempDir(), 1024*1024)
+	require.NoError(t, err)
+	stored := 20
+	source := sources.NewLogSource("", &config.LogsConfig{})
+	for i := 0; i < stored; i++ {
+		require.NoError(t, diskQueue.Store(newMessage([]byte(fmt.Sprintf("stored %d", i)), source, message.StatusInfo)))
+	}
+
+	// the senders of all the pipelines share the disk queue
+	output := make(chan *message.Payload, 2*stored)
+	var senders []*Sender
+	for i := 0; i < 3; i++ {
+		server := http.NewTestServer(200)
+		defer server.Stop()
+		destinations := client.NewDestinations([]client.Destination{server.Destination}, nil)
+		sender := NewSender(make(chan *message.Payload), output, destinations, 10, diskQueue)
+		sender.Start()
+		senders = append(senders, sender)
+	}
+
+	sent := make(map[string]int)
+	for i := 0; i < stored; i++ {
+		select {
+		case payload := <-output:
+			sent[string(payload.Encoded)]++
+		case <-time.After(10 * time.Second):
+			require.FailNow(t, "the stored payloads were not sent")
+		}
+	}
+	for _, sender := range senders {
+		sender.Stop()
+	}
+
+	// each stored payload is sent by a single sender
+	assert.Len(t, sent, stored)
+	for content, count := range sent {
+		assert.Equal(t, 1, count, content)
+	}
+	assert.Len(t, output, 0)
+	assert.Equal(t, 0, diskQueue.Len())
+}
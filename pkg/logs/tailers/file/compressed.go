@@ -0,0 +1,167 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package file
+
+import (
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/internal/decoder"
+	"github.com/DataDog/datadog-agent/pkg/util/filesystem"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// fingerprintSize is the number of bytes of a compressed file used to identify it.
+const fingerprintSize = 4096
+
+// compressionOf returns the compression format of the file from its extension,
+// an empty string if the file isn't compressed.
+func compressionOf(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".gz", ".gzip":
+		return "gzip"
+	case ".zst", ".zstd":
+		return "zstd"
+	}
+	return ""
+}
+
+// IsCompressed returns true if the file is a compressed archive to decompress,
+// which is read once instead of being tailed.
+func (t *File) IsCompressed() bool {
+	return t.Source != nil && t.Source.Config() != nil && t.Source.Config().IngestCompressedFiles && compressionOf(t.Path) != ""
+}
+
+// archiveFingerprint identifies a compressed file from its size and first bytes,
+// so that a renamed archive is recognized and not read again.
+func archiveFingerprint(path string) (string, error) {
+	f, err := filesystem.OpenShared(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	stat, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	if _, err := io.CopyN(h, f, fingerprintSize); err != nil && err != io.EOF {
+		return "", err
+	}
+	return fmt.Sprintf("%d-%s", stat.Size(), hex.EncodeToString(h.Sum(nil))[:32]), nil
+}
+
+// newDecompressor returns a reader decompressing the content of f.
+func newDecompressor(f io.Reader, compression string) (io.ReadCloser, error) {
+	switch compression {
+	case "gzip":
+		return gzip.NewReader(f)
+	case "zstd":
+		d, err := zstd.NewReader(f)
+		if err != nil {
+			return nil, err
+		}
+		return d.IOReadCloser(), nil
+	}
+	return nil, fmt.Errorf("unsupported compression %q", compression)
+}
+
+// setupArchive opens the compressed file and skips the decompressed content already sent,
+// the offsets of archives are offsets in the decompressed content.
+func (t *Tailer) setupArchive(offset int64, whence int) error {
+	fullpath, err := filepath.Abs(t.file.Path)
+	if err != nil {
+		return err
+	}
+	t.fullpath = fullpath
+
+	// adds metadata to enable users to filter logs by filename
+	t.tags = t.buildTailerTags()
+
+	if whence == io.SeekEnd {
+		// nothing to read, the archive was written before the logs were collected
+		log.Info("Skipping the content of", t.file.Path, "for tailer key", t.file.GetScanKey())
+		return nil
+	}
+
+	log.Info("Opening", t.file.Path, "for tailer key", t.file.GetScanKey())
+	f, err := filesystem.OpenShared(fullpath)
+	if err != nil {
+		return err
+	}
+	archive, err := newDecompressor(f, compressionOf(t.file.Path))
+	if err != nil {
+		f.Close()
+		return err
+	}
+	skipped, err := io.CopyN(io.Discard, archive, offset)
+	if err != nil && err != io.EOF {
+		archive.Close()
+		f.Close()
+		return err
+	}
+
+	t.osFile = f
+	t.archive = archive
+	t.lastReadOffset.Store(skipped)
+	t.decodedOffset.Store(skipped)
+	return nil
+}
+
+// readArchive reads the compressed file until its end, after which there is nothing more to read.
+func (t *Tailer) readArchive() (int, error) {
+	if t.archive == nil {
+		return 0, nil
+	}
+	inBuf := make([]byte, 4096)
+	n, err := t.archive.Read(inBuf)
+	if n > 0 {
+		t.recordBytes(int64(n))
+		t.decoder.InputChan <- decoder.NewInput(inBuf[:n])
+		t.lastReadOffset.Add(int64(n))
+	}
+	if errors.Is(err, io.EOF) {
+		log.Info("Finished reading", t.file.Path, "after", t.lastReadOffset.Load(), "decompressed bytes")
+		t.closeArchive()
+		return n, nil
+	}
+	if err != nil {
+		t.file.Source.Status().Error(err)
+		return n, log.Error("Unexpected error occurred while decompressing file: ", err)
+	}
+	return n, nil
+}
+
+// closeArchive releases the compressed file, the tailer then stops once its messages are flushed.
+func (t *Tailer) closeArchive() {
+	if t.archive != nil {
+		t.archive.Close()
+		t.archive = nil
+		t.osFile.Close()
+		t.osFile = nil
+	}
+}
+
+// didArchiveChange returns true if the compressed file has been replaced by another one.
+func (t *Tailer) didArchiveChange() (bool, error) {
+	fingerprint, err := archiveFingerprint(t.file.Path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return t.fingerprint != "" && fingerprint != t.fingerprint, nil
+}
@@ -0,0 +1,166 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build !windows
+
+package file
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/comp/logs/agent/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/auditor"
+	"github.com/DataDog/datadog-agent/pkg/logs/internal/decoder"
+	"github.com/DataDog/datadog-agent/pkg/logs/internal/status"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/logs/sources"
+)
+
+const archiveContent = "first line\nsecond line\nthird line\n"
+
+func writeArchive(t *testing.T, path string, content string) {
+	var buffer bytes.Buffer
+	var w io.WriteCloser
+	var err error
+	switch compressionOf(path) {
+	case "gzip":
+		w = gzip.NewWriter(&buffer)
+	case "zstd":
+		w, err = zstd.NewWriter(&buffer)
+		require.NoError(t, err)
+	default:
+		require.Fail(t, "unexpected extension", path)
+	}
+	_, err = w.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	require.NoError(t, os.WriteFile(path, buffer.Bytes(), 0644))
+}
+
+func newArchiveTailer(path string, ingestCompressedFiles bool) (*Tailer, chan *message.Message) {
+	source := sources.NewReplaceableSource(sources.NewLogSource("", &config.LogsConfig{
+		Type:                  config.FileType,
+		Path:                  path,
+		IngestCompressedFiles: ingestCompressedFiles,
+	}))
+	info := status.NewInfoRegistry()
+	outputChan := make(chan *message.Message, chanSize)
+	return NewTailer(&TailerOptions{
+		OutputChan:    outputChan,
+		File:          NewFile(path, source.UnderlyingSource(), false),
+		SleepDuration: 10 * time.Millisecond,
+		Decoder:       decoder.NewDecoderFromSource(source, info),
+		Info:          info,
+	}), outputChan
+}
+
+func TestIsCompressed(t *testing.T) {
+	for path, expected := range map[string]bool{
+		"/var/log/app.log":        false,
+		"/var/log/app.log.1":      false,
+		"/var/log/app.log.1.gz":   true,
+		"/var/log/app.log.GZ":     true,
+		"/var/log/app-2023.zst":   true,
+		"/var/log/app-2023.zstd":  true,
+		"/var/log/app-2023.gzlog": false,
+	} {
+		file := NewFile(path, sources.NewLogSource("", &config.LogsConfig{IngestCompressedFiles: true}), false)
+		assert.Equal(t, expected, file.IsCompressed(), path)
+	}
+
+	file := NewFile("/var/log/app.log.1.gz", sources.NewLogSource("", &config.LogsConfig{}), false)
+	assert.False(t, file.IsCompressed())
+}
+
+func TestTailerReadsCompressedFiles(t *testing.T) {
+	for _, name := range []string{"app.log.1.gz", "app.log.1.zst"} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+			writeArchive(t, path, archiveContent)
+
+			tailer, outputChan := newArchiveTailer(path, true)
+			assert.True(t, strings.HasPrefix(tailer.Identifier(), auditor.ArchiveIdentifierPrefix))
+			require.NoError(t, tailer.StartFromBeginning())
+
+			var msg *message.Message
+			for _, expected := range []string{"first line", "second line", "third line"} {
+				msg = <-outputChan
+				assert.Equal(t, expected, string(msg.Content))
+				assert.Equal(t, tailer.Identifier(), msg.Origin.Identifier)
+			}
+			// offsets are offsets in the decompressed content
+			assert.Equal(t, "34", msg.Origin.Offset)
+			assert.Equal(t, int64(len(archiveContent)), tailer.lastReadOffset.Load())
+			assert.Equal(t, int64(len(archiveContent)), tailer.bytesRead.Get())
+			assert.Equal(t, int64(len(archiveContent)), tailer.Source().BytesRead.Get())
+
+			// the tailer stops by itself once the archive is read
+			assert.Eventually(t, tailer.IsFinished, time.Second, 10*time.Millisecond)
+			assert.Nil(t, tailer.osFile)
+			tailer.Stop()
+		})
+	}
+}
+
+func TestTailerSkipsCompressedContentAlreadySent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log.1.gz")
+	writeArchive(t, path, archiveContent)
+
+	tailer, outputChan := newArchiveTailer(path, true)
+	require.NoError(t, tailer.Start(int64(len("first line\n")), io.SeekStart))
+	assert.Equal(t, "second line", string((<-outputChan).Content))
+	assert.Equal(t, "third line", string((<-outputChan).Content))
+	tailer.Stop()
+
+	// the whole archive has already been sent
+	tailer, outputChan = newArchiveTailer(path, true)
+	require.NoError(t, tailer.Start(int64(len(archiveContent)), io.SeekStart))
+	time.Sleep(50 * time.Millisecond)
+	tailer.Stop()
+	assert.Len(t, outputChan, 0)
+
+	// the archive was written before the logs were collected
+	tailer, outputChan = newArchiveTailer(path, true)
+	require.NoError(t, tailer.Start(0, io.SeekEnd))
+	time.Sleep(50 * time.Millisecond)
+	tailer.Stop()
+	assert.Len(t, outputChan, 0)
+}
+
+func TestTailerDetectsReplacedCompressedFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log.1.gz")
+	writeArchive(t, path, archiveContent)
+
+	tailer, _ := newArchiveTailer(path, true)
+	require.NoError(t, tailer.StartFromBeginning())
+	defer tailer.Stop()
+
+	didRotate, err := tailer.DidRotate()
+	assert.NoError(t, err)
+	assert.False(t, didRotate)
+
+	// a renamed archive keeps its identifier
+	renamed := filepath.Join(dir, "app.log.2.gz")
+	require.NoError(t, os.Rename(path, renamed))
+	renamedTailer, _ := newArchiveTailer(renamed, true)
+	assert.Equal(t, tailer.Identifier(), renamedTailer.Identifier())
+
+	writeArchive(t, path, "new content\n")
+	didRotate, err = tailer.DidRotate()
+	assert.NoError(t, err)
+	assert.True(t, didRotate)
+}
@@ -20,7 +20,11 @@ import (
 // - renamed and recreated
 // - removed and recreated
 // - truncated
+// A compressed file is rotated when it is replaced by another one.
 func (t *Tailer) DidRotate() (bool, error) {
+	if t.file.IsCompressed() {
+		return t.didArchiveChange()
+	}
 	f, err := filesystem.OpenShared(t.osFile.Name())
 	if err != nil {
 		return false, err
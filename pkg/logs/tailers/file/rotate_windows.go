@@ -15,8 +15,12 @@ import (
 // DidRotate returns true if the file has been log-rotated.
 //
 // On Windows, log rotation is identified by the file size being smaller
-// than the last offset read.
+// than the last offset read. A compressed file is rotated when it is replaced
+// by another one.
 func (t *Tailer) DidRotate() (bool, error) {
+	if t.file.IsCompressed() {
+		return t.didArchiveChange()
+	}
 	f, err := filesystem.OpenShared(t.fullpath)
 	if err != nil {
 		return false, err
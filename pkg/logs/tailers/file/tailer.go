@@ -22,6 +22,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 	"github.com/benbjohnson/clock"
 
+	"github.com/DataDog/datadog-agent/pkg/logs/auditor"
 	"github.com/DataDog/datadog-agent/pkg/logs/internal/decoder"
 	"github.com/DataDog/datadog-agent/pkg/logs/internal/status"
 	"github.com/DataDog/datadog-agent/pkg/logs/internal/tag"
@@ -60,6 +61,13 @@ type Tailer struct {
 	// is platform-specific.
 	osFile *os.File
 
+	// archive decompresses the content of osFile when the file is compressed, it is
+	// closed once the whole file has been read.
+	archive io.ReadCloser
+
+	// fingerprint identifies the content of a compressed file in the registry.
+	fingerprint string
+
 	// tags are the tags to be attached to each log message, excluding tags provided
 	// by the tag provider.
 	tags []string
@@ -185,6 +193,14 @@ func NewTailer(opts *TailerOptions) *Tailer {
 		addToTailerInfo("Last Rotation Date", getFormattedTime(), t.info)
 	}
 
+	if opts.File.IsCompressed() {
+		fingerprint, err := archiveFingerprint(opts.File.Path)
+		if err != nil {
+			log.Debugf("Could not fingerprint %s, it is identified by its path: %v", opts.File.Path, err)
+		}
+		t.fingerprint = fingerprint
+	}
+
 	return t
 }
 
@@ -219,12 +235,21 @@ func (t *Tailer) Identifier() string {
 	//
 	// This is the identifier used in the registry, so changing it will invalidate existing
 	// registry entries on upgrade.
+	if t.fingerprint != "" {
+		// compressed files are identified by their content, to not read them again once renamed
+		return auditor.ArchiveIdentifierPrefix + t.fingerprint
+	}
 	return fmt.Sprintf("file:%s", t.file.Path)
 }
 
 // Start begins the tailer's operation in a dedicated goroutine.
 func (t *Tailer) Start(offset int64, whence int) error {
-	err := t.setup(offset, whence)
+	var err error
+	if t.file.IsCompressed() {
+		err = t.setupArchive(offset, whence)
+	} else {
+		err = t.setup(offset, whence)
+	}
 	if err != nil {
 		t.file.Source.Status().Error(err)
 		return err
@@ -282,18 +307,35 @@ func (t *Tailer) StopAfterFileRotation() {
 // until it is closed or the tailer is stopped.
 func (t *Tailer) readForever() {
 	defer func() {
-		t.osFile.Close()
+		t.closeArchive()
+		if t.osFile != nil {
+			t.osFile.Close()
+		}
 		t.decoder.Stop()
 		log.Info("Closed", t.file.Path, "for tailer key", t.file.GetScanKey(), "read", t.Source().BytesRead.Get(), "bytes and", t.decoder.GetLineCount(), "lines")
 	}()
 
+	isCompressed := t.file.IsCompressed()
 	for {
-		n, err := t.read()
+		var n int
+		var err error
+		if isCompressed {
+			// readArchive records the bytes it decompressed, even when it fails afterwards
+			n, err = t.readArchive()
+		} else {
+			n, err = t.read()
+			if err == nil {
+				t.recordBytes(int64(n))
+			}
+		}
 		if err != nil {
 			return
 		}
-		t.recordBytes(int64(n))
 		t.movingSum.Add(int64(n))
+		if isCompressed && t.archive == nil {
+			// the archive was read until its end, the registry keeps it from being read again
+			return
+		}
 
 		select {
 		case <-t.stop:
@@ -12,6 +12,8 @@ import (
 	"fmt"
 	"io"
 	"regexp"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/coreos/go-systemd/sdjournal"
@@ -38,10 +40,18 @@ type Tailer struct {
 	source     *sources.LogSource
 	outputChan chan *message.Message
 	journal    Journal
-	exclude    struct {
+	// maxPriority is the highest journal priority value, i.e. the least important, collected.
+	maxPriority int
+	include     struct {
+		matchGroups []map[string]string
+		fields      map[string]bool
+	}
+	exclude struct {
 		systemUnits map[string]bool
 		userUnits   map[string]bool
 		matches     map[string]map[string]bool
+		matchGroups []map[string]string
+		fields      map[string]bool
 	}
 	stop chan struct{}
 	done chan struct{}
@@ -169,9 +179,86 @@ func (t *Tailer) setup() error {
 		t.exclude.matches[key][value] = true
 	}
 
+	// unlike the journal matches, the groups and the priority are checked once the entries are read,
+	// an entry is collected if it matches all the matches of any include group and of no exclude group.
+	var err error
+	t.include.matchGroups, err = parseMatchGroups(config.IncludeMatchGroups, matchRe)
+	if err != nil {
+		return fmt.Errorf("incorrectly formatted IncludeMatchGroups: %s", err)
+	}
+	t.exclude.matchGroups, err = parseMatchGroups(config.ExcludeMatchGroups, matchRe)
+	if err != nil {
+		return fmt.Errorf("incorrectly formatted ExcludeMatchGroups: %s", err)
+	}
+
+	t.maxPriority = maxPriority
+	if config.MinPriority != "" {
+		t.maxPriority, err = parsePriority(config.MinPriority)
+		if err != nil {
+			return err
+		}
+	}
+
+	t.include.fields = toSet(config.IncludeFields)
+	t.exclude.fields = toSet(config.ExcludeFields)
+
 	return nil
 }
 
+// parseMatchGroups parses groups of `[field]=[value]` matches.
+func parseMatchGroups(groups [][]string, matchRe *regexp.Regexp) ([]map[string]string, error) {
+	var matchGroups []map[string]string
+	for _, group := range groups {
+		matchGroup := make(map[string]string)
+		for _, match := range group {
+			submatches := matchRe.FindStringSubmatch(match)
+			if len(submatches) < 1 {
+				return nil, fmt.Errorf("match must be `[field]=[value]`: %s", match)
+			}
+			matchGroup[submatches[1]] = submatches[2]
+		}
+		if len(matchGroup) > 0 {
+			matchGroups = append(matchGroups, matchGroup)
+		}
+	}
+	return matchGroups, nil
+}
+
+// maxPriority is the value of the least important journal priority, "debug".
+const maxPriority = 7
+
+// priorityNames maps the journal priority names, as accepted by journalctl, to their values.
+var priorityNames = map[string]int{
+	"emerg":   0,
+	"alert":   1,
+	"crit":    2,
+	"err":     3,
+	"warning": 4,
+	"notice":  5,
+	"info":    6,
+	"debug":   7,
+}
+
+// parsePriority parses a journal priority given either as a name or as a value.
+func parsePriority(priority string) (int, error) {
+	if value, exists := priorityNames[strings.ToLower(priority)]; exists {
+		return value, nil
+	}
+	value, err := strconv.Atoi(priority)
+	if err != nil || value < 0 || value > maxPriority {
+		return 0, fmt.Errorf("invalid MinPriority (must be a value between 0 and 7 or a priority name): %s", priority)
+	}
+	return value, nil
+}
+
+func toSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, value := range values {
+		set[value] = true
+	}
+	return set
+}
+
 func (t *Tailer) forwardMessages() {
 	for decodedMessage := range t.decoder.OutputChan {
 		if len(decodedMessage.Content) > 0 {
@@ -259,6 +346,30 @@ func (t *Tailer) tail() {
 // shouldDrop returns true if the entry should be dropped,
 // returns false otherwise.
 func (t *Tailer) shouldDrop(entry *sdjournal.JournalEntry) bool {
+	if priority, exists := entry.Fields[sdjournal.SD_JOURNAL_FIELD_PRIORITY]; exists {
+		if value, err := strconv.Atoi(priority); err == nil && value > t.maxPriority {
+			return true
+		}
+	}
+
+	for _, group := range t.exclude.matchGroups {
+		if matchesGroup(entry, group) {
+			return true
+		}
+	}
+	if len(t.include.matchGroups) > 0 {
+		included := false
+		for _, group := range t.include.matchGroups {
+			if matchesGroup(entry, group) {
+				included = true
+				break
+			}
+		}
+		if !included {
+			return true
+		}
+	}
+
 	for key, values := range t.exclude.matches {
 		if value, ok := entry.Fields[key]; ok {
 			if _, contains := values[value]; contains {
@@ -290,6 +401,16 @@ func (t *Tailer) shouldDrop(entry *sdjournal.JournalEntry) bool {
 	return false
 }
 
+// matchesGroup returns true if the entry has all the field values of the group.
+func matchesGroup(entry *sdjournal.JournalEntry, group map[string]string) bool {
+	for key, value := range group {
+		if entry.Fields[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
 // getContent returns all the fields of the entry as a json-string,
 // remapping "MESSAGE" into "message" and bundling all the other keys in a "journald" attribute.
 // ex:
@@ -314,7 +435,7 @@ func (t *Tailer) getContent(entry *sdjournal.JournalEntry) []byte {
 		payload["message"] = message
 		delete(fields, sdjournal.SD_JOURNAL_FIELD_MESSAGE)
 	}
-	payload["journald"] = fields
+	payload["journald"] = t.selectFields(fields)
 
 	content, err := json.Marshal(payload)
 	if err != nil {
@@ -327,6 +448,25 @@ func (t *Tailer) getContent(entry *sdjournal.JournalEntry) []byte {
 	return content
 }
 
+// selectFields returns the fields sent as attributes according to the IncludeFields and ExcludeFields,
+// the fields used to compute the origin and the status of the message are left untouched.
+func (t *Tailer) selectFields(fields map[string]string) map[string]string {
+	if len(t.include.fields) == 0 && len(t.exclude.fields) == 0 {
+		return fields
+	}
+	selected := make(map[string]string)
+	for key, value := range fields {
+		if len(t.include.fields) > 0 && !t.include.fields[key] {
+			continue
+		}
+		if t.exclude.fields[key] {
+			continue
+		}
+		selected[key] = value
+	}
+	return selected
+}
+
 // getOrigin returns the message origin computed from the journal entry
 func (t *Tailer) getOrigin(entry *sdjournal.JournalEntry) *message.Origin {
 	origin := message.NewOrigin(t.source)
@@ -338,6 +478,10 @@ func (t *Tailer) getOrigin(entry *sdjournal.JournalEntry) *message.Origin {
 	applicationName := t.getApplicationName(entry, tags)
 	origin.SetSource(applicationName)
 	origin.SetService(applicationName)
+	if identifier, exists := entry.Fields[sdjournal.SD_JOURNAL_FIELD_SYSLOG_IDENTIFIER]; exists && t.source.Config.SyslogIdentifierAsService {
+		// the identifier takes precedence over the service of the integration config
+		origin.ForceService(identifier)
+	}
 	origin.SetTags(tags)
 	return origin
 }
@@ -240,6 +240,65 @@ func TestShouldDropEntry(t *testing.T) {
 
 }
 
+func TestShouldDropEntryWithPriority(t *testing.T) {
+	source := sources.NewLogSource("", &config.LogsConfig{MinPriority: "warning"})
+	tailer := NewTailer(source, nil, nil)
+	assert.Nil(t, tailer.setup())
+
+	for priority, dropped := range map[string]bool{"0": false, "3": false, "4": false, "5": true, "7": true, "foo": false} {
+		assert.Equal(t, dropped, tailer.shouldDrop(
+			&sdjournal.JournalEntry{
+				Fields: map[string]string{
+					sdjournal.SD_JOURNAL_FIELD_PRIORITY: priority,
+				},
+			}), priority)
+	}
+
+	// entries without priority are collected
+	assert.False(t, tailer.shouldDrop(&sdjournal.JournalEntry{Fields: map[string]string{}}))
+
+	source = sources.NewLogSource("", &config.LogsConfig{MinPriority: "3"})
+	tailer = NewTailer(source, nil, nil)
+	assert.Nil(t, tailer.setup())
+	assert.False(t, tailer.shouldDrop(&sdjournal.JournalEntry{Fields: map[string]string{sdjournal.SD_JOURNAL_FIELD_PRIORITY: "3"}}))
+	assert.True(t, tailer.shouldDrop(&sdjournal.JournalEntry{Fields: map[string]string{sdjournal.SD_JOURNAL_FIELD_PRIORITY: "4"}}))
+}
+
+func TestShouldDropEntryWithMatchGroups(t *testing.T) {
+	source := sources.NewLogSource("", &config.LogsConfig{
+		IncludeMatchGroups: [][]string{
+			{"_SYSTEMD_UNIT=nginx.service", "PRIORITY=3"},
+			{"_COMM=sshd"},
+		},
+		ExcludeMatchGroups: [][]string{
+			{"_COMM=sshd", "_PID=42"},
+		},
+	})
+	tailer := NewTailer(source, nil, nil)
+	assert.Nil(t, tailer.setup())
+
+	// all the matches of a group must match
+	assert.False(t, tailer.shouldDrop(&sdjournal.JournalEntry{Fields: map[string]string{"_SYSTEMD_UNIT": "nginx.service", "PRIORITY": "3"}}))
+	assert.True(t, tailer.shouldDrop(&sdjournal.JournalEntry{Fields: map[string]string{"_SYSTEMD_UNIT": "nginx.service", "PRIORITY": "6"}}))
+	// any include group can match
+	assert.False(t, tailer.shouldDrop(&sdjournal.JournalEntry{Fields: map[string]string{"_COMM": "sshd", "_PID": "1"}}))
+	assert.True(t, tailer.shouldDrop(&sdjournal.JournalEntry{Fields: map[string]string{"_COMM": "bash"}}))
+	// exclude groups take precedence
+	assert.True(t, tailer.shouldDrop(&sdjournal.JournalEntry{Fields: map[string]string{"_COMM": "sshd", "_PID": "42"}}))
+}
+
+func TestSetupShouldFailWithInvalidFilters(t *testing.T) {
+	for _, config := range []*config.LogsConfig{
+		{MinPriority: "8"},
+		{MinPriority: "foo"},
+		{IncludeMatchGroups: [][]string{{"_COMM=sshd", "foo"}}},
+		{ExcludeMatchGroups: [][]string{{"=foo"}}},
+	} {
+		tailer := NewTailer(sources.NewLogSource("", config), nil, nil)
+		assert.NotNil(t, tailer.setup())
+	}
+}
+
 func TestApplicationName(t *testing.T) {
 	source := sources.NewLogSource("", &config.LogsConfig{})
 	tailer := NewTailer(source, nil, nil)
@@ -309,6 +368,59 @@ func TestContent(t *testing.T) {
 		}))
 }
 
+func TestContentWithFieldSelection(t *testing.T) {
+	entry := func() *sdjournal.JournalEntry {
+		return &sdjournal.JournalEntry{
+			Fields: map[string]string{
+				sdjournal.SD_JOURNAL_FIELD_MESSAGE:           "bar",
+				sdjournal.SD_JOURNAL_FIELD_PRIORITY:          "3",
+				sdjournal.SD_JOURNAL_FIELD_SYSLOG_IDENTIFIER: "foo",
+				"_A": "foo.service",
+				"_B": "baz",
+			},
+		}
+	}
+
+	source := sources.NewLogSource("", &config.LogsConfig{IncludeFields: []string{"_A", "_B"}, ExcludeFields: []string{"_B"}})
+	tailer := NewTailer(source, nil, &MockJournal{m: &sync.Mutex{}})
+	assert.Nil(t, tailer.setup())
+
+	e := entry()
+	assert.Equal(t, []byte(`{"journald":{"_A":"foo.service"},"message":"bar"}`), tailer.getContent(e))
+	// the fields which are not selected are still used for the status and the origin
+	assert.Equal(t, message.StatusError, tailer.getStatus(e))
+	assert.Equal(t, "foo", tailer.getOrigin(e).Service())
+
+	source = sources.NewLogSource("", &config.LogsConfig{ExcludeFields: []string{"_B", sdjournal.SD_JOURNAL_FIELD_PRIORITY}})
+	tailer = NewTailer(source, nil, &MockJournal{m: &sync.Mutex{}})
+	assert.Nil(t, tailer.setup())
+	assert.Equal(t, []byte(`{"journald":{"SYSLOG_IDENTIFIER":"foo","_A":"foo.service"},"message":"bar"}`), tailer.getContent(entry()))
+}
+
+func TestSyslogIdentifierAsService(t *testing.T) {
+	entry := &sdjournal.JournalEntry{
+		Fields: map[string]string{
+			sdjournal.SD_JOURNAL_FIELD_SYSLOG_IDENTIFIER: "foo",
+			sdjournal.SD_JOURNAL_FIELD_SYSTEMD_UNIT:      "foo.service",
+		},
+	}
+	noIdentifierEntry := &sdjournal.JournalEntry{
+		Fields: map[string]string{
+			sdjournal.SD_JOURNAL_FIELD_SYSTEMD_UNIT: "foo.service",
+		},
+	}
+
+	// the service of the integration config takes precedence by default
+	source := sources.NewLogSource("", &config.LogsConfig{Service: "my-service"})
+	tailer := NewTailer(source, nil, &MockJournal{m: &sync.Mutex{}})
+	assert.Equal(t, "my-service", tailer.getOrigin(entry).Service())
+
+	source = sources.NewLogSource("", &config.LogsConfig{Service: "my-service", SyslogIdentifierAsService: true})
+	tailer = NewTailer(source, nil, &MockJournal{m: &sync.Mutex{}})
+	assert.Equal(t, "foo", tailer.getOrigin(entry).Service())
+	assert.Equal(t, "my-service", tailer.getOrigin(noIdentifierEntry).Service())
+}
+
 func TestSeverity(t *testing.T) {
 	source := sources.NewLogSource("", &config.LogsConfig{})
 	tailer := NewTailer(source, nil, nil)
@@ -464,3 +576,34 @@ func TestTailerCanTailJournal(t *testing.T) {
 
 	tailer.Stop()
 }
+
+func TestTailerCanTailJournalWithFilters(t *testing.T) {
+
+	mockJournal := &MockJournal{m: &sync.Mutex{}, next: 1}
+	source := sources.NewLogSource("", &config.LogsConfig{
+		Service:                   "my-service",
+		MinPriority:               "err",
+		IncludeMatchGroups:        [][]string{{"_COMM=foo.sh"}},
+		IncludeFields:             []string{"_COMM"},
+		SyslogIdentifierAsService: true,
+	})
+	tailer := NewTailer(source, make(chan *message.Message, 1), mockJournal)
+
+	mockJournal.entry = &sdjournal.JournalEntry{Fields: map[string]string{
+		"MESSAGE":           "foobar",
+		"PRIORITY":          "2",
+		"SYSLOG_IDENTIFIER": "foo",
+		"_COMM":             "foo.sh",
+		"_PID":              "42",
+	}}
+
+	tailer.Start("")
+
+	resultMessage := <-tailer.outputChan
+
+	assert.Equal(t, `{"journald":{"_COMM":"foo.sh"},"message":"foobar"}`, string(resultMessage.Content))
+	assert.Equal(t, message.StatusCritical, resultMessage.GetStatus())
+	assert.Equal(t, "foo", resultMessage.Origin.Service())
+
+	tailer.Stop()
+}
@@ -11,8 +11,11 @@ import (
 
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 
+	"github.com/DataDog/datadog-agent/comp/logs/agent/config"
 	"github.com/DataDog/datadog-agent/pkg/logs/internal/decoder"
+	"github.com/DataDog/datadog-agent/pkg/logs/internal/framer"
 	"github.com/DataDog/datadog-agent/pkg/logs/internal/parsers/noop"
+	"github.com/DataDog/datadog-agent/pkg/logs/internal/parsers/syslog"
 	"github.com/DataDog/datadog-agent/pkg/logs/internal/status"
 	"github.com/DataDog/datadog-agent/pkg/logs/message"
 	"github.com/DataDog/datadog-agent/pkg/logs/sources"
@@ -37,13 +40,21 @@ func NewTailer(source *sources.LogSource, conn net.Conn, outputChan chan *messag
 		Conn:       conn,
 		outputChan: outputChan,
 		read:       read,
-		// tailer info is currently unused for this tailer type.
-		decoder: decoder.InitializeDecoder(sources.NewReplaceableSource(source), noop.New(), status.NewInfoRegistry()),
-		stop:    make(chan struct{}, 1),
-		done:    make(chan struct{}, 1),
+		decoder:    buildDecoder(source),
+		stop:       make(chan struct{}, 1),
+		done:       make(chan struct{}, 1),
 	}
 }
 
+// buildDecoder returns a decoder matching the format of the source.
+func buildDecoder(source *sources.LogSource) *decoder.Decoder {
+	// tailer info is currently unused for this tailer type.
+	if source.Config.Format == config.SyslogFormat {
+		return decoder.NewDecoderWithFraming(sources.NewReplaceableSource(source), syslog.New(), framer.SyslogStream, nil, status.NewInfoRegistry())
+	}
+	return decoder.InitializeDecoder(sources.NewReplaceableSource(source), noop.New(), status.NewInfoRegistry())
+}
+
 // Start prepares the tailer to read and decode data from the connection
 func (t *Tailer) Start() {
 	go t.forwardMessages()
@@ -66,7 +77,11 @@ func (t *Tailer) forwardMessages() {
 	}()
 	for output := range t.decoder.OutputChan {
 		if len(output.Content) > 0 {
-			t.outputChan <- message.NewMessageWithSource(output.Content, message.StatusInfo, t.source, output.IngestionTimestamp)
+			msg := message.NewMessageWithSource(output.Content, output.GetStatus(), t.source, output.IngestionTimestamp)
+			if len(output.ParsingExtra.Tags) > 0 {
+				msg.Origin.SetTags(output.ParsingExtra.Tags)
+			}
+			t.outputChan <- msg
 		}
 	}
 }
@@ -40,6 +40,27 @@ func TestReadAndForwardShouldSucceedWithSuccessfulRead(t *testing.T) {
 	tailer.Stop()
 }
 
+func TestReadAndForwardShouldParseSyslog(t *testing.T) {
+	msgChan := make(chan *message.Message)
+	r, w := net.Pipe()
+	tailer := NewTailer(sources.NewLogSource("", &config.LogsConfig{Format: config.SyslogFormat}), r, msgChan, read)
+	tailer.Start()
+
+	var msg *message.Message
+
+	// should decode newline-terminated and octet-counted messages
+	w.Write([]byte("<11>1 - router1 app - - - foo\n" + "27 <12>Oct 11 22:14:15 bar\nbaz"))
+	msg = <-msgChan
+	assert.Equal(t, `{"message":"foo","syslog":{"hostname":"router1","appname":"app","facility":1,"severity":3,"version":1}}`, string(msg.Content))
+	assert.Equal(t, message.StatusError, msg.GetStatus())
+	assert.Equal(t, []string{"syslog_facility:user", "syslog_hostname:router1", "syslog_appname:app"}, msg.Origin.Tags())
+	msg = <-msgChan
+	assert.Contains(t, string(msg.Content), `"message":"bar\nbaz"`)
+	assert.Equal(t, message.StatusWarning, msg.GetStatus())
+
+	tailer.Stop()
+}
+
 func TestReadShouldFailWithError(t *testing.T) {
 	msgChan := make(chan *message.Message)
 	r, w := net.Pipe()
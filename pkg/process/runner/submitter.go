@@ -34,6 +34,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/process/util/api"
 	apicfg "github.com/DataDog/datadog-agent/pkg/process/util/api/config"
 	"github.com/DataDog/datadog-agent/pkg/process/util/api/headers"
+	"github.com/DataDog/datadog-agent/pkg/util/compression"
 	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/clustername"
 	"github.com/DataDog/datadog-agent/pkg/version"
 )
@@ -65,6 +66,11 @@ type CheckSubmitter struct {
 	orchestrator *oconfig.OrchestratorConfig
 	hostname     string
 
+	// The compression of the process and orchestrator payloads, nil when they keep the one of their
+	// message encoding
+	processCompressor      compression.Compressor
+	orchestratorCompressor compression.Compressor
+
 	exit chan struct{}
 	wg   *sync.WaitGroup
 
@@ -159,6 +165,9 @@ func NewSubmitter(config config.Component, log log.Component, forwarders forward
 		orchestrator: orchestrator,
 		hostname:     hostname,
 
+		processCompressor:      api.NewPayloadCompressor(config, "process"),
+		orchestratorCompressor: api.NewPayloadCompressor(config, "orchestrator"),
+
 		dropCheckPayloads: dropCheckPayloads,
 
 		forwarderRetryMaxQueueBytes: queueBytes,
@@ -446,8 +455,13 @@ func (s *CheckSubmitter) messagesToCheckResult(start time.Time, name string, mes
 	payloads := make([]checkPayload, 0, len(messages))
 	sizeInBytes := 0
 
+	compressor := s.processCompressor
+	if name == checks.PodCheckName || name == checks.PodCheckManifestName {
+		compressor = s.orchestratorCompressor
+	}
+
 	for messageIndex, m := range messages {
-		body, err := api.EncodePayload(m)
+		body, err := api.EncodePayloadWithCompressor(m, compressor)
 		if err != nil {
 			s.log.Errorf("Unable to encode message: %s", err)
 			continue
@@ -461,6 +475,9 @@ func (s *CheckSubmitter) messagesToCheckResult(start time.Time, name string, mes
 		extraHeaders.Set(headers.ContainerCountHeader, strconv.Itoa(getContainerCount(m)))
 		extraHeaders.Set(headers.ContentTypeHeader, headers.ProtobufContentType)
 		extraHeaders.Set(headers.AgentStartTime, strconv.FormatInt(s.agentStartTime, 10))
+		if compressor != nil && compressor.ContentEncoding() != "" {
+			extraHeaders.Set(headers.ContentEncodingHeader, compressor.ContentEncoding())
+		}
 
 		if s.orchestrator.OrchestrationCollectionEnabled {
 			if cid, err := clustername.GetClusterID(); err == nil && cid != "" {
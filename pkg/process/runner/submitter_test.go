@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/fx"
 
 	model "github.com/DataDog/agent-payload/v5/process"
@@ -18,7 +19,9 @@ import (
 	"github.com/DataDog/datadog-agent/comp/core/log"
 	"github.com/DataDog/datadog-agent/comp/process/forwarders"
 	ddconfig "github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/process/checks"
 	"github.com/DataDog/datadog-agent/pkg/process/util/api/headers"
+	"github.com/DataDog/datadog-agent/pkg/util/compression"
 	"github.com/DataDog/datadog-agent/pkg/util/fxutil"
 	"github.com/DataDog/datadog-agent/pkg/version"
 )
@@ -292,6 +295,41 @@ func TestCollectorMessagesToCheckResult(t *testing.T) {
 	}
 }
 
+func TestCollectorMessagesToCheckResultWithCompression(t *testing.T) {
+	mockConfig := ddconfig.Mock(t)
+	mockConfig.Set("serializer_compression.process.kind", "zstd")
+	deps := newSubmitterDepsWithConfig(t, mockConfig)
+	submitter, err := NewSubmitter(deps.Config, deps.Log, deps.Forwarders, testHostName)
+	require.NoError(t, err)
+
+	now := time.Now()
+	message := &model.CollectorProc{HostName: testHostName}
+
+	// the process payloads are compressed with the configured compression
+	result := submitter.messagesToCheckResult(now, checks.ProcessCheckName, []model.MessageBody{message})
+	require.Len(t, result.payloads, 1)
+	payload := result.payloads[0]
+	assert.Equal(t, "zstd", payload.headers.Get(headers.ContentEncodingHeader))
+
+	compressor, err := compression.NewCompressor(compression.ZstdKind, 0)
+	require.NoError(t, err)
+	body, err := compressor.Decompress(payload.body)
+	require.NoError(t, err)
+	decoded, err := model.DecodeMessage(body)
+	require.NoError(t, err)
+	assert.Equal(t, model.MessageEncodingProtobuf, decoded.Header.Encoding)
+	assert.Equal(t, message, decoded.Body)
+
+	// the orchestrator payloads have no configured compression and keep their message encoding
+	result = submitter.messagesToCheckResult(now, checks.PodCheckName, []model.MessageBody{&model.CollectorPod{HostName: testHostName}})
+	require.Len(t, result.payloads, 1)
+	payload = result.payloads[0]
+	assert.Empty(t, payload.headers.Get(headers.ContentEncodingHeader))
+	decoded, err = model.DecodeMessage(payload.body)
+	require.NoError(t, err)
+	assert.Equal(t, model.MessageEncodingZstdPB, decoded.Header.Encoding)
+}
+
 func Test_getRequestID(t *testing.T) {
 	deps := newSubmitterDeps(t)
 	s, err := NewSubmitter(deps.Config, deps.Log, deps.Forwarders, testHostName)
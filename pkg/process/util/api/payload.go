@@ -11,7 +11,10 @@ import (
 	model "github.com/DataDog/agent-payload/v5/process"
 	"github.com/gogo/protobuf/proto"
 
+	ddconfig "github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/util/compression"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
 var (
@@ -21,8 +24,32 @@ var (
 		[]string{"type"}, "Count of bytes after encoding payload")
 )
 
+// NewPayloadCompressor returns the compressor configured with `serializer_compression` for a
+// kind of payload (`process` or `orchestrator`). It returns nil when no compression kind is
+// configured or when the configuration is invalid: the payloads then keep the zstd compression
+// of their message encoding.
+func NewPayloadCompressor(cfg ddconfig.Reader, payload string) compression.Compressor {
+	kind, level := ddconfig.GetSerializerCompression(cfg, payload)
+	if kind == "" {
+		return nil
+	}
+	compressor, err := compression.NewCompressor(kind, level)
+	if err != nil {
+		log.Errorf("invalid compression for the %s payloads, using their message encoding: %v", payload, err)
+		return nil
+	}
+	return compressor
+}
+
 // EncodePayload encodes a process message into a payload
 func EncodePayload(m model.MessageBody) ([]byte, error) {
+	return EncodePayloadWithCompressor(m, nil)
+}
+
+// EncodePayloadWithCompressor encodes a process message into a payload compressed with the given
+// compressor, the message itself is then encoded without compression. The payload must be sent
+// with the content encoding of the compressor. A nil compressor keeps the zstd message encoding.
+func EncodePayloadWithCompressor(m model.MessageBody, compressor compression.Compressor) ([]byte, error) {
 	msgType, err := model.DetectMessageType(m)
 	if err != nil {
 		return nil, fmt.Errorf("unable to detect message type: %s", err)
@@ -36,7 +63,9 @@ func EncodePayload(m model.MessageBody) ([]byte, error) {
 		encoded, err = proto.Marshal(m)
 	} else {
 		encoding := model.MessageEncodingZstdPB
-		if msgType == model.TypeCollectorConnections {
+		if compressor != nil {
+			encoding = model.MessageEncodingProtobuf
+		} else if msgType == model.TypeCollectorConnections {
 			encoding = model.MessageEncodingZstd1xPB
 		}
 		encoded, err = model.EncodeMessage(model.Message{
@@ -46,6 +75,9 @@ func EncodePayload(m model.MessageBody) ([]byte, error) {
 				Type:     msgType,
 			}, Body: m})
 	}
+	if err == nil && compressor != nil {
+		encoded, err = compressor.Compress(encoded)
+	}
 
 	tlmBytesOut.Add(float64(len(encoded)), typeTag)
 
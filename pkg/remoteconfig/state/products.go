@@ -18,6 +18,8 @@ var validProducts = map[string]struct{}{
 	ProductASMDD:             {},
 	ProductASMData:           {},
 	ProductAPMTracing:        {},
+	ProductAPMSpanRules:      {},
+	ProductMetricControl:     {},
 }
 
 const (
@@ -45,4 +47,8 @@ const (
 	ProductASMData = "ASM_DATA"
 	// ProductAPMTracing is the apm tracing product
 	ProductAPMTracing = "APM_TRACING"
+	// ProductAPMSpanRules is to receive the rules the trace-agent applies to the spans
+	ProductAPMSpanRules = "APM_SPAN_RULES"
+	// ProductMetricControl is to receive the dogstatsd metric blocklist and mapper profiles
+	ProductMetricControl = "METRIC_CONTROL"
 )
@@ -0,0 +1,25 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2022-present Datadog, Inc.
+
+package apmspanrules
+
+// SpanRulesConfig holds the rules the trace-agent applies to the spans after computing the stats.
+type SpanRulesConfig struct {
+	Rules []SpanRule `json:"rules"`
+}
+
+// SpanRule is an action applied to the spans matching the service, name and resource patterns
+// and having all the tags.
+type SpanRule struct {
+	Service   string   `json:"service"`
+	Name      string   `json:"name"`
+	Resource  string   `json:"resource"`
+	Tags      []string `json:"tags"`
+	Action    string   `json:"action"`
+	Key       string   `json:"key"`
+	Value     string   `json:"value"`
+	NewKey    string   `json:"new_key"`
+	MaxLength int      `json:"max_length"`
+}
@@ -324,7 +324,7 @@ func BenchmarkCreateSingleMarshalerOneEventBySource(b *testing.B) {
 
 func benchmarkCreateSingleMarshaler(b *testing.B, createEvents func(numberOfItem int) Events) {
 	runBenchmark(b, func(b *testing.B, numberOfItem int) {
-		payloadBuilder := stream.NewJSONPayloadBuilder(true)
+		payloadBuilder := stream.NewJSONPayloadBuilder(true, testCompressor)
 		events := createEvents(numberOfItem)
 
 		b.ResetTimer()
@@ -337,7 +337,7 @@ func benchmarkCreateSingleMarshaler(b *testing.B, createEvents func(numberOfItem
 
 func BenchmarkCreateMarshalersBySourceType(b *testing.B) {
 	runBenchmark(b, func(b *testing.B, numberOfItem int) {
-		payloadBuilder := stream.NewJSONPayloadBuilder(true)
+		payloadBuilder := stream.NewJSONPayloadBuilder(true, testCompressor)
 		events := createBenchmarkEvents(numberOfItem)
 
 		b.ResetTimer()
@@ -352,7 +352,7 @@ func BenchmarkCreateMarshalersBySourceType(b *testing.B) {
 
 func BenchmarkCreateMarshalersSeveralSourceTypes(b *testing.B) {
 	runBenchmark(b, func(b *testing.B, numberOfItem int) {
-		payloadBuilder := stream.NewJSONPayloadBuilder(true)
+		payloadBuilder := stream.NewJSONPayloadBuilder(true, testCompressor)
 
 		var events Events
 		// Half of events have the same source type
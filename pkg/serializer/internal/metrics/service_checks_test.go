@@ -76,7 +76,7 @@ func createServiceCheck(checkName string) *servicecheck.ServiceCheck {
 }
 
 func buildPayload(t *testing.T, m marshaler.StreamJSONMarshaler) [][]byte {
-	builder := stream.NewJSONPayloadBuilder(true)
+	builder := stream.NewJSONPayloadBuilder(true, testCompressor)
 	payloads, err := stream.BuildJSONPayload(builder, m)
 	assert.NoError(t, err)
 	var uncompressedPayloads [][]byte
@@ -168,7 +168,7 @@ func decompressPayload(payload []byte) ([]byte, error) {
 }
 
 func benchmarkJSONPayloadBuilderServiceCheck(b *testing.B, numberOfItem int) {
-	payloadBuilder := stream.NewJSONPayloadBuilder(true)
+	payloadBuilder := stream.NewJSONPayloadBuilder(true, testCompressor)
 	serviceChecks := createServiceChecks(numberOfItem)
 
 	b.ResetTimer()
@@ -209,7 +209,7 @@ func benchmarkPayloadsServiceCheck(b *testing.B, numberOfItem int) {
 	b.ResetTimer()
 
 	for n := 0; n < b.N; n++ {
-		split.Payloads(serviceChecks, true, split.JSONMarshalFct)
+		split.Payloads(serviceChecks, true, split.JSONMarshalFct, testCompressor)
 	}
 }
 
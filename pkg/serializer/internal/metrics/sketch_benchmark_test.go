@@ -28,7 +28,7 @@ func benchmarkSplitPayloadsSketchesSplit(b *testing.B, numPoints int) {
 	b.ResetTimer()
 
 	for n := 0; n < b.N; n++ {
-		split.Payloads(serializer, true, split.ProtoMarshalFct)
+		split.Payloads(serializer, true, split.ProtoMarshalFct, testCompressor)
 	}
 }
 
@@ -42,7 +42,7 @@ func benchmarkSplitPayloadsSketchesNew(b *testing.B, numPoints int) {
 	b.ResetTimer()
 
 	for n := 0; n < b.N; n++ {
-		payloads, err := serializer.MarshalSplitCompress(marshaler.NewBufferContext())
+		payloads, err := serializer.MarshalSplitCompress(marshaler.NewBufferContext(), testCompressor)
 		require.NoError(b, err)
 		var pb int
 		for _, p := range payloads {
@@ -94,7 +94,7 @@ func TestSketchSeriesMarshalSplitCompressEmpty(t *testing.T) {
 
 	sl := SketchSeriesList{SketchesSource: metrics.NewSketchesSourceTest()}
 	payload, _ := sl.Marshal()
-	payloads, err := sl.MarshalSplitCompress(marshaler.NewBufferContext())
+	payloads, err := sl.MarshalSplitCompress(marshaler.NewBufferContext(), testCompressor)
 
 	assert.Nil(t, err)
 
@@ -128,7 +128,7 @@ func TestSketchSeriesMarshalSplitCompressItemTooBigIsDropped(t *testing.T) {
 	})
 
 	serializer := SketchSeriesList{SketchesSource: sl}
-	payloads, err := serializer.MarshalSplitCompress(marshaler.NewBufferContext())
+	payloads, err := serializer.MarshalSplitCompress(marshaler.NewBufferContext(), testCompressor)
 
 	assert.Nil(t, err)
 
@@ -159,7 +159,7 @@ func TestSketchSeriesMarshalSplitCompress(t *testing.T) {
 	payload, _ := serializer1.Marshal()
 	sl.Reset()
 	serializer2 := SketchSeriesList{SketchesSource: sl}
-	payloads, err := serializer2.MarshalSplitCompress(marshaler.NewBufferContext())
+	payloads, err := serializer2.MarshalSplitCompress(marshaler.NewBufferContext(), testCompressor)
 	require.NoError(t, err)
 
 	firstPayload := payloads[0]
@@ -209,7 +209,7 @@ func TestSketchSeriesMarshalSplitCompressSplit(t *testing.T) {
 	}
 
 	serializer := SketchSeriesList{SketchesSource: sl}
-	payloads, err := serializer.MarshalSplitCompress(marshaler.NewBufferContext())
+	payloads, err := serializer.MarshalSplitCompress(marshaler.NewBufferContext(), testCompressor)
 	assert.Nil(t, err)
 
 	recoveredSketches := []gogen.SketchPayload{}
@@ -13,9 +13,13 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/aggregator/ckey"
 	"github.com/DataDog/datadog-agent/pkg/metrics"
 	"github.com/DataDog/datadog-agent/pkg/tagset"
+	"github.com/DataDog/datadog-agent/pkg/util/compression"
 	"github.com/DataDog/opentelemetry-mapping-go/pkg/quantile"
 )
 
+// testCompressor is the compression the agent was built with
+var testCompressor, _ = compression.NewCompressor("", 0)
+
 // Makeseries creates a metrics.SketchSeries with i+5 Sketch Points
 func Makeseries(i int) *metrics.SketchSeries {
 	// Makeseries is deterministic so that we can test for mutation.
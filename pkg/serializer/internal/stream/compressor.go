@@ -9,7 +9,6 @@ package stream
 
 import (
 	"bytes"
-	"compress/zlib"
 	"errors"
 	"expvar"
 
@@ -62,7 +61,8 @@ func init() {
 type Compressor struct {
 	input               *bytes.Buffer // temporary buffer for data that has not been compressed yet
 	compressed          *bytes.Buffer // output buffer containing the compressed payload
-	zipper              *zlib.Writer
+	compressor          compression.Compressor
+	zipper              compression.StreamWriter
 	header              []byte // json header to print at the beginning of the payload
 	footer              []byte // json footer to append at the end of the payload
 	uncompressedWritten int    // uncompressed bytes written
@@ -75,9 +75,10 @@ type Compressor struct {
 	separator           []byte
 }
 
-// NewCompressor returns a new instance of a Compressor
-func NewCompressor(input, output *bytes.Buffer, maxPayloadSize, maxUncompressedSize int, header, footer []byte, separator []byte) (*Compressor, error) {
+// NewCompressor returns a new instance of a Compressor, compressing the payload with the given compression
+func NewCompressor(input, output *bytes.Buffer, maxPayloadSize, maxUncompressedSize int, header, footer []byte, separator []byte, compressor compression.Compressor) (*Compressor, error) {
 	c := &Compressor{
+		compressor:          compressor,
 		header:              header,
 		footer:              footer,
 		input:               input,
@@ -86,11 +87,15 @@ func NewCompressor(input, output *bytes.Buffer, maxPayloadSize, maxUncompressedS
 		maxPayloadSize:      maxPayloadSize,
 		maxUncompressedSize: maxUncompressedSize,
 		maxUnzippedItemSize: maxPayloadSize - len(footer) - len(header),
-		maxZippedItemSize:   maxUncompressedSize - compression.CompressBound(len(footer)+len(header)),
+		maxZippedItemSize:   maxUncompressedSize - compressor.CompressBound(len(footer)+len(header)),
 		separator:           separator,
 	}
 
-	c.zipper = zlib.NewWriter(c.compressed)
+	zipper, err := compressor.NewStreamWriter(c.compressed)
+	if err != nil {
+		return nil, err
+	}
+	c.zipper = zipper
 	n, err := c.zipper.Write(header)
 	c.uncompressedWritten += n
 
@@ -103,7 +108,7 @@ func NewCompressor(input, output *bytes.Buffer, maxPayloadSize, maxUncompressedS
 // to have a 2MB+ item that is valid for the backend.
 func (c *Compressor) checkItemSize(data []byte) bool {
 	maxEffectivePayloadSize := (c.maxPayloadSize - len(c.footer) - len(c.header))
-	compressedWillFit := compression.CompressBound(len(data)) < c.maxZippedItemSize && compression.CompressBound(len(data)) < maxEffectivePayloadSize
+	compressedWillFit := c.compressor.CompressBound(len(data)) < c.maxZippedItemSize && c.compressor.CompressBound(len(data)) < maxEffectivePayloadSize
 
 	return len(data) < c.maxUnzippedItemSize && compressedWillFit
 }
@@ -114,7 +119,7 @@ func (c *Compressor) hasRoomForItem(item []byte) bool {
 	if !c.firstItem {
 		uncompressedDataSize += len(c.separator)
 	}
-	return compression.CompressBound(uncompressedDataSize) <= c.remainingSpace() && c.uncompressedWritten+uncompressedDataSize <= c.maxUncompressedSize
+	return c.compressor.CompressBound(uncompressedDataSize) <= c.remainingSpace() && c.uncompressedWritten+uncompressedDataSize <= c.maxUncompressedSize
 }
 
 // pack flushes the temporary uncompressed buffer input to the compression writer
@@ -188,7 +193,7 @@ func (c *Compressor) Close() ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
-	// Add zlib footer and close
+	// Add the compression footer and close
 	err = c.zipper.Close()
 	if err != nil {
 		return nil, err
@@ -20,10 +20,12 @@ import (
 
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/serializer/marshaler"
+	"github.com/DataDog/datadog-agent/pkg/util/compression"
 )
 
 var (
 	maxPayloadSizeDefault = config.Datadog.GetInt("serializer_max_payload_size")
+	testCompressor, _     = compression.NewCompressor(compression.ZlibKind, 0)
 )
 
 func resetDefaults() {
@@ -58,7 +60,7 @@ func TestCompressorSimple(t *testing.T) {
 	c, err := NewCompressor(
 		&bytes.Buffer{}, &bytes.Buffer{},
 		maxPayloadSize, maxUncompressedSize,
-		[]byte("{["), []byte("]}"), []byte(","))
+		[]byte("{["), []byte("]}"), []byte(","), testCompressor)
 	require.NoError(t, err)
 
 	for i := 0; i < 5; i++ {
@@ -78,7 +80,7 @@ func TestCompressorAddItemErrCodeWithEmptyCompressor(t *testing.T) {
 		c, err := NewCompressor(
 			&bytes.Buffer{}, &bytes.Buffer{},
 			maxPayloadSize, maxUncompressedSize,
-			[]byte("{["), []byte("]}"), []byte(","))
+			[]byte("{["), []byte("]}"), []byte(","), testCompressor)
 		require.NoError(t, err)
 
 		payload := strings.Repeat("A", dataLen)
@@ -113,7 +115,7 @@ func TestOnePayloadSimple(t *testing.T) {
 		Footer: "]}",
 	}
 
-	builder := NewJSONPayloadBuilder(true)
+	builder := NewJSONPayloadBuilder(true, testCompressor)
 	payloads, err := BuildJSONPayload(builder, m)
 	require.NoError(t, err)
 	require.Len(t, payloads, 1)
@@ -130,7 +132,7 @@ func TestMaxCompressedSizePayload(t *testing.T) {
 	config.Datadog.SetDefault("serializer_max_payload_size", 22)
 	defer resetDefaults()
 
-	builder := NewJSONPayloadBuilder(true)
+	builder := NewJSONPayloadBuilder(true, testCompressor)
 	payloads, err := BuildJSONPayload(builder, m)
 	require.NoError(t, err)
 	require.Len(t, payloads, 1)
@@ -147,7 +149,7 @@ func TestTwoPayload(t *testing.T) {
 	config.Datadog.SetDefault("serializer_max_payload_size", 22)
 	defer resetDefaults()
 
-	builder := NewJSONPayloadBuilder(true)
+	builder := NewJSONPayloadBuilder(true, testCompressor)
 	payloads, err := BuildJSONPayload(builder, m)
 	require.NoError(t, err)
 	require.Len(t, payloads, 2)
@@ -164,8 +166,8 @@ func TestLockedCompressorProducesSamePayloads(t *testing.T) {
 	}
 	defer resetDefaults()
 
-	builderLocked := NewJSONPayloadBuilder(true)
-	builderUnLocked := NewJSONPayloadBuilder(false)
+	builderLocked := NewJSONPayloadBuilder(true, testCompressor)
+	builderUnLocked := NewJSONPayloadBuilder(false, testCompressor)
 	payloads1, err := BuildJSONPayload(builderLocked, m)
 	require.NoError(t, err)
 	payloads2, err := BuildJSONPayload(builderUnLocked, m)
@@ -178,7 +180,7 @@ func TestBuildWithOnErrItemTooBigPolicyMetadata(t *testing.T) {
 	config.Datadog.Set("serializer_max_uncompressed_payload_size", 40)
 	defer config.Datadog.Set("serializer_max_uncompressed_payload_size", nil)
 	marshaler := &IterableStreamJSONMarshalerMock{index: 0, maxIndex: 100}
-	builder := NewJSONPayloadBuilder(false)
+	builder := NewJSONPayloadBuilder(false, testCompressor)
 	payloads, err := builder.BuildWithOnErrItemTooBigPolicy(
 		marshaler,
 		DropItemOnErrItemTooBig)
@@ -213,3 +215,26 @@ func (i *IterableStreamJSONMarshalerMock) MoveNext() bool {
 	i.index++
 	return i.index < i.maxIndex
 }
+
+func TestCompressorZstd(t *testing.T) {
+	zstdCompressor, err := compression.NewCompressor(compression.ZstdKind, 0)
+	require.NoError(t, err)
+
+	maxPayloadSize := config.Datadog.GetInt("serializer_max_payload_size")
+	maxUncompressedSize := config.Datadog.GetInt("serializer_max_uncompressed_payload_size")
+	c, err := NewCompressor(
+		&bytes.Buffer{}, &bytes.Buffer{},
+		maxPayloadSize, maxUncompressedSize,
+		[]byte("{["), []byte("]}"), []byte(","), zstdCompressor)
+	require.NoError(t, err)
+
+	for i := 0; i < 5; i++ {
+		require.NoError(t, c.AddItem([]byte("A")))
+	}
+
+	p, err := c.Close()
+	require.NoError(t, err)
+	decompressed, err := zstdCompressor.Decompress(p)
+	require.NoError(t, err)
+	require.Equal(t, "{[A,A,A,A,A]}", string(decompressed))
+}
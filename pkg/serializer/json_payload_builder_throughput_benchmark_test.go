@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/serializer/internal/stream"
+	"github.com/DataDog/datadog-agent/pkg/util/compression"
 )
 
 func benchmarkJSONPayloadBuilderThroughput(points int, items int, tags int, runs int) { //nolint:unuse
@@ -21,7 +22,8 @@ func benchmarkJSONPayloadBuilderThroughput(points int, items int, tags int, runs
 	initialSize := len(json)
 	metricsCount := len(series)
 
-	payloadBuilder := stream.NewJSONPayloadBuilder(true)
+	compressor, _ := compression.NewCompressor("", 0)
+	payloadBuilder := stream.NewJSONPayloadBuilder(true, compressor)
 	var totalTime time.Duration
 
 	for i := 0; i < runs; i++ {
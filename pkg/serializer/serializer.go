@@ -87,6 +87,37 @@ func initExtraHeaders() {
 	}
 }
 
+// payloadCompression is the compression of a kind of payload, with the extra headers of
+// its compressed JSON and protobuf payloads
+type payloadCompression struct {
+	compressor                          compression.Compressor
+	jsonExtraHeadersWithCompression     http.Header
+	protobufExtraHeadersWithCompression http.Header
+}
+
+// newPayloadCompression returns the compression configured for a kind of payload, the
+// settings of the payload override the ones of all the payloads. It falls back to the
+// compression the agent was built with when the configuration is invalid.
+func newPayloadCompression(payload string) *payloadCompression {
+	kind, level := config.GetSerializerCompression(config.Datadog, payload)
+	compressor, err := compression.NewCompressor(kind, level)
+	if err != nil {
+		log.Errorf("invalid compression for the %s payloads, using the default one: %v", payload, err)
+		compressor, _ = compression.NewCompressor("", 0)
+	}
+
+	pc := &payloadCompression{
+		compressor:                          compressor,
+		jsonExtraHeadersWithCompression:     jsonExtraHeaders.Clone(),
+		protobufExtraHeadersWithCompression: protobufExtraHeaders.Clone(),
+	}
+	if contentEncoding := compressor.ContentEncoding(); contentEncoding != "" {
+		pc.jsonExtraHeadersWithCompression.Set("Content-Encoding", contentEncoding)
+		pc.protobufExtraHeadersWithCompression.Set("Content-Encoding", contentEncoding)
+	}
+	return pc
+}
+
 // MetricSerializer represents the interface of method needed by the aggregator to serialize its data
 type MetricSerializer interface {
 	SendEvents(e event.Events) error
@@ -110,7 +141,19 @@ type Serializer struct {
 	Forwarder             forwarder.Forwarder
 	orchestratorForwarder forwarder.Forwarder
 
-	seriesJSONPayloadBuilder *stream.JSONPayloadBuilder
+	seriesJSONPayloadBuilder        *stream.JSONPayloadBuilder
+	eventsJSONPayloadBuilder        *stream.JSONPayloadBuilder
+	serviceChecksJSONPayloadBuilder *stream.JSONPayloadBuilder
+
+	// the compression of each kind of payload. The orchestrator payloads are compressed by
+	// their message encoding unless a compression is configured for them
+	seriesCompression        *payloadCompression
+	sketchesCompression      *payloadCompression
+	eventsCompression        *payloadCompression
+	serviceChecksCompression *payloadCompression
+	metadataCompression      *payloadCompression
+	processesCompression     *payloadCompression
+	orchestratorCompressor   compression.Compressor
 
 	// Those variables allow users to blacklist any kind of payload
 	// from being sent by the agent. This was introduced for
@@ -131,20 +174,34 @@ type Serializer struct {
 
 // NewSerializer returns a new Serializer initialized
 func NewSerializer(forwarder, orchestratorForwarder forwarder.Forwarder) *Serializer {
+	shareAndLockBuffers := config.Datadog.GetBool("enable_json_stream_shared_compressor_buffers")
+	seriesCompression := newPayloadCompression("series")
+	eventsCompression := newPayloadCompression("events")
+	serviceChecksCompression := newPayloadCompression("service_checks")
+
 	s := &Serializer{
-		clock:                         clock.New(),
-		Forwarder:                     forwarder,
-		orchestratorForwarder:         orchestratorForwarder,
-		seriesJSONPayloadBuilder:      stream.NewJSONPayloadBuilder(config.Datadog.GetBool("enable_json_stream_shared_compressor_buffers")),
-		enableEvents:                  config.Datadog.GetBool("enable_payloads.events"),
-		enableSeries:                  config.Datadog.GetBool("enable_payloads.series"),
-		enableServiceChecks:           config.Datadog.GetBool("enable_payloads.service_checks"),
-		enableSketches:                config.Datadog.GetBool("enable_payloads.sketches"),
-		enableJSONToV1Intake:          config.Datadog.GetBool("enable_payloads.json_to_v1_intake"),
-		enableJSONStream:              stream.Available && config.Datadog.GetBool("enable_stream_payload_serialization"),
-		enableServiceChecksJSONStream: stream.Available && config.Datadog.GetBool("enable_service_checks_stream_payload_serialization"),
-		enableEventsJSONStream:        stream.Available && config.Datadog.GetBool("enable_events_stream_payload_serialization"),
-		enableSketchProtobufStream:    stream.Available && config.Datadog.GetBool("enable_sketch_stream_payload_serialization"),
+		clock:                           clock.New(),
+		Forwarder:                       forwarder,
+		orchestratorForwarder:           orchestratorForwarder,
+		seriesJSONPayloadBuilder:        stream.NewJSONPayloadBuilder(shareAndLockBuffers, seriesCompression.compressor),
+		eventsJSONPayloadBuilder:        stream.NewJSONPayloadBuilder(shareAndLockBuffers, eventsCompression.compressor),
+		serviceChecksJSONPayloadBuilder: stream.NewJSONPayloadBuilder(shareAndLockBuffers, serviceChecksCompression.compressor),
+		seriesCompression:               seriesCompression,
+		sketchesCompression:             newPayloadCompression("sketches"),
+		eventsCompression:               eventsCompression,
+		serviceChecksCompression:        serviceChecksCompression,
+		metadataCompression:             newPayloadCompression("metadata"),
+		processesCompression:            newPayloadCompression("process"),
+		orchestratorCompressor:          types.NewProcessPayloadCompressor(config.Datadog, "orchestrator"),
+		enableEvents:                    config.Datadog.GetBool("enable_payloads.events"),
+		enableSeries:                    config.Datadog.GetBool("enable_payloads.series"),
+		enableServiceChecks:             config.Datadog.GetBool("enable_payloads.service_checks"),
+		enableSketches:                  config.Datadog.GetBool("enable_payloads.sketches"),
+		enableJSONToV1Intake:            config.Datadog.GetBool("enable_payloads.json_to_v1_intake"),
+		enableJSONStream:                stream.Available && config.Datadog.GetBool("enable_stream_payload_serialization"),
+		enableServiceChecksJSONStream:   stream.Available && config.Datadog.GetBool("enable_service_checks_stream_payload_serialization"),
+		enableEventsJSONStream:          stream.Available && config.Datadog.GetBool("enable_events_stream_payload_serialization"),
+		enableSketchProtobufStream:      stream.Available && config.Datadog.GetBool("enable_sketch_stream_payload_serialization"),
 	}
 
 	if !s.enableEvents {
@@ -174,37 +231,38 @@ func (s Serializer) serializePayload(
 	jsonMarshaler marshaler.JSONMarshaler,
 	protoMarshaler marshaler.ProtoMarshaler,
 	compress bool,
-	useV1API bool) (transaction.BytesPayloads, http.Header, error) {
+	useV1API bool,
+	pc *payloadCompression) (transaction.BytesPayloads, http.Header, error) {
 	if useV1API {
-		return s.serializePayloadJSON(jsonMarshaler, compress)
+		return s.serializePayloadJSON(jsonMarshaler, compress, pc)
 	}
-	return s.serializePayloadProto(protoMarshaler, compress)
+	return s.serializePayloadProto(protoMarshaler, compress, pc)
 }
 
-func (s Serializer) serializePayloadJSON(payload marshaler.JSONMarshaler, compress bool) (transaction.BytesPayloads, http.Header, error) {
+func (s Serializer) serializePayloadJSON(payload marshaler.JSONMarshaler, compress bool, pc *payloadCompression) (transaction.BytesPayloads, http.Header, error) {
 	var extraHeaders http.Header
 
 	if compress {
-		extraHeaders = jsonExtraHeadersWithCompression
+		extraHeaders = pc.jsonExtraHeadersWithCompression
 	} else {
 		extraHeaders = jsonExtraHeaders
 	}
 
-	return s.serializePayloadInternal(payload, compress, extraHeaders, split.JSONMarshalFct)
+	return s.serializePayloadInternal(payload, compress, extraHeaders, split.JSONMarshalFct, pc.compressor)
 }
 
-func (s Serializer) serializePayloadProto(payload marshaler.ProtoMarshaler, compress bool) (transaction.BytesPayloads, http.Header, error) {
+func (s Serializer) serializePayloadProto(payload marshaler.ProtoMarshaler, compress bool, pc *payloadCompression) (transaction.BytesPayloads, http.Header, error) {
 	var extraHeaders http.Header
 	if compress {
-		extraHeaders = protobufExtraHeadersWithCompression
+		extraHeaders = pc.protobufExtraHeadersWithCompression
 	} else {
 		extraHeaders = protobufExtraHeaders
 	}
-	return s.serializePayloadInternal(payload, compress, extraHeaders, split.ProtoMarshalFct)
+	return s.serializePayloadInternal(payload, compress, extraHeaders, split.ProtoMarshalFct, pc.compressor)
 }
 
-func (s Serializer) serializePayloadInternal(payload marshaler.AbstractMarshaler, compress bool, extraHeaders http.Header, marshalFct split.MarshalFct) (transaction.BytesPayloads, http.Header, error) {
-	payloads, err := split.Payloads(payload, compress, marshalFct)
+func (s Serializer) serializePayloadInternal(payload marshaler.AbstractMarshaler, compress bool, extraHeaders http.Header, marshalFct split.MarshalFct, compressor compression.Compressor) (transaction.BytesPayloads, http.Header, error) {
+	payloads, err := split.Payloads(payload, compress, marshalFct, compressor)
 
 	if err != nil {
 		return nil, nil, fmt.Errorf("could not split payload into small enough chunks: %s", err)
@@ -213,15 +271,16 @@ func (s Serializer) serializePayloadInternal(payload marshaler.AbstractMarshaler
 	return payloads, extraHeaders, nil
 }
 
-func (s Serializer) serializeStreamablePayload(payload marshaler.StreamJSONMarshaler, policy stream.OnErrItemTooBigPolicy) (transaction.BytesPayloads, http.Header, error) {
+// serializeStreamablePayload serializes the payload with the builder, which must use the compression of pc
+func (s Serializer) serializeStreamablePayload(builder *stream.JSONPayloadBuilder, pc *payloadCompression, payload marshaler.StreamJSONMarshaler, policy stream.OnErrItemTooBigPolicy) (transaction.BytesPayloads, http.Header, error) {
 	adapter := marshaler.NewIterableStreamJSONMarshalerAdapter(payload)
-	payloads, err := s.seriesJSONPayloadBuilder.BuildWithOnErrItemTooBigPolicy(adapter, policy)
-	return payloads, jsonExtraHeadersWithCompression, err
+	payloads, err := builder.BuildWithOnErrItemTooBigPolicy(adapter, policy)
+	return payloads, pc.jsonExtraHeadersWithCompression, err
 }
 
 func (s Serializer) serializeIterableStreamablePayload(payload marshaler.IterableStreamJSONMarshaler, policy stream.OnErrItemTooBigPolicy) (transaction.BytesPayloads, http.Header, error) {
 	payloads, err := s.seriesJSONPayloadBuilder.BuildWithOnErrItemTooBigPolicy(payload, policy)
-	return payloads, jsonExtraHeadersWithCompression, err
+	return payloads, s.seriesCompression.jsonExtraHeadersWithCompression, err
 }
 
 // As events are gathered by SourceType, the serialization logic is more complex than for the other serializations.
@@ -236,7 +295,7 @@ func (s Serializer) serializeIterableStreamablePayload(payload marshaler.Iterabl
 func (s Serializer) serializeEventsStreamJSONMarshalerPayload(
 	eventsSerializer metricsserializer.Events, useV1API bool) (transaction.BytesPayloads, http.Header, error) {
 	marshaler := eventsSerializer.CreateSingleMarshaler()
-	eventPayloads, extraHeaders, err := s.serializeStreamablePayload(marshaler, stream.FailOnErrItemTooBig)
+	eventPayloads, extraHeaders, err := s.serializeStreamablePayload(s.eventsJSONPayloadBuilder, s.eventsCompression, marshaler, stream.FailOnErrItemTooBig)
 
 	if err == stream.ErrItemTooBig {
 		expvarsSendEventsErrItemTooBigs.Add(1)
@@ -244,12 +303,12 @@ func (s Serializer) serializeEventsStreamJSONMarshalerPayload(
 		// Do not use CreateMarshalersBySourceType when there are too many source types (Performance issue).
 		if marshaler.Len() > maxItemCountForCreateMarshalersBySourceType {
 			expvarsSendEventsErrItemTooBigsFallback.Add(1)
-			eventPayloads, extraHeaders, err = s.serializePayload(eventsSerializer, eventsSerializer, true, useV1API)
+			eventPayloads, extraHeaders, err = s.serializePayload(eventsSerializer, eventsSerializer, true, useV1API, s.eventsCompression)
 		} else {
 			eventPayloads = nil
 			for _, v := range eventsSerializer.CreateMarshalersBySourceType() {
 				var eventPayloadsForSourceType transaction.BytesPayloads
-				eventPayloadsForSourceType, extraHeaders, err = s.serializeStreamablePayload(v, stream.DropItemOnErrItemTooBig)
+				eventPayloadsForSourceType, extraHeaders, err = s.serializeStreamablePayload(s.eventsJSONPayloadBuilder, s.eventsCompression, v, stream.DropItemOnErrItemTooBig)
 				if err != nil {
 					return nil, nil, err
 				}
@@ -275,7 +334,7 @@ func (s *Serializer) SendEvents(events event.Events) error {
 	if s.enableEventsJSONStream {
 		eventPayloads, extraHeaders, err = s.serializeEventsStreamJSONMarshalerPayload(eventsSerializer, true)
 	} else {
-		eventPayloads, extraHeaders, err = s.serializePayload(eventsSerializer, eventsSerializer, true, true)
+		eventPayloads, extraHeaders, err = s.serializePayload(eventsSerializer, eventsSerializer, true, true, s.eventsCompression)
 	}
 	if err != nil {
 		return fmt.Errorf("dropping event payload: %s", err)
@@ -297,9 +356,9 @@ func (s *Serializer) SendServiceChecks(serviceChecks servicecheck.ServiceChecks)
 	var err error
 
 	if s.enableServiceChecksJSONStream {
-		serviceCheckPayloads, extraHeaders, err = s.serializeStreamablePayload(serviceChecksSerializer, stream.DropItemOnErrItemTooBig)
+		serviceCheckPayloads, extraHeaders, err = s.serializeStreamablePayload(s.serviceChecksJSONPayloadBuilder, s.serviceChecksCompression, serviceChecksSerializer, stream.DropItemOnErrItemTooBig)
 	} else {
-		serviceCheckPayloads, extraHeaders, err = s.serializePayloadJSON(serviceChecksSerializer, true)
+		serviceCheckPayloads, extraHeaders, err = s.serializePayloadJSON(serviceChecksSerializer, true, s.serviceChecksCompression)
 	}
 	if err != nil {
 		return fmt.Errorf("dropping service check payload: %s", err)
@@ -330,10 +389,10 @@ func (s *Serializer) SendIterableSeries(serieSource metrics.SerieSource) error {
 	if useV1API && s.enableJSONStream {
 		seriesBytesPayloads, extraHeaders, err = s.serializeIterableStreamablePayload(seriesSerializer, stream.DropItemOnErrItemTooBig)
 	} else if useV1API && !s.enableJSONStream {
-		seriesBytesPayloads, extraHeaders, err = s.serializePayloadJSON(seriesSerializer, true)
+		seriesBytesPayloads, extraHeaders, err = s.serializePayloadJSON(seriesSerializer, true, s.seriesCompression)
 	} else {
-		seriesBytesPayloads, err = seriesSerializer.MarshalSplitCompress(marshaler.NewBufferContext())
-		extraHeaders = protobufExtraHeadersWithCompression
+		seriesBytesPayloads, err = seriesSerializer.MarshalSplitCompress(marshaler.NewBufferContext(), s.seriesCompression.compressor)
+		extraHeaders = s.seriesCompression.protobufExtraHeadersWithCompression
 	}
 
 	if err != nil {
@@ -359,15 +418,15 @@ func (s *Serializer) SendSketch(sketches metrics.SketchesSource) error {
 	}
 	sketchesSerializer := metricsserializer.SketchSeriesList{SketchesSource: sketches}
 	if s.enableSketchProtobufStream {
-		payloads, err := sketchesSerializer.MarshalSplitCompress(marshaler.NewBufferContext())
+		payloads, err := sketchesSerializer.MarshalSplitCompress(marshaler.NewBufferContext(), s.sketchesCompression.compressor)
 		if err != nil {
 			return fmt.Errorf("dropping sketch payload: %v", err)
 		}
 
-		return s.Forwarder.SubmitSketchSeries(payloads, protobufExtraHeadersWithCompression)
+		return s.Forwarder.SubmitSketchSeries(payloads, s.sketchesCompression.protobufExtraHeadersWithCompression)
 	} else {
 		compress := true
-		splitSketches, extraHeaders, err := s.serializePayloadProto(sketchesSerializer, compress)
+		splitSketches, extraHeaders, err := s.serializePayloadProto(sketchesSerializer, compress, s.sketchesCompression)
 		if err != nil {
 			return fmt.Errorf("dropping sketch payload: %s", err)
 		}
@@ -392,7 +451,7 @@ func (s *Serializer) SendAgentchecksMetadata(m marshaler.JSONMarshaler) error {
 }
 
 func (s *Serializer) sendMetadata(m marshaler.JSONMarshaler, submit func(payload transaction.BytesPayloads, extra http.Header) error) error {
-	mustSplit, compressedPayload, payload, err := split.CheckSizeAndSerialize(m, true, split.JSONMarshalFct)
+	mustSplit, compressedPayload, payload, err := split.CheckSizeAndSerialize(m, true, split.JSONMarshalFct, s.metadataCompression.compressor)
 	if err != nil {
 		return fmt.Errorf("could not determine size of metadata payload: %s", err)
 	}
@@ -403,7 +462,7 @@ func (s *Serializer) sendMetadata(m marshaler.JSONMarshaler, submit func(payload
 		return fmt.Errorf("metadata payload was too big to send (%d bytes compressed, %d bytes uncompressed), metadata payloads cannot be split", len(compressedPayload), len(payload))
 	}
 
-	if err := submit(transaction.NewBytesPayloadsWithoutMetaData([]*[]byte{&compressedPayload}), jsonExtraHeadersWithCompression); err != nil {
+	if err := submit(transaction.NewBytesPayloadsWithoutMetaData([]*[]byte{&compressedPayload}), s.metadataCompression.jsonExtraHeadersWithCompression); err != nil {
 		return err
 	}
 
@@ -423,11 +482,11 @@ func (s *Serializer) SendProcessesMetadata(data interface{}) error {
 	if err != nil {
 		return fmt.Errorf("could not serialize processes metadata payload: %s", err)
 	}
-	compressedPayload, err := compression.Compress(payload)
+	compressedPayload, err := s.processesCompression.compressor.Compress(payload)
 	if err != nil {
 		return fmt.Errorf("could not compress processes metadata payload: %s", err)
 	}
-	if err := s.Forwarder.SubmitV1Intake(transaction.NewBytesPayloadsWithoutMetaData([]*[]byte{&compressedPayload}), jsonExtraHeadersWithCompression); err != nil {
+	if err := s.Forwarder.SubmitV1Intake(transaction.NewBytesPayloadsWithoutMetaData([]*[]byte{&compressedPayload}), s.processesCompression.jsonExtraHeadersWithCompression); err != nil {
 		return err
 	}
 
@@ -442,7 +501,7 @@ func (s *Serializer) SendOrchestratorMetadata(msgs []types.ProcessMessageBody, h
 		return errors.New("orchestrator forwarder is not setup")
 	}
 	for _, m := range msgs {
-		payloads, extraHeaders, err := makeOrchestratorPayloads(m, hostName, clusterID)
+		payloads, extraHeaders, err := makeOrchestratorPayloads(m, hostName, clusterID, s.orchestratorCompressor)
 		if err != nil {
 			return log.Errorf("Unable to encode message: %s", err)
 		}
@@ -467,7 +526,7 @@ func (s *Serializer) SendOrchestratorManifests(msgs []types.ProcessMessageBody,
 		return errors.New("orchestrator forwarder is not setup")
 	}
 	for _, m := range msgs {
-		payloads, extraHeaders, err := makeOrchestratorPayloads(m, hostName, clusterID)
+		payloads, extraHeaders, err := makeOrchestratorPayloads(m, hostName, clusterID, s.orchestratorCompressor)
 		if err != nil {
 			log.Errorf("Unable to encode message: %s", err)
 			continue
@@ -487,7 +546,7 @@ func (s *Serializer) SendOrchestratorManifests(msgs []types.ProcessMessageBody,
 	return nil
 }
 
-func makeOrchestratorPayloads(msg types.ProcessMessageBody, hostName, clusterID string) (transaction.BytesPayloads, http.Header, error) {
+func makeOrchestratorPayloads(msg types.ProcessMessageBody, hostName, clusterID string, compressor compression.Compressor) (transaction.BytesPayloads, http.Header, error) {
 	extraHeaders := make(http.Header)
 	extraHeaders.Set(headers.HostHeader, hostName)
 	extraHeaders.Set(headers.ClusterIDHeader, clusterID)
@@ -495,8 +554,11 @@ func makeOrchestratorPayloads(msg types.ProcessMessageBody, hostName, clusterID
 	extraHeaders.Set(headers.EVPOriginHeader, "agent")
 	extraHeaders.Set(headers.EVPOriginVersionHeader, version.AgentVersion)
 	extraHeaders.Set(headers.ContentTypeHeader, headers.ProtobufContentType)
+	if compressor != nil && compressor.ContentEncoding() != "" {
+		extraHeaders.Set(headers.ContentEncodingHeader, compressor.ContentEncoding())
+	}
 
-	body, err := types.ProcessPayloadEncoder(msg)
+	body, err := types.ProcessPayloadEncoder(msg, compressor)
 	if err != nil {
 		return nil, nil, err
 	}
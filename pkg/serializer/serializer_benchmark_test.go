@@ -40,7 +40,7 @@ var results transaction.BytesPayloads
 func benchmarkJSONStream(b *testing.B, passes int, sharedBuffers bool, numberOfEvents int) {
 	events := buildEvents(numberOfEvents)
 	marshaler := events.CreateSingleMarshaler()
-	payloadBuilder := stream.NewJSONPayloadBuilder(sharedBuffers)
+	payloadBuilder := stream.NewJSONPayloadBuilder(sharedBuffers, testCompressor)
 	b.ResetTimer()
 
 	for n := 0; n < b.N; n++ {
@@ -55,7 +55,7 @@ func benchmarkSplit(b *testing.B, numberOfEvents int) {
 	b.ResetTimer()
 
 	for n := 0; n < b.N; n++ {
-		results, _ = split.Payloads(events, true, split.JSONMarshalFct)
+		results, _ = split.Payloads(events, true, split.JSONMarshalFct, testCompressor)
 	}
 }
 
@@ -28,11 +28,15 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/metrics/servicecheck"
 	metricsserializer "github.com/DataDog/datadog-agent/pkg/serializer/internal/metrics"
 	"github.com/DataDog/datadog-agent/pkg/serializer/marshaler"
+	"github.com/DataDog/datadog-agent/pkg/serializer/types"
 	"github.com/DataDog/datadog-agent/pkg/util/compression"
 )
 
 var initialContentEncoding = compression.ContentEncoding
 
+// testCompressor is the compression the agent was built with
+var testCompressor, _ = compression.NewCompressor("", 0)
+
 func resetContentEncoding() {
 	compression.ContentEncoding = initialContentEncoding
 	initExtraHeaders()
@@ -115,9 +119,9 @@ type testPayload struct{}
 
 func (p *testPayload) MarshalJSON() ([]byte, error) { return jsonString, nil }
 func (p *testPayload) Marshal() ([]byte, error)     { return protobufString, nil }
-func (p *testPayload) MarshalSplitCompress(bufferContext *marshaler.BufferContext) (transaction.BytesPayloads, error) {
+func (p *testPayload) MarshalSplitCompress(bufferContext *marshaler.BufferContext, compressor compression.Compressor) (transaction.BytesPayloads, error) {
 	payloads := transaction.BytesPayloads{}
-	payload, err := compression.Compress(protobufString)
+	payload, err := compressor.Compress(protobufString)
 	if err != nil {
 		return nil, err
 	}
@@ -413,3 +417,82 @@ func TestSendWithDisabledKind(t *testing.T) {
 	s.SendMetadata(payload)
 	f.AssertNumberOfCalls(t, "SubmitMetadata", 1) // called once for the metadata
 }
+
+func TestPayloadCompression(t *testing.T) {
+	config.Datadog.Set("serializer_compression.kind", "zstd")
+	defer config.Datadog.Set("serializer_compression.kind", "")
+	config.Datadog.Set("serializer_compression.events.kind", "zlib")
+	defer config.Datadog.Set("serializer_compression.events.kind", "")
+	config.Datadog.Set("serializer_compression.metadata.kind", "gzip")
+	defer config.Datadog.Set("serializer_compression.metadata.kind", "")
+
+	s := NewSerializer(&forwarder.MockedForwarder{}, nil)
+
+	// the setting of all the payloads
+	assert.Equal(t, "zstd", s.seriesCompression.compressor.ContentEncoding())
+	assert.Equal(t, "zstd", s.sketchesCompression.protobufExtraHeadersWithCompression.Get("Content-Encoding"))
+	assert.Equal(t, "zstd", s.serviceChecksCompression.jsonExtraHeadersWithCompression.Get("Content-Encoding"))
+	// overridden for the events
+	assert.Equal(t, "deflate", s.eventsCompression.compressor.ContentEncoding())
+	assert.Equal(t, "deflate", s.eventsCompression.jsonExtraHeadersWithCompression.Get("Content-Encoding"))
+	// invalid, the compression the agent was built with is used
+	assert.Equal(t, jsonExtraHeadersWithCompression, s.metadataCompression.jsonExtraHeadersWithCompression)
+
+	// the headers of the other payloads are not modified
+	assert.Equal(t, jsonContentType, s.eventsCompression.jsonExtraHeadersWithCompression.Get("Content-Type"))
+	assert.Empty(t, jsonExtraHeaders.Get("Content-Encoding"))
+}
+
+func TestOrchestratorPayloadsCompression(t *testing.T) {
+	var encodedWith compression.Compressor
+	defer func(encoder func(types.ProcessMessageBody, compression.Compressor) ([]byte, error)) {
+		types.ProcessPayloadEncoder = encoder
+	}(types.ProcessPayloadEncoder)
+	types.ProcessPayloadEncoder = func(_ types.ProcessMessageBody, compressor compression.Compressor) ([]byte, error) {
+		encodedWith = compressor
+		return []byte("payload"), nil
+	}
+	var msg types.ProcessMessageBody
+
+	// without compressor the payloads keep the compression of their message encoding
+	_, extraHeaders, err := makeOrchestratorPayloads(msg, "host", "cluster", nil)
+	require.NoError(t, err)
+	assert.Nil(t, encodedWith)
+	assert.Empty(t, extraHeaders.Get("Content-Encoding"))
+
+	zstdCompressor, err := compression.NewCompressor(compression.ZstdKind, 0)
+	require.NoError(t, err)
+	_, extraHeaders, err = makeOrchestratorPayloads(msg, "host", "cluster", zstdCompressor)
+	require.NoError(t, err)
+	assert.Equal(t, zstdCompressor, encodedWith)
+	assert.Equal(t, "zstd", extraHeaders.Get("Content-Encoding"))
+	assert.Equal(t, protobufContentType, extraHeaders.Get("Content-Type"))
+}
+
+func TestSendSketchZstd(t *testing.T) {
+	config.Datadog.Set("serializer_compression.sketches.kind", "zstd")
+	defer config.Datadog.Set("serializer_compression.sketches.kind", "")
+	config.Datadog.Set("serializer_compression.sketches.level", 5)
+	defer config.Datadog.Set("serializer_compression.sketches.level", 0)
+
+	zstdCompressor, err := compression.NewCompressor(compression.ZstdKind, 0)
+	require.NoError(t, err)
+	matcher := mock.MatchedBy(func(payloads transaction.BytesPayloads) bool {
+		expected, err := protoscope.NewScanner(`2: {}`).Exec()
+		if err != nil || len(payloads) != 1 {
+			return false
+		}
+		payload, err := zstdCompressor.Decompress(payloads[0].GetContent())
+		return err == nil && reflect.DeepEqual(expected, payload)
+	})
+	expectedHeaders := protobufExtraHeaders.Clone()
+	expectedHeaders.Set("Content-Encoding", "zstd")
+
+	f := &forwarder.MockedForwarder{}
+	f.On("SubmitSketchSeries", matcher, expectedHeaders).Return(nil).Times(1)
+
+	s := NewSerializer(f, nil)
+	err = s.SendSketch(metrics.NewSketchesSourceTest())
+	require.Nil(t, err)
+	f.AssertExpectations(t)
+}
@@ -23,6 +23,8 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/util/compression"
 )
 
+var testCompressor, _ = compression.NewCompressor("", 0)
+
 func TestSplitPayloadsSeries(t *testing.T) {
 	// Override size limits to avoid test timeouts
 	prevMaxPayloadSizeCompressed := maxPayloadSizeCompressed
@@ -34,20 +36,25 @@ func TestSplitPayloadsSeries(t *testing.T) {
 	defer func() { maxPayloadSizeUnCompressed = prevMaxPayloadSizeUnCompressed }()
 
 	t.Run("both compressed and uncompressed series payload under limits", func(t *testing.T) {
-		testSplitPayloadsSeries(t, 2, false)
+		testSplitPayloadsSeries(t, 2, false, testCompressor)
 	})
 	t.Run("compressed series payload over limit but uncompressed under limit", func(t *testing.T) {
-		testSplitPayloadsSeries(t, 5, false)
+		testSplitPayloadsSeries(t, 5, false, testCompressor)
 	})
 	t.Run("both compressed and uncompressed series payload over limits", func(t *testing.T) {
-		testSplitPayloadsSeries(t, 8, false)
+		testSplitPayloadsSeries(t, 8, false, testCompressor)
 	})
 	t.Run("compressed series payload under limit and uncompressed series payload over limit", func(t *testing.T) {
-		testSplitPayloadsSeries(t, 8, true)
+		testSplitPayloadsSeries(t, 8, true, testCompressor)
+	})
+	t.Run("series payload compressed with zstd", func(t *testing.T) {
+		zstdCompressor, err := compression.NewCompressor(compression.ZstdKind, 0)
+		require.NoError(t, err)
+		testSplitPayloadsSeries(t, 8, true, zstdCompressor)
 	})
 }
 
-func testSplitPayloadsSeries(t *testing.T, numPoints int, compress bool) {
+func testSplitPayloadsSeries(t *testing.T, numPoints int, compress bool, compressor compression.Compressor) {
 	testSeries := metricsserializer.Series{}
 	for i := 0; i < numPoints; i++ {
 		point := metrics.Serie{
@@ -73,7 +80,7 @@ func testSplitPayloadsSeries(t *testing.T, numPoints int, compress bool) {
 		testSeries = append(testSeries, &point)
 	}
 
-	payloads, err := Payloads(testSeries, compress, JSONMarshalFct)
+	payloads, err := Payloads(testSeries, compress, JSONMarshalFct, compressor)
 	require.Nil(t, err)
 
 	originalLength := len(testSeries)
@@ -83,7 +90,7 @@ func testSplitPayloadsSeries(t *testing.T, numPoints int, compress bool) {
 		localPayload := payload.GetContent()
 
 		if compress {
-			localPayload, err = compression.Decompress(localPayload)
+			localPayload, err = compressor.Decompress(localPayload)
 			require.Nil(t, err)
 		}
 
@@ -122,7 +129,7 @@ func BenchmarkSplitPayloadsSeries(b *testing.B) {
 	for n := 0; n < b.N; n++ {
 		// always record the result of Payloads to prevent
 		// the compiler eliminating the function call.
-		r, _ = Payloads(testSeries, true, JSONMarshalFct)
+		r, _ = Payloads(testSeries, true, JSONMarshalFct, testCompressor)
 
 	}
 	// ensure we actually had to split
@@ -184,7 +191,7 @@ func testSplitPayloadsEvents(t *testing.T, numPoints int, compress bool) {
 		testEvent = append(testEvent, &event)
 	}
 
-	payloads, err := Payloads(testEvent, compress, JSONMarshalFct)
+	payloads, err := Payloads(testEvent, compress, JSONMarshalFct, testCompressor)
 	require.Nil(t, err)
 
 	originalLength := len(testEvent)
@@ -193,7 +200,7 @@ func testSplitPayloadsEvents(t *testing.T, numPoints int, compress bool) {
 		var s map[string]interface{}
 		localPayload := payload.GetContent()
 		if compress {
-			localPayload, err = compression.Decompress(localPayload)
+			localPayload, err = testCompressor.Decompress(localPayload)
 			require.Nil(t, err)
 		}
 
@@ -249,7 +256,7 @@ func testSplitPayloadsServiceChecks(t *testing.T, numPoints int, compress bool)
 		testServiceChecks = append(testServiceChecks, &sc)
 	}
 
-	payloads, err := Payloads(testServiceChecks, compress, JSONMarshalFct)
+	payloads, err := Payloads(testServiceChecks, compress, JSONMarshalFct, testCompressor)
 	require.Nil(t, err)
 
 	originalLength := len(testServiceChecks)
@@ -258,7 +265,7 @@ func testSplitPayloadsServiceChecks(t *testing.T, numPoints int, compress bool)
 		var s []interface{}
 		localPayload := payload.GetContent()
 		if compress {
-			localPayload, err = compression.Decompress(localPayload)
+			localPayload, err = testCompressor.Decompress(localPayload)
 			require.Nil(t, err)
 		}
 
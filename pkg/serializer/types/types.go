@@ -7,6 +7,11 @@
 
 package types
 
+import (
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/compression"
+)
+
 // ProcessMessageBody is a type alias for processes proto message body
 // this type alias allows to avoid importing the process agent payload proto
 // in case it's not needed (dogstastd)
@@ -21,6 +26,12 @@ func (stubMessageBody) Size() int      { return 0 }
 
 // ProcessPayloadEncoder is a dummy ProcessMessageBody to avoid importing
 // the process agent payload proto in case it's not needed (dogstastd)
-var ProcessPayloadEncoder = func(m ProcessMessageBody) ([]byte, error) {
+var ProcessPayloadEncoder = func(m ProcessMessageBody, compressor compression.Compressor) ([]byte, error) {
 	return []byte{}, nil
 }
+
+// NewProcessPayloadCompressor is a dummy compressor constructor to avoid importing
+// the process agent payload encoding in case it's not needed (dogstastd)
+var NewProcessPayloadCompressor = func(cfg config.Reader, payload string) compression.Compressor {
+	return nil
+}
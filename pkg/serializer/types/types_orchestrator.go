@@ -16,4 +16,10 @@ import (
 // ProcessMessageBody is a type alias for processes proto message body
 type ProcessMessageBody = model.MessageBody
 
-var ProcessPayloadEncoder = api.EncodePayload
+// ProcessPayloadEncoder encodes a process message into a payload, compressed with the given
+// compressor when it is not nil
+var ProcessPayloadEncoder = api.EncodePayloadWithCompressor
+
+// NewProcessPayloadCompressor returns the compressor configured for a kind of process payload,
+// nil when the payloads keep the compression of their message encoding
+var NewProcessPayloadCompressor = api.NewPayloadCompressor
@@ -92,6 +92,29 @@ func (h *HashingTagsAccumulator) Truncate(len int) {
 	h.hash = h.hash[0:len]
 }
 
+// Retain removes the tags for which keep returns false, preserving the order of the other tags
+func (h *HashingTagsAccumulator) Retain(keep func(tag string) bool) {
+	n := 0
+	for i, t := range h.data {
+		if keep(t) {
+			h.data[n] = t
+			h.hash[n] = h.hash[i]
+			n++
+		}
+	}
+	h.Truncate(n)
+}
+
+// Rewrite replaces each tag by the result of rewrite, the hash is only computed again for the modified tags
+func (h *HashingTagsAccumulator) Rewrite(rewrite func(tag string) string) {
+	for i, t := range h.data {
+		if r := rewrite(t); r != t {
+			h.data[i] = r
+			h.hash[i] = murmur3.StringSum64(r)
+		}
+	}
+}
+
 // Less implements sort.Interface.Less
 func (h *HashingTagsAccumulator) Less(i, j int) bool {
 	if h.hash[i] == h.hash[j] {
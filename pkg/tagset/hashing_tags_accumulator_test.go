@@ -6,6 +6,7 @@
 package tagset
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -44,6 +45,29 @@ func TestHashingTagsAccumulatorReset(t *testing.T) {
 	assert.Equal(t, []string{}, tb.data)
 }
 
+func TestHashingTagsAccumulatorRetain(t *testing.T) {
+	tb := NewHashingTagsAccumulatorWithTags([]string{"a:1", "b:2", "c:3", "b:4"})
+	tb.Retain(func(tag string) bool { return !strings.HasPrefix(tag, "b:") })
+	assert.Equal(t, []string{"a:1", "c:3"}, tb.Get())
+	assert.Equal(t, NewHashingTagsAccumulatorWithTags([]string{"a:1", "c:3"}).Hashes(), tb.Hashes())
+
+	tb.Retain(func(string) bool { return false })
+	assert.Empty(t, tb.Get())
+	assert.Empty(t, tb.Hashes())
+}
+
+func TestHashingTagsAccumulatorRewrite(t *testing.T) {
+	tb := NewHashingTagsAccumulatorWithTags([]string{"a:1", "b:2", "c:3"})
+	tb.Rewrite(func(tag string) string {
+		if strings.HasPrefix(tag, "b:") {
+			return "b:other"
+		}
+		return tag
+	})
+	assert.Equal(t, []string{"a:1", "b:other", "c:3"}, tb.Get())
+	assert.Equal(t, NewHashingTagsAccumulatorWithTags([]string{"a:1", "b:other", "c:3"}).Hashes(), tb.Hashes())
+}
+
 func TestHashingTagsAccumulatorGet(t *testing.T) {
 	tb := NewHashingTagsAccumulator()
 
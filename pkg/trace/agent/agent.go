@@ -49,10 +49,12 @@ type Agent struct {
 	ClientStatsAggregator *stats.ClientStatsAggregator
 	Blacklister           *filters.Blacklister
 	Replacer              *filters.Replacer
+	SpanFilter            *filters.SpanFilter
 	PrioritySampler       *sampler.PrioritySampler
 	ErrorsSampler         *sampler.ErrorsSampler
 	RareSampler           *sampler.RareSampler
 	NoPrioritySampler     *sampler.NoPrioritySampler
+	TailSampler           *TailSampler // nil when tail sampling is disabled
 	EventProcessor        *event.Processor
 	TraceWriter           *writer.TraceWriter
 	StatsWriter           *writer.StatsWriter
@@ -98,6 +100,7 @@ func NewAgent(ctx context.Context, conf *config.AgentConfig, telemetryCollector
 		ClientStatsAggregator: stats.NewClientStatsAggregator(conf, statsChan),
 		Blacklister:           filters.NewBlacklister(conf.Ignore["resource"]),
 		Replacer:              filters.NewReplacer(conf.ReplaceTags),
+		SpanFilter:            filters.NewSpanFilter(conf.SpanRules),
 		PrioritySampler:       sampler.NewPrioritySampler(conf, dynConf),
 		ErrorsSampler:         sampler.NewErrorsSampler(conf),
 		RareSampler:           sampler.NewRareSampler(conf),
@@ -113,8 +116,15 @@ func NewAgent(ctx context.Context, conf *config.AgentConfig, telemetryCollector
 	}
 	agnt.Receiver = api.NewHTTPReceiver(conf, dynConf, in, agnt, telemetryCollector)
 	agnt.OTLPReceiver = api.NewOTLPReceiver(in, conf)
-	agnt.RemoteConfigHandler = remoteconfighandler.New(conf, agnt.PrioritySampler, agnt.RareSampler, agnt.ErrorsSampler)
+	agnt.RemoteConfigHandler = remoteconfighandler.New(conf, agnt.PrioritySampler, agnt.RareSampler, agnt.ErrorsSampler, agnt.SpanFilter)
 	agnt.TraceWriter = writer.NewTraceWriter(conf, agnt.PrioritySampler, agnt.ErrorsSampler, agnt.RareSampler, telemetryCollector)
+	if conf.TailSamplingEnabled {
+		if conf.SynchronousFlushing {
+			log.Warn("Tail sampling is disabled as it is not compatible with synchronous flushing")
+		} else {
+			agnt.TailSampler = NewTailSampler(conf, agnt.TraceWriter)
+		}
+	}
 	return agnt
 }
 
@@ -134,6 +144,9 @@ func (a *Agent) Run() {
 	} {
 		starter.Start()
 	}
+	if a.TailSampler != nil {
+		a.TailSampler.Start()
+	}
 
 	go a.TraceWriter.Run()
 	go a.StatsWriter.Run()
@@ -191,6 +204,10 @@ func (a *Agent) loop() {
 	if err := a.Receiver.Stop(); err != nil {
 		log.Error(err)
 	}
+	if a.TailSampler != nil {
+		// Stop the TailSampler before the TraceWriter as it sends the buffered traces to it.
+		a.TailSampler.Stop()
+	}
 	for _, stopper := range []interface{ Stop() }{
 		a.Concentrator,
 		a.ClientStatsAggregator,
@@ -231,6 +248,8 @@ func (a *Agent) Process(p *api.Payload) {
 	defer timing.Since("datadog.trace_agent.internal.process_payload_ms", now)
 	ts := p.Source
 	sampledChunks := new(writer.SampledChunks)
+	// tailPayload holds the attributes of the payload for the chunks buffered by the TailSampler.
+	var tailPayload *pb.TracerPayload
 	statsInput := stats.NewStatsInput(len(p.TracerPayload.Chunks), p.TracerPayload.ContainerID, p.ClientComputedStats, a.conf)
 
 	p.TracerPayload.Env = traceutil.NormalizeTag(p.TracerPayload.Env)
@@ -313,6 +332,28 @@ func (a *Agent) Process(p *api.Payload) {
 			statsInput.Traces = append(statsInput.Traces, *pt.Clone())
 		}
 
+		// The span rules are applied once the stats input holds the original spans.
+		var spansDropped int
+		pt.Root, spansDropped = a.SpanFilter.Apply(pt.TraceChunk, pt.Root)
+		ts.SpansFiltered.Add(int64(spansDropped))
+
+		if a.TailSampler != nil && a.TailSampler.Handles(pt) && !a.hasUserPriority(pt) {
+			if tailPayload == nil {
+				tailPayload = tracerPayloadHeader(p.TracerPayload)
+			}
+			// The samplers still see the chunk to keep the rates sent back to the tracers up to date,
+			// but the decision is left to the TailSampler, which sends the analyzed events when it
+			// drops the trace.
+			var events []*pb.Span
+			if _, checkAnalyticsEvents := a.traceSampling(now, ts, pt); checkAnalyticsEvents {
+				pt.TraceChunk.DroppedTrace = true
+				events = a.getAnalyzedEvents(pt, ts)
+			}
+			a.TailSampler.Add(now, tailPayload, pt, events)
+			p.RemoveChunk(i)
+			continue
+		}
+
 		keep, numEvents := a.sample(now, ts, pt)
 		if !keep && len(pt.TraceChunk.Spans) == 0 {
 			// The entire trace was dropped and no spans were kept.
@@ -475,6 +516,22 @@ func isManualUserDrop(priority sampler.SamplingPriority, pt *traceutil.Processed
 	return dm == manualSampling
 }
 
+// hasUserPriority reports whether the user set the sampling priority of the chunk to keep or drop
+// its trace. The TailSampler doesn't override such decisions.
+func (a *Agent) hasUserPriority(pt *traceutil.ProcessedTrace) bool {
+	priority, ok := sampler.GetSamplingPriority(pt.TraceChunk)
+	switch {
+	case !ok:
+		return false
+	case priority == sampler.PriorityUserKeep:
+		return true
+	case a.conf.HasFeature("error_rare_sample_tracer_drop"):
+		return isManualUserDrop(priority, pt)
+	default:
+		return priority == sampler.PriorityUserDrop
+	}
+}
+
 // sample performs all sampling on the processedTrace modifying it as needed and returning if the trace should be kept and the number of events in the trace
 func (a *Agent) sample(now time.Time, ts *info.TagStats, pt *traceutil.ProcessedTrace) (keep bool, numEvents int) {
 	// We have a `keep` that is different from pt's `DroppedTrace` field as `DroppedTrace` will be sent to intake.
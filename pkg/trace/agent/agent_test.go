@@ -442,6 +442,62 @@ func TestProcess(t *testing.T) {
 		}
 	})
 
+	t.Run("SpanRules", func(t *testing.T) {
+		cfg := config.New()
+		cfg.Endpoints[0].APIKey = "test"
+		cfg.SpanRules = []*config.SpanRule{
+			{Name: "^redis.command$", Action: config.SpanRuleDrop},
+			{Action: config.SpanRuleRemoveTag, Key: "secret"},
+		}
+		require.NoError(t, config.CompileSpanRules(cfg.SpanRules))
+		ctx, cancel := context.WithCancel(context.Background())
+		agnt := NewTestAgent(ctx, cfg, telemetry.NewNoopCollector())
+		defer cancel()
+
+		now := time.Now()
+		newSpan := func(spanID, parentID uint64, name string) *pb.Span {
+			return &pb.Span{
+				TraceID:  1,
+				SpanID:   spanID,
+				ParentID: parentID,
+				Service:  "a",
+				Name:     name,
+				Resource: "resource",
+				Start:    now.Add(-time.Second).UnixNano(),
+				Duration: time.Millisecond.Nanoseconds(),
+				Meta:     map[string]string{"secret": "password"},
+				Metrics:  map[string]float64{"_dd.measured": 1},
+			}
+		}
+		c := spansToChunk(newSpan(1, 0, "http.request"), newSpan(2, 1, "redis.command"), newSpan(3, 2, "redis.parse"))
+		c.Priority = 1
+		tp := testutil.TracerPayloadWithChunk(c)
+
+		agnt.Process(&api.Payload{
+			TracerPayload: tp,
+			Source:        agnt.Receiver.Stats.GetTagStats(info.Tags{}),
+		})
+
+		ss := <-agnt.TraceWriter.In
+		require.Len(t, ss.TracerPayload.Chunks, 1)
+		spans := ss.TracerPayload.Chunks[0].Spans
+		require.Len(t, spans, 2)
+		assert.EqualValues(t, 3, spans[1].SpanID)
+		assert.EqualValues(t, 1, spans[1].ParentID)
+		for _, s := range spans {
+			assert.NotContains(t, s.Meta, "secret")
+		}
+
+		// The stats are computed on the spans before the rules are applied
+		in := <-agnt.Concentrator.In
+		require.Len(t, in.Traces, 1)
+		statsSpans := in.Traces[0].TraceChunk.Spans
+		require.Len(t, statsSpans, 3)
+		assert.Equal(t, "redis.command", statsSpans[1].Name)
+		assert.Equal(t, "password", statsSpans[0].Meta["secret"])
+		assert.Equal(t, "password", in.Traces[0].Root.Meta["secret"])
+	})
+
 	t.Run("chunking", func(t *testing.T) {
 		cfg := config.New()
 		cfg.Endpoints[0].APIKey = "test"
@@ -1273,6 +1329,7 @@ func TestPartialSamplingFree(t *testing.T) {
 		Concentrator:      stats.NewConcentrator(cfg, statsChan, time.Now()),
 		Blacklister:       filters.NewBlacklister(cfg.Ignore["resource"]),
 		Replacer:          filters.NewReplacer(cfg.ReplaceTags),
+		SpanFilter:        filters.NewSpanFilter(cfg.SpanRules),
 		NoPrioritySampler: sampler.NewNoPrioritySampler(cfg),
 		ErrorsSampler:     sampler.NewErrorsSampler(cfg),
 		PrioritySampler:   sampler.NewPrioritySampler(cfg, &sampler.DynamicConfig{}),
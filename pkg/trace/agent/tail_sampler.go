@@ -0,0 +1,446 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package agent
+
+import (
+	"sync"
+	"time"
+
+	"github.com/DataDog/sketches-go/ddsketch"
+
+	pb "github.com/DataDog/datadog-agent/pkg/proto/pbgo/trace"
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+	"github.com/DataDog/datadog-agent/pkg/trace/info"
+	"github.com/DataDog/datadog-agent/pkg/trace/log"
+	"github.com/DataDog/datadog-agent/pkg/trace/metrics"
+	"github.com/DataDog/datadog-agent/pkg/trace/sampler"
+	"github.com/DataDog/datadog-agent/pkg/trace/traceutil"
+	"github.com/DataDog/datadog-agent/pkg/trace/writer"
+)
+
+const (
+	// tailSamplerTickInterval is the frequency at which the traces whose decision window
+	// is over are sampled.
+	tailSamplerTickInterval = time.Second
+	// tailSamplerReportInterval is the frequency at which the stats are reported.
+	tailSamplerReportInterval = 10 * time.Second
+
+	// tailLatencyWindow is the period after which the root durations of a service are forgotten.
+	tailLatencyWindow = 5 * time.Minute
+	// tailLatencyMinCount is the number of root durations needed before applying the latency policy.
+	tailLatencyMinCount = 100
+	// tailLatencyMaxServices bounds the number of services whose root durations are tracked.
+	tailLatencyMaxServices = 1000
+	// tailLatencyRelativeAccuracy and tailLatencyMaxBins size the sketches of the root durations.
+	tailLatencyRelativeAccuracy = 0.01
+	tailLatencyMaxBins          = 2048
+)
+
+// Tail sampling policies, reported as the "policy" tag of the kept traces.
+const (
+	tailPolicyErrors        = "errors"
+	tailPolicyLatency       = "latency"
+	tailPolicyTags          = "tags"
+	tailPolicyProbabilistic = "probabilistic"
+)
+
+// TailSampler samples whole traces whose chunks may be received in several payloads. The chunks are
+// buffered by trace ID until the end of a decision window, or until the buffer is full, and the
+// trace is then kept as soon as one of the policies matches:
+//   - one of its spans has an error;
+//   - its root is slower than a percentile of the roots of its service;
+//   - one of its spans holds one of the configured tags;
+//   - the probabilistic fallback keeps it.
+//
+// The decision replaces the one of the other samplers, except for the chunks whose priority was set
+// by the user, which are not tail sampled. The analyzed events of the dropped traces are still sent.
+// Chunks received after the decision on their trace are sampled the same way.
+type TailSampler struct {
+	conf     *config.AgentConfig
+	services map[string]struct{}
+	// traceWriter receives the sampled chunks
+	traceWriter *writer.TraceWriter
+
+	mu sync.Mutex
+	// traces holds the buffered traces by trace ID and order their IDs by time of the first chunk,
+	// which is also the order in which their decision windows end.
+	traces map[uint64]*tailTrace
+	order  []uint64
+	chunks int
+	size   int64
+	// decisions holds the recent decisions to sample the late chunks, decisionOrder their trace IDs by
+	// decision time.
+	decisions     map[uint64]bool
+	decisionOrder []tailDecision
+	// latencies holds the root durations by service and env.
+	latencies          map[sampler.ServiceSignature]*latencyDistribution
+	latencyWindowStart time.Time
+
+	stats *info.TailSamplerInfo
+	exit  chan struct{}
+	done  sync.WaitGroup
+}
+
+type tailTrace struct {
+	traceID   uint64
+	firstSeen time.Time
+	chunks    []tailChunk
+	size      int64
+}
+
+type tailChunk struct {
+	pt *traceutil.ProcessedTrace
+	// payload holds the attributes of the tracer payload the chunk was received in, without its chunks.
+	payload *pb.TracerPayload
+	// events holds the analyzed events of the chunk, sent when its trace is dropped.
+	events []*pb.Span
+}
+
+type tailDecision struct {
+	traceID uint64
+	expire  time.Time
+}
+
+// sampledTrace is a trace on which a decision was made.
+type sampledTrace struct {
+	*tailTrace
+	keep bool
+}
+
+// NewTailSampler returns a TailSampler sending the sampled chunks to traceWriter.
+func NewTailSampler(conf *config.AgentConfig, traceWriter *writer.TraceWriter) *TailSampler {
+	services := make(map[string]struct{}, len(conf.TailSamplingServices))
+	for _, service := range conf.TailSamplingServices {
+		services[service] = struct{}{}
+	}
+	return &TailSampler{
+		conf:               conf,
+		services:           services,
+		traceWriter:        traceWriter,
+		traces:             make(map[uint64]*tailTrace),
+		decisions:          make(map[uint64]bool),
+		latencies:          make(map[sampler.ServiceSignature]*latencyDistribution),
+		latencyWindowStart: time.Now(),
+		stats:              &info.TailSamplerInfo{},
+		exit:               make(chan struct{}),
+	}
+}
+
+// Start starts sampling the traces whose decision window is over.
+func (s *TailSampler) Start() {
+	s.done.Add(1)
+	go func() {
+		defer s.done.Done()
+		tick := time.NewTicker(tailSamplerTickInterval)
+		defer tick.Stop()
+		report := time.NewTicker(tailSamplerReportInterval)
+		defer report.Stop()
+		for {
+			select {
+			case now := <-tick.C:
+				s.flush(now, false)
+			case <-report.C:
+				s.report()
+			case <-s.exit:
+				s.flush(time.Now(), true)
+				s.report()
+				return
+			}
+		}
+	}()
+}
+
+// Stop samples all the buffered traces and stops the sampler.
+func (s *TailSampler) Stop() {
+	close(s.exit)
+	s.done.Wait()
+}
+
+// Handles reports whether the chunk of pt is tail sampled, depending on the service of its root.
+func (s *TailSampler) Handles(pt *traceutil.ProcessedTrace) bool {
+	if len(s.services) == 0 {
+		return true
+	}
+	_, ok := s.services[pt.Root.Service]
+	return ok
+}
+
+// Add buffers the chunk of pt until the decision on its trace, the chunk is sampled right away when
+// the trace was already decided. payload holds the attributes of the tracer payload of the chunk and
+// events its analyzed events.
+func (s *TailSampler) Add(now time.Time, payload *pb.TracerPayload, pt *traceutil.ProcessedTrace, events []*pb.Span) {
+	traceID := pt.TraceChunk.Spans[0].TraceID
+	chunk := tailChunk{pt: pt, payload: payload, events: events}
+	size := int64(pt.TraceChunk.Msgsize())
+
+	s.mu.Lock()
+	if keep, ok := s.decisions[traceID]; ok {
+		s.mu.Unlock()
+		s.stats.LateChunks.Inc()
+		s.send([]sampledTrace{{tailTrace: &tailTrace{traceID: traceID, chunks: []tailChunk{chunk}}, keep: keep}})
+		return
+	}
+
+	t, ok := s.traces[traceID]
+	if !ok {
+		t = &tailTrace{traceID: traceID, firstSeen: now}
+		s.traces[traceID] = t
+		s.order = append(s.order, traceID)
+	}
+	t.chunks = append(t.chunks, chunk)
+	t.size += size
+	s.chunks++
+	s.size += size
+
+	// Under buffer pressure, the oldest traces are sampled before the end of their decision window.
+	var sampled []sampledTrace
+	for s.size > s.conf.TailSamplingMaxMemory && len(s.order) > 0 {
+		sampled = append(sampled, s.sampleOldest(now))
+		s.stats.TracesEvicted.Inc()
+	}
+	s.updateBufferStats()
+	s.mu.Unlock()
+
+	s.send(sampled)
+}
+
+// flush samples the traces whose decision window is over, or all of them when all is set.
+func (s *TailSampler) flush(now time.Time, all bool) {
+	s.mu.Lock()
+	var sampled []sampledTrace
+	for len(s.order) > 0 && (all || now.Sub(s.traces[s.order[0]].firstSeen) >= s.conf.TailSamplingDecisionWait) {
+		sampled = append(sampled, s.sampleOldest(now))
+	}
+	for len(s.decisionOrder) > 0 && now.After(s.decisionOrder[0].expire) {
+		delete(s.decisions, s.decisionOrder[0].traceID)
+		s.decisionOrder = s.decisionOrder[1:]
+	}
+	if now.Sub(s.latencyWindowStart) >= tailLatencyWindow {
+		s.rotateLatencies(now)
+	}
+	s.updateBufferStats()
+	s.mu.Unlock()
+
+	s.send(sampled)
+}
+
+// sampleOldest removes the oldest trace from the buffer and samples it. It must be called with
+// the lock held.
+func (s *TailSampler) sampleOldest(now time.Time) sampledTrace {
+	t := s.traces[s.order[0]]
+	s.order = s.order[1:]
+	delete(s.traces, t.traceID)
+	s.chunks -= len(t.chunks)
+	s.size -= t.size
+
+	keep := s.sample(t)
+	s.decisions[t.traceID] = keep
+	// Keep the decision as long as the chunks of the trace could have been buffered.
+	s.decisionOrder = append(s.decisionOrder, tailDecision{traceID: t.traceID, expire: now.Add(s.conf.TailSamplingDecisionWait)})
+	return sampledTrace{tailTrace: t, keep: keep}
+}
+
+// sample applies the policies to the trace. It must be called with the lock held.
+func (s *TailSampler) sample(t *tailTrace) bool {
+	var spans []*pb.Span
+	for _, c := range t.chunks {
+		spans = append(spans, c.pt.TraceChunk.Spans...)
+	}
+	root := traceutil.GetRoot(spans)
+
+	// The root durations are recorded whatever the decision.
+	slow := s.conf.TailSamplingLatencyPercentile > 0 && s.isSlow(t.chunks[0].pt.TracerEnv, root)
+
+	var policy string
+	switch {
+	case s.conf.TailSamplingKeepErrors && traceContainsError(spans):
+		policy = tailPolicyErrors
+	case slow:
+		policy = tailPolicyLatency
+	case containsTag(spans, s.conf.TailSamplingKeepTags):
+		policy = tailPolicyTags
+	case sampler.SampleByRate(t.traceID, s.conf.TailSamplingRate):
+		policy = tailPolicyProbabilistic
+	default:
+		s.stats.TracesDropped.Inc()
+		return false
+	}
+	s.stats.TracesKept.Inc()
+	metrics.Count("datadog.trace_agent.tail_sampler.kept", 1, []string{"policy:" + policy}, 1)
+	return true
+}
+
+// isSlow records the duration of the root and reports whether it is slower than the configured
+// percentile of the durations of the roots of its service. It must be called with the lock held.
+func (s *TailSampler) isSlow(env string, root *pb.Span) bool {
+	key := sampler.ServiceSignature{Name: root.Service, Env: env}
+	d, ok := s.latencies[key]
+	if !ok {
+		if len(s.latencies) >= tailLatencyMaxServices {
+			return false
+		}
+		var err error
+		if d, err = newLatencyDistribution(); err != nil {
+			log.Errorf("Error creating the latency distribution of service %q: %v", root.Service, err)
+			return false
+		}
+		s.latencies[key] = d
+	}
+	threshold, ok := d.threshold(s.conf.TailSamplingLatencyPercentile)
+	d.add(root.Duration)
+	return ok && float64(root.Duration) > threshold
+}
+
+// rotateLatencies starts a new latency window, forgetting the services without roots in the
+// previous one. It must be called with the lock held.
+func (s *TailSampler) rotateLatencies(now time.Time) {
+	s.latencyWindowStart = now
+	for key, d := range s.latencies {
+		if d.current.IsEmpty() {
+			delete(s.latencies, key)
+			continue
+		}
+		d.previous, d.current = d.current, d.previous
+		d.current.Clear()
+	}
+}
+
+// send sends the chunks to keep to the trace writer, grouping them by tracer payload. The chunks of
+// the dropped traces are replaced by their single sampled spans, or else by their analyzed events.
+func (s *TailSampler) send(traces []sampledTrace) {
+	payloads := make(map[*pb.TracerPayload]*writer.SampledChunks)
+	for _, t := range traces {
+		for _, c := range t.chunks {
+			var numEvents int
+			if t.keep {
+				c.pt.TraceChunk.DroppedTrace = false
+			} else {
+				c.pt.TraceChunk.DroppedTrace = true
+				if !sampler.SingleSpanSampling(c.pt) {
+					if len(c.events) == 0 {
+						continue
+					}
+					c.pt.TraceChunk.Spans = c.events
+					numEvents = len(c.events)
+				}
+			}
+
+			sc, ok := payloads[c.payload]
+			if !ok {
+				sc = &writer.SampledChunks{TracerPayload: tracerPayloadHeader(c.payload)}
+				payloads[c.payload] = sc
+			}
+			if !c.pt.TraceChunk.DroppedTrace {
+				sc.SpanCount += int64(len(c.pt.TraceChunk.Spans))
+			}
+			sc.EventCount += int64(numEvents)
+			sc.Size += c.pt.TraceChunk.Msgsize()
+			sc.TracerPayload.Chunks = append(sc.TracerPayload.Chunks, c.pt.TraceChunk)
+
+			if sc.Size > writer.MaxPayloadSize {
+				s.traceWriter.In <- sc
+				delete(payloads, c.payload)
+			}
+		}
+	}
+	for _, sc := range payloads {
+		s.traceWriter.In <- sc
+	}
+}
+
+// updateBufferStats updates the stats describing the buffer. It must be called with the lock held.
+func (s *TailSampler) updateBufferStats() {
+	s.stats.TracesBuffered.Store(int64(len(s.traces)))
+	s.stats.ChunksBuffered.Store(int64(s.chunks))
+	s.stats.BytesBuffered.Store(s.size)
+}
+
+func (s *TailSampler) report() {
+	var stats info.TailSamplerInfo
+	stats.TracesBuffered.Store(s.stats.TracesBuffered.Load())
+	stats.ChunksBuffered.Store(s.stats.ChunksBuffered.Load())
+	stats.BytesBuffered.Store(s.stats.BytesBuffered.Load())
+	stats.TracesEvicted.Store(s.stats.TracesEvicted.Swap(0))
+	stats.LateChunks.Store(s.stats.LateChunks.Swap(0))
+	stats.TracesKept.Store(s.stats.TracesKept.Swap(0))
+	stats.TracesDropped.Store(s.stats.TracesDropped.Swap(0))
+	info.UpdateTailSamplerInfo(stats)
+
+	metrics.Gauge("datadog.trace_agent.tail_sampler.traces_buffered", float64(stats.TracesBuffered.Load()), nil, 1)
+	metrics.Gauge("datadog.trace_agent.tail_sampler.chunks_buffered", float64(stats.ChunksBuffered.Load()), nil, 1)
+	metrics.Gauge("datadog.trace_agent.tail_sampler.bytes_buffered", float64(stats.BytesBuffered.Load()), nil, 1)
+	metrics.Count("datadog.trace_agent.tail_sampler.evicted", stats.TracesEvicted.Load(), nil, 1)
+	metrics.Count("datadog.trace_agent.tail_sampler.late_chunks", stats.LateChunks.Load(), nil, 1)
+	metrics.Count("datadog.trace_agent.tail_sampler.dropped", stats.TracesDropped.Load(), nil, 1)
+}
+
+// tracerPayloadHeader returns a copy of the attributes of the tracer payload, without its chunks.
+func tracerPayloadHeader(p *pb.TracerPayload) *pb.TracerPayload {
+	return &pb.TracerPayload{
+		ContainerID:     p.GetContainerID(),
+		LanguageName:    p.GetLanguageName(),
+		LanguageVersion: p.GetLanguageVersion(),
+		TracerVersion:   p.GetTracerVersion(),
+		RuntimeID:       p.GetRuntimeID(),
+		Env:             p.GetEnv(),
+		Hostname:        p.GetHostname(),
+		AppVersion:      p.GetAppVersion(),
+		Tags:            p.GetTags(),
+	}
+}
+
+func containsTag(spans []*pb.Span, tags []*config.Tag) bool {
+	if len(tags) == 0 {
+		return false
+	}
+	for _, span := range spans {
+		for _, tag := range tags {
+			if v, ok := span.Meta[tag.K]; ok && (tag.V == "" || v == tag.V) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// latencyDistribution holds the root durations of a service over the current and the previous
+// latency windows.
+type latencyDistribution struct {
+	previous *ddsketch.DDSketch
+	current  *ddsketch.DDSketch
+}
+
+func newLatencyDistribution() (*latencyDistribution, error) {
+	previous, err := ddsketch.LogCollapsingLowestDenseDDSketch(tailLatencyRelativeAccuracy, tailLatencyMaxBins)
+	if err != nil {
+		return nil, err
+	}
+	current, err := ddsketch.LogCollapsingLowestDenseDDSketch(tailLatencyRelativeAccuracy, tailLatencyMaxBins)
+	if err != nil {
+		return nil, err
+	}
+	return &latencyDistribution{previous: previous, current: current}, nil
+}
+
+func (d *latencyDistribution) add(duration int64) {
+	// Only negative values are rejected, and they are meaningless anyway.
+	_ = d.current.Add(float64(duration))
+}
+
+// threshold returns the duration at the given percentile, using the previous window when it holds
+// enough durations. It returns false when there are too few durations to trust the percentile.
+func (d *latencyDistribution) threshold(percentile float64) (float64, bool) {
+	for _, sketch := range []*ddsketch.DDSketch{d.previous, d.current} {
+		if sketch.GetCount() < tailLatencyMinCount {
+			continue
+		}
+		if v, err := sketch.GetValueAtQuantile(percentile); err == nil {
+			// The durations within the accuracy of the sketch are not considered slower.
+			return v * (1 + tailLatencyRelativeAccuracy), true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,345 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package agent
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	pb "github.com/DataDog/datadog-agent/pkg/proto/pbgo/trace"
+	"github.com/DataDog/datadog-agent/pkg/trace/api"
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+	"github.com/DataDog/datadog-agent/pkg/trace/info"
+	"github.com/DataDog/datadog-agent/pkg/trace/sampler"
+	"github.com/DataDog/datadog-agent/pkg/trace/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/trace/testutil"
+	"github.com/DataDog/datadog-agent/pkg/trace/traceutil"
+	"github.com/DataDog/datadog-agent/pkg/trace/writer"
+)
+
+func newTestTailSampler(conf *config.AgentConfig) *TailSampler {
+	return NewTailSampler(conf, &writer.TraceWriter{In: make(chan *writer.SampledChunks, 1000)})
+}
+
+func newTailTestConfig() *config.AgentConfig {
+	conf := config.New()
+	conf.TailSamplingEnabled = true
+	conf.TailSamplingRate = 0
+	return conf
+}
+
+func tailTestTrace(traceID uint64, spans ...*pb.Span) *traceutil.ProcessedTrace {
+	for _, span := range spans {
+		span.TraceID = traceID
+		if span.Service == "" {
+			span.Service = "service"
+		}
+	}
+	chunk := testutil.TraceChunkWithSpans(spans)
+	return &traceutil.ProcessedTrace{
+		TraceChunk: chunk,
+		Root:       traceutil.GetRoot(chunk.Spans),
+		TracerEnv:  "env",
+	}
+}
+
+// sampledSpanIDs returns the IDs of the spans sent to the trace writer.
+func sampledSpanIDs(s *TailSampler) []uint64 {
+	var ids []uint64
+	for {
+		select {
+		case sc := <-s.traceWriter.In:
+			for _, chunk := range sc.TracerPayload.Chunks {
+				for _, span := range chunk.Spans {
+					ids = append(ids, span.SpanID)
+				}
+			}
+		default:
+			return ids
+		}
+	}
+}
+
+func TestTailSamplerBuffersChunks(t *testing.T) {
+	conf := newTailTestConfig()
+	s := newTestTailSampler(conf)
+	now := time.Now()
+	payload := &pb.TracerPayload{Env: "env", Hostname: "host"}
+
+	s.Add(now, payload, tailTestTrace(1, &pb.Span{SpanID: 1, ParentID: 0, Duration: 100}), nil)
+	s.Add(now.Add(time.Second), payload, tailTestTrace(1, &pb.Span{SpanID: 2, ParentID: 1, Duration: 10, Error: 1}), nil)
+	s.Add(now.Add(time.Second), payload, tailTestTrace(2, &pb.Span{SpanID: 3, ParentID: 0, Duration: 10}), nil)
+	assert.EqualValues(t, 2, s.stats.TracesBuffered.Load())
+	assert.EqualValues(t, 3, s.stats.ChunksBuffered.Load())
+
+	// The decision window of the traces is not over
+	s.flush(now.Add(conf.TailSamplingDecisionWait-time.Millisecond), false)
+	assert.Empty(t, sampledSpanIDs(s))
+
+	// The first trace holds an error in its second chunk, the other one is dropped
+	s.flush(now.Add(conf.TailSamplingDecisionWait+time.Second), false)
+	assert.Empty(t, s.traces)
+	var sc *writer.SampledChunks
+	select {
+	case sc = <-s.traceWriter.In:
+	default:
+		require.Fail(t, "no chunks were sampled")
+	}
+	assert.Equal(t, "host", sc.TracerPayload.Hostname)
+	assert.Len(t, sc.TracerPayload.Chunks, 2)
+	assert.EqualValues(t, 2, sc.SpanCount)
+	assert.Empty(t, payload.Chunks)
+	assert.EqualValues(t, 1, s.stats.TracesKept.Load())
+	assert.EqualValues(t, 1, s.stats.TracesDropped.Load())
+	assert.EqualValues(t, 0, s.stats.TracesBuffered.Load())
+
+	// Late chunks follow the decision on their trace
+	s.Add(now.Add(conf.TailSamplingDecisionWait+2*time.Second), payload, tailTestTrace(1, &pb.Span{SpanID: 4, ParentID: 1}), nil)
+	s.Add(now.Add(conf.TailSamplingDecisionWait+2*time.Second), payload, tailTestTrace(2, &pb.Span{SpanID: 5, ParentID: 3}), nil)
+	assert.Equal(t, []uint64{4}, sampledSpanIDs(s))
+	assert.EqualValues(t, 2, s.stats.LateChunks.Load())
+	assert.Empty(t, s.traces)
+
+	// The decisions are eventually forgotten
+	s.flush(now.Add(3*conf.TailSamplingDecisionWait), false)
+	assert.Empty(t, s.decisions)
+}
+
+func TestTailSamplerPolicies(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		conf  func(*config.AgentConfig)
+		spans []*pb.Span
+		keep  bool
+	}{
+		{
+			name:  "error",
+			spans: []*pb.Span{{SpanID: 1}, {SpanID: 2, ParentID: 1, Error: 1}},
+			keep:  true,
+		},
+		{
+			name:  "error policy disabled",
+			conf:  func(c *config.AgentConfig) { c.TailSamplingKeepErrors = false },
+			spans: []*pb.Span{{SpanID: 1}, {SpanID: 2, ParentID: 1, Error: 1}},
+			keep:  false,
+		},
+		{
+			name:  "tag key",
+			conf:  func(c *config.AgentConfig) { c.TailSamplingKeepTags = []*config.Tag{{K: "customer"}} },
+			spans: []*pb.Span{{SpanID: 1}, {SpanID: 2, ParentID: 1, Meta: map[string]string{"customer": "acme"}}},
+			keep:  true,
+		},
+		{
+			name:  "tag value",
+			conf:  func(c *config.AgentConfig) { c.TailSamplingKeepTags = []*config.Tag{{K: "customer", V: "acme"}} },
+			spans: []*pb.Span{{SpanID: 1, Meta: map[string]string{"customer": "other"}}},
+			keep:  false,
+		},
+		{
+			name:  "probabilistic",
+			conf:  func(c *config.AgentConfig) { c.TailSamplingRate = 1 },
+			spans: []*pb.Span{{SpanID: 1}},
+			keep:  true,
+		},
+		{
+			name:  "no policy",
+			spans: []*pb.Span{{SpanID: 1}},
+			keep:  false,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			conf := newTailTestConfig()
+			if tt.conf != nil {
+				tt.conf(conf)
+			}
+			s := newTestTailSampler(conf)
+			s.Add(time.Now(), &pb.TracerPayload{}, tailTestTrace(42, tt.spans...), nil)
+			s.flush(time.Now(), true)
+			assert.Equal(t, tt.keep, len(sampledSpanIDs(s)) > 0)
+		})
+	}
+}
+
+func TestTailSamplerLatencyPolicy(t *testing.T) {
+	conf := newTailTestConfig()
+	s := newTestTailSampler(conf)
+	now := time.Now()
+
+	traceID := uint64(1)
+	addTrace := func(service string, duration time.Duration) {
+		s.Add(now, &pb.TracerPayload{}, tailTestTrace(traceID, &pb.Span{SpanID: traceID, Service: service, Duration: duration.Nanoseconds()}), nil)
+		traceID++
+	}
+
+	// Too few roots are known to compute the percentile
+	addTrace("service", time.Second)
+	s.flush(now, true)
+	assert.Empty(t, sampledSpanIDs(s))
+
+	for i := 0; i < tailLatencyMinCount; i++ {
+		addTrace("service", time.Duration(10+i%10)*time.Millisecond)
+	}
+	s.flush(now, true)
+	assert.Empty(t, sampledSpanIDs(s))
+
+	// The roots slower than the percentile of their service are kept
+	slowTraceID := traceID
+	addTrace("service", time.Second)
+	addTrace("service", 10*time.Millisecond)
+	addTrace("other-service", time.Second)
+	s.flush(now, true)
+	assert.Equal(t, []uint64{slowTraceID}, sampledSpanIDs(s))
+
+	// The percentile is still known in the next window, computed on the previous one
+	s.flush(now.Add(tailLatencyWindow), false)
+	addTrace("service", time.Second)
+	s.flush(now.Add(tailLatencyWindow), true)
+	assert.Len(t, sampledSpanIDs(s), 1)
+
+	// The services without roots in the previous window are forgotten
+	s.flush(now.Add(2*tailLatencyWindow), false)
+	s.flush(now.Add(3*tailLatencyWindow), false)
+	assert.Empty(t, s.latencies)
+}
+
+func TestTailSamplerBufferPressure(t *testing.T) {
+	conf := newTailTestConfig()
+	conf.TailSamplingRate = 1
+	s := newTestTailSampler(conf)
+	now := time.Now()
+
+	pt := tailTestTrace(1, &pb.Span{SpanID: 1})
+	conf.TailSamplingMaxMemory = int64(2 * pt.TraceChunk.Msgsize())
+	s.Add(now, &pb.TracerPayload{}, pt, nil)
+	s.Add(now, &pb.TracerPayload{}, tailTestTrace(2, &pb.Span{SpanID: 2}), nil)
+	assert.Empty(t, sampledSpanIDs(s))
+
+	// The oldest trace is sampled to make room for the new chunk
+	s.Add(now, &pb.TracerPayload{}, tailTestTrace(3, &pb.Span{SpanID: 3}), nil)
+	assert.Equal(t, []uint64{1}, sampledSpanIDs(s))
+	assert.EqualValues(t, 1, s.stats.TracesEvicted.Load())
+	assert.EqualValues(t, 2, s.stats.TracesBuffered.Load())
+	assert.LessOrEqual(t, s.stats.BytesBuffered.Load(), conf.TailSamplingMaxMemory)
+}
+
+func TestTailSamplerServices(t *testing.T) {
+	conf := newTailTestConfig()
+	conf.TailSamplingServices = []string{"tailed"}
+	s := newTestTailSampler(conf)
+
+	assert.True(t, s.Handles(tailTestTrace(1, &pb.Span{Service: "tailed"})))
+	assert.False(t, s.Handles(tailTestTrace(1, &pb.Span{Service: "other"})))
+}
+
+func TestProcessTailSampling(t *testing.T) {
+	conf := newTailTestConfig()
+	conf.Endpoints[0].APIKey = "test"
+	conf.TailSamplingServices = []string{"tailed"}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	agnt := NewTestAgent(ctx, conf, telemetry.NewNoopCollector())
+	agnt.TailSampler.traceWriter = agnt.TraceWriter
+
+	now := time.Now()
+	newChunk := func(traceID uint64, service string, spanError int32) *pb.TraceChunk {
+		chunk := testutil.TraceChunkWithSpan(&pb.Span{
+			TraceID:  traceID,
+			SpanID:   traceID,
+			Service:  service,
+			Name:     "name",
+			Resource: "resource",
+			Start:    now.Add(-time.Second).UnixNano(),
+			Duration: time.Millisecond.Nanoseconds(),
+			Error:    spanError,
+		})
+		chunk.Priority = 1
+		return chunk
+	}
+	payload := testutil.TracerPayloadWithChunks([]*pb.TraceChunk{newChunk(1, "tailed", 1), newChunk(2, "head", 0)})
+	agnt.Process(&api.Payload{
+		TracerPayload: payload,
+		Source:        info.NewReceiverStats().GetTagStats(info.Tags{}),
+	})
+
+	// Only the chunk of the service which is not tail sampled is sent right away
+	sc := <-agnt.TraceWriter.In
+	require.Len(t, sc.TracerPayload.Chunks, 1)
+	assert.Equal(t, "head", sc.TracerPayload.Chunks[0].Spans[0].Service)
+	assert.EqualValues(t, 1, agnt.TailSampler.stats.TracesBuffered.Load())
+
+	agnt.TailSampler.flush(now, true)
+	sc = <-agnt.TraceWriter.In
+	require.Len(t, sc.TracerPayload.Chunks, 1)
+	assert.Equal(t, "tailed", sc.TracerPayload.Chunks[0].Spans[0].Service)
+	assert.Equal(t, payload.Env, sc.TracerPayload.Env)
+}
+
+func TestTailSamplerDroppedTraceEvents(t *testing.T) {
+	conf := newTailTestConfig()
+	s := newTestTailSampler(conf)
+
+	event := &pb.Span{SpanID: 2, ParentID: 1}
+	s.Add(time.Now(), &pb.TracerPayload{}, tailTestTrace(1, &pb.Span{SpanID: 1}, event), []*pb.Span{event})
+	s.Add(time.Now(), &pb.TracerPayload{}, tailTestTrace(2, &pb.Span{SpanID: 3}), nil)
+	s.flush(time.Now(), true)
+
+	// Only the analyzed events of the dropped traces are sent
+	sc := <-s.traceWriter.In
+	require.Len(t, sc.TracerPayload.Chunks, 1)
+	assert.Equal(t, []*pb.Span{event}, sc.TracerPayload.Chunks[0].Spans)
+	assert.True(t, sc.TracerPayload.Chunks[0].DroppedTrace)
+	assert.EqualValues(t, 1, sc.EventCount)
+	assert.EqualValues(t, 0, sc.SpanCount)
+	assert.Empty(t, sampledSpanIDs(s))
+}
+
+func TestProcessTailSamplingPriorities(t *testing.T) {
+	conf := newTailTestConfig()
+	conf.Endpoints[0].APIKey = "test"
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	agnt := NewTestAgent(ctx, conf, telemetry.NewNoopCollector())
+	agnt.TailSampler.traceWriter = agnt.TraceWriter
+
+	now := time.Now()
+	newChunk := func(traceID uint64, priority sampler.SamplingPriority) *pb.TraceChunk {
+		chunk := testutil.TraceChunkWithSpans([]*pb.Span{
+			{TraceID: traceID, SpanID: 1, Service: "service", Name: "root", Start: now.Add(-time.Second).UnixNano(), Duration: time.Millisecond.Nanoseconds()},
+			{TraceID: traceID, SpanID: 2, ParentID: 1, Service: "service", Name: "event", Start: now.Add(-time.Second).UnixNano(), Duration: time.Microsecond.Nanoseconds(),
+				Metrics: map[string]float64{sampler.KeySamplingRateEventExtraction: 1}},
+		})
+		chunk.Priority = int32(priority)
+		return chunk
+	}
+	ts := info.NewReceiverStats().GetTagStats(info.Tags{})
+	agnt.Process(&api.Payload{
+		TracerPayload: testutil.TracerPayloadWithChunks([]*pb.TraceChunk{newChunk(1, sampler.PriorityUserKeep), newChunk(2, sampler.PriorityAutoKeep)}),
+		Source:        ts,
+	})
+
+	// The trace kept by the user is not tail sampled
+	sc := <-agnt.TraceWriter.In
+	require.Len(t, sc.TracerPayload.Chunks, 1)
+	assert.EqualValues(t, 1, sc.TracerPayload.Chunks[0].Spans[0].TraceID)
+	assert.Len(t, sc.TracerPayload.Chunks[0].Spans, 2)
+	assert.EqualValues(t, 1, agnt.TailSampler.stats.TracesBuffered.Load())
+	// The samplers and the event processor still saw the tail sampled chunk
+	assert.EqualValues(t, 2, ts.EventsExtracted.Load())
+
+	// The tail sampler drops the other trace and only sends its analyzed event
+	agnt.TailSampler.flush(now, true)
+	sc = <-agnt.TraceWriter.In
+	require.Len(t, sc.TracerPayload.Chunks, 1)
+	chunk := sc.TracerPayload.Chunks[0]
+	assert.True(t, chunk.DroppedTrace)
+	require.Len(t, chunk.Spans, 1)
+	assert.Equal(t, "event", chunk.Spans[0].Name)
+	assert.EqualValues(t, 1, sc.EventCount)
+}
@@ -353,7 +353,7 @@ func (r *HTTPReceiver) tagStats(v Version, httpHeader http.Header) *info.TagStat
 // - tp is the decoded payload
 // - ranHook reports whether the decoder was able to run the pb.MetaHook
 // - err is the first error encountered
-func decodeTracerPayload(v Version, req *http.Request, ts *info.TagStats, cIDProvider IDProvider) (tp *pb.TracerPayload, ranHook bool, err error) {
+func decodeTracerPayload(v Version, req *http.Request, ts *info.TagStats, cIDProvider IDProvider, maxRequestBytes int64) (tp *pb.TracerPayload, ranHook bool, err error) {
 	switch v {
 	case v01:
 		var spans []*pb.Span
@@ -391,6 +391,34 @@ func decodeTracerPayload(v Version, req *http.Request, ts *info.TagStats, cIDPro
 		var tracerPayload pb.TracerPayload
 		_, err = tracerPayload.UnmarshalMsg(buf.Bytes())
 		return &tracerPayload, true, err
+	case zipkinV2:
+		chunks, err := decodeZipkinRequest(req, maxRequestBytes)
+		if err != nil {
+			return nil, false, err
+		}
+		runMetaHook(chunks)
+		return &pb.TracerPayload{
+			LanguageName:    ts.Lang,
+			LanguageVersion: ts.LangVersion,
+			ContainerID:     cIDProvider.GetContainerID(req.Context(), req.Header),
+			Chunks:          chunks,
+			TracerVersion:   ts.TracerVersion,
+		}, true, nil
+	case jaegerThrift:
+		if tp, err = decodeJaegerRequest(req); err != nil {
+			return nil, false, err
+		}
+		runMetaHook(tp.Chunks)
+		// the Datadog headers take precedence over the tags of the Jaeger process
+		if ts.Lang != "" {
+			tp.LanguageName = ts.Lang
+		}
+		if ts.TracerVersion != "" {
+			tp.TracerVersion = ts.TracerVersion
+		}
+		tp.LanguageVersion = ts.LangVersion
+		tp.ContainerID = cIDProvider.GetContainerID(req.Context(), req.Header)
+		return tp, true, nil
 	default:
 		var traces pb.Traces
 		if ranHook, err = decodeRequest(req, &traces); err != nil {
@@ -411,7 +439,7 @@ func decodeTracerPayload(v Version, req *http.Request, ts *info.TagStats, cIDPro
 // was successful.
 func (r *HTTPReceiver) replyOK(req *http.Request, v Version, w http.ResponseWriter) (n uint64, ok bool) {
 	switch v {
-	case v01, v02, v03:
+	case v01, v02, v03, zipkinV2, jaegerThrift:
 		return httpOK(w)
 	default:
 		ratesVersion := req.Header.Get(header.RatesPayloadVersion)
@@ -481,7 +509,7 @@ func (r *HTTPReceiver) handleTraces(v Version, w http.ResponseWriter, req *http.
 	}()
 
 	start := time.Now()
-	tp, ranHook, err := decodeTracerPayload(v, req, ts, r.containerIDProvider)
+	tp, ranHook, err := decodeTracerPayload(v, req, ts, r.containerIDProvider, r.conf.MaxRequestBytes)
 	defer func(err error) {
 		tags := append(ts.AsTags(), fmt.Sprintf("success:%v", err == nil))
 		metrics.Histogram("datadog.trace_agent.receiver.serve_traces_ms", float64(time.Since(start))/float64(time.Millisecond), tags, 1)
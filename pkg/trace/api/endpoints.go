@@ -89,6 +89,16 @@ var endpoints = []Endpoint{
 		Pattern: "/v0.7/traces",
 		Handler: func(r *HTTPReceiver) http.Handler { return r.handleWithVersion(V07, r.handleTraces) },
 	},
+	{
+		Pattern:   "/api/v2/spans",
+		Handler:   func(r *HTTPReceiver) http.Handler { return r.handleWithVersion(zipkinV2, r.handleTraces) },
+		IsEnabled: func(cfg *config.AgentConfig) bool { return cfg.ZipkinReceiverEnabled },
+	},
+	{
+		Pattern:   "/api/traces",
+		Handler:   func(r *HTTPReceiver) http.Handler { return r.handleWithVersion(jaegerThrift, r.handleTraces) },
+		IsEnabled: func(cfg *config.AgentConfig) bool { return cfg.JaegerReceiverEnabled },
+	},
 	{
 		Pattern: "/profiling/v1/input",
 		Handler: func(r *HTTPReceiver) http.Handler { return r.profileProxyHandler() },
@@ -0,0 +1,241 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package thrift implements a reader for the Thrift binary protocol, as used by the
+// Jaeger clients to send their spans over HTTP.
+package thrift
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// Type specifies the type of a Thrift value.
+type Type byte
+
+// The types of the Thrift binary protocol.
+const (
+	STOP   Type = 0
+	BOOL   Type = 2
+	BYTE   Type = 3
+	DOUBLE Type = 4
+	I16    Type = 6
+	I32    Type = 8
+	I64    Type = 10
+	STRING Type = 11
+	STRUCT Type = 12
+	MAP    Type = 13
+	SET    Type = 14
+	LIST   Type = 15
+)
+
+// maxSkipDepth is the maximum nesting of the values skipped by Skip.
+const maxSkipDepth = 64
+
+// ErrInvalidSize is returned when the size of a value exceeds the remaining length of the payload.
+var ErrInvalidSize = errors.New("thrift: invalid size")
+
+// Reader reads Thrift values encoded with the binary protocol from a byte slice.
+type Reader struct {
+	b   []byte
+	off int
+}
+
+// NewReader returns a new Reader reading from b.
+func NewReader(b []byte) *Reader {
+	return &Reader{b: b}
+}
+
+func (r *Reader) next(n int) ([]byte, error) {
+	if n > len(r.b)-r.off {
+		r.off = len(r.b)
+		return nil, io.ErrUnexpectedEOF
+	}
+	b := r.b[r.off : r.off+n]
+	r.off += n
+	return b, nil
+}
+
+// ReadByte reads a byte.
+func (r *Reader) ReadByte() (byte, error) {
+	b, err := r.next(1)
+	if err != nil {
+		return 0, err
+	}
+	return b[0], nil
+}
+
+// ReadBool reads a boolean.
+func (r *Reader) ReadBool() (bool, error) {
+	b, err := r.ReadByte()
+	return b != 0, err
+}
+
+// ReadI16 reads a 16-bit integer.
+func (r *Reader) ReadI16() (int16, error) {
+	b, err := r.next(2)
+	if err != nil {
+		return 0, err
+	}
+	return int16(binary.BigEndian.Uint16(b)), nil
+}
+
+// ReadI32 reads a 32-bit integer.
+func (r *Reader) ReadI32() (int32, error) {
+	b, err := r.next(4)
+	if err != nil {
+		return 0, err
+	}
+	return int32(binary.BigEndian.Uint32(b)), nil
+}
+
+// ReadI64 reads a 64-bit integer.
+func (r *Reader) ReadI64() (int64, error) {
+	b, err := r.next(8)
+	if err != nil {
+		return 0, err
+	}
+	return int64(binary.BigEndian.Uint64(b)), nil
+}
+
+// ReadDouble reads a double.
+func (r *Reader) ReadDouble() (float64, error) {
+	b, err := r.next(8)
+	if err != nil {
+		return 0, err
+	}
+	return math.Float64frombits(binary.BigEndian.Uint64(b)), nil
+}
+
+// ReadBinary reads a binary value. The returned slice references the payload of the reader.
+func (r *Reader) ReadBinary() ([]byte, error) {
+	n, err := r.readSize(1)
+	if err != nil {
+		return nil, err
+	}
+	return r.next(n)
+}
+
+// ReadString reads a string.
+func (r *Reader) ReadString() (string, error) {
+	b, err := r.ReadBinary()
+	return string(b), err
+}
+
+// ReadFieldBegin reads the header of a struct field. The type is STOP after the last field
+// of the struct.
+func (r *Reader) ReadFieldBegin() (typ Type, id int16, err error) {
+	b, err := r.ReadByte()
+	if err != nil || Type(b) == STOP {
+		return Type(b), 0, err
+	}
+	id, err = r.ReadI16()
+	return Type(b), id, err
+}
+
+// ReadListBegin reads the header of a list, or of a set.
+func (r *Reader) ReadListBegin() (elem Type, size int, err error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return 0, 0, err
+	}
+	// each element takes at least one byte
+	size, err = r.readSize(1)
+	return Type(b), size, err
+}
+
+// ReadMapBegin reads the header of a map.
+func (r *Reader) ReadMapBegin() (key, value Type, size int, err error) {
+	k, err := r.ReadByte()
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	v, err := r.ReadByte()
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	size, err = r.readSize(2)
+	return Type(k), Type(v), size, err
+}
+
+// readSize reads a size and checks it against the remaining length of the payload, given
+// the minimum number of bytes taken by each element. This prevents huge allocations on
+// invalid payloads.
+func (r *Reader) readSize(minElemSize int) (int, error) {
+	n, err := r.ReadI32()
+	if err != nil {
+		return 0, err
+	}
+	if n < 0 || int64(n)*int64(minElemSize) > int64(len(r.b)-r.off) {
+		return 0, ErrInvalidSize
+	}
+	return int(n), nil
+}
+
+// Skip skips a value of the given type.
+func (r *Reader) Skip(typ Type) error {
+	return r.skip(typ, 0)
+}
+
+func (r *Reader) skip(typ Type, depth int) error {
+	if depth > maxSkipDepth {
+		return errors.New("thrift: maximum depth exceeded")
+	}
+	var err error
+	switch typ {
+	case BOOL, BYTE:
+		_, err = r.next(1)
+	case I16:
+		_, err = r.next(2)
+	case I32:
+		_, err = r.next(4)
+	case DOUBLE, I64:
+		_, err = r.next(8)
+	case STRING:
+		_, err = r.ReadBinary()
+	case STRUCT:
+		for {
+			ft, _, err := r.ReadFieldBegin()
+			if err != nil {
+				return err
+			}
+			if ft == STOP {
+				return nil
+			}
+			if err := r.skip(ft, depth+1); err != nil {
+				return err
+			}
+		}
+	case MAP:
+		k, v, n, err := r.ReadMapBegin()
+		if err != nil {
+			return err
+		}
+		for i := 0; i < n; i++ {
+			if err := r.skip(k, depth+1); err != nil {
+				return err
+			}
+			if err := r.skip(v, depth+1); err != nil {
+				return err
+			}
+		}
+	case SET, LIST:
+		elem, n, err := r.ReadListBegin()
+		if err != nil {
+			return err
+		}
+		for i := 0; i < n; i++ {
+			if err := r.skip(elem, depth+1); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("thrift: unknown type %d", typ)
+	}
+	return err
+}
@@ -0,0 +1,392 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package api
+
+import (
+	"encoding/base64"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	pb "github.com/DataDog/datadog-agent/pkg/proto/pbgo/trace"
+	"github.com/DataDog/datadog-agent/pkg/trace/api/internal/thrift"
+	"github.com/DataDog/datadog-agent/pkg/trace/sampler"
+)
+
+// The types of the values of the Jaeger tags.
+const (
+	jaegerTagString int32 = iota
+	jaegerTagDouble
+	jaegerTagBool
+	jaegerTagLong
+	jaegerTagBinary
+)
+
+// jaegerFlagDebug is the flag of the Jaeger spans which must be kept.
+const jaegerFlagDebug = 2
+
+// jaegerRefChildOf is the type of the references to the parent of a Jaeger span.
+const jaegerRefChildOf = 0
+
+// jaegerProcess, jaegerSpan and their fields hold the structures of the Jaeger Thrift definition
+// (https://github.com/jaegertracing/jaeger-idl/blob/main/thrift/jaeger.thrift).
+type jaegerProcess struct {
+	serviceName string
+	tags        []jaegerTag
+}
+
+type jaegerSpan struct {
+	traceIDLow    int64
+	traceIDHigh   int64
+	spanID        int64
+	parentSpanID  int64
+	operationName string
+	references    []jaegerSpanRef
+	flags         int32
+	startTime     int64 // microseconds since epoch
+	duration      int64 // microseconds
+	tags          []jaegerTag
+	logs          []jaegerLog
+}
+
+type jaegerSpanRef struct {
+	refType int32
+	spanID  int64
+}
+
+type jaegerTag struct {
+	key     string
+	vType   int32
+	vStr    string
+	vDouble float64
+	vBool   bool
+	vLong   int64
+	vBinary []byte
+}
+
+type jaegerLog struct {
+	timestamp int64 // microseconds since epoch
+	fields    []jaegerTag
+}
+
+// decodeJaegerRequest decodes the Jaeger batch of the request, encoded with the Thrift binary
+// protocol, and returns it as a tracer payload. The hostname, the language and the tracer
+// version of the payload are taken from the tags of the process which sent the batch.
+func decodeJaegerRequest(req *http.Request) (*pb.TracerPayload, error) {
+	buf := getBuffer()
+	defer putBuffer(buf)
+	if _, err := io.Copy(buf, req.Body); err != nil {
+		return nil, err
+	}
+	process, spans, err := readJaegerBatch(thrift.NewReader(buf.Bytes()))
+	if err != nil {
+		return nil, err
+	}
+	tp := &pb.TracerPayload{}
+	var processTags []jaegerTag
+	for _, t := range process.tags {
+		switch t.key {
+		case "hostname":
+			tp.Hostname = t.vStr
+		case "jaeger.version":
+			// the version of the client, e.g. "Go-2.30.0"
+			if lang, version, ok := strings.Cut(t.vStr, "-"); ok {
+				tp.LanguageName = strings.ToLower(lang)
+				tp.TracerVersion = "jaeger-" + version
+			}
+		default:
+			processTags = append(processTags, t)
+		}
+	}
+	converted := make([]*pb.Span, 0, len(spans))
+	priorities := make(map[uint64]sampler.SamplingPriority)
+	traceIDsHigh := make(map[uint64]uint64)
+	for i := range spans {
+		span := convertJaegerSpan(process.serviceName, processTags, &spans[i])
+		if spans[i].traceIDHigh != 0 {
+			traceIDsHigh[span.TraceID] = uint64(spans[i].traceIDHigh)
+		}
+		if p, ok := span.Metrics["_sampling_priority_v1"]; ok {
+			priorities[span.TraceID] = sampler.SamplingPriority(p)
+		} else if spans[i].flags&jaegerFlagDebug != 0 {
+			priorities[span.TraceID] = sampler.PriorityUserKeep
+		}
+		converted = append(converted, span)
+	}
+	tp.Chunks = traceChunksFromSpans(converted)
+	setUserPriorities(tp.Chunks, priorities)
+	setTraceIDsHigh(tp.Chunks, traceIDsHigh)
+	return tp, nil
+}
+
+// convertJaegerSpan converts the Jaeger span in to a Datadog span. The tags of the process
+// are added to the span.
+func convertJaegerSpan(service string, processTags []jaegerTag, in *jaegerSpan) *pb.Span {
+	span := &pb.Span{
+		Service:  service,
+		Name:     in.operationName,
+		TraceID:  uint64(in.traceIDLow),
+		SpanID:   uint64(in.spanID),
+		ParentID: uint64(in.parentSpanID),
+		Start:    in.startTime * 1000,
+		Duration: in.duration * 1000,
+		Meta:     make(map[string]string, len(processTags)+len(in.tags)),
+		Metrics:  map[string]float64{},
+	}
+	if span.ParentID == 0 {
+		// recent clients only set the parent in the references
+		for _, ref := range in.references {
+			if ref.refType == jaegerRefChildOf {
+				span.ParentID = uint64(ref.spanID)
+				break
+			}
+		}
+	}
+	for i := range processTags {
+		setJaegerTag(span, &processTags[i])
+	}
+	for i := range in.tags {
+		t := &in.tags[i]
+		if t.key == "error" {
+			if (t.vType == jaegerTagBool && t.vBool) || (t.vType == jaegerTagString && t.vStr == "true") {
+				span.Error = 1
+			}
+			continue
+		}
+		setJaegerTag(span, t)
+	}
+	if len(in.logs) > 0 {
+		events := make([]spanEvent, 0, len(in.logs))
+		for _, l := range in.logs {
+			e := spanEvent{TimeUnixNano: uint64(l.timestamp) * 1000}
+			for i := range l.fields {
+				f := &l.fields[i]
+				if f.key == "event" {
+					e.Name = f.vStr
+					continue
+				}
+				if e.Attributes == nil {
+					e.Attributes = make(map[string]string, len(l.fields))
+				}
+				e.Attributes[f.key] = f.String()
+			}
+			if span.Error == 1 && e.Name == "error" {
+				setJaegerErrorDetails(span, e.Attributes)
+			}
+			events = append(events, e)
+		}
+		span.Meta["events"] = marshalSpanEvents(events)
+	}
+	completeSpan(span, in.operationName)
+	return span
+}
+
+// setJaegerErrorDetails sets the error details of the span from the fields of an error log,
+// as defined by the OpenTracing semantic conventions.
+func setJaegerErrorDetails(span *pb.Span, fields map[string]string) {
+	for tag, keys := range map[string][]string{
+		"error.msg":   {"message", "error.object"},
+		"error.type":  {"error.kind"},
+		"error.stack": {"stack"},
+	} {
+		if _, ok := span.Meta[tag]; ok {
+			continue
+		}
+		if _, v := getFirstFromMap(fields, keys...); v != "" {
+			span.Meta[tag] = v
+		}
+	}
+}
+
+// setJaegerTag sets the Jaeger tag t on span s, numeric tags are set as metrics.
+func setJaegerTag(s *pb.Span, t *jaegerTag) {
+	switch t.vType {
+	case jaegerTagDouble:
+		setMetricOTLP(s, t.key, t.vDouble)
+	case jaegerTagLong:
+		setMetricOTLP(s, t.key, float64(t.vLong))
+	default:
+		setMetaOTLP(s, t.key, t.String())
+	}
+}
+
+// String returns the value of the tag as a string.
+func (t *jaegerTag) String() string {
+	switch t.vType {
+	case jaegerTagDouble:
+		return strconv.FormatFloat(t.vDouble, 'f', -1, 64)
+	case jaegerTagBool:
+		return strconv.FormatBool(t.vBool)
+	case jaegerTagLong:
+		return strconv.FormatInt(t.vLong, 10)
+	case jaegerTagBinary:
+		return base64.StdEncoding.EncodeToString(t.vBinary)
+	default:
+		return t.vStr
+	}
+}
+
+// readThriftStruct calls fn for each field of the struct read by r. fn must read or skip the
+// value of the field.
+func readThriftStruct(r *thrift.Reader, fn func(typ thrift.Type, id int16) error) error {
+	for {
+		typ, id, err := r.ReadFieldBegin()
+		if err != nil {
+			return err
+		}
+		if typ == thrift.STOP {
+			return nil
+		}
+		if err := fn(typ, id); err != nil {
+			return err
+		}
+	}
+}
+
+// readThriftList calls fn for each element of the list read by r. The elements which are
+// not of type elem are skipped.
+func readThriftList(r *thrift.Reader, elem thrift.Type, fn func() error) error {
+	typ, n, err := r.ReadListBegin()
+	if err != nil {
+		return err
+	}
+	for i := 0; i < n; i++ {
+		if typ != elem {
+			err = r.Skip(typ)
+		} else {
+			err = fn()
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readJaegerBatch reads a Batch struct of the Jaeger Thrift definition.
+func readJaegerBatch(r *thrift.Reader) (process jaegerProcess, spans []jaegerSpan, err error) {
+	err = readThriftStruct(r, func(typ thrift.Type, id int16) error {
+		switch {
+		case id == 1 && typ == thrift.STRUCT:
+			return readThriftStruct(r, func(typ thrift.Type, id int16) (err error) {
+				switch {
+				case id == 1 && typ == thrift.STRING:
+					process.serviceName, err = r.ReadString()
+				case id == 2 && typ == thrift.LIST:
+					process.tags, err = readJaegerTags(r)
+				default:
+					err = r.Skip(typ)
+				}
+				return err
+			})
+		case id == 2 && typ == thrift.LIST:
+			return readThriftList(r, thrift.STRUCT, func() error {
+				var s jaegerSpan
+				if err := readJaegerSpan(r, &s); err != nil {
+					return err
+				}
+				spans = append(spans, s)
+				return nil
+			})
+		}
+		return r.Skip(typ)
+	})
+	return process, spans, err
+}
+
+func readJaegerSpan(r *thrift.Reader, s *jaegerSpan) error {
+	return readThriftStruct(r, func(typ thrift.Type, id int16) (err error) {
+		switch {
+		case id == 1 && typ == thrift.I64:
+			s.traceIDLow, err = r.ReadI64()
+		case id == 2 && typ == thrift.I64:
+			s.traceIDHigh, err = r.ReadI64()
+		case id == 3 && typ == thrift.I64:
+			s.spanID, err = r.ReadI64()
+		case id == 4 && typ == thrift.I64:
+			s.parentSpanID, err = r.ReadI64()
+		case id == 5 && typ == thrift.STRING:
+			s.operationName, err = r.ReadString()
+		case id == 6 && typ == thrift.LIST:
+			err = readThriftList(r, thrift.STRUCT, func() error {
+				var ref jaegerSpanRef
+				err := readThriftStruct(r, func(typ thrift.Type, id int16) (err error) {
+					switch {
+					case id == 1 && typ == thrift.I32:
+						ref.refType, err = r.ReadI32()
+					case id == 4 && typ == thrift.I64:
+						ref.spanID, err = r.ReadI64()
+					default:
+						err = r.Skip(typ)
+					}
+					return err
+				})
+				s.references = append(s.references, ref)
+				return err
+			})
+		case id == 7 && typ == thrift.I32:
+			s.flags, err = r.ReadI32()
+		case id == 8 && typ == thrift.I64:
+			s.startTime, err = r.ReadI64()
+		case id == 9 && typ == thrift.I64:
+			s.duration, err = r.ReadI64()
+		case id == 10 && typ == thrift.LIST:
+			s.tags, err = readJaegerTags(r)
+		case id == 11 && typ == thrift.LIST:
+			err = readThriftList(r, thrift.STRUCT, func() error {
+				var l jaegerLog
+				err := readThriftStruct(r, func(typ thrift.Type, id int16) (err error) {
+					switch {
+					case id == 1 && typ == thrift.I64:
+						l.timestamp, err = r.ReadI64()
+					case id == 2 && typ == thrift.LIST:
+						l.fields, err = readJaegerTags(r)
+					default:
+						err = r.Skip(typ)
+					}
+					return err
+				})
+				s.logs = append(s.logs, l)
+				return err
+			})
+		default:
+			err = r.Skip(typ)
+		}
+		return err
+	})
+}
+
+func readJaegerTags(r *thrift.Reader) ([]jaegerTag, error) {
+	var tags []jaegerTag
+	err := readThriftList(r, thrift.STRUCT, func() error {
+		var t jaegerTag
+		err := readThriftStruct(r, func(typ thrift.Type, id int16) (err error) {
+			switch {
+			case id == 1 && typ == thrift.STRING:
+				t.key, err = r.ReadString()
+			case id == 2 && typ == thrift.I32:
+				t.vType, err = r.ReadI32()
+			case id == 3 && typ == thrift.STRING:
+				t.vStr, err = r.ReadString()
+			case id == 4 && typ == thrift.DOUBLE:
+				t.vDouble, err = r.ReadDouble()
+			case id == 5 && typ == thrift.BOOL:
+				t.vBool, err = r.ReadBool()
+			case id == 6 && typ == thrift.I64:
+				t.vLong, err = r.ReadI64()
+			case id == 7 && typ == thrift.STRING:
+				t.vBinary, err = r.ReadBinary()
+			default:
+				err = r.Skip(typ)
+			}
+			return err
+		})
+		tags = append(tags, t)
+		return err
+	})
+	return tags, err
+}
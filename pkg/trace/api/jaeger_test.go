@@ -0,0 +1,121 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package api
+
+import (
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	pb "github.com/DataDog/datadog-agent/pkg/proto/pbgo/trace"
+	"github.com/DataDog/datadog-agent/pkg/trace/sampler"
+)
+
+func TestJaegerReceiver(t *testing.T) {
+	rr, p := postForeignSpans(t, jaegerThrift, "testdata/jaeger_batch.thrift", "application/x-thrift")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	require.NotNil(t, p)
+	assert.Equal(t, "payments-7d9f", p.TracerPayload.Hostname)
+	assert.Equal(t, "go", p.TracerPayload.LanguageName)
+	assert.Equal(t, "jaeger-2.30.0", p.TracerPayload.TracerVersion)
+	assert.Equal(t, "jaeger_thrift", p.Source.EndpointVersion)
+
+	expected := []*pb.TraceChunk{
+		{
+			Priority: int32(sampler.PriorityUserKeep),
+			Spans: []*pb.Span{{
+				Service:  "payments",
+				Name:     "reconcile",
+				Resource: "reconcile",
+				TraceID:  7,
+				SpanID:   7,
+				Start:    1700000001000000000,
+				Duration: 45000000,
+				Type:     "custom",
+				Meta: map[string]string{
+					"ip":          "10.1.2.3",
+					"client-uuid": "6b9d7a0e1f2c3d4e",
+					"_dd.p.dm":    "-4",
+				},
+				Metrics: map[string]float64{
+					"_sampling_priority_v1": 2,
+					"ratio":                 0.25,
+				},
+			}},
+		},
+		{
+			Priority: int32(sampler.PriorityUserKeep),
+			Spans: []*pb.Span{
+				{
+					Service:  "payments",
+					Name:     "GET",
+					Resource: "GET",
+					TraceID:  0xa3ce929d0e0e4736,
+					SpanID:   2,
+					ParentID: 0x00f067aa0ba902b7,
+					Start:    1700000000010000000,
+					Duration: 2000000,
+					Type:     "cache",
+					Meta: map[string]string{
+						"ip":           "10.1.2.3",
+						"client-uuid":  "6b9d7a0e1f2c3d4e",
+						"span.kind":    "client",
+						"db.system":    "redis",
+						"db.statement": "GET session",
+						"cache.hit":    "false",
+					},
+					Metrics: map[string]float64{},
+				},
+				{
+					Service:  "payments",
+					Name:     "HTTP POST /charge",
+					Resource: "POST",
+					TraceID:  0xa3ce929d0e0e4736,
+					SpanID:   0x00f067aa0ba902b7,
+					Start:    1700000000000000000,
+					Duration: 350000000,
+					Error:    1,
+					Type:     "web",
+					Meta: map[string]string{
+						"ip":          "10.1.2.3",
+						"client-uuid": "6b9d7a0e1f2c3d4e",
+						"span.kind":   "server",
+						"http.method": "POST",
+						"http.url":    "/charge",
+						"error.msg":   "upstream timed out",
+						"error.type":  "TimeoutError",
+						"error.stack": "main.charge()\n\tmain.go:42",
+						"events":      `[{"time_unix_nano":1700000000300000000,"name":"error","attributes":{"error.kind":"TimeoutError","message":"upstream timed out","stack":"main.charge()\n\tmain.go:42"}}]`,
+						"_dd.p.dm":    "-4",
+						"_dd.p.tid":   "4bf92f3577b34da6",
+					},
+					Metrics: map[string]float64{
+						"http.status_code": 502,
+					},
+				},
+			},
+		},
+	}
+	assert.Equal(t, expected, sortedChunks(p))
+}
+
+func TestJaegerReceiverInvalidPayload(t *testing.T) {
+	body, err := os.ReadFile("testdata/jaeger_batch.thrift")
+	require.NoError(t, err)
+
+	// truncated payload
+	rr, p := postForeignPayload(t, jaegerThrift, body[:len(body)/2], "application/x-thrift")
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Nil(t, p)
+
+	// list larger than the payload
+	rr, p = postForeignPayload(t, jaegerThrift, []byte{0x0f, 0x00, 0x02, 0x0c, 0x7f, 0xff, 0xff, 0xff}, "application/x-thrift")
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Nil(t, p)
+}
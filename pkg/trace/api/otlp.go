@@ -350,6 +350,10 @@ func (o *OTLPReceiver) createChunks(tracesByID map[uint64]pb.Trace, prioritiesBy
 			chunk.Priority = int32(o.sample(k))
 			traceutil.SetMeta(spans[0], "_dd.p.dm", "-9")
 		}
+		if tid := spans[0].Meta["otel.trace_id"]; len(tid) == 32 && tid[:16] != "0000000000000000" {
+			// the high 64 bits of the trace ID, as propagated by the Datadog tracers
+			traceutil.SetMeta(spans[0], "_dd.p.tid", tid[:16])
+		}
 		traceChunks = append(traceChunks, chunk)
 	}
 	return traceChunks
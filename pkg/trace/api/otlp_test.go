@@ -203,7 +203,7 @@ func TestCreateChunks(t *testing.T) {
 	traces := map[uint64]pb.Trace{
 		traceID1: {{TraceID: traceID1, SpanID: 1}, {TraceID: traceID1, SpanID: 2}},
 		traceID2: {{TraceID: traceID2, SpanID: 1}, {TraceID: traceID2, SpanID: 2}},
-		traceID3: {{TraceID: traceID3, SpanID: 1}, {TraceID: traceID3, SpanID: 2}},
+		traceID3: {{TraceID: traceID3, SpanID: 1, Meta: map[string]string{"otel.trace_id": "72df520af2bde7a500000120381f0792"}}, {TraceID: traceID3, SpanID: 2}},
 	}
 	priorities := map[uint64]sampler.SamplingPriority{
 		traceID3: sampler.PriorityUserKeep,
@@ -219,6 +219,7 @@ func TestCreateChunks(t *testing.T) {
 			found += 1
 			require.Equal(t, "-9", c.Spans[0].Meta["_dd.p.dm"])
 			require.Equal(t, int32(1), c.Priority)
+			require.NotContains(t, c.Spans[0].Meta, "_dd.p.tid")
 		case traceID2:
 			found += 2
 			require.Equal(t, "-9", c.Spans[0].Meta["_dd.p.dm"])
@@ -227,6 +228,7 @@ func TestCreateChunks(t *testing.T) {
 			found += 3
 			require.Equal(t, "-4", c.Spans[0].Meta["_dd.p.dm"])
 			require.Equal(t, int32(2), c.Priority)
+			require.Equal(t, "72df520af2bde7a5", c.Spans[0].Meta["_dd.p.tid"])
 		}
 	}
 	require.Equal(t, 6, found)
@@ -118,4 +118,24 @@ const (
 	// Response: Service sampling rates (see description in v04).
 	//
 	V07 Version = "v0.7"
+
+	// zipkinV2 API
+	//
+	// Request: Zipkin v2 spans (https://zipkin.io/zipkin-api/#/default/post_spans).
+	// 	Content-Type: application/json or application/x-protobuf
+	// 	Payload: A list of spans.
+	//
+	// Response: OK.
+	//
+	zipkinV2 Version = "zipkin_v2"
+
+	// jaegerThrift API
+	//
+	// Request: Jaeger batch (https://github.com/jaegertracing/jaeger-idl/blob/main/thrift/jaeger.thrift).
+	// 	Content-Type: application/x-thrift
+	// 	Payload: A Batch struct, encoded with the Thrift binary protocol.
+	//
+	// Response: OK.
+	//
+	jaegerThrift Version = "jaeger_thrift"
 )
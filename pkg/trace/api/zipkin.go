@@ -0,0 +1,498 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package api
+
+import (
+	"compress/gzip"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	semconv "go.opentelemetry.io/collector/semconv/v1.6.1"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	pb "github.com/DataDog/datadog-agent/pkg/proto/pbgo/trace"
+	"github.com/DataDog/datadog-agent/pkg/trace/api/apiutil"
+	"github.com/DataDog/datadog-agent/pkg/trace/sampler"
+	"github.com/DataDog/datadog-agent/pkg/trace/traceutil"
+)
+
+// zipkinSpan is a span of the Zipkin v2 API (https://zipkin.io/zipkin-api/#/default/post_spans).
+// The spans encoded in protobuf are decoded into the same structure as the JSON ones.
+type zipkinSpan struct {
+	TraceID        string             `json:"traceId"`
+	ParentID       string             `json:"parentId"`
+	ID             string             `json:"id"`
+	Kind           string             `json:"kind"`
+	Name           string             `json:"name"`
+	Timestamp      uint64             `json:"timestamp"` // microseconds since epoch
+	Duration       uint64             `json:"duration"`  // microseconds
+	Debug          bool               `json:"debug"`
+	Shared         bool               `json:"shared"`
+	LocalEndpoint  *zipkinEndpoint    `json:"localEndpoint"`
+	RemoteEndpoint *zipkinEndpoint    `json:"remoteEndpoint"`
+	Annotations    []zipkinAnnotation `json:"annotations"`
+	Tags           map[string]string  `json:"tags"`
+}
+
+type zipkinEndpoint struct {
+	ServiceName string `json:"serviceName"`
+	IPv4        string `json:"ipv4"`
+	IPv6        string `json:"ipv6"`
+	Port        int32  `json:"port"`
+}
+
+type zipkinAnnotation struct {
+	Timestamp uint64 `json:"timestamp"` // microseconds since epoch
+	Value     string `json:"value"`
+}
+
+// zipkinProtoKinds maps the values of the Span.Kind enum of the Zipkin protobuf
+// definition to the kinds of the JSON encoding.
+var zipkinProtoKinds = []string{"", "CLIENT", "SERVER", "PRODUCER", "CONSUMER"}
+
+// decodeZipkinRequest decodes the Zipkin v2 spans of the request, encoded in JSON or in
+// protobuf and optionally compressed with gzip, and returns them as trace chunks. At most
+// maxBytes bytes are decompressed.
+func decodeZipkinRequest(req *http.Request, maxBytes int64) ([]*pb.TraceChunk, error) {
+	body := req.Body
+	if strings.EqualFold(req.Header.Get("Content-Encoding"), "gzip") {
+		gz, err := gzip.NewReader(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		body = apiutil.NewLimitedReader(gz, maxBytes)
+	}
+	var spans []zipkinSpan
+	switch getMediaType(req) {
+	case "application/x-protobuf", "application/protobuf":
+		buf := getBuffer()
+		defer putBuffer(buf)
+		if _, err := io.Copy(buf, body); err != nil {
+			return nil, err
+		}
+		var err error
+		if spans, err = unmarshalZipkinProto(buf.Bytes()); err != nil {
+			return nil, err
+		}
+	default:
+		if err := json.NewDecoder(body).Decode(&spans); err != nil {
+			return nil, err
+		}
+	}
+	converted := make([]*pb.Span, 0, len(spans))
+	priorities := make(map[uint64]sampler.SamplingPriority)
+	traceIDsHigh := make(map[uint64]uint64)
+	sharedIDs := make(map[zipkinSharedSpan]uint64)
+	for i := range spans {
+		span, traceIDHigh, err := convertZipkinSpan(&spans[i])
+		if err != nil {
+			return nil, err
+		}
+		if traceIDHigh != 0 {
+			traceIDsHigh[span.TraceID] = traceIDHigh
+		}
+		if spans[i].Debug {
+			priorities[span.TraceID] = sampler.PriorityUserKeep
+		}
+		if spans[i].Shared {
+			// The server side of a shared span has the ID of its client side, it gets its own
+			// ID and becomes a child of the client side.
+			key := zipkinSharedSpan{traceID: span.TraceID, spanID: span.SpanID, service: span.Service}
+			span.ParentID = span.SpanID
+			span.SpanID = sharedSpanID(span.SpanID, span.Service)
+			sharedIDs[key] = span.SpanID
+		}
+		converted = append(converted, span)
+	}
+	if len(sharedIDs) > 0 {
+		// The children of the server side of a shared span are recorded by the same service.
+		for _, span := range converted {
+			if id, ok := sharedIDs[zipkinSharedSpan{traceID: span.TraceID, spanID: span.ParentID, service: span.Service}]; ok && id != span.SpanID {
+				span.ParentID = id
+			}
+		}
+	}
+	chunks := traceChunksFromSpans(converted)
+	setUserPriorities(chunks, priorities)
+	setTraceIDsHigh(chunks, traceIDsHigh)
+	return chunks, nil
+}
+
+// zipkinSharedSpan identifies the server side of a shared span.
+type zipkinSharedSpan struct {
+	traceID uint64
+	spanID  uint64
+	service string
+}
+
+// sharedSpanID returns the ID given to the server side of the shared span id recorded by
+// service. It doesn't depend on the payload, so that retried payloads get the same IDs.
+func sharedSpanID(id uint64, service string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(strconv.FormatUint(id, 16)))
+	h.Write([]byte(service))
+	return h.Sum64()
+}
+
+// convertZipkinSpan converts the Zipkin span in to a Datadog span. It also returns the high
+// 64 bits of the trace ID, which are 0 for 64-bit trace IDs.
+func convertZipkinSpan(in *zipkinSpan) (span *pb.Span, traceIDHigh uint64, err error) {
+	traceIDHigh, traceID, err := parseTraceID(in.TraceID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid trace ID %q: %v", in.TraceID, err)
+	}
+	spanID, err := parseHexID(in.ID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid span ID %q: %v", in.ID, err)
+	}
+	var parentID uint64
+	if in.ParentID != "" {
+		if parentID, err = parseHexID(in.ParentID); err != nil {
+			return nil, 0, fmt.Errorf("invalid parent ID %q: %v", in.ParentID, err)
+		}
+	}
+	span = &pb.Span{
+		TraceID:  traceID,
+		SpanID:   spanID,
+		ParentID: parentID,
+		Name:     in.Name,
+		Start:    int64(in.Timestamp) * 1000,
+		Duration: int64(in.Duration) * 1000,
+		Meta:     make(map[string]string, len(in.Tags)+1),
+		Metrics:  map[string]float64{},
+	}
+	if in.LocalEndpoint != nil {
+		span.Service = in.LocalEndpoint.ServiceName
+	}
+	if in.Kind != "" {
+		span.Meta["span.kind"] = strings.ToLower(in.Kind)
+	}
+	var errMsg string
+	for k, v := range in.Tags {
+		if k == "error" {
+			// the value of the error tag is the error message, when it is known
+			span.Error = 1
+			if v != "" && v != "true" {
+				errMsg = v
+			}
+			continue
+		}
+		setMetaOTLP(span, k, v)
+	}
+	if _, ok := span.Meta["error.msg"]; !ok && errMsg != "" {
+		span.Meta["error.msg"] = errMsg
+	}
+	if re := in.RemoteEndpoint; re != nil {
+		if _, ok := span.Meta[semconv.AttributePeerService]; !ok && re.ServiceName != "" {
+			span.Meta[semconv.AttributePeerService] = re.ServiceName
+		}
+		host := re.IPv4
+		if host == "" {
+			host = re.IPv6
+		}
+		if _, ok := span.Meta["out.host"]; !ok && host != "" {
+			span.Meta["out.host"] = host
+			if re.Port != 0 {
+				span.Meta["out.port"] = strconv.Itoa(int(re.Port))
+			}
+		}
+	}
+	if len(in.Annotations) > 0 {
+		events := make([]spanEvent, 0, len(in.Annotations))
+		for _, a := range in.Annotations {
+			events = append(events, spanEvent{TimeUnixNano: a.Timestamp * 1000, Name: a.Value})
+		}
+		span.Meta["events"] = marshalSpanEvents(events)
+	}
+	completeSpan(span, in.Name)
+	return span, traceIDHigh, nil
+}
+
+// parseTraceID parses the high and low 64 bits of a trace ID of at most 32 hexadecimal
+// characters.
+func parseTraceID(id string) (high, low uint64, err error) {
+	if len(id) <= 16 {
+		low, err = parseHexID(id)
+		return 0, low, err
+	}
+	if len(id) > 32 {
+		return 0, 0, fmt.Errorf("expected 1 to 32 hexadecimal characters")
+	}
+	if high, err = strconv.ParseUint(id[:len(id)-16], 16, 64); err != nil {
+		return 0, 0, err
+	}
+	low, err = parseHexID(id[len(id)-16:])
+	return high, low, err
+}
+
+// parseHexID parses an ID of at most 16 hexadecimal characters.
+func parseHexID(id string) (uint64, error) {
+	if id == "" || len(id) > 16 {
+		return 0, fmt.Errorf("expected 1 to 16 hexadecimal characters")
+	}
+	return strconv.ParseUint(id, 16, 64)
+}
+
+// unmarshalZipkinProto decodes a ListOfSpans message of the Zipkin protobuf definition
+// (https://github.com/openzipkin/zipkin-api/blob/master/zipkin.proto).
+func unmarshalZipkinProto(b []byte) ([]zipkinSpan, error) {
+	var spans []zipkinSpan
+	err := walkProtoFields(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		if num != 1 || typ != protowire.BytesType {
+			return protowire.ConsumeFieldValue(num, typ, b), nil
+		}
+		v, n := protowire.ConsumeBytes(b)
+		if n < 0 {
+			return n, nil
+		}
+		var s zipkinSpan
+		if err := unmarshalZipkinProtoSpan(v, &s); err != nil {
+			return 0, err
+		}
+		spans = append(spans, s)
+		return n, nil
+	})
+	return spans, err
+}
+
+func unmarshalZipkinProtoSpan(b []byte, s *zipkinSpan) error {
+	return walkProtoFields(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		switch {
+		case num == 1 && typ == protowire.BytesType:
+			v, n := protowire.ConsumeBytes(b)
+			s.TraceID = hex.EncodeToString(v)
+			return n, nil
+		case num == 2 && typ == protowire.BytesType:
+			v, n := protowire.ConsumeBytes(b)
+			s.ParentID = hex.EncodeToString(v)
+			return n, nil
+		case num == 3 && typ == protowire.BytesType:
+			v, n := protowire.ConsumeBytes(b)
+			s.ID = hex.EncodeToString(v)
+			return n, nil
+		case num == 4 && typ == protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			if v < uint64(len(zipkinProtoKinds)) {
+				s.Kind = zipkinProtoKinds[v]
+			}
+			return n, nil
+		case num == 5 && typ == protowire.BytesType:
+			v, n := protowire.ConsumeString(b)
+			s.Name = v
+			return n, nil
+		case num == 6 && typ == protowire.Fixed64Type:
+			v, n := protowire.ConsumeFixed64(b)
+			s.Timestamp = v
+			return n, nil
+		case num == 7 && typ == protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			s.Duration = v
+			return n, nil
+		case (num == 8 || num == 9) && typ == protowire.BytesType:
+			v, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return n, nil
+			}
+			e := &zipkinEndpoint{}
+			if err := unmarshalZipkinProtoEndpoint(v, e); err != nil {
+				return 0, err
+			}
+			if num == 8 {
+				s.LocalEndpoint = e
+			} else {
+				s.RemoteEndpoint = e
+			}
+			return n, nil
+		case num == 10 && typ == protowire.BytesType:
+			v, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return n, nil
+			}
+			var a zipkinAnnotation
+			err := walkProtoFields(v, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+				switch {
+				case num == 1 && typ == protowire.Fixed64Type:
+					v, n := protowire.ConsumeFixed64(b)
+					a.Timestamp = v
+					return n, nil
+				case num == 2 && typ == protowire.BytesType:
+					v, n := protowire.ConsumeString(b)
+					a.Value = v
+					return n, nil
+				}
+				return protowire.ConsumeFieldValue(num, typ, b), nil
+			})
+			if err != nil {
+				return 0, err
+			}
+			s.Annotations = append(s.Annotations, a)
+			return n, nil
+		case num == 11 && typ == protowire.BytesType:
+			v, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return n, nil
+			}
+			var key, value string
+			err := walkProtoFields(v, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+				if (num == 1 || num == 2) && typ == protowire.BytesType {
+					v, n := protowire.ConsumeString(b)
+					if num == 1 {
+						key = v
+					} else {
+						value = v
+					}
+					return n, nil
+				}
+				return protowire.ConsumeFieldValue(num, typ, b), nil
+			})
+			if err != nil {
+				return 0, err
+			}
+			if s.Tags == nil {
+				s.Tags = make(map[string]string)
+			}
+			s.Tags[key] = value
+			return n, nil
+		case num == 12 && typ == protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			s.Debug = v != 0
+			return n, nil
+		case num == 13 && typ == protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			s.Shared = v != 0
+			return n, nil
+		}
+		return protowire.ConsumeFieldValue(num, typ, b), nil
+	})
+}
+
+func unmarshalZipkinProtoEndpoint(b []byte, e *zipkinEndpoint) error {
+	return walkProtoFields(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		switch {
+		case num == 1 && typ == protowire.BytesType:
+			v, n := protowire.ConsumeString(b)
+			e.ServiceName = v
+			return n, nil
+		case (num == 2 || num == 3) && typ == protowire.BytesType:
+			v, n := protowire.ConsumeBytes(b)
+			if len(v) == 0 {
+				return n, nil
+			}
+			if num == 2 {
+				e.IPv4 = net.IP(v).String()
+			} else {
+				e.IPv6 = net.IP(v).String()
+			}
+			return n, nil
+		case num == 4 && typ == protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			e.Port = int32(v)
+			return n, nil
+		}
+		return protowire.ConsumeFieldValue(num, typ, b), nil
+	})
+}
+
+// walkProtoFields calls fn for each field of the protobuf message b. fn must return the
+// number of bytes taken by the value of the field, or a negative protowire error code.
+func walkProtoFields(b []byte, fn func(num protowire.Number, typ protowire.Type, b []byte) (int, error)) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		n, err := fn(num, typ, b)
+		if err != nil {
+			return err
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+	}
+	return nil
+}
+
+// spanEvent is an event of a span, marshalled in the format used for the events of the
+// OTLP spans.
+type spanEvent struct {
+	TimeUnixNano uint64            `json:"time_unix_nano,omitempty"`
+	Name         string            `json:"name,omitempty"`
+	Attributes   map[string]string `json:"attributes,omitempty"`
+}
+
+// marshalSpanEvents marshals events into JSON.
+func marshalSpanEvents(events []spanEvent) string {
+	b, err := json.Marshal(events)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+// spanKindsByName maps the span kinds used as tags to their OTLP values.
+var spanKindsByName = map[string]ptrace.SpanKind{
+	"internal": ptrace.SpanKindInternal,
+	"server":   ptrace.SpanKindServer,
+	"client":   ptrace.SpanKindClient,
+	"producer": ptrace.SpanKindProducer,
+	"consumer": ptrace.SpanKindConsumer,
+}
+
+// completeSpan sets the fields of the span converted from the Zipkin or Jaeger formats
+// which were not set through its tags: the resource is deduced from the tags, or is the
+// operation name, the type is deduced from the span kind.
+func completeSpan(span *pb.Span, operationName string) {
+	if span.Resource == "" {
+		if r := resourceFromTags(span.Meta); r != "" {
+			span.Resource = r
+		} else {
+			span.Resource = operationName
+		}
+	}
+	if span.Type == "" {
+		span.Type = spanKind2Type(spanKindsByName[span.Meta["span.kind"]], span)
+	}
+	if _, ok := span.Meta["env"]; !ok {
+		if env := span.Meta[string(semconv.AttributeDeploymentEnvironment)]; env != "" {
+			span.Meta["env"] = traceutil.NormalizeTag(env)
+		}
+	}
+}
+
+// setTraceIDsHigh sets the high 64 bits of the 128-bit trace IDs on the first span of their
+// chunks, in the "_dd.p.tid" tag used by the Datadog tracers.
+func setTraceIDsHigh(chunks []*pb.TraceChunk, traceIDsHigh map[uint64]uint64) {
+	for _, chunk := range chunks {
+		if high, ok := traceIDsHigh[chunk.Spans[0].TraceID]; ok {
+			traceutil.SetMeta(chunk.Spans[0], "_dd.p.tid", fmt.Sprintf("%016x", high))
+		}
+	}
+}
+
+// setUserPriorities sets the priority of the chunks of the traces sampled by the user,
+// through the debug flag of their spans for instance. The other chunks have no priority,
+// they are sampled by the agent.
+func setUserPriorities(chunks []*pb.TraceChunk, priorities map[uint64]sampler.SamplingPriority) {
+	for _, chunk := range chunks {
+		p, ok := priorities[chunk.Spans[0].TraceID]
+		if !ok {
+			continue
+		}
+		chunk.Priority = int32(p)
+		traceutil.SetMeta(chunk.Spans[0], "_dd.p.dm", "-4")
+	}
+}
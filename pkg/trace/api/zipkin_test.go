@@ -0,0 +1,233 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package api
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	pb "github.com/DataDog/datadog-agent/pkg/proto/pbgo/trace"
+	"github.com/DataDog/datadog-agent/pkg/trace/sampler"
+)
+
+// postForeignSpans sends the spans of the testdata file to the receiver through the handler
+// of the endpoint v. It returns the payload sent to the agent, if any.
+func postForeignSpans(t *testing.T, v Version, file, contentType string) (*httptest.ResponseRecorder, *Payload) {
+	body, err := os.ReadFile(file)
+	require.NoError(t, err)
+	return postForeignPayload(t, v, body, contentType)
+}
+
+func postForeignPayload(t *testing.T, v Version, body []byte, contentType string) (*httptest.ResponseRecorder, *Payload) {
+	req, err := http.NewRequest("POST", "/", bytes.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", contentType)
+	return postForeignRequest(v, req)
+}
+
+func postForeignRequest(v Version, req *http.Request) (*httptest.ResponseRecorder, *Payload) {
+	receiver := newTestReceiverFromConfig(newTestReceiverConfig())
+	rr := httptest.NewRecorder()
+	receiver.handleWithVersion(v, receiver.handleTraces).ServeHTTP(rr, req)
+	select {
+	case p := <-receiver.out:
+		return rr, p
+	default:
+		return rr, nil
+	}
+}
+
+// sortedChunks returns the chunks of the payload sorted by trace ID, with their spans sorted by ID.
+func sortedChunks(p *Payload) []*pb.TraceChunk {
+	chunks := p.TracerPayload.Chunks
+	sort.Slice(chunks, func(i, j int) bool { return chunks[i].Spans[0].TraceID < chunks[j].Spans[0].TraceID })
+	for _, c := range chunks {
+		sort.Slice(c.Spans, func(i, j int) bool { return c.Spans[i].SpanID < c.Spans[j].SpanID })
+	}
+	return chunks
+}
+
+func TestZipkinReceiver(t *testing.T) {
+	expected := []*pb.TraceChunk{
+		{
+			Priority: int32(sampler.PriorityNone),
+			Spans: []*pb.Span{
+				{
+					Service:  "frontend",
+					Name:     "get /users/{id}",
+					Resource: "GET /users/{id}",
+					TraceID:  0x48485a3953bb6124,
+					SpanID:   0x48485a3953bb6124,
+					Start:    1700000000000000000,
+					Duration: 207000000,
+					Error:    1,
+					Type:     "web",
+					Meta: map[string]string{
+						"_dd.p.tid":        "463ac35c9f6413ad",
+						"span.kind":        "server",
+						"http.method":      "GET",
+						"http.path":        "/users/42",
+						"http.route":       "/users/{id}",
+						"http.status_code": "500",
+						"error.msg":        "Internal Server Error",
+						"out.host":         "172.19.0.2",
+						"out.port":         "58648",
+						"events":           `[{"time_unix_nano":1700000000001000000,"name":"wr"}]`,
+					},
+					Metrics: map[string]float64{},
+				},
+				{
+					Service:  "frontend",
+					Name:     "select",
+					Resource: "select",
+					TraceID:  0x48485a3953bb6124,
+					SpanID:   0xe457b5a2e4d86bd1,
+					ParentID: 0x48485a3953bb6124,
+					Start:    1700000000005000000,
+					Duration: 150000000,
+					Type:     "db",
+					Meta: map[string]string{
+						"span.kind":    "client",
+						"db.system":    "mysql",
+						"sql.query":    "SELECT * FROM users WHERE id = ?",
+						"peer.service": "mysql",
+						"out.host":     "10.0.0.5",
+						"out.port":     "3306",
+					},
+					Metrics: map[string]float64{},
+				},
+			},
+		},
+		{
+			Priority: int32(sampler.PriorityUserKeep),
+			Spans: []*pb.Span{{
+				Service:  "backend",
+				Name:     "send",
+				Resource: "send orders",
+				TraceID:  0x5c3b1f2d8e7a6b49,
+				SpanID:   0x5c3b1f2d8e7a6b49,
+				Start:    1700000001000000000,
+				Duration: 1200000,
+				Type:     "custom",
+				Meta: map[string]string{
+					"span.kind":             "producer",
+					"messaging.destination": "orders",
+					"messaging.operation":   "send",
+					"messaging.system":      "kafka",
+					"_dd.p.dm":              "-4",
+				},
+				Metrics: map[string]float64{},
+			}},
+		},
+	}
+	for _, tt := range []struct {
+		file        string
+		contentType string
+	}{
+		{"testdata/zipkin_v2.json", "application/json"},
+		{"testdata/zipkin_v2.pb", "application/x-protobuf"},
+	} {
+		t.Run(tt.contentType, func(t *testing.T) {
+			rr, p := postForeignSpans(t, zipkinV2, tt.file, tt.contentType)
+			assert.Equal(t, http.StatusOK, rr.Code)
+			require.NotNil(t, p)
+			assert.Equal(t, expected, sortedChunks(p))
+			assert.EqualValues(t, 2, p.Source.TracesReceived.Load())
+			assert.Equal(t, "zipkin_v2", p.Source.EndpointVersion)
+		})
+	}
+}
+
+func TestZipkinReceiverGzip(t *testing.T) {
+	body, err := os.ReadFile("testdata/zipkin_v2.json")
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	_, err = gz.Write(body)
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+
+	req, err := http.NewRequest("POST", "/", &buf)
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Encoding", "gzip")
+	rr, p := postForeignRequest(zipkinV2, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	require.NotNil(t, p)
+	assert.Len(t, p.TracerPayload.Chunks, 2)
+
+	req, err = http.NewRequest("POST", "/", bytes.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Encoding", "gzip")
+	rr, p = postForeignRequest(zipkinV2, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Nil(t, p)
+}
+
+func TestZipkinReceiverSharedSpans(t *testing.T) {
+	body := `[
+		{"traceId":"a","id":"1","name":"get","kind":"SERVER","localEndpoint":{"serviceName":"frontend"}},
+		{"traceId":"a","id":"2","parentId":"1","name":"call","kind":"CLIENT","localEndpoint":{"serviceName":"frontend"}},
+		{"traceId":"a","id":"2","parentId":"1","name":"call","kind":"SERVER","shared":true,"localEndpoint":{"serviceName":"backend"}},
+		{"traceId":"a","id":"3","parentId":"2","name":"query","localEndpoint":{"serviceName":"backend"}}
+	]`
+	rr, p := postForeignPayload(t, zipkinV2, []byte(body), "application/json")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	require.NotNil(t, p)
+	require.Len(t, p.TracerPayload.Chunks, 1)
+
+	parents := make(map[uint64]uint64)
+	for _, span := range p.TracerPayload.Chunks[0].Spans {
+		_, ok := parents[span.SpanID]
+		require.False(t, ok, "duplicate span ID %d", span.SpanID)
+		parents[span.SpanID] = span.ParentID
+	}
+	// The server side of the shared span is a child of its client side, and the parent of
+	// the spans of its service.
+	serverID := sharedSpanID(2, "backend")
+	assert.Equal(t, map[uint64]uint64{1: 0, 2: 1, serverID: 2, 3: serverID}, parents)
+}
+
+func TestZipkinReceiverInvalidSpans(t *testing.T) {
+	for name, body := range map[string]string{
+		"trace ID":  `[{"traceId":"not-hex","id":"1"}]`,
+		"span ID":   `[{"traceId":"1","id":"463ac35c9f6413ad48485a3953bb6124"}]`,
+		"parent ID": `[{"traceId":"1","id":"1","parentId":"x"}]`,
+		"json":      `{"traceId":"1"}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			rr, p := postForeignPayload(t, zipkinV2, []byte(body), "application/json")
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+			assert.Nil(t, p)
+		})
+	}
+	rr, p := postForeignPayload(t, zipkinV2, []byte{0x0a, 0x10, 0x01}, "application/x-protobuf")
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Nil(t, p)
+}
+
+func TestZipkinJaegerEndpointsEnabled(t *testing.T) {
+	for _, enabled := range []bool{false, true} {
+		conf := newTestReceiverConfig()
+		conf.ZipkinReceiverEnabled = enabled
+		conf.JaegerReceiverEnabled = enabled
+		mux := newTestReceiverFromConfig(conf).buildMux()
+		for _, path := range []string{"/api/v2/spans", "/api/traces"} {
+			req := httptest.NewRequest("POST", path, nil)
+			_, pattern := mux.Handler(req)
+			assert.Equal(t, enabled, pattern == path, path)
+		}
+	}
+}
@@ -8,6 +8,7 @@ package config
 import (
 	"crypto/tls"
 	"errors"
+	"fmt"
 	"net"
 	"net/http"
 	"net/url"
@@ -77,6 +78,17 @@ type OTLP struct {
 	ProbabilisticSampling float64
 }
 
+// OTLPExporter holds the configuration for the OTLP/HTTP exporter, which sends the
+// sampled traces to an OpenTelemetry compatible backend alongside the Datadog intake.
+type OTLPExporter struct {
+	// Endpoint specifies the URL to which the traces are exported, e.g. http://localhost:4318/v1/traces.
+	// If empty, the exporter is disabled.
+	Endpoint string
+
+	// Headers specifies additional HTTP headers sent with each request, e.g. for authentication.
+	Headers map[string]string
+}
+
 // ObfuscationConfig holds the configuration for obfuscating sensitive data
 // for various span types.
 type ObfuscationConfig struct {
@@ -180,6 +192,105 @@ type ReplaceRule struct {
 	Repl string `mapstructure:"repl"`
 }
 
+// Span rule actions.
+const (
+	// SpanRuleDrop drops the matching spans, their children are attached to their parent.
+	SpanRuleDrop = "drop"
+	// SpanRuleSetTag sets the Key tag to Value.
+	SpanRuleSetTag = "set_tag"
+	// SpanRuleRemoveTag removes the Key tag.
+	SpanRuleRemoveTag = "remove_tag"
+	// SpanRuleRenameTag renames the Key tag to NewKey.
+	SpanRuleRenameTag = "rename_tag"
+	// SpanRuleTruncateTag truncates the value of the Key tag to MaxLength bytes. The "*" key
+	// targets all the string tags.
+	SpanRuleTruncateTag = "truncate_tag"
+)
+
+// SpanRule specifies an action applied to the spans matching its conditions. A rule without
+// conditions matches all the spans.
+type SpanRule struct {
+	// Service, Name and Resource are regexp patterns the service, the operation name and
+	// the resource of the span must match. They must compile.
+	Service  string `mapstructure:"service"`
+	Name     string `mapstructure:"name"`
+	Resource string `mapstructure:"resource"`
+
+	// Tags lists the tags the span must have, as "key:value" or "key" to only require the
+	// tag to be set.
+	Tags []string `mapstructure:"tags"`
+
+	// Action specifies what is done to the matching spans, it is one of the SpanRule* constants.
+	Action string `mapstructure:"action"`
+
+	// Key specifies the tag targeted by the action.
+	Key string `mapstructure:"key"`
+
+	// Value specifies the value of the tag set by the set_tag action.
+	Value string `mapstructure:"value"`
+
+	// NewKey specifies the new name of the tag renamed by the rename_tag action.
+	NewKey string `mapstructure:"new_key"`
+
+	// MaxLength specifies the maximum length of the tag values for the truncate_tag action.
+	MaxLength int `mapstructure:"max_length"`
+
+	// ServiceRe, NameRe and ResourceRe hold the compiled patterns and are only used internally.
+	ServiceRe  *regexp.Regexp `mapstructure:"-"`
+	NameRe     *regexp.Regexp `mapstructure:"-"`
+	ResourceRe *regexp.Regexp `mapstructure:"-"`
+}
+
+// CompileSpanRules validates the span rules and compiles their patterns.
+// If it fails it returns the first error.
+func CompileSpanRules(rules []*SpanRule) error {
+	for i, r := range rules {
+		if err := r.compile(); err != nil {
+			return fmt.Errorf("rule %d: %s", i, err)
+		}
+	}
+	return nil
+}
+
+// compileSpanRulePattern returns nil for an empty pattern, which matches all the spans.
+func compileSpanRulePattern(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+	return regexp.Compile(pattern)
+}
+
+func (r *SpanRule) compile() error {
+	switch r.Action {
+	case SpanRuleDrop:
+	case SpanRuleSetTag, SpanRuleRemoveTag:
+		if r.Key == "" {
+			return fmt.Errorf("the %q action requires a \"key\"", r.Action)
+		}
+	case SpanRuleRenameTag:
+		if r.Key == "" || r.NewKey == "" {
+			return fmt.Errorf("the %q action requires a \"key\" and a \"new_key\"", r.Action)
+		}
+	case SpanRuleTruncateTag:
+		if r.Key == "" || r.MaxLength <= 0 {
+			return fmt.Errorf("the %q action requires a \"key\" and a positive \"max_length\"", r.Action)
+		}
+	default:
+		return fmt.Errorf("unknown action %q", r.Action)
+	}
+	var err error
+	if r.ServiceRe, err = compileSpanRulePattern(r.Service); err != nil {
+		return err
+	}
+	if r.NameRe, err = compileSpanRulePattern(r.Name); err != nil {
+		return err
+	}
+	if r.ResourceRe, err = compileSpanRulePattern(r.Resource); err != nil {
+		return err
+	}
+	return nil
+}
+
 // WriterConfig specifies configuration for an API writer.
 type WriterConfig struct {
 	// ConnectionLimit specifies the maximum number of concurrent outgoing
@@ -284,6 +395,12 @@ type AgentConfig struct {
 	PeerServiceAggregation bool          // enables/disables stats aggregation for peer.service, used by Concentrator and ClientStatsAggregator
 	ComputeStatsBySpanKind bool          // enables/disables the computing of stats based on a span's `span.kind` field
 	PeerTags               []string      // additional tags to use for peer.service-related stats aggregation
+	// StatsCustomTags are the keys of the span tags used as additional stats aggregation
+	// dimensions, by both the Concentrator and the ClientStatsAggregator.
+	StatsCustomTags []string
+	// StatsCustomTagsMaxCardinality is the maximum number of distinct values of each custom
+	// tag in a stats bucket. The values past the limit are folded into a single one.
+	StatsCustomTagsMaxCardinality int
 
 	// Sampler configuration
 	ExtraSampleRate float64
@@ -298,6 +415,17 @@ type AgentConfig struct {
 	RareSamplerCooldownPeriod time.Duration
 	RareSamplerCardinality    int
 
+	// Tail Sampler configuration
+	TailSamplingEnabled      bool
+	TailSamplingServices     []string      // services whose traces are tail sampled, all of them when empty
+	TailSamplingDecisionWait time.Duration // time a trace is buffered after its first chunk before being sampled
+	TailSamplingMaxMemory    int64         // maximum size of the buffered chunks, in bytes
+	// Tail sampling policies, a trace is kept as soon as one of them matches.
+	TailSamplingKeepErrors        bool    // keeps the traces with an error
+	TailSamplingLatencyPercentile float64 // keeps the traces whose root is slower than this percentile of its service, disabled when 0
+	TailSamplingKeepTags          []*Tag  // keeps the traces holding one of these tags
+	TailSamplingRate              float64 // rate of the traces kept when no other policy matches
+
 	// Receiver
 	ReceiverHost    string
 	ReceiverPort    int
@@ -310,6 +438,11 @@ type AgentConfig struct {
 	MaxConnections  int   // specifies the maximum number of concurrent incoming connections allowed.
 	DecoderTimeout  int   // specifies the maximum time in milliseconds that the decoders will wait for a turn to accept a payload before returning 429
 
+	// ZipkinReceiverEnabled enables the Zipkin v2 endpoint of the receiver (/api/v2/spans).
+	ZipkinReceiverEnabled bool
+	// JaegerReceiverEnabled enables the Jaeger Thrift over HTTP endpoint of the receiver (/api/traces).
+	JaegerReceiverEnabled bool
+
 	WindowsPipeName        string
 	PipeBufferSize         int
 	PipeSecurityDescriptor string
@@ -355,6 +488,9 @@ type AgentConfig struct {
 	// It maps tag keys to a set of replacements. Only supported in A6.
 	ReplaceTags []*ReplaceRule
 
+	// SpanRules drop spans and update their tags after the stats are computed.
+	SpanRules []*SpanRule
+
 	// GlobalTags list metadata that will be added to all spans
 	GlobalTags map[string]string
 
@@ -386,6 +522,9 @@ type AgentConfig struct {
 	// OTLPReceiver holds the configuration for OpenTelemetry receiver.
 	OTLPReceiver *OTLP
 
+	// OTLPExporter holds the configuration for the OTLP/HTTP exporter of sampled traces.
+	OTLPExporter *OTLPExporter
+
 	// ProfilingProxy specifies settings for the profiling proxy.
 	ProfilingProxy ProfilingProxyConfig
 
@@ -458,7 +597,8 @@ func New() *AgentConfig {
 		Site:                "datadoghq.com",
 		MaxCatalogEntries:   5000,
 
-		BucketInterval: time.Duration(10) * time.Second,
+		BucketInterval:                time.Duration(10) * time.Second,
+		StatsCustomTagsMaxCardinality: 100,
 
 		ExtraSampleRate: 1.0,
 		TargetTPS:       10,
@@ -471,6 +611,12 @@ func New() *AgentConfig {
 		RareSamplerCooldownPeriod: 5 * time.Minute,
 		RareSamplerCardinality:    200,
 
+		TailSamplingDecisionWait:      10 * time.Second,
+		TailSamplingMaxMemory:         50 * 1024 * 1024, // 50MB
+		TailSamplingKeepErrors:        true,
+		TailSamplingLatencyPercentile: 0.99,
+		TailSamplingRate:              0.1,
+
 		ReceiverHost:           "localhost",
 		ReceiverPort:           8126,
 		MaxRequestBytes:        25 * 1024 * 1024, // 25MB
@@ -502,6 +648,7 @@ func New() *AgentConfig {
 
 		Proxy:         http.ProxyFromEnvironment,
 		OTLPReceiver:  &OTLP{},
+		OTLPExporter:  &OTLPExporter{},
 		ContainerTags: noopContainerTagsFunc,
 		TelemetryConfig: &TelemetryConfig{
 			Endpoints: []*Endpoint{{Host: TelemetryEndpointPrefix + "datadoghq.com"}},
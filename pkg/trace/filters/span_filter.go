@@ -0,0 +1,203 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package filters
+
+import (
+	"strings"
+	"sync"
+
+	pb "github.com/DataDog/datadog-agent/pkg/proto/pbgo/trace"
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+	"github.com/DataDog/datadog-agent/pkg/trace/traceutil"
+)
+
+// SpanFilter is a filter which drops spans and updates their tags based on its rules.
+// The rules of the configuration are applied first, followed by the rules received
+// through remote configuration, which can be updated at any time.
+//
+// The spans are never updated in place, the filter updates copies of them. This way
+// the stats computed on the original trace are not affected by the rules.
+type SpanFilter struct {
+	rules []*config.SpanRule
+
+	mu          sync.RWMutex
+	remoteRules []*config.SpanRule
+}
+
+// NewSpanFilter returns a new SpanFilter which will use the given set of rules. The rules
+// must have been compiled with config.CompileSpanRules.
+func NewSpanFilter(rules []*config.SpanRule) *SpanFilter {
+	return &SpanFilter{rules: rules}
+}
+
+// SetRemoteRules replaces the rules received through remote configuration. The rules must
+// have been compiled with config.CompileSpanRules.
+func (f *SpanFilter) SetRemoteRules(rules []*config.SpanRule) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.remoteRules = rules
+}
+
+// Apply applies the rules to the spans of the chunk. The children of the dropped spans are
+// attached to the closest parent which is kept. The root is never dropped.
+//
+// It returns the root of the chunk, which is a copy of the given one when it was updated,
+// and the number of dropped spans.
+func (f *SpanFilter) Apply(chunk *pb.TraceChunk, root *pb.Span) (*pb.Span, int) {
+	f.mu.RLock()
+	remoteRules := f.remoteRules
+	f.mu.RUnlock()
+	if len(f.rules) == 0 && len(remoteRules) == 0 {
+		return root, 0
+	}
+
+	spans := make([]*pb.Span, 0, len(chunk.Spans))
+	// droppedParents maps the IDs of the dropped spans to the IDs of their parent
+	var droppedParents map[uint64]uint64
+	updated := false
+	for _, s := range chunk.Spans {
+		isRoot := s == root
+		s, drop, copied := applySpanRules(s, isRoot, f.rules, remoteRules)
+		if drop {
+			if droppedParents == nil {
+				droppedParents = make(map[uint64]uint64)
+			}
+			droppedParents[s.SpanID] = s.ParentID
+			continue
+		}
+		if copied {
+			updated = true
+			if isRoot {
+				root = s
+			}
+		}
+		spans = append(spans, s)
+	}
+	if !updated && len(droppedParents) == 0 {
+		return root, 0
+	}
+
+	for i, s := range spans {
+		parentID, ok := droppedParents[s.ParentID]
+		if !ok {
+			continue
+		}
+		// the number of iterations is bounded in case of a cycle between the dropped spans
+		for n := 0; n < len(droppedParents); n++ {
+			next, ok := droppedParents[parentID]
+			if !ok {
+				break
+			}
+			parentID = next
+		}
+		c := s.ShallowCopy()
+		c.ParentID = parentID
+		if s == root {
+			root = c
+		}
+		spans[i] = c
+	}
+	chunk.Spans = spans
+	return root, len(droppedParents)
+}
+
+// applySpanRules applies the rules to the span. It returns the span, which is a copy when
+// it was updated, and whether it must be dropped.
+func applySpanRules(s *pb.Span, isRoot bool, ruleSets ...[]*config.SpanRule) (span *pb.Span, drop bool, copied bool) {
+	span = s
+	update := func() {
+		if !copied {
+			span = copySpan(s)
+			copied = true
+		}
+	}
+	for _, rules := range ruleSets {
+		for _, r := range rules {
+			if !spanRuleMatches(r, span) {
+				continue
+			}
+			switch r.Action {
+			case config.SpanRuleDrop:
+				if !isRoot {
+					return s, true, false
+				}
+			case config.SpanRuleSetTag:
+				if v, ok := span.Meta[r.Key]; !ok || v != r.Value {
+					update()
+					traceutil.SetMeta(span, r.Key, r.Value)
+				}
+			case config.SpanRuleRemoveTag:
+				_, inMeta := span.Meta[r.Key]
+				_, inMetrics := span.Metrics[r.Key]
+				if inMeta || inMetrics {
+					update()
+					delete(span.Meta, r.Key)
+					delete(span.Metrics, r.Key)
+				}
+			case config.SpanRuleRenameTag:
+				if v, ok := span.Meta[r.Key]; ok {
+					update()
+					delete(span.Meta, r.Key)
+					span.Meta[r.NewKey] = v
+				} else if v, ok := span.Metrics[r.Key]; ok {
+					update()
+					delete(span.Metrics, r.Key)
+					span.Metrics[r.NewKey] = v
+				}
+			case config.SpanRuleTruncateTag:
+				for k, v := range span.Meta {
+					if (r.Key == "*" || k == r.Key) && len(v) > r.MaxLength {
+						update()
+						span.Meta[k] = traceutil.TruncateUTF8(v, r.MaxLength)
+					}
+				}
+			}
+		}
+	}
+	return span, false, copied
+}
+
+// spanRuleMatches returns true if the span matches all the conditions of the rule.
+func spanRuleMatches(r *config.SpanRule, s *pb.Span) bool {
+	if r.ServiceRe != nil && !r.ServiceRe.MatchString(s.Service) {
+		return false
+	}
+	if r.NameRe != nil && !r.NameRe.MatchString(s.Name) {
+		return false
+	}
+	if r.ResourceRe != nil && !r.ResourceRe.MatchString(s.Resource) {
+		return false
+	}
+	for _, t := range r.Tags {
+		k, v, hasValue := strings.Cut(t, ":")
+		if hasValue {
+			if s.Meta[k] != v {
+				return false
+			}
+			continue
+		}
+		_, inMeta := s.Meta[k]
+		_, inMetrics := s.Metrics[k]
+		if !inMeta && !inMetrics {
+			return false
+		}
+	}
+	return true
+}
+
+// copySpan returns a copy of the span with copies of its tags, which can be updated.
+func copySpan(s *pb.Span) *pb.Span {
+	c := s.ShallowCopy()
+	c.Meta = make(map[string]string, len(s.Meta))
+	for k, v := range s.Meta {
+		c.Meta[k] = v
+	}
+	c.Metrics = make(map[string]float64, len(s.Metrics))
+	for k, v := range s.Metrics {
+		c.Metrics[k] = v
+	}
+	return c
+}
@@ -0,0 +1,161 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package filters
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	pb "github.com/DataDog/datadog-agent/pkg/proto/pbgo/trace"
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+)
+
+func newSpanFilter(t *testing.T, rules ...*config.SpanRule) *SpanFilter {
+	require.NoError(t, config.CompileSpanRules(rules))
+	return NewSpanFilter(rules)
+}
+
+func spanParents(spans []*pb.Span) map[uint64]uint64 {
+	parents := make(map[uint64]uint64, len(spans))
+	for _, s := range spans {
+		parents[s.SpanID] = s.ParentID
+	}
+	return parents
+}
+
+func TestSpanFilterDrop(t *testing.T) {
+	root := &pb.Span{SpanID: 1, Service: "web", Name: "redis.command"}
+	spans := []*pb.Span{
+		root,
+		{SpanID: 2, ParentID: 1, Service: "web", Name: "redis.command"},
+		{SpanID: 3, ParentID: 2, Service: "web", Name: "redis.command"},
+		{SpanID: 4, ParentID: 3, Service: "web", Name: "redis.parse"},
+		{SpanID: 5, ParentID: 1, Service: "web", Name: "http.request"},
+		{SpanID: 6, ParentID: 5, Service: "web", Name: "redis.command"},
+	}
+	chunk := &pb.TraceChunk{Spans: append([]*pb.Span(nil), spans...)}
+	f := newSpanFilter(t, &config.SpanRule{Name: "^redis\\.command$", Action: config.SpanRuleDrop})
+
+	newRoot, dropped := f.Apply(chunk, root)
+	assert.Equal(t, 3, dropped)
+	assert.Same(t, root, newRoot)
+	// The children are attached to the closest parent which is kept
+	assert.Equal(t, map[uint64]uint64{1: 0, 4: 1, 5: 1}, spanParents(chunk.Spans))
+	// The original spans are not updated
+	assert.EqualValues(t, 3, spans[3].ParentID)
+	assert.Len(t, spans, 6)
+}
+
+func TestSpanFilterConditions(t *testing.T) {
+	span := &pb.Span{
+		SpanID:   2,
+		ParentID: 1,
+		Service:  "web",
+		Name:     "http.request",
+		Resource: "GET /users",
+		Meta:     map[string]string{"env": "prod"},
+		Metrics:  map[string]float64{"retries": 2},
+	}
+	for _, tt := range []struct {
+		name string
+		rule config.SpanRule
+		drop bool
+	}{
+		{"no condition", config.SpanRule{}, true},
+		{"service", config.SpanRule{Service: "^web$"}, true},
+		{"other service", config.SpanRule{Service: "^db$"}, false},
+		{"name", config.SpanRule{Name: "http"}, true},
+		{"resource", config.SpanRule{Resource: "^POST"}, false},
+		{"tag value", config.SpanRule{Tags: []string{"env:prod"}}, true},
+		{"other tag value", config.SpanRule{Tags: []string{"env:dev"}}, false},
+		{"tag key", config.SpanRule{Tags: []string{"retries"}}, true},
+		{"all conditions", config.SpanRule{Service: "web", Tags: []string{"env:prod", "missing"}}, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := tt.rule
+			rule.Action = config.SpanRuleDrop
+			chunk := &pb.TraceChunk{Spans: []*pb.Span{{SpanID: 1}, span}}
+			_, dropped := newSpanFilter(t, &rule).Apply(chunk, chunk.Spans[0])
+			assert.Equal(t, tt.drop, dropped == 1)
+		})
+	}
+}
+
+func TestSpanFilterTags(t *testing.T) {
+	root := &pb.Span{
+		SpanID:  1,
+		Service: "web",
+		Meta:    map[string]string{"old": "value", "secret": "password", "long": "abcdefgh", "team": "a"},
+		Metrics: map[string]float64{"counter": 1},
+	}
+	chunk := &pb.TraceChunk{Spans: []*pb.Span{root}}
+	f := newSpanFilter(t,
+		&config.SpanRule{Action: config.SpanRuleRenameTag, Key: "old", NewKey: "new"},
+		&config.SpanRule{Action: config.SpanRuleRenameTag, Key: "counter", NewKey: "count"},
+		&config.SpanRule{Action: config.SpanRuleRemoveTag, Key: "secret"},
+		&config.SpanRule{Action: config.SpanRuleTruncateTag, Key: "*", MaxLength: 4},
+		&config.SpanRule{Tags: []string{"team:a"}, Action: config.SpanRuleSetTag, Key: "owner", Value: "payments"},
+		// the root is never dropped
+		&config.SpanRule{Action: config.SpanRuleDrop},
+	)
+
+	newRoot, dropped := f.Apply(chunk, root)
+	assert.Equal(t, 0, dropped)
+	assert.NotSame(t, root, newRoot)
+	assert.Equal(t, []*pb.Span{newRoot}, chunk.Spans)
+	assert.Equal(t, map[string]string{"new": "valu", "long": "abcd", "team": "a", "owner": "payments"}, newRoot.Meta)
+	assert.Equal(t, map[string]float64{"count": 1}, newRoot.Metrics)
+	// The original span is not updated
+	assert.Equal(t, map[string]string{"old": "value", "secret": "password", "long": "abcdefgh", "team": "a"}, root.Meta)
+	assert.Equal(t, map[string]float64{"counter": 1}, root.Metrics)
+}
+
+func TestSpanFilterUnchanged(t *testing.T) {
+	root := &pb.Span{SpanID: 1, Meta: map[string]string{"team": "a"}}
+	spans := []*pb.Span{root, {SpanID: 2, ParentID: 1}}
+	chunk := &pb.TraceChunk{Spans: spans}
+	f := newSpanFilter(t,
+		&config.SpanRule{Tags: []string{"team"}, Action: config.SpanRuleSetTag, Key: "team", Value: "a"},
+		&config.SpanRule{Action: config.SpanRuleRemoveTag, Key: "missing"},
+		&config.SpanRule{Name: "other", Action: config.SpanRuleDrop},
+	)
+
+	newRoot, dropped := f.Apply(chunk, root)
+	assert.Equal(t, 0, dropped)
+	assert.Same(t, root, newRoot)
+	assert.Same(t, spans[1], chunk.Spans[1])
+}
+
+func TestSpanFilterRemoteRules(t *testing.T) {
+	newChunk := func() *pb.TraceChunk {
+		return &pb.TraceChunk{Spans: []*pb.Span{{SpanID: 1}, {SpanID: 2, ParentID: 1, Name: "noisy"}}}
+	}
+	f := newSpanFilter(t, &config.SpanRule{Action: config.SpanRuleSetTag, Key: "k", Value: "local"})
+	remote := []*config.SpanRule{
+		{Action: config.SpanRuleSetTag, Key: "k", Value: "remote"},
+		{Name: "noisy", Action: config.SpanRuleDrop},
+	}
+	require.NoError(t, config.CompileSpanRules(remote))
+
+	chunk := newChunk()
+	root, dropped := f.Apply(chunk, chunk.Spans[0])
+	assert.Equal(t, 0, dropped)
+	assert.Equal(t, "local", root.Meta["k"])
+
+	// The remote rules are applied after the local ones
+	f.SetRemoteRules(remote)
+	chunk = newChunk()
+	root, dropped = f.Apply(chunk, chunk.Spans[0])
+	assert.Equal(t, 1, dropped)
+	assert.Equal(t, "remote", root.Meta["k"])
+
+	f.SetRemoteRules(nil)
+	chunk = newChunk()
+	_, dropped = f.Apply(chunk, chunk.Spans[0])
+	assert.Equal(t, 0, dropped)
+}
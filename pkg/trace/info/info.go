@@ -31,8 +31,10 @@ var (
 
 	// TODO: move from package globals to a clean single struct
 
-	traceWriterInfo TraceWriterInfo
-	statsWriterInfo StatsWriterInfo
+	traceWriterInfo  TraceWriterInfo
+	statsWriterInfo  StatsWriterInfo
+	tailSamplerInfo  TailSamplerInfo
+	otlpExporterInfo OTLPExporterInfo
 
 	watchdogInfo  watchdog.Info
 	rateByService map[string]float64
@@ -75,6 +77,9 @@ const (
   {{ range $key, $value := .Status.RateByService }}
   Priority sampling rate for '{{ $key }}': {{percent $value}} %
   {{ end }}
+  {{if gt .Status.TailSampler.TracesBuffered.Load 0}}
+  Tail sampling: {{.Status.TailSampler.TracesBuffered.Load}} traces buffered ({{.Status.TailSampler.BytesBuffered.Load}} bytes), {{.Status.TailSampler.TracesEvicted.Load}} traces evicted by buffer pressure
+  {{end}}
 
   --- Writer stats (1 min) ---
 
@@ -82,6 +87,8 @@ const (
   {{if gt .Status.TraceWriter.Errors.Load 0}}WARNING: Traces API errors (1 min): {{.Status.TraceWriter.Errors.Load}}{{end}}
   Stats: {{.Status.StatsWriter.Payloads.Load}} payloads, {{.Status.StatsWriter.StatsBuckets.Load}} stats buckets, {{.Status.StatsWriter.Bytes.Load}} bytes
   {{if gt .Status.StatsWriter.Errors.Load 0}}WARNING: Stats API errors (1 min): {{.Status.StatsWriter.Errors.Load}}{{end}}
+  {{if or (gt .Status.OTLPExporter.Payloads.Load 0) (gt .Status.OTLPExporter.Errors.Load 0)}}OTLP exporter: {{.Status.OTLPExporter.Payloads.Load}} payloads, {{.Status.OTLPExporter.Traces.Load}} traces, {{.Status.OTLPExporter.Spans.Load}} spans, {{.Status.OTLPExporter.Bytes.Load}} bytes
+  {{if gt .Status.OTLPExporter.Errors.Load 0}}WARNING: OTLP exporter errors (1 min): {{.Status.OTLPExporter.Errors.Load}}{{end}}{{end}}
 `
 
 	notRunningTmplSrc = `{{.Banner}}
@@ -211,6 +218,8 @@ type StatusInfo struct {
 	RateByService map[string]float64 `json:"ratebyservice_filtered"`
 	TraceWriter   TraceWriterInfo    `json:"trace_writer"`
 	StatsWriter   StatsWriterInfo    `json:"stats_writer"`
+	TailSampler   TailSamplerInfo    `json:"tail_sampler"`
+	OTLPExporter  OTLPExporterInfo   `json:"otlp_exporter"`
 	Watchdog      watchdog.Info      `json:"watchdog"`
 	Config        config.AgentConfig `json:"config"`
 }
@@ -326,6 +335,8 @@ func initInfo(conf *config.AgentConfig) error {
 	expvar.Publish("receiver", expvar.Func(publishReceiverStats))
 	expvar.Publish("trace_writer", expvar.Func(publishTraceWriterInfo))
 	expvar.Publish("stats_writer", expvar.Func(publishStatsWriterInfo))
+	expvar.Publish("tail_sampler", expvar.Func(publishTailSamplerInfo))
+	expvar.Publish("otlp_exporter", expvar.Func(publishOTLPExporterInfo))
 	expvar.Publish("ratebyservice", expvar.Func(publishRateByService))
 	expvar.Publish("ratebyservice_filtered", expvar.Func(publishRateByServiceFiltered))
 	expvar.Publish("watchdog", expvar.Func(publishWatchdogInfo))
@@ -336,6 +347,10 @@ func initInfo(conf *config.AgentConfig) error {
 	for i, e := range conf.Endpoints {
 		c.Endpoints[i] = &config.Endpoint{Host: e.Host, NoProxy: e.NoProxy}
 	}
+	if conf.OTLPExporter != nil {
+		// the headers usually hold credentials
+		c.OTLPExporter = &config.OTLPExporter{Endpoint: conf.OTLPExporter.Endpoint}
+	}
 
 	var buf []byte
 	buf, err := json.Marshal(&c)
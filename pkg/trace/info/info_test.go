@@ -137,6 +137,10 @@ func testInit(t *testing.T) *config.AgentConfig {
 	conf.EVPProxy.AdditionalEndpoints = clearAddEp
 	conf.ProfilingProxy.AdditionalEndpoints = clearAddEp
 	conf.DebuggerProxy.APIKey = "debugger_proxy_key"
+	conf.OTLPExporter = &config.OTLPExporter{
+		Endpoint: "http://tempo:4318/v1/traces",
+		Headers:  map[string]string{"Authorization": "Bearer otlp_token"},
+	}
 	assert.NotNil(conf)
 
 	err := InitInfo(conf)
@@ -397,6 +401,8 @@ func TestInfoConfig(t *testing.T) {
 	conf.EVPProxy.ApplicationKey = ""
 	assert.Equal("", confCopy.DebuggerProxy.APIKey, "Debugger Proxy API Key should *NEVER* be exported")
 	conf.DebuggerProxy.APIKey = ""
+	assert.Empty(confCopy.OTLPExporter.Headers, "OTLP exporter headers should *NEVER* be exported")
+	conf.OTLPExporter.Headers = nil
 
 	// Any key-like data should scrubbed
 	conf.EVPProxy.AdditionalEndpoints = scrubbedAddEp
@@ -0,0 +1,60 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package info
+
+import (
+	"encoding/json"
+
+	"go.uber.org/atomic"
+)
+
+// OTLPExporterInfo represents statistics from the OTLP exporter of the trace writer.
+type OTLPExporterInfo struct {
+	// all atomic values are included as values in this struct, to simplify
+	// initialization of the type.  The atomic values _must_ occur first in the
+	// struct.
+
+	Payloads          atomic.Int64
+	Traces            atomic.Int64
+	Spans             atomic.Int64
+	Bytes             atomic.Int64
+	BytesUncompressed atomic.Int64
+	Retries           atomic.Int64
+	// Errors counts the payloads rejected by the endpoint.
+	Errors atomic.Int64
+	// Dropped counts the payloads dropped because the sender queue was full.
+	Dropped atomic.Int64
+	// Skipped counts the chunks of the traces dropped by the samplers, which are not exported.
+	Skipped atomic.Int64
+}
+
+// UpdateOTLPExporterInfo updates internal OTLP exporter stats
+func UpdateOTLPExporterInfo(oei OTLPExporterInfo) {
+	infoMu.Lock()
+	defer infoMu.Unlock()
+	otlpExporterInfo = oei
+}
+
+func publishOTLPExporterInfo() interface{} {
+	infoMu.RLock()
+	defer infoMu.RUnlock()
+	return otlpExporterInfo
+}
+
+// MarshalJSON implements encoding/json.MarshalJSON.
+func (oei OTLPExporterInfo) MarshalJSON() ([]byte, error) {
+	asMap := map[string]float64{
+		"Payloads":          float64(oei.Payloads.Load()),
+		"Traces":            float64(oei.Traces.Load()),
+		"Spans":             float64(oei.Spans.Load()),
+		"Bytes":             float64(oei.Bytes.Load()),
+		"BytesUncompressed": float64(oei.BytesUncompressed.Load()),
+		"Retries":           float64(oei.Retries.Load()),
+		"Errors":            float64(oei.Errors.Load()),
+		"Dropped":           float64(oei.Dropped.Load()),
+	}
+	return json.Marshal(asMap)
+}
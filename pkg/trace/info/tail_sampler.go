@@ -0,0 +1,59 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package info
+
+import (
+	"encoding/json"
+
+	"go.uber.org/atomic"
+)
+
+// TailSamplerInfo represents statistics from the tail sampler.
+type TailSamplerInfo struct {
+	// all atomic values are included as values in this struct, to simplify
+	// initialization of the type.  The atomic values _must_ occur first in the
+	// struct.
+
+	// TracesBuffered, ChunksBuffered and BytesBuffered describe the traces
+	// waiting for a sampling decision.
+	TracesBuffered atomic.Int64
+	ChunksBuffered atomic.Int64
+	BytesBuffered  atomic.Int64
+	// TracesEvicted counts the traces decided before the end of the decision
+	// window because the buffer was full.
+	TracesEvicted atomic.Int64
+	// LateChunks counts the chunks received after the decision on their trace.
+	LateChunks    atomic.Int64
+	TracesKept    atomic.Int64
+	TracesDropped atomic.Int64
+}
+
+// UpdateTailSamplerInfo updates internal tail sampler stats
+func UpdateTailSamplerInfo(tsi TailSamplerInfo) {
+	infoMu.Lock()
+	defer infoMu.Unlock()
+	tailSamplerInfo = tsi
+}
+
+func publishTailSamplerInfo() interface{} {
+	infoMu.RLock()
+	defer infoMu.RUnlock()
+	return tailSamplerInfo
+}
+
+// MarshalJSON implements encoding/json.MarshalJSON.
+func (tsi TailSamplerInfo) MarshalJSON() ([]byte, error) {
+	asMap := map[string]float64{
+		"TracesBuffered": float64(tsi.TracesBuffered.Load()),
+		"ChunksBuffered": float64(tsi.ChunksBuffered.Load()),
+		"BytesBuffered":  float64(tsi.BytesBuffered.Load()),
+		"TracesEvicted":  float64(tsi.TracesEvicted.Load()),
+		"LateChunks":     float64(tsi.LateChunks.Load()),
+		"TracesKept":     float64(tsi.TracesKept.Load()),
+		"TracesDropped":  float64(tsi.TracesDropped.Load()),
+	}
+	return json.Marshal(asMap)
+}
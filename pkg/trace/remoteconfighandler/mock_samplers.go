@@ -6,6 +6,7 @@ package remoteconfighandler
 import (
 	reflect "reflect"
 
+	config "github.com/DataDog/datadog-agent/pkg/trace/config"
 	gomock "github.com/golang/mock/gomock"
 )
 
@@ -113,3 +114,38 @@ func (mr *MockrareSamplerMockRecorder) SetEnabled(enabled interface{}) *gomock.C
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetEnabled", reflect.TypeOf((*MockrareSampler)(nil).SetEnabled), enabled)
 }
+
+// MockspanFilter is a mock of spanFilter interface.
+type MockspanFilter struct {
+	ctrl     *gomock.Controller
+	recorder *MockspanFilterMockRecorder
+}
+
+// MockspanFilterMockRecorder is the mock recorder for MockspanFilter.
+type MockspanFilterMockRecorder struct {
+	mock *MockspanFilter
+}
+
+// NewMockspanFilter creates a new mock instance.
+func NewMockspanFilter(ctrl *gomock.Controller) *MockspanFilter {
+	mock := &MockspanFilter{ctrl: ctrl}
+	mock.recorder = &MockspanFilterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockspanFilter) EXPECT() *MockspanFilterMockRecorder {
+	return m.recorder
+}
+
+// SetRemoteRules mocks base method.
+func (m *MockspanFilter) SetRemoteRules(rules []*config.SpanRule) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetRemoteRules", rules)
+}
+
+// SetRemoteRules indicates an expected call of SetRemoteRules.
+func (mr *MockspanFilterMockRecorder) SetRemoteRules(rules interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetRemoteRules", reflect.TypeOf((*MockspanFilter)(nil).SetRemoteRules), rules)
+}
@@ -10,10 +10,12 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"sort"
 	"strconv"
 
 	"github.com/DataDog/datadog-agent/pkg/remoteconfig/state"
 	"github.com/DataDog/datadog-agent/pkg/remoteconfig/state/products/apmsampling"
+	"github.com/DataDog/datadog-agent/pkg/remoteconfig/state/products/apmspanrules"
 	"github.com/DataDog/datadog-agent/pkg/trace/config"
 	"github.com/DataDog/datadog-agent/pkg/trace/log"
 	pkglog "github.com/DataDog/datadog-agent/pkg/util/log"
@@ -34,18 +36,23 @@ type rareSampler interface {
 	SetEnabled(enabled bool)
 }
 
+type spanFilter interface {
+	SetRemoteRules(rules []*config.SpanRule)
+}
+
 // RemoteConfigHandler holds pointers to samplers that need to be updated when APM remote config changes
 type RemoteConfigHandler struct {
 	remoteClient                  config.RemoteClient
 	prioritySampler               prioritySampler
 	errorsSampler                 errorsSampler
 	rareSampler                   rareSampler
+	spanFilter                    spanFilter
 	agentConfig                   *config.AgentConfig
 	configState                   *state.AgentConfigState
 	configSetEndpointFormatString string
 }
 
-func New(conf *config.AgentConfig, prioritySampler prioritySampler, rareSampler rareSampler, errorsSampler errorsSampler) *RemoteConfigHandler {
+func New(conf *config.AgentConfig, prioritySampler prioritySampler, rareSampler rareSampler, errorsSampler errorsSampler, spanFilter spanFilter) *RemoteConfigHandler {
 	if conf.RemoteConfigClient == nil {
 		return nil
 	}
@@ -61,6 +68,7 @@ func New(conf *config.AgentConfig, prioritySampler prioritySampler, rareSampler
 		prioritySampler: prioritySampler,
 		rareSampler:     rareSampler,
 		errorsSampler:   errorsSampler,
+		spanFilter:      spanFilter,
 		agentConfig:     conf,
 		configState: &state.AgentConfigState{
 			FallbackLogLevel: level.String(),
@@ -79,6 +87,61 @@ func (h *RemoteConfigHandler) Start() {
 	h.remoteClient.Start()
 	h.remoteClient.Subscribe(state.ProductAPMSampling, h.onUpdate)
 	h.remoteClient.Subscribe(state.ProductAgentConfig, h.onAgentConfigUpdate)
+	h.remoteClient.Subscribe(state.ProductAPMSpanRules, h.onSpanRulesUpdate)
+}
+
+// onSpanRulesUpdate replaces the remote span rules with the rules of all the configs, in the
+// order of their paths. The invalid configs are ignored.
+func (h *RemoteConfigHandler) onSpanRulesUpdate(updates map[string]state.RawConfig, applyStateCallback func(string, state.ApplyStatus)) {
+	paths := make([]string, 0, len(updates))
+	for path := range updates {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var rules []*config.SpanRule
+	for _, path := range paths {
+		configRules, err := parseSpanRules(updates[path].Config)
+		if err != nil {
+			log.Errorf("couldn't apply the remote configuration span rules %s: %s", path, err)
+			applyStateCallback(path, state.ApplyStatus{
+				State: state.ApplyStateError,
+				Error: err.Error(),
+			})
+			continue
+		}
+		rules = append(rules, configRules...)
+		applyStateCallback(path, state.ApplyStatus{State: state.ApplyStateAcknowledged})
+	}
+
+	log.Debugf("updating span rules with remote configuration: %v", spew.Sdump(rules))
+	h.spanFilter.SetRemoteRules(rules)
+}
+
+// parseSpanRules returns the compiled span rules of a remote config.
+func parseSpanRules(raw []byte) ([]*config.SpanRule, error) {
+	var payload apmspanrules.SpanRulesConfig
+	if err := json.Unmarshal(raw, &payload); err != nil {
+		return nil, err
+	}
+	rules := make([]*config.SpanRule, 0, len(payload.Rules))
+	for _, r := range payload.Rules {
+		rules = append(rules, &config.SpanRule{
+			Service:   r.Service,
+			Name:      r.Name,
+			Resource:  r.Resource,
+			Tags:      r.Tags,
+			Action:    r.Action,
+			Key:       r.Key,
+			Value:     r.Value,
+			NewKey:    r.NewKey,
+			MaxLength: r.MaxLength,
+		})
+	}
+	if err := config.CompileSpanRules(rules); err != nil {
+		return nil, err
+	}
+	return rules, nil
 }
 
 func (h *RemoteConfigHandler) onAgentConfigUpdate(updates map[string]state.RawConfig, applyStateCallback func(string, state.ApplyStatus)) {
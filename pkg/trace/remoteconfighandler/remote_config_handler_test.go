@@ -15,6 +15,7 @@ import (
 
 	"github.com/DataDog/datadog-agent/pkg/remoteconfig/state"
 	"github.com/DataDog/datadog-agent/pkg/remoteconfig/state/products/apmsampling"
+	"github.com/DataDog/datadog-agent/pkg/remoteconfig/state/products/apmspanrules"
 	"github.com/DataDog/datadog-agent/pkg/trace/config"
 	pkglog "github.com/DataDog/datadog-agent/pkg/util/log"
 	"github.com/DataDog/datadog-agent/pkg/util/pointer"
@@ -35,10 +36,11 @@ func TestStart(t *testing.T) {
 	rareSampler := NewMockrareSampler(ctrl)
 	pkglog.SetupLogger(seelog.Default, "debug")
 
-	h := New(&agentConfig, prioritySampler, rareSampler, errorsSampler)
+	h := New(&agentConfig, prioritySampler, rareSampler, errorsSampler, nil)
 
 	remoteClient.EXPECT().Subscribe(state.ProductAPMSampling, gomock.Any()).Times(1)
 	remoteClient.EXPECT().Subscribe(state.ProductAgentConfig, gomock.Any()).Times(1)
+	remoteClient.EXPECT().Subscribe(state.ProductAPMSpanRules, gomock.Any()).Times(1)
 	remoteClient.EXPECT().Start().Times(1)
 
 	h.Start()
@@ -60,7 +62,7 @@ func TestPrioritySampler(t *testing.T) {
 	pkglog.SetupLogger(seelog.Default, "debug")
 
 	agentConfig := config.AgentConfig{RemoteConfigClient: remoteClient, TargetTPS: 41, ErrorTPS: 41, RareSamplerEnabled: true}
-	h := New(&agentConfig, prioritySampler, rareSampler, errorsSampler)
+	h := New(&agentConfig, prioritySampler, rareSampler, errorsSampler, nil)
 
 	payload := apmsampling.SamplerConfig{
 		AllEnvs: apmsampling.SamplerEnvConfig{
@@ -91,7 +93,7 @@ func TestErrorsSampler(t *testing.T) {
 	pkglog.SetupLogger(seelog.Default, "debug")
 
 	agentConfig := config.AgentConfig{RemoteConfigClient: remoteClient, TargetTPS: 41, ErrorTPS: 41, RareSamplerEnabled: true}
-	h := New(&agentConfig, prioritySampler, rareSampler, errorsSampler)
+	h := New(&agentConfig, prioritySampler, rareSampler, errorsSampler, nil)
 
 	payload := apmsampling.SamplerConfig{
 		AllEnvs: apmsampling.SamplerEnvConfig{
@@ -122,7 +124,7 @@ func TestRareSampler(t *testing.T) {
 	pkglog.SetupLogger(seelog.Default, "debug")
 
 	agentConfig := config.AgentConfig{RemoteConfigClient: remoteClient, TargetTPS: 41, ErrorTPS: 41, RareSamplerEnabled: true}
-	h := New(&agentConfig, prioritySampler, rareSampler, errorsSampler)
+	h := New(&agentConfig, prioritySampler, rareSampler, errorsSampler, nil)
 
 	payload := apmsampling.SamplerConfig{
 		AllEnvs: apmsampling.SamplerEnvConfig{
@@ -153,7 +155,7 @@ func TestEnvPrecedence(t *testing.T) {
 	pkglog.SetupLogger(seelog.Default, "debug")
 
 	agentConfig := config.AgentConfig{RemoteConfigClient: remoteClient, TargetTPS: 41, ErrorTPS: 41, RareSamplerEnabled: true, DefaultEnv: "agent-env"}
-	h := New(&agentConfig, prioritySampler, rareSampler, errorsSampler)
+	h := New(&agentConfig, prioritySampler, rareSampler, errorsSampler, nil)
 
 	payload := apmsampling.SamplerConfig{
 		AllEnvs: apmsampling.SamplerEnvConfig{
@@ -205,7 +207,7 @@ func TestLogLevel(t *testing.T) {
 		ReceiverHost:       "127.0.0.1",
 		ReceiverPort:       port,
 	}
-	h := New(&agentConfig, prioritySampler, rareSampler, errorsSampler)
+	h := New(&agentConfig, prioritySampler, rareSampler, errorsSampler, nil)
 
 	layer := state.RawConfig{Config: []byte(`{"name": "layer1", "config": {"log_level": "debug"}}`)}
 	configOrder := state.RawConfig{Config: []byte(`{"internal_order": ["layer1", "layer2"]}`)}
@@ -226,3 +228,55 @@ func TestLogLevel(t *testing.T) {
 
 	ctrl.Finish()
 }
+
+func TestSpanRules(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	remoteClient := NewMockRemoteClient(ctrl)
+	spanFilter := NewMockspanFilter(ctrl)
+	pkglog.SetupLogger(seelog.Default, "debug")
+
+	agentConfig := config.AgentConfig{RemoteConfigClient: remoteClient}
+	h := New(&agentConfig, nil, nil, nil, spanFilter)
+
+	rawConfig := func(payload apmspanrules.SpanRulesConfig) state.RawConfig {
+		raw, _ := json.Marshal(payload)
+		return state.RawConfig{Config: raw}
+	}
+	statuses := make(map[string]state.ApplyStatus)
+	applyStatus := func(path string, status state.ApplyStatus) {
+		statuses[path] = status
+	}
+
+	var rules []*config.SpanRule
+	spanFilter.EXPECT().SetRemoteRules(gomock.Any()).Do(func(r []*config.SpanRule) { rules = r }).Times(1)
+	h.onSpanRulesUpdate(map[string]state.RawConfig{
+		"datadog/2/APM_SPAN_RULES/b/config": rawConfig(apmspanrules.SpanRulesConfig{Rules: []apmspanrules.SpanRule{
+			{Service: "^web$", Tags: []string{"team:a"}, Action: config.SpanRuleSetTag, Key: "k", Value: "v"},
+		}}),
+		"datadog/2/APM_SPAN_RULES/a/config": rawConfig(apmspanrules.SpanRulesConfig{Rules: []apmspanrules.SpanRule{
+			{Name: "redis.command", Action: config.SpanRuleDrop},
+		}}),
+		"datadog/2/APM_SPAN_RULES/c/config": rawConfig(apmspanrules.SpanRulesConfig{Rules: []apmspanrules.SpanRule{
+			{Action: "unknown"},
+		}}),
+		"datadog/2/APM_SPAN_RULES/d/config": {Config: []byte("{")},
+	}, applyStatus)
+
+	if assert.Len(t, rules, 2) {
+		assert.Equal(t, config.SpanRuleDrop, rules[0].Action)
+		assert.True(t, rules[0].NameRe.MatchString("redis.command"))
+		assert.Equal(t, config.SpanRuleSetTag, rules[1].Action)
+		assert.Equal(t, []string{"team:a"}, rules[1].Tags)
+		assert.True(t, rules[1].ServiceRe.MatchString("web"))
+	}
+	assert.Equal(t, state.ApplyStateAcknowledged, statuses["datadog/2/APM_SPAN_RULES/a/config"].State)
+	assert.Equal(t, state.ApplyStateAcknowledged, statuses["datadog/2/APM_SPAN_RULES/b/config"].State)
+	assert.Equal(t, state.ApplyStateError, statuses["datadog/2/APM_SPAN_RULES/c/config"].State)
+	assert.Equal(t, state.ApplyStateError, statuses["datadog/2/APM_SPAN_RULES/d/config"].State)
+
+	// The rules are removed with their configs
+	spanFilter.EXPECT().SetRemoteRules(gomock.Nil()).Times(1)
+	h.onSpanRulesUpdate(map[string]state.RawConfig{}, applyStatus)
+
+	ctrl.Finish()
+}
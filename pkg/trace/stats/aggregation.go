@@ -31,15 +31,16 @@ type Aggregation struct {
 
 // BucketsAggregationKey specifies the key by which a bucket is aggregated.
 type BucketsAggregationKey struct {
-	Service      string
-	Name         string
-	PeerService  string
-	Resource     string
-	Type         string
-	SpanKind     string
-	StatusCode   uint32
-	Synthetics   bool
-	PeerTagsHash uint64
+	Service        string
+	Name           string
+	PeerService    string
+	Resource       string
+	Type           string
+	SpanKind       string
+	StatusCode     uint32
+	Synthetics     bool
+	PeerTagsHash   uint64
+	CustomTagsHash uint64
 }
 
 // PayloadAggregationKey specifies the key by which a payload is aggregated.
@@ -94,7 +95,7 @@ func NewAggregationFromSpan(s *pb.Span, origin string, aggKey PayloadAggregation
 			agg.PeerService = s.Meta[tagPeerService]
 		}
 		peerTags = matchingPeerTags(s, peerTagKeys)
-		agg.PeerTagsHash = peerTagsHash(peerTags)
+		agg.PeerTagsHash = tagsHash(peerTags)
 	}
 	return agg, peerTags
 }
@@ -112,7 +113,7 @@ func matchingPeerTags(s *pb.Span, peerTagKeys []string) []string {
 	return pt
 }
 
-func peerTagsHash(tags []string) uint64 {
+func tagsHash(tags []string) uint64 {
 	if len(tags) == 0 {
 		return 0
 	}
@@ -140,7 +141,7 @@ func NewAggregationFromGroup(g *pb.ClientGroupedStats) Aggregation {
 			SpanKind:     g.SpanKind,
 			StatusCode:   g.HTTPStatusCode,
 			Synthetics:   g.Synthetics,
-			PeerTagsHash: peerTagsHash(g.PeerTags),
+			PeerTagsHash: tagsHash(g.PeerTags),
 		},
 	}
 }
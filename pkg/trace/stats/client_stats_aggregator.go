@@ -46,6 +46,9 @@ type ClientStatsAggregator struct {
 	agentVersion       string
 	peerSvcAggregation bool // flag to enable peer.service aggregation
 
+	customTagKeys         []string // keys for the span tags used as additional aggregation dimensions
+	customTagsCardinality int      // maximum number of distinct values of each custom tag in a bucket
+
 	exit chan struct{}
 	done chan struct{}
 }
@@ -64,6 +67,9 @@ func NewClientStatsAggregator(conf *config.AgentConfig, out chan *pb.StatsPayloa
 		oldestTs:           alignAggTs(time.Now().Add(bucketDuration - oldestBucketStart)),
 		exit:               make(chan struct{}),
 		done:               make(chan struct{}),
+
+		customTagKeys:         prepareTagKeys(conf.StatsCustomTags...),
+		customTagsCardinality: conf.StatsCustomTagsMaxCardinality,
 	}
 	return c
 }
@@ -134,7 +140,7 @@ func (a *ClientStatsAggregator) add(now time.Time, p *pb.ClientStatsPayload) {
 		}
 		b, ok := a.buckets[ts.Unix()]
 		if !ok {
-			b = &bucket{ts: ts}
+			b = &bucket{ts: ts, customTags: newCustomTagsLimiter(a.customTagKeys, a.customTagsCardinality)}
 			a.buckets[ts.Unix()] = b
 		}
 		p.Stats = []*pb.ClientStatsBucket{clientBucket}
@@ -175,9 +181,12 @@ type bucket struct {
 	n int
 	// agg contains the aggregated Hits/Errors/Duration counts
 	agg map[PayloadAggregationKey]map[BucketsAggregationKey]*aggregatedCounts
+	// customTags limits the cardinality of the custom tags, it is nil when there are none
+	customTags *customTagsLimiter
 }
 
 func (b *bucket) add(p *pb.ClientStatsPayload, enablePeerSvcAgg bool) []*pb.ClientStatsPayload {
+	b.limitCustomTags(p)
 	b.n++
 	if b.n == 1 {
 		b.first = &pb.ClientStatsPayload{
@@ -209,6 +218,18 @@ func (b *bucket) add(p *pb.ClientStatsPayload, enablePeerSvcAgg bool) []*pb.Clie
 	return []*pb.ClientStatsPayload{trimCounts(p)}
 }
 
+// limitCustomTags folds the values of the custom tags past the cardinality limit, the custom
+// tags are sent by the tracers with the peer tags.
+func (b *bucket) limitCustomTags(p *pb.ClientStatsPayload) {
+	for _, s := range p.Stats {
+		for _, sb := range s.Stats {
+			if sb != nil {
+				sb.PeerTags = b.customTags.fromPeerTags(sb.PeerTags)
+			}
+		}
+	}
+}
+
 func (b *bucket) aggregateCounts(p *pb.ClientStatsPayload, enablePeerSvcAgg bool) {
 	payloadAggKey := newPayloadAggregationKey(p.Env, p.Hostname, p.Version, p.ContainerID)
 	payloadAgg, ok := b.agg[payloadAggKey]
@@ -225,13 +246,17 @@ func (b *bucket) aggregateCounts(p *pb.ClientStatsPayload, enablePeerSvcAgg bool
 			if sb == nil {
 				continue
 			}
+			customTags := b.customTags.filter(sb.PeerTags)
 			aggKey := newBucketAggregationKey(sb, enablePeerSvcAgg)
+			aggKey.CustomTagsHash = tagsHash(customTags)
 			agg, ok := payloadAgg[aggKey]
 			if !ok {
 				agg = &aggregatedCounts{}
 				payloadAgg[aggKey] = agg
 				if enablePeerSvcAgg {
 					agg.peerTags = sb.PeerTags
+				} else {
+					agg.peerTags = customTags
 				}
 			}
 			agg.hits += sb.Hits
@@ -301,7 +326,7 @@ func newBucketAggregationKey(b *pb.ClientGroupedStats, enablePeerSvcAgg bool) Bu
 	}
 	if enablePeerSvcAgg {
 		k.PeerService = b.PeerService
-		k.PeerTagsHash = peerTagsHash(b.GetPeerTags())
+		k.PeerTagsHash = tagsHash(b.GetPeerTags())
 	}
 	return k
 }
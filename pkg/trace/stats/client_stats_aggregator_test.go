@@ -547,6 +547,45 @@ func TestCountAggregationPeerTags(t *testing.T) {
 	}
 }
 
+func TestCountAggregationCustomTags(t *testing.T) {
+	assert := assert.New(t)
+	a := newTestAggregator()
+	a.customTagKeys = []string{"tenant"}
+	a.customTagsCardinality = 1
+	testTime := time.Unix(time.Now().Unix(), 0)
+
+	k := BucketsAggregationKey{Service: "s"}
+	c1 := payloadWithCounts(testTime, k, 11, 7, 100)
+	c2 := payloadWithCounts(testTime, k, 27, 2, 300)
+	c3 := payloadWithCounts(testTime, k, 5, 10, 3)
+	c4 := payloadWithCounts(testTime, k, 1, 0, 4)
+	// the custom tags are sent with the peer tags
+	c1.Stats[0].Stats[0].PeerTags = []string{"other:x", "tenant:a"}
+	c2.Stats[0].Stats[0].PeerTags = []string{"tenant:a"}
+	c3.Stats[0].Stats[0].PeerTags = []string{"tenant:b"}
+
+	a.add(testTime, deepCopy(c1))
+	a.add(testTime, deepCopy(c2))
+	a.add(testTime, deepCopy(c3))
+	a.add(testTime, deepCopy(c4))
+	assert.Len(a.out, 3)
+	a.flushOnTime(testTime.Add(oldestBucketStart + time.Nanosecond))
+	assert.Len(a.out, 4)
+	for i := 0; i < 3; i++ {
+		<-a.out
+	}
+	aggCounts := <-a.out
+	assertAggCountsPayload(t, aggCounts)
+
+	assert.ElementsMatch([]*proto.ClientGroupedStats{
+		// the peer tags which are not custom tags are dropped without peer service aggregation
+		{Service: "s", PeerTags: []string{"tenant:a"}, Hits: 38, Errors: 9, Duration: 400},
+		// the values past the cardinality limit are folded
+		{Service: "s", PeerTags: []string{"tenant:_other"}, Hits: 5, Errors: 10, Duration: 3},
+		{Service: "s", Hits: 1, Errors: 0, Duration: 4},
+	}, aggCounts.Stats[0].Stats[0].Stats)
+}
+
 func TestNewBucketAggregationKeyPeerService(t *testing.T) {
 	t.Run("disabled", func(t *testing.T) {
 		assert := assert.New(t)
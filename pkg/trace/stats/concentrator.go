@@ -50,6 +50,8 @@ type Concentrator struct {
 	peerSvcAggregation     bool     // flag to enable peer.service aggregation
 	computeStatsBySpanKind bool     // flag to enable computation of stats through checking the span.kind field
 	peerTagKeys            []string // keys for supplementary tags that describe peer.service entities
+	customTagKeys          []string // keys for the span tags used as additional aggregation dimensions
+	customTagsCardinality  int      // maximum number of distinct values of each custom tag in a bucket
 }
 
 func prepareTagKeys(tags ...string) []string {
@@ -87,6 +89,8 @@ func NewConcentrator(conf *config.AgentConfig, out chan *pb.StatsPayload, now ti
 		agentVersion:           conf.AgentVersion,
 		peerSvcAggregation:     conf.PeerServiceAggregation,
 		computeStatsBySpanKind: conf.ComputeStatsBySpanKind,
+		customTagKeys:          prepareTagKeys(conf.StatsCustomTags...),
+		customTagsCardinality:  conf.StatsCustomTagsMaxCardinality,
 	}
 	if conf.PeerServiceAggregation {
 		c.peerTagKeys = prepareTagKeys(conf.PeerTags...)
@@ -217,6 +221,7 @@ func (c *Concentrator) addNow(pt *traceutil.ProcessedTrace, containerID string)
 		b, ok := c.buckets[btime]
 		if !ok {
 			b = NewRawBucket(uint64(btime), uint64(c.bsize))
+			b.customTags = newCustomTagsLimiter(c.customTagKeys, c.customTagsCardinality)
 			c.buckets[btime] = b
 		}
 		b.HandleSpan(s, weight, isTop, pt.TraceChunk.Origin, aggKey, c.peerSvcAggregation, c.peerTagKeys)
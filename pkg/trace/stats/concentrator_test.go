@@ -8,6 +8,7 @@ package stats
 import (
 	"fmt"
 	"math/rand"
+	"strings"
 	"testing"
 	"time"
 
@@ -685,6 +686,49 @@ func TestPeerTags(t *testing.T) {
 	})
 }
 
+func TestCustomTags(t *testing.T) {
+	assert := assert.New(t)
+	now := time.Now()
+	newSpan := func(spanID uint64, meta map[string]string) *pb.Span {
+		return testSpan(now, spanID, 0, 100, 0, "myservice", "GET /users", 0, meta)
+	}
+	spans := []*pb.Span{
+		newSpan(1, map[string]string{"tenant": "a", "region": "eu"}),
+		newSpan(2, map[string]string{"tenant": "a", "region": "eu"}),
+		newSpan(3, map[string]string{"tenant": "b", "region": "eu"}),
+		newSpan(4, map[string]string{"tenant": "c", "region": "eu"}),
+		newSpan(5, map[string]string{"tenant": "d", "region": "eu"}),
+		newSpan(6, nil),
+	}
+	traceutil.ComputeTopLevel(spans)
+	testTrace := toProcessedTrace(spans, "none", "")
+
+	t.Run("not configured", func(t *testing.T) {
+		c := NewTestConcentrator(now)
+		c.addNow(testTrace, "")
+		stats := c.flushNow(now.UnixNano()+int64(c.bufferLen)*testBucketInterval, false)
+		assert.Len(stats.Stats[0].Stats[0].Stats, 1)
+		assert.Nil(stats.Stats[0].Stats[0].Stats[0].PeerTags)
+	})
+	t.Run("configured", func(t *testing.T) {
+		c := NewTestConcentrator(now)
+		c.customTagKeys = prepareTagKeys("tenant", "region")
+		c.customTagsCardinality = 2
+		c.addNow(testTrace, "")
+		stats := c.flushNow(now.UnixNano()+int64(c.bufferLen)*testBucketInterval, false)
+		hits := make(map[string]uint64)
+		for _, st := range stats.Stats[0].Stats[0].Stats {
+			hits[strings.Join(st.PeerTags, ",")] += st.Hits
+		}
+		assert.Equal(map[string]uint64{
+			"region:eu,tenant:a":      2,
+			"region:eu,tenant:b":      1,
+			"region:eu,tenant:_other": 2,
+			"":                        1,
+		}, hits)
+	})
+}
+
 // TestComputeStatsThroughSpanKindCheck ensures that we generate stats for spans that have an eligible span.kind.
 func TestComputeStatsThroughSpanKindCheck(t *testing.T) {
 	assert := assert.New(t)
@@ -0,0 +1,134 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package stats
+
+import (
+	"sort"
+	"strings"
+
+	pb "github.com/DataDog/datadog-agent/pkg/proto/pbgo/trace"
+)
+
+// customTagOverflowValue replaces the values of a custom tag past its cardinality limit.
+const customTagOverflowValue = "_other"
+
+// customTagsLimiter computes the custom tags of the stats, the span tags configured as
+// additional aggregation dimensions. It keeps track of the distinct values of each custom
+// tag and folds the values past maxCardinality into customTagOverflowValue.
+//
+// A limiter is used for a single stats bucket, the limit applies to each bucket separately.
+type customTagsLimiter struct {
+	keys           []string // sorted keys of the custom tags
+	maxCardinality int      // maximum number of distinct values per key, no limit if <= 0
+	values         map[string]map[string]struct{}
+}
+
+// newCustomTagsLimiter returns a limiter for the given keys, or nil when there are no
+// custom tags. keys must be sorted and deduplicated, see prepareTagKeys.
+func newCustomTagsLimiter(keys []string, maxCardinality int) *customTagsLimiter {
+	if len(keys) == 0 {
+		return nil
+	}
+	return &customTagsLimiter{
+		keys:           keys,
+		maxCardinality: maxCardinality,
+		values:         make(map[string]map[string]struct{}, len(keys)),
+	}
+}
+
+// fromSpan returns the custom tags of the span, as key:value, sorted by key.
+func (l *customTagsLimiter) fromSpan(s *pb.Span) []string {
+	if l == nil {
+		return nil
+	}
+	var tags []string
+	for _, k := range l.keys {
+		if v, ok := s.Meta[k]; ok {
+			tags = append(tags, k+":"+l.limit(k, v))
+		}
+	}
+	return tags
+}
+
+// fromPeerTags returns the peer tags of client computed stats with the values of the custom
+// tags limited. The custom tags of the client computed stats are sent with their peer tags.
+func (l *customTagsLimiter) fromPeerTags(peerTags []string) []string {
+	if l == nil || len(peerTags) == 0 {
+		return peerTags
+	}
+	tags := make([]string, 0, len(peerTags))
+	for _, t := range peerTags {
+		if k, v, ok := strings.Cut(t, ":"); ok && l.isKey(k) {
+			t = k + ":" + l.limit(k, v)
+		}
+		tags = append(tags, t)
+	}
+	return tags
+}
+
+// filter returns the custom tags among the peer tags of client computed stats.
+func (l *customTagsLimiter) filter(peerTags []string) []string {
+	if l == nil {
+		return nil
+	}
+	var tags []string
+	for _, t := range peerTags {
+		if k, _, _ := strings.Cut(t, ":"); l.isKey(k) {
+			tags = append(tags, t)
+		}
+	}
+	return tags
+}
+
+func (l *customTagsLimiter) isKey(k string) bool {
+	for _, key := range l.keys {
+		if key == k {
+			return true
+		}
+	}
+	return false
+}
+
+// limit returns the value to use for the custom tag k.
+func (l *customTagsLimiter) limit(k, v string) string {
+	seen, ok := l.values[k]
+	if !ok {
+		seen = make(map[string]struct{})
+		l.values[k] = seen
+	}
+	if _, ok := seen[v]; ok {
+		return v
+	}
+	if l.maxCardinality > 0 && len(seen) >= l.maxCardinality {
+		return customTagOverflowValue
+	}
+	seen[v] = struct{}{}
+	return v
+}
+
+// withCustomTags returns the peer tags completed with the custom tags, sorted and without
+// duplicates. The stats intake has no field for the custom tags: they are sent with the peer
+// tags, which are aggregation dimensions as well.
+func withCustomTags(peerTags, customTags []string) []string {
+	if len(customTags) == 0 {
+		return peerTags
+	}
+	if len(peerTags) == 0 {
+		return customTags
+	}
+	tags := make([]string, 0, len(peerTags)+len(customTags))
+	tags = append(tags, peerTags...)
+	tags = append(tags, customTags...)
+	sort.Strings(tags)
+	n := 1
+	for i := 1; i < len(tags); i++ {
+		if tags[i] != tags[n-1] {
+			tags[n] = tags[i]
+			n++
+		}
+	}
+	return tags[:n]
+}
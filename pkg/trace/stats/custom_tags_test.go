@@ -0,0 +1,65 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package stats
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	pb "github.com/DataDog/datadog-agent/pkg/proto/pbgo/trace"
+)
+
+func TestCustomTagsLimiter(t *testing.T) {
+	t.Run("not configured", func(t *testing.T) {
+		l := newCustomTagsLimiter(nil, 10)
+		assert.Nil(t, l)
+		assert.Nil(t, l.fromSpan(&pb.Span{Meta: map[string]string{"tenant": "a"}}))
+		assert.Equal(t, []string{"tenant:a"}, l.fromPeerTags([]string{"tenant:a"}))
+		assert.Nil(t, l.filter([]string{"tenant:a"}))
+	})
+
+	t.Run("span", func(t *testing.T) {
+		l := newCustomTagsLimiter(prepareTagKeys("tenant", "region"), 10)
+		assert.Equal(t, []string{"region:eu", "tenant:a"}, l.fromSpan(&pb.Span{Meta: map[string]string{"tenant": "a", "region": "eu", "other": "x"}}))
+		assert.Equal(t, []string{"tenant:"}, l.fromSpan(&pb.Span{Meta: map[string]string{"tenant": ""}}))
+		assert.Nil(t, l.fromSpan(&pb.Span{Meta: map[string]string{"other": "x"}}))
+	})
+
+	t.Run("peer tags", func(t *testing.T) {
+		l := newCustomTagsLimiter(prepareTagKeys("tenant"), 10)
+		peerTags := []string{"invalid", "other:x", "tenant:a:b"}
+		assert.Equal(t, peerTags, l.fromPeerTags(peerTags))
+		assert.Equal(t, []string{"tenant:a:b"}, l.filter(peerTags))
+	})
+
+	t.Run("cardinality", func(t *testing.T) {
+		l := newCustomTagsLimiter(prepareTagKeys("tenant", "region"), 2)
+		span := func(tenant, region string) *pb.Span {
+			return &pb.Span{Meta: map[string]string{"tenant": tenant, "region": region}}
+		}
+		assert.Equal(t, []string{"region:eu", "tenant:a"}, l.fromSpan(span("a", "eu")))
+		assert.Equal(t, []string{"region:us", "tenant:b"}, l.fromSpan(span("b", "us")))
+		assert.Equal(t, []string{"region:eu", "tenant:_other"}, l.fromSpan(span("c", "eu")))
+		assert.Equal(t, []string{"other:x", "region:_other", "tenant:a"}, l.fromPeerTags([]string{"other:x", "region:ap", "tenant:a"}))
+		// the values seen before the limit was reached are kept
+		assert.Equal(t, []string{"region:us", "tenant:b"}, l.fromSpan(span("b", "us")))
+	})
+
+	t.Run("with peer tags", func(t *testing.T) {
+		assert.Nil(t, withCustomTags(nil, nil))
+		assert.Equal(t, []string{"tenant:a"}, withCustomTags(nil, []string{"tenant:a"}))
+		assert.Equal(t, []string{"peer.hostname:h", "tenant:a"}, withCustomTags([]string{"peer.hostname:h", "tenant:a"}, []string{"tenant:a"}))
+		assert.Equal(t, []string{"db.instance:i", "region:eu", "tenant:a"}, withCustomTags([]string{"db.instance:i", "tenant:a"}, []string{"region:eu"}))
+	})
+
+	t.Run("no limit", func(t *testing.T) {
+		l := newCustomTagsLimiter(prepareTagKeys("tenant"), 0)
+		for _, tenant := range []string{"a", "b", "c", "d"} {
+			assert.Equal(t, []string{"tenant:" + tenant}, l.fromSpan(&pb.Span{Meta: map[string]string{"tenant": tenant}}))
+		}
+	})
+}
@@ -106,6 +106,9 @@ type RawBucket struct {
 
 	// this should really remain private as it's subject to refactoring
 	data map[Aggregation]*groupedStats
+
+	// customTags computes the custom tags of the spans, it is nil when there are none
+	customTags *customTagsLimiter
 }
 
 // NewRawBucket opens a new calculation bucket for time ts and initializes it properly
@@ -154,7 +157,9 @@ func (sb *RawBucket) HandleSpan(s *pb.Span, weight float64, isTop bool, origin s
 		panic("env should never be empty")
 	}
 	aggr, peerTags := NewAggregationFromSpan(s, origin, aggKey, enablePeerSvcAgg, peerTagKeys)
-	sb.add(s, weight, isTop, aggr, peerTags)
+	customTags := sb.customTags.fromSpan(s)
+	aggr.CustomTagsHash = tagsHash(customTags)
+	sb.add(s, weight, isTop, aggr, withCustomTags(peerTags, customTags))
 }
 
 func (sb *RawBucket) add(s *pb.Span, weight float64, isTop bool, aggr Aggregation, peerTags []string) {
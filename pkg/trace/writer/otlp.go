@@ -0,0 +1,332 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package writer
+
+import (
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.opentelemetry.io/collector/pdata/ptrace/ptraceotlp"
+	semconv "go.opentelemetry.io/collector/semconv/v1.6.1"
+
+	pb "github.com/DataDog/datadog-agent/pkg/proto/pbgo/trace"
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+	"github.com/DataDog/datadog-agent/pkg/trace/info"
+	"github.com/DataDog/datadog-agent/pkg/trace/log"
+	"github.com/DataDog/datadog-agent/pkg/trace/metrics"
+	"github.com/DataDog/datadog-agent/pkg/trace/metrics/timing"
+)
+
+// otlpScopeName is the name of the instrumentation scope of the exported spans.
+const otlpScopeName = "datadog-trace-agent"
+
+// otlpWriter exports the payloads of the TraceWriter to an OTLP/HTTP endpoint, after
+// they were sampled and obfuscated.
+type otlpWriter struct {
+	senders []*sender
+	headers map[string]string
+	stats   *info.OTLPExporterInfo
+	easylog *log.ThrottledLogger
+}
+
+// newOTLPWriter returns a new otlpWriter exporting to the endpoint of cfg.OTLPExporter,
+// or nil if it is not set.
+func newOTLPWriter(cfg *config.AgentConfig, climit, qsize int) *otlpWriter {
+	if cfg.OTLPExporter == nil || cfg.OTLPExporter.Endpoint == "" {
+		return nil
+	}
+	url, err := url.Parse(cfg.OTLPExporter.Endpoint)
+	if err != nil {
+		log.Errorf("Invalid OTLP exporter endpoint %q, traces will not be exported: %v", cfg.OTLPExporter.Endpoint, err)
+		return nil
+	}
+	w := &otlpWriter{
+		headers: cfg.OTLPExporter.Headers,
+		stats:   &info.OTLPExporterInfo{},
+		easylog: log.NewThrottled(5, 10*time.Second), // no more than 5 messages every 10 seconds
+	}
+	// the API key is left empty so that it is not sent to the endpoint
+	w.senders = []*sender{newSender(&senderConfig{
+		client:     cfg.NewHTTPClient(),
+		maxConns:   climit,
+		maxQueued:  qsize,
+		maxRetries: cfg.MaxSenderRetries,
+		url:        url,
+		recorder:   w,
+		userAgent:  fmt.Sprintf("Datadog Trace Agent/%s/%s", cfg.AgentVersion, cfg.GitCommit),
+	})}
+	log.Infof("Exporting traces to OTLP endpoint %s", url.Redacted())
+	return w
+}
+
+// write converts the payload to OTLP and sends it to the endpoint.
+func (w *otlpWriter) write(pl *pb.AgentPayload, syncMode bool) {
+	defer timing.Since("datadog.trace_agent.otlp_exporter.encode_ms", time.Now())
+
+	traces, skipped := otlpTracesFromPayload(pl)
+	w.stats.Skipped.Add(int64(skipped))
+	if traces.SpanCount() == 0 {
+		return
+	}
+	b, err := ptraceotlp.NewExportRequestFromTraces(traces).MarshalProto()
+	if err != nil {
+		log.Errorf("Failed to serialize OTLP payload, data dropped: %v", err)
+		return
+	}
+	for _, tp := range pl.TracerPayloads {
+		w.stats.Traces.Add(int64(len(tp.Chunks)))
+	}
+	w.stats.Traces.Add(-int64(skipped))
+	w.stats.Spans.Add(int64(traces.SpanCount()))
+	w.stats.BytesUncompressed.Add(int64(len(b)))
+
+	headers := make(map[string]string, len(w.headers)+2)
+	for k, v := range w.headers {
+		headers[k] = v
+	}
+	headers["Content-Type"] = "application/x-protobuf"
+	headers["Content-Encoding"] = "gzip"
+	p := newPayload(headers)
+	gzipw, err := gzip.NewWriterLevel(p.body, gzip.BestSpeed)
+	if err != nil {
+		// it will never happen, unless an invalid compression is chosen;
+		// we know gzip.BestSpeed is valid.
+		log.Errorf("gzip.NewWriterLevel: %d", err)
+		return
+	}
+	if _, err := gzipw.Write(b); err != nil {
+		log.Errorf("Error gzipping OTLP payload: %v", err)
+	}
+	if err := gzipw.Close(); err != nil {
+		log.Errorf("Error closing gzip stream when writing OTLP payload: %v", err)
+	}
+	sendPayloads(w.senders, p, syncMode)
+}
+
+// stop waits for the queued payloads to be sent.
+func (w *otlpWriter) stop() {
+	stopSenders(w.senders)
+}
+
+func (w *otlpWriter) report() {
+	var stats info.OTLPExporterInfo
+	stats.Payloads.Store(w.stats.Payloads.Swap(0))
+	stats.Traces.Store(w.stats.Traces.Swap(0))
+	stats.Spans.Store(w.stats.Spans.Swap(0))
+	stats.Bytes.Store(w.stats.Bytes.Swap(0))
+	stats.BytesUncompressed.Store(w.stats.BytesUncompressed.Swap(0))
+	stats.Retries.Store(w.stats.Retries.Swap(0))
+	stats.Errors.Store(w.stats.Errors.Swap(0))
+	stats.Dropped.Store(w.stats.Dropped.Swap(0))
+	stats.Skipped.Store(w.stats.Skipped.Swap(0))
+	info.UpdateOTLPExporterInfo(stats)
+
+	metrics.Count("datadog.trace_agent.otlp_exporter.payloads", stats.Payloads.Load(), nil, 1)
+	metrics.Count("datadog.trace_agent.otlp_exporter.traces", stats.Traces.Load(), nil, 1)
+	metrics.Count("datadog.trace_agent.otlp_exporter.spans", stats.Spans.Load(), nil, 1)
+	metrics.Count("datadog.trace_agent.otlp_exporter.bytes", stats.Bytes.Load(), nil, 1)
+	metrics.Count("datadog.trace_agent.otlp_exporter.bytes_uncompressed", stats.BytesUncompressed.Load(), nil, 1)
+	metrics.Count("datadog.trace_agent.otlp_exporter.retries", stats.Retries.Load(), nil, 1)
+	metrics.Count("datadog.trace_agent.otlp_exporter.errors", stats.Errors.Load(), nil, 1)
+	metrics.Count("datadog.trace_agent.otlp_exporter.dropped", stats.Dropped.Load(), nil, 1)
+	metrics.Count("datadog.trace_agent.otlp_exporter.skipped", stats.Skipped.Load(), nil, 1)
+}
+
+var _ eventRecorder = (*otlpWriter)(nil)
+
+// recordEvent implements eventRecorder.
+func (w *otlpWriter) recordEvent(t eventType, data *eventData) {
+	switch t {
+	case eventTypeRetry:
+		log.Debugf("Retrying to export OTLP payload; error: %s", data.err)
+		w.stats.Retries.Inc()
+
+	case eventTypeSent:
+		log.Debugf("Exported traces to the OTLP endpoint; time: %s, bytes: %d", data.duration, data.bytes)
+		timing.Since("datadog.trace_agent.otlp_exporter.flush_duration", time.Now().Add(-data.duration))
+		w.stats.Bytes.Add(int64(data.bytes))
+		w.stats.Payloads.Inc()
+
+	case eventTypeRejected:
+		w.easylog.Warn("OTLP exporter payload rejected by endpoint: %v", data.err)
+		w.stats.Errors.Inc()
+
+	case eventTypeDropped:
+		w.easylog.Warn("OTLP Payload dropped (%.2fKB).", float64(data.bytes)/1024)
+		w.stats.Dropped.Inc()
+	}
+}
+
+// otlpTracesFromPayload converts the tracer payloads of pl to OTLP traces, with one
+// resource per tracer payload and service. The chunks of the traces dropped by the samplers,
+// which only hold their analyzed events or single sampled spans, are skipped and counted.
+func otlpTracesFromPayload(pl *pb.AgentPayload) (traces ptrace.Traces, skipped int) {
+	traces = ptrace.NewTraces()
+	for _, tp := range pl.TracerPayloads {
+		scopes := make(map[string]ptrace.SpanSlice)
+		for _, chunk := range tp.Chunks {
+			if chunk.DroppedTrace {
+				skipped++
+				continue
+			}
+			hi := traceIDHigh(chunk)
+			for _, s := range chunk.Spans {
+				spans, ok := scopes[s.Service]
+				if !ok {
+					rs := traces.ResourceSpans().AppendEmpty()
+					setOTLPResource(rs.Resource().Attributes(), pl, tp, s.Service)
+					ss := rs.ScopeSpans().AppendEmpty()
+					ss.Scope().SetName(otlpScopeName)
+					ss.Scope().SetVersion(pl.AgentVersion)
+					spans = ss.Spans()
+					scopes[s.Service] = spans
+				}
+				convertToOTLPSpan(s, hi, spans.AppendEmpty())
+			}
+		}
+	}
+	return traces, skipped
+}
+
+func setOTLPResource(attrs pcommon.Map, pl *pb.AgentPayload, tp *pb.TracerPayload, service string) {
+	putStr := func(k, v string) {
+		if v != "" {
+			attrs.PutStr(k, v)
+		}
+	}
+	putStr(semconv.AttributeServiceName, service)
+	putStr(semconv.AttributeServiceVersion, tp.AppVersion)
+	env, hostname := tp.Env, tp.Hostname
+	if env == "" {
+		env = pl.Env
+	}
+	if hostname == "" {
+		hostname = pl.HostName
+	}
+	putStr(semconv.AttributeDeploymentEnvironment, env)
+	putStr(semconv.AttributeHostName, hostname)
+	putStr(semconv.AttributeContainerID, tp.ContainerID)
+	putStr(semconv.AttributeTelemetrySDKLanguage, tp.LanguageName)
+	putStr(semconv.AttributeTelemetrySDKVersion, tp.TracerVersion)
+}
+
+var otlpSpanKinds = map[string]ptrace.SpanKind{
+	"internal": ptrace.SpanKindInternal,
+	"server":   ptrace.SpanKindServer,
+	"client":   ptrace.SpanKindClient,
+	"producer": ptrace.SpanKindProducer,
+	"consumer": ptrace.SpanKindConsumer,
+}
+
+// convertToOTLPSpan converts the Datadog span s into out, given the high 64 bits of its
+// trace ID. The resource of the span is used as its name, which is the reverse of the
+// mapping done by the OTLP receiver.
+func convertToOTLPSpan(s *pb.Span, hi uint64, out ptrace.Span) {
+	out.SetTraceID(otlpTraceID(s, hi))
+	out.SetSpanID(otlpSpanID(s.SpanID))
+	if s.ParentID != 0 {
+		out.SetParentSpanID(otlpSpanID(s.ParentID))
+	}
+	out.SetName(s.Resource)
+	out.SetStartTimestamp(pcommon.Timestamp(s.Start))
+	out.SetEndTimestamp(pcommon.Timestamp(s.Start + s.Duration))
+	out.SetKind(otlpSpanKinds[s.Meta["span.kind"]])
+	if s.Error != 0 {
+		out.Status().SetCode(ptrace.StatusCodeError)
+		out.Status().SetMessage(s.Meta["error.msg"])
+	}
+
+	attrs := out.Attributes()
+	attrs.EnsureCapacity(len(s.Meta) + len(s.Metrics) + 2)
+	attrs.PutStr("operation.name", s.Name)
+	if s.Type != "" {
+		attrs.PutStr("span.type", s.Type)
+	}
+	for k, v := range s.Meta {
+		switch k {
+		case "span.kind":
+			continue
+		case "events":
+			if setOTLPEvents(out.Events(), v) {
+				continue
+			}
+		}
+		if strings.HasPrefix(k, "_") || k == "otel.trace_id" {
+			// internal tags, such as the sampling decision maker, and the
+			// trace ID already set on the span
+			continue
+		}
+		attrs.PutStr(k, v)
+	}
+	for k, v := range s.Metrics {
+		if strings.HasPrefix(k, "_") {
+			continue
+		}
+		attrs.PutDouble(k, v)
+	}
+}
+
+// traceIDHigh returns the high 64 bits of the trace ID of the chunk, as propagated by the
+// Datadog tracers in the "_dd.p.tid" tag of the chunk or of one of its spans. It returns 0
+// for 64-bit trace IDs.
+func traceIDHigh(chunk *pb.TraceChunk) uint64 {
+	v, ok := chunk.Tags["_dd.p.tid"]
+	for i := 0; !ok && i < len(chunk.Spans); i++ {
+		v, ok = chunk.Spans[i].Meta["_dd.p.tid"]
+	}
+	hi, err := strconv.ParseUint(v, 16, 64)
+	if !ok || err != nil {
+		return 0
+	}
+	return hi
+}
+
+// otlpTraceID returns the 128-bit trace ID of s, whose high bits are hi.
+func otlpTraceID(s *pb.Span, hi uint64) pcommon.TraceID {
+	var tid [16]byte
+	binary.BigEndian.PutUint64(tid[:8], hi)
+	binary.BigEndian.PutUint64(tid[8:], s.TraceID)
+	return tid
+}
+
+func otlpSpanID(id uint64) pcommon.SpanID {
+	var sid [8]byte
+	binary.BigEndian.PutUint64(sid[:], id)
+	return sid
+}
+
+// otlpEvent is a span event, as encoded in the "events" tag of a span.
+type otlpEvent struct {
+	TimeUnixNano uint64                 `json:"time_unix_nano"`
+	Name         string                 `json:"name"`
+	Attributes   map[string]interface{} `json:"attributes"`
+}
+
+// setOTLPEvents decodes the "events" tag of a span into events. It reports whether
+// the tag could be decoded.
+func setOTLPEvents(events ptrace.SpanEventSlice, tag string) bool {
+	var in []otlpEvent
+	if err := json.Unmarshal([]byte(tag), &in); err != nil {
+		return false
+	}
+	for _, e := range in {
+		out := events.AppendEmpty()
+		out.SetTimestamp(pcommon.Timestamp(e.TimeUnixNano))
+		out.SetName(e.Name)
+		if err := out.Attributes().FromRaw(e.Attributes); err != nil {
+			log.Debugf("Invalid attributes for span event %q: %v", e.Name, err)
+		}
+	}
+	return true
+}
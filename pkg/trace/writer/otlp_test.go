@@ -0,0 +1,317 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package writer
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"go.opentelemetry.io/collector/pdata/ptrace/ptraceotlp"
+
+	pb "github.com/DataDog/datadog-agent/pkg/proto/pbgo/trace"
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+	"github.com/DataDog/datadog-agent/pkg/trace/telemetry"
+)
+
+// otlpTestServer records the OTLP requests it receives, responding with the given status
+// codes in order, then with http.StatusOK.
+type otlpTestServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	codes    []int
+	headers  []http.Header
+	requests []ptraceotlp.ExportRequest
+}
+
+func newOTLPTestServer(t *testing.T, codes ...int) *otlpTestServer {
+	srv := &otlpTestServer{codes: codes}
+	srv.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		srv.mu.Lock()
+		defer srv.mu.Unlock()
+		if len(srv.codes) > 0 {
+			code := srv.codes[0]
+			srv.codes = srv.codes[1:]
+			if code != http.StatusOK {
+				w.WriteHeader(code)
+				return
+			}
+		}
+		r, err := gzip.NewReader(req.Body)
+		require.NoError(t, err)
+		b, err := io.ReadAll(r)
+		require.NoError(t, err)
+		er := ptraceotlp.NewExportRequest()
+		require.NoError(t, er.UnmarshalProto(b))
+		srv.headers = append(srv.headers, req.Header)
+		srv.requests = append(srv.requests, er)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func (srv *otlpTestServer) received() ([]http.Header, []ptraceotlp.ExportRequest) {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	return srv.headers, srv.requests
+}
+
+func testOTLPWriterConfig(srv, otlpSrv string) *config.AgentConfig {
+	return &config.AgentConfig{
+		Hostname:   testHostname,
+		DefaultEnv: testEnv,
+		Endpoints: []*config.Endpoint{{
+			APIKey: "123",
+			Host:   srv,
+		}},
+		OTLPExporter: &config.OTLPExporter{
+			Endpoint: otlpSrv + "/v1/traces",
+			Headers:  map[string]string{"X-Scope-OrgID": "team-a"},
+		},
+		TraceWriter:      &config.WriterConfig{ConnectionLimit: 200, QueueSize: 40},
+		MaxSenderRetries: 4,
+	}
+}
+
+func TestOTLPWriter(t *testing.T) {
+	srv := newTestServer()
+	defer srv.Close()
+	otlpSrv := newOTLPTestServer(t)
+	tw := NewTraceWriter(testOTLPWriterConfig(srv.URL, otlpSrv.URL), mockSampler, mockSampler, mockSampler, telemetry.NewNoopCollector())
+	go tw.Run()
+
+	sampled := []*SampledChunks{randomSampledSpans(20, 8), randomSampledSpans(10, 0)}
+	var spans int64
+	for _, ss := range sampled {
+		tw.In <- ss
+		spans += ss.SpanCount
+	}
+	// the payload is flushed on stop
+	tw.Stop()
+
+	assert.Equal(t, 1, srv.Accepted())
+	headers, requests := otlpSrv.received()
+	require.Len(t, requests, 1)
+	assert.Equal(t, "application/x-protobuf", headers[0].Get("Content-Type"))
+	assert.Equal(t, "team-a", headers[0].Get("X-Scope-OrgID"))
+	assert.Empty(t, headers[0].Get(headerAPIKey), "the API key must not be sent to the OTLP endpoint")
+	assert.EqualValues(t, spans, requests[0].Traces().SpanCount())
+
+	assert.EqualValues(t, 1, tw.otlp.stats.Payloads.Load())
+	assert.EqualValues(t, 2, tw.otlp.stats.Traces.Load())
+	assert.EqualValues(t, spans, tw.otlp.stats.Spans.Load())
+	assert.NotZero(t, tw.otlp.stats.Bytes.Load())
+}
+
+func TestOTLPWriterErrors(t *testing.T) {
+	for name, tt := range map[string]struct {
+		codes                     []int
+		payloads, retries, errors int64
+	}{
+		"retried":  {codes: []int{http.StatusServiceUnavailable, http.StatusOK}, payloads: 1, retries: 1},
+		"rejected": {codes: []int{http.StatusBadRequest}, errors: 1},
+	} {
+		t.Run(name, func(t *testing.T) {
+			otlpSrv := newOTLPTestServer(t, tt.codes...)
+			w := newOTLPWriter(testOTLPWriterConfig("", otlpSrv.URL), 10, 1)
+			w.write(&pb.AgentPayload{TracerPayloads: []*pb.TracerPayload{randomSampledSpans(10, 0).TracerPayload}}, false)
+			// wait for the payload to be sent, or to be rejected
+			assert.Eventually(t, func() bool {
+				return w.stats.Payloads.Load()+w.stats.Errors.Load()+w.stats.Dropped.Load() > 0
+			}, 5*time.Second, 10*time.Millisecond)
+			w.stop()
+
+			_, requests := otlpSrv.received()
+			assert.Len(t, requests, int(tt.payloads))
+			assert.Equal(t, tt.payloads, w.stats.Payloads.Load())
+			assert.Equal(t, tt.retries, w.stats.Retries.Load())
+			assert.Equal(t, tt.errors, w.stats.Errors.Load())
+		})
+	}
+}
+
+func TestOTLPWriterSkipsDroppedTraces(t *testing.T) {
+	otlpSrv := newOTLPTestServer(t)
+	w := newOTLPWriter(testOTLPWriterConfig("", otlpSrv.URL), 10, 1)
+	tp := &pb.TracerPayload{Chunks: []*pb.TraceChunk{
+		{Spans: []*pb.Span{{TraceID: 1, SpanID: 1, Service: "kept"}, {TraceID: 1, SpanID: 2, ParentID: 1, Service: "kept"}}},
+		// the analyzed event of a trace dropped by the samplers
+		{DroppedTrace: true, Spans: []*pb.Span{{TraceID: 2, SpanID: 3, ParentID: 4, Service: "dropped"}}},
+	}}
+	w.write(&pb.AgentPayload{TracerPayloads: []*pb.TracerPayload{tp}}, true)
+	w.stop()
+
+	_, requests := otlpSrv.received()
+	require.Len(t, requests, 1)
+	rs := requests[0].Traces().ResourceSpans()
+	require.Equal(t, 1, rs.Len())
+	assert.Equal(t, "kept", rs.At(0).Resource().Attributes().AsRaw()["service.name"])
+	assert.Equal(t, 2, requests[0].Traces().SpanCount())
+	assert.EqualValues(t, 1, w.stats.Traces.Load())
+	assert.EqualValues(t, 2, w.stats.Spans.Load())
+	assert.EqualValues(t, 1, w.stats.Skipped.Load())
+
+	// payloads holding only dropped traces are not sent
+	w = newOTLPWriter(testOTLPWriterConfig("", otlpSrv.URL), 10, 1)
+	w.write(&pb.AgentPayload{TracerPayloads: []*pb.TracerPayload{{Chunks: tp.Chunks[1:]}}}, true)
+	w.stop()
+	_, requests = otlpSrv.received()
+	assert.Len(t, requests, 1)
+	assert.EqualValues(t, 1, w.stats.Skipped.Load())
+	assert.Zero(t, w.stats.Traces.Load())
+}
+
+func TestOTLPWriterDisabled(t *testing.T) {
+	srv := newTestServer()
+	defer srv.Close()
+	cfg := testOTLPWriterConfig(srv.URL, "")
+	cfg.OTLPExporter = &config.OTLPExporter{}
+	tw := NewTraceWriter(cfg, mockSampler, mockSampler, mockSampler, telemetry.NewNoopCollector())
+	go tw.Run()
+	tw.In <- randomSampledSpans(10, 0)
+	tw.Stop()
+
+	assert.Nil(t, tw.otlp)
+	assert.Equal(t, 1, srv.Accepted())
+}
+
+func TestOTLPTracesFromPayload(t *testing.T) {
+	pl := &pb.AgentPayload{
+		HostName:     "agent-host",
+		Env:          "agent-env",
+		AgentVersion: "7.50.0",
+		TracerPayloads: []*pb.TracerPayload{{
+			ContainerID:   "abc123",
+			LanguageName:  "go",
+			TracerVersion: "1.55.0",
+			Env:           "prod",
+			AppVersion:    "v2",
+			Chunks: []*pb.TraceChunk{{
+				Tags: map[string]string{"_dd.p.tid": "640cfd8d00000000"},
+				Spans: []*pb.Span{
+					{
+						Service:  "web",
+						Name:     "http.request",
+						Resource: "GET /users",
+						TraceID:  42,
+						SpanID:   1,
+						Start:    1700000000000000000,
+						Duration: 5000,
+						Type:     "web",
+						Error:    1,
+						Meta: map[string]string{
+							"span.kind":   "server",
+							"http.method": "GET",
+							"error.msg":   "boom",
+							"_dd.p.dm":    "-1",
+							"events":      `[{"time_unix_nano":1700000000000001000,"name":"retry","attributes":{"attempt":"2"}}]`,
+						},
+						Metrics: map[string]float64{
+							"http.status_code":      500,
+							"_sampling_priority_v1": 1,
+						},
+					},
+					{
+						Service:  "db",
+						Name:     "postgres.query",
+						Resource: "SELECT ?",
+						TraceID:  42,
+						SpanID:   2,
+						ParentID: 1,
+						Start:    1700000000000001000,
+						Duration: 1000,
+						Meta:     map[string]string{"span.kind": "client", "events": "not json"},
+					},
+				},
+			}},
+		}, {
+			Chunks: []*pb.TraceChunk{{
+				Spans: []*pb.Span{{
+					Service:  "otel-svc",
+					Name:     "opentelemetry.internal",
+					Resource: "work",
+					TraceID:  0x0e0e4736,
+					SpanID:   3,
+					Meta: map[string]string{
+						"otel.trace_id": "4bf92f3577b34da600000000" + "0e0e4736",
+						"_dd.p.tid":     "4bf92f3577b34da6",
+					},
+				}},
+			}},
+		}},
+	}
+	traces, skipped := otlpTracesFromPayload(pl)
+	assert.Zero(t, skipped)
+	require.Equal(t, 3, traces.ResourceSpans().Len())
+
+	res := traces.ResourceSpans().At(0)
+	assert.Equal(t, map[string]any{
+		"service.name":           "web",
+		"service.version":        "v2",
+		"deployment.environment": "prod",
+		"host.name":              "agent-host",
+		"container.id":           "abc123",
+		"telemetry.sdk.language": "go",
+		"telemetry.sdk.version":  "1.55.0",
+	}, res.Resource().Attributes().AsRaw())
+	assert.Equal(t, otlpScopeName, res.ScopeSpans().At(0).Scope().Name())
+	assert.Equal(t, "7.50.0", res.ScopeSpans().At(0).Scope().Version())
+
+	span := res.ScopeSpans().At(0).Spans().At(0)
+	assert.Equal(t, pcommon.TraceID{0x64, 0x0c, 0xfd, 0x8d, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 42}, span.TraceID())
+	assert.Equal(t, pcommon.SpanID{0, 0, 0, 0, 0, 0, 0, 1}, span.SpanID())
+	assert.True(t, span.ParentSpanID().IsEmpty())
+	assert.Equal(t, "GET /users", span.Name())
+	assert.Equal(t, ptrace.SpanKindServer, span.Kind())
+	assert.Equal(t, pcommon.Timestamp(1700000000000000000), span.StartTimestamp())
+	assert.Equal(t, pcommon.Timestamp(1700000000000005000), span.EndTimestamp())
+	assert.Equal(t, ptrace.StatusCodeError, span.Status().Code())
+	assert.Equal(t, "boom", span.Status().Message())
+	assert.Equal(t, map[string]any{
+		"operation.name":   "http.request",
+		"span.type":        "web",
+		"http.method":      "GET",
+		"error.msg":        "boom",
+		"http.status_code": 500.0,
+	}, span.Attributes().AsRaw())
+	require.Equal(t, 1, span.Events().Len())
+	assert.Equal(t, "retry", span.Events().At(0).Name())
+	assert.Equal(t, pcommon.Timestamp(1700000000000001000), span.Events().At(0).Timestamp())
+	assert.Equal(t, map[string]any{"attempt": "2"}, span.Events().At(0).Attributes().AsRaw())
+
+	db := traces.ResourceSpans().At(1)
+	assert.Equal(t, "db", db.Resource().Attributes().AsRaw()["service.name"])
+	span = db.ScopeSpans().At(0).Spans().At(0)
+	assert.Equal(t, pcommon.TraceID{0x64, 0x0c, 0xfd, 0x8d, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 42}, span.TraceID())
+	assert.Equal(t, pcommon.SpanID{0, 0, 0, 0, 0, 0, 0, 1}, span.ParentSpanID())
+	assert.Equal(t, ptrace.SpanKindClient, span.Kind())
+	assert.Equal(t, ptrace.StatusCodeUnset, span.Status().Code())
+	assert.Equal(t, 0, span.Events().Len())
+	assert.Equal(t, map[string]any{
+		"operation.name": "postgres.query",
+		"events":         "not json",
+	}, span.Attributes().AsRaw())
+
+	otel := traces.ResourceSpans().At(2)
+	assert.Equal(t, map[string]any{
+		"service.name":           "otel-svc",
+		"deployment.environment": "agent-env",
+		"host.name":              "agent-host",
+	}, otel.Resource().Attributes().AsRaw())
+	span = otel.ScopeSpans().At(0).Spans().At(0)
+	assert.Equal(t, pcommon.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0, 0, 0, 0, 0x0e, 0x0e, 0x47, 0x36}, span.TraceID())
+	assert.Equal(t, ptrace.SpanKindUnspecified, span.Kind())
+	assert.Equal(t, map[string]any{"operation.name": "opentelemetry.internal"}, span.Attributes().AsRaw())
+}
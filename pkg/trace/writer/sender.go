@@ -341,7 +341,10 @@ const (
 )
 
 func (s *sender) do(req *http.Request) error {
-	req.Header.Set(headerAPIKey, s.cfg.apiKey)
+	if s.cfg.apiKey != "" {
+		// senders targeting third-party endpoints have no API key, which must not leak to them
+		req.Header.Set(headerAPIKey, s.cfg.apiKey)
+	}
 	req.Header.Set(headerUserAgent, s.cfg.userAgent)
 	resp, err := s.cfg.client.Do(req)
 	if err != nil {
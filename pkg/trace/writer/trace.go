@@ -66,6 +66,7 @@ type TraceWriter struct {
 	hostname     string
 	env          string
 	senders      []*sender
+	otlp         *otlpWriter // exports the payloads over OTLP; nil when disabled
 	stop         chan struct{}
 	stats        *info.TraceWriterInfo
 	wg           sync.WaitGroup // waits for gzippers
@@ -115,6 +116,7 @@ func NewTraceWriter(cfg *config.AgentConfig, prioritySampler samplerTPSReader, e
 	qsize := 1
 	log.Warnf("Trace writer initialized (climit=%d qsize=%d)", climit, qsize)
 	tw.senders = newSenders(cfg, tw, pathTraces, climit, qsize, telemetryCollector)
+	tw.otlp = newOTLPWriter(cfg, climit, qsize)
 	for i := 0; i < runtime.GOMAXPROCS(0); i++ {
 		tw.wg.Add(1)
 		go tw.serializer()
@@ -131,6 +133,9 @@ func (w *TraceWriter) Stop() {
 	// and submission to senders
 	w.wg.Wait()
 	stopSenders(w.senders)
+	if w.otlp != nil {
+		w.otlp.stop()
+	}
 }
 
 // Run starts the TraceWriter.
@@ -286,6 +291,9 @@ func (w *TraceWriter) serializer() {
 				log.Errorf("Error closing gzip stream when writing trace payload: %v", err)
 			}
 			sendPayloads(w.senders, p, w.syncMode)
+			if w.otlp != nil {
+				w.otlp.write(pl, w.syncMode)
+			}
 		}()
 	}
 }
@@ -299,6 +307,9 @@ func (w *TraceWriter) report() {
 	metrics.Count("datadog.trace_agent.trace_writer.traces", w.stats.Traces.Swap(0), nil, 1)
 	metrics.Count("datadog.trace_agent.trace_writer.events", w.stats.Events.Swap(0), nil, 1)
 	metrics.Count("datadog.trace_agent.trace_writer.spans", w.stats.Spans.Swap(0), nil, 1)
+	if w.otlp != nil {
+		w.otlp.report()
+	}
 }
 
 var _ eventRecorder = (*TraceWriter)(nil)
@@ -0,0 +1,78 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package compression
+
+import (
+	"fmt"
+	"io"
+)
+
+const (
+	// NoneKind disables the compression
+	NoneKind = "none"
+	// ZlibKind is the zlib compression, sent with the deflate content encoding
+	ZlibKind = "zlib"
+	// ZstdKind is the zstd compression, using the stable v1 format
+	ZstdKind = "zstd"
+)
+
+// Compressor compresses payloads with an algorithm and a level selected at runtime,
+// unlike the package functions which use the compression the agent was built with.
+type Compressor interface {
+	Compress(src []byte) ([]byte, error)
+	Decompress(src []byte) ([]byte, error)
+	// CompressBound returns the worst case size needed for a destination buffer
+	CompressBound(sourceLen int) int
+	// ContentEncoding returns the HTTP header value associated with the compression method,
+	// empty when the payloads are not compressed
+	ContentEncoding() string
+	// NewStreamWriter returns a writer compressing the data written to it into w
+	NewStreamWriter(w io.Writer) (StreamWriter, error)
+}
+
+// StreamWriter compresses a stream of data. Flush writes the pending compressed data to
+// the underlying writer and Close writes the end of the stream.
+type StreamWriter interface {
+	io.Writer
+	Flush() error
+	Close() error
+}
+
+// NewCompressor returns a Compressor for the given algorithm and level. An empty kind
+// returns the compression the agent was built with, a level of 0 the default level of
+// the algorithm.
+func NewCompressor(kind string, level int) (Compressor, error) {
+	switch kind {
+	case "":
+		return newBuildCompressor(level)
+	case NoneKind:
+		return noopCompressor{}, nil
+	case ZlibKind:
+		return newZlibCompressor(level)
+	case ZstdKind:
+		return newZstdCompressor(level)
+	}
+	return nil, fmt.Errorf("unknown compression kind %q, supported ones are %q, %q and %q", kind, NoneKind, ZlibKind, ZstdKind)
+}
+
+// noopCompressor doesn't compress anything
+type noopCompressor struct{}
+
+func (noopCompressor) Compress(src []byte) ([]byte, error)   { return src, nil }
+func (noopCompressor) Decompress(src []byte) ([]byte, error) { return src, nil }
+func (noopCompressor) CompressBound(sourceLen int) int       { return sourceLen }
+func (noopCompressor) ContentEncoding() string               { return "" }
+
+func (noopCompressor) NewStreamWriter(w io.Writer) (StreamWriter, error) {
+	return noopStreamWriter{w}, nil
+}
+
+type noopStreamWriter struct {
+	io.Writer
+}
+
+func (noopStreamWriter) Flush() error { return nil }
+func (noopStreamWriter) Close() error { return nil }
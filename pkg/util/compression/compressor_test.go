@@ -0,0 +1,112 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package compression
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestCompressors(t *testing.T) {
+	payload := []byte(strings.Repeat(`{"metric":"system.cpu.user","points":[[1700000000,12.5]]}`, 100))
+
+	for _, tc := range []struct {
+		kind            string
+		level           int
+		contentEncoding string
+	}{
+		{NoneKind, 0, ""},
+		{ZlibKind, 0, "deflate"},
+		{ZlibKind, 9, "deflate"},
+		{ZstdKind, 0, "zstd"},
+		{ZstdKind, 1, "zstd"},
+		{ZstdKind, 19, "zstd"},
+	} {
+		compressor, err := NewCompressor(tc.kind, tc.level)
+		if err != nil {
+			t.Fatalf("%s level %d: %v", tc.kind, tc.level, err)
+		}
+		if compressor.ContentEncoding() != tc.contentEncoding {
+			t.Errorf("%s level %d: content encoding %q, expected %q", tc.kind, tc.level, compressor.ContentEncoding(), tc.contentEncoding)
+		}
+
+		compressed, err := compressor.Compress(payload)
+		if err != nil {
+			t.Fatalf("%s level %d: %v", tc.kind, tc.level, err)
+		}
+		if len(compressed) > compressor.CompressBound(len(payload)) {
+			t.Errorf("%s level %d: compressed size %d above the bound %d", tc.kind, tc.level, len(compressed), compressor.CompressBound(len(payload)))
+		}
+		if tc.kind != NoneKind && len(compressed) >= len(payload) {
+			t.Errorf("%s level %d: the payload wasn't compressed", tc.kind, tc.level)
+		}
+		decompressed, err := compressor.Decompress(compressed)
+		if err != nil {
+			t.Fatalf("%s level %d: %v", tc.kind, tc.level, err)
+		}
+		if !bytes.Equal(payload, decompressed) {
+			t.Errorf("%s level %d: the decompressed payload differs", tc.kind, tc.level)
+		}
+	}
+}
+
+func TestCompressorsStream(t *testing.T) {
+	payload := []byte(strings.Repeat(`{"metric":"system.cpu.user","points":[[1700000000,12.5]]}`, 100))
+
+	for _, kind := range []string{NoneKind, ZlibKind, ZstdKind} {
+		compressor, err := NewCompressor(kind, 0)
+		if err != nil {
+			t.Fatalf("%s: %v", kind, err)
+		}
+
+		var output bytes.Buffer
+		writer, err := compressor.NewStreamWriter(&output)
+		if err != nil {
+			t.Fatalf("%s: %v", kind, err)
+		}
+		half := len(payload) / 2
+		if _, err := writer.Write(payload[:half]); err != nil {
+			t.Fatalf("%s: %v", kind, err)
+		}
+		if err := writer.Flush(); err != nil {
+			t.Fatalf("%s: %v", kind, err)
+		}
+		if output.Len() == 0 {
+			t.Errorf("%s: nothing was written after the flush", kind)
+		}
+		if _, err := writer.Write(payload[half:]); err != nil {
+			t.Fatalf("%s: %v", kind, err)
+		}
+		if err := writer.Close(); err != nil {
+			t.Fatalf("%s: %v", kind, err)
+		}
+
+		decompressed, err := compressor.Decompress(output.Bytes())
+		if err != nil {
+			t.Fatalf("%s: %v", kind, err)
+		}
+		if !bytes.Equal(payload, decompressed) {
+			t.Errorf("%s: the decompressed stream differs", kind)
+		}
+	}
+}
+
+func TestNewCompressorErrors(t *testing.T) {
+	for _, tc := range []struct {
+		kind  string
+		level int
+	}{
+		{"gzip", 0},
+		{ZlibKind, 10},
+		{ZlibKind, -1},
+		{ZstdKind, 23},
+	} {
+		if _, err := NewCompressor(tc.kind, tc.level); err == nil {
+			t.Errorf("%s level %d: expected an error", tc.kind, tc.level)
+		}
+	}
+}
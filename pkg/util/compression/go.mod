@@ -2,4 +2,7 @@ module github.com/DataDog/datadog-agent/pkg/util/compression
 
 go 1.20
 
-require github.com/DataDog/zstd_0 v0.0.0-20210310093942-586c1286621f
+require (
+	github.com/DataDog/zstd_0 v0.0.0-20210310093942-586c1286621f
+	github.com/klauspost/compress v1.17.0
+)
@@ -55,3 +55,8 @@ func CompressBound(sourceLen int) int {
 	// From https://code.woboq.org/gcc/zlib/compress.c.html#compressBound
 	return sourceLen + (sourceLen >> 12) + (sourceLen >> 14) + (sourceLen >> 25) + 13
 }
+
+// newBuildCompressor returns the Compressor of the compression the agent was built with
+func newBuildCompressor(level int) (Compressor, error) {
+	return newZlibCompressor(level)
+}
@@ -0,0 +1,66 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package compression
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+)
+
+// zlibCompressor compresses the payloads with zlib
+type zlibCompressor struct {
+	level int
+}
+
+func newZlibCompressor(level int) (*zlibCompressor, error) {
+	if level == 0 {
+		level = zlib.DefaultCompression
+	} else if level < zlib.BestSpeed || level > zlib.BestCompression {
+		return nil, fmt.Errorf("invalid zlib compression level %d, it must be between %d and %d", level, zlib.BestSpeed, zlib.BestCompression)
+	}
+	return &zlibCompressor{level: level}, nil
+}
+
+func (c *zlibCompressor) Compress(src []byte) ([]byte, error) {
+	var b bytes.Buffer
+	w, err := zlib.NewWriterLevel(&b, c.level)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(src); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+func (c *zlibCompressor) Decompress(src []byte) ([]byte, error) {
+	r, err := zlib.NewReader(bytes.NewReader(src))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return io.ReadAll(r)
+}
+
+// CompressBound returns the worst case size needed for a destination buffer
+// Ref: https://refspecs.linuxbase.org/LSB_3.0.0/LSB-Core-generic/LSB-Core-generic/zlib-compressbound-1.html
+func (c *zlibCompressor) CompressBound(sourceLen int) int {
+	return sourceLen + (sourceLen >> 12) + (sourceLen >> 14) + (sourceLen >> 25) + 13
+}
+
+func (c *zlibCompressor) ContentEncoding() string {
+	return "deflate"
+}
+
+func (c *zlibCompressor) NewStreamWriter(w io.Writer) (StreamWriter, error) {
+	return zlib.NewWriterLevel(w, c.level)
+}
@@ -8,6 +8,9 @@
 package compression
 
 import (
+	"fmt"
+	"io"
+
 	zstd_0 "github.com/DataDog/zstd_0"
 )
 
@@ -32,3 +35,21 @@ func Decompress(src []byte) ([]byte, error) {
 func CompressBound(sourceLen int) int {
 	return zstd_0.CompressBound(sourceLen)
 }
+
+// newBuildCompressor returns the Compressor of the compression the agent was built with.
+// The level can't be changed for the pre-v1 zstd format.
+func newBuildCompressor(_ int) (Compressor, error) {
+	return zstd0Compressor{}, nil
+}
+
+// zstd0Compressor compresses the payloads with the pre-v1 zstd format
+type zstd0Compressor struct{}
+
+func (zstd0Compressor) Compress(src []byte) ([]byte, error)   { return Compress(src) }
+func (zstd0Compressor) Decompress(src []byte) ([]byte, error) { return Decompress(src) }
+func (zstd0Compressor) CompressBound(sourceLen int) int       { return CompressBound(sourceLen) }
+func (zstd0Compressor) ContentEncoding() string               { return ContentEncoding }
+
+func (zstd0Compressor) NewStreamWriter(_ io.Writer) (StreamWriter, error) {
+	return nil, fmt.Errorf("stream compression isn't supported by the pre-v1 zstd format")
+}
@@ -0,0 +1,79 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package compression
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	zstdMinLevel     = 1
+	zstdMaxLevel     = 22
+	zstdDefaultLevel = 3
+)
+
+// zstdCompressor compresses the payloads with zstd. Unlike the compression selected with
+// the zstd build tag, it uses the stable v1 format.
+type zstdCompressor struct {
+	level zstd.EncoderLevel
+	// encoder and decoder are only used for EncodeAll and DecodeAll, which are safe
+	// for concurrent use
+	encoder *zstd.Encoder
+	decoder *zstd.Decoder
+}
+
+func newZstdCompressor(level int) (*zstdCompressor, error) {
+	if level == 0 {
+		level = zstdDefaultLevel
+	} else if level < zstdMinLevel || level > zstdMaxLevel {
+		return nil, fmt.Errorf("invalid zstd compression level %d, it must be between %d and %d", level, zstdMinLevel, zstdMaxLevel)
+	}
+
+	encoderLevel := zstd.EncoderLevelFromZstd(level)
+	encoder, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(encoderLevel))
+	if err != nil {
+		return nil, err
+	}
+	decoder, err := zstd.NewReader(nil)
+	if err != nil {
+		return nil, err
+	}
+	return &zstdCompressor{
+		level:   encoderLevel,
+		encoder: encoder,
+		decoder: decoder,
+	}, nil
+}
+
+func (c *zstdCompressor) Compress(src []byte) ([]byte, error) {
+	return c.encoder.EncodeAll(src, make([]byte, 0, c.CompressBound(len(src)))), nil
+}
+
+func (c *zstdCompressor) Decompress(src []byte) ([]byte, error) {
+	return c.decoder.DecodeAll(src, nil)
+}
+
+// CompressBound returns the worst case size needed for a destination buffer
+// Ref: ZSTD_COMPRESSBOUND in https://github.com/facebook/zstd/blob/dev/lib/zstd.h
+func (c *zstdCompressor) CompressBound(sourceLen int) int {
+	bound := sourceLen + (sourceLen >> 8)
+	if sourceLen < 128<<10 {
+		bound += ((128 << 10) - sourceLen) >> 11
+	}
+	return bound
+}
+
+func (c *zstdCompressor) ContentEncoding() string {
+	return "zstd"
+}
+
+func (c *zstdCompressor) NewStreamWriter(w io.Writer) (StreamWriter, error) {
+	// a stream is written by a single goroutine, there is no need for concurrent encoding
+	return zstd.NewWriter(w, zstd.WithEncoderLevel(c.level), zstd.WithEncoderConcurrency(1))
+}
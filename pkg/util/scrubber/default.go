@@ -98,6 +98,11 @@ func AddDefaultReplacers(scrubber *Scrubber) {
 		[]string{"community_string", "authKey", "privKey", "community", "authentication_key", "privacy_key"},
 		[]byte(`$1 "********"`),
 	)
+	encryptionKeyReplacer := matchYAMLKeyEnding(
+		`encryption_key`,
+		[]string{"encryption_key"},
+		[]byte(`$1 "********"`),
+	)
 	snmpMultilineReplacer := matchYAMLKeyWithListValue(
 		"(community_strings)",
 		"community_strings",
@@ -160,6 +165,7 @@ func AddDefaultReplacers(scrubber *Scrubber) {
 	scrubber.AddReplacer(SingleLine, passwordReplacer)
 	scrubber.AddReplacer(SingleLine, tokenReplacer)
 	scrubber.AddReplacer(SingleLine, snmpReplacer)
+	scrubber.AddReplacer(SingleLine, encryptionKeyReplacer)
 
 	scrubber.AddReplacer(SingleLine, apiKeyYaml)
 	scrubber.AddReplacer(SingleLine, appKeyYaml)
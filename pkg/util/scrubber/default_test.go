@@ -480,6 +480,15 @@ other_config_with_list: [abc]
 		`privacy_key: "********"`)
 }
 
+func TestEncryptionKeyConfig(t *testing.T) {
+	assertClean(t,
+		`forwarder_storage_encryption_key: 0123456789abcdef0123456789abcdef`,
+		`forwarder_storage_encryption_key: "********"`)
+	assertClean(t,
+		`  encryption_key: "secret"`,
+		`  encryption_key: "********"`)
+}
+
 func TestAddStrippedKeys(t *testing.T) {
 	contents := `foobar: baz`
 	cleaned, err := ScrubBytes([]byte(contents))
@@ -8,6 +8,9 @@ package main
 import (
 	"bytes"
 	"compress/zlib"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
 	"encoding/json"
 	"errors"
 	"flag"
@@ -20,27 +23,37 @@ import (
 	proto "github.com/golang/protobuf/proto"
 )
 
+// The header of the retry files, see comp/forwarder/defaultforwarder/internal/retry/retry_file_encoder.go
+var retryFileMagic = []byte("DDRQ")
+
+const (
+	retryFileFormatVersion = 1
+	retryFileHeaderSize    = 6 + sha256.Size
+	retryFileEncrypted     = 1
+)
+
 func main() {
-	folder, err := parseArg()
+	folder, encryptionKey, err := parseArg()
 	if err != nil {
 		fmt.Println(err)
 		return
 	}
-	if err = dumpRetryFiles(folder); err != nil {
+	if err = dumpRetryFiles(folder, encryptionKey); err != nil {
 		fmt.Println(err)
 	}
 }
 
-func parseArg() (string, error) {
+func parseArg() (string, string, error) {
 	var folder = flag.String("folder", "", "The folder containing `.retry` files.")
+	var encryptionKey = flag.String("encryption-key", "", "The value of `forwarder_storage_encryption_key` when the files are encrypted.")
 	flag.Parse()
 	if *folder == "" {
-		return "", errors.New("Invalid folder: Usage `./retry_file_dump --folder=/opt/datadog-agent/run/transactions_to_retry/c47da40ac935c8fd5ca1441a5ee3d068/`")
+		return "", "", errors.New("Invalid folder: Usage `./retry_file_dump --folder=/opt/datadog-agent/run/transactions_to_retry/c47da40ac935c8fd5ca1441a5ee3d068/`")
 	}
-	return *folder, nil
+	return *folder, *encryptionKey, nil
 }
 
-func dumpRetryFiles(folder string) error {
+func dumpRetryFiles(folder string, encryptionKey string) error {
 	entries, err := os.ReadDir(folder)
 	if err != nil {
 		return err
@@ -50,7 +63,7 @@ func dumpRetryFiles(folder string) error {
 		if entry.Type().IsRegular() && filepath.Ext(entry.Name()) == ".retry" {
 			fmt.Println(entry.Name())
 			filePath := path.Join(folder, entry.Name())
-			fileContent, err := dumpRetryFile(filePath)
+			fileContent, err := dumpRetryFile(filePath, encryptionKey)
 			if err != nil {
 				return err
 			}
@@ -64,11 +77,15 @@ func dumpRetryFiles(folder string) error {
 	return nil
 }
 
-func dumpRetryFile(file string) ([]byte, error) {
+func dumpRetryFile(file string, encryptionKey string) ([]byte, error) {
 	content, err := os.ReadFile(file)
 	if err != nil {
 		return nil, err
 	}
+	content, err = decodeRetryFile(content, encryptionKey)
+	if err != nil {
+		return nil, err
+	}
 	collection := HttpTransactionProtoCollection{}
 
 	if err := proto.Unmarshal(content, &collection); err != nil {
@@ -119,3 +136,39 @@ func decodePayload(payload []byte, buffer *bytes.Buffer) (string, error) {
 	}
 	return string(buff[:n]), nil
 }
+
+// decodeRetryFile returns the serialized transactions held by a retry file.
+func decodeRetryFile(content []byte, encryptionKey string) ([]byte, error) {
+	if !bytes.HasPrefix(content, retryFileMagic) {
+		// File written by an Agent not adding the header
+		return content, nil
+	}
+	if len(content) < retryFileHeaderSize || content[4] != retryFileFormatVersion {
+		return nil, errors.New("Unsupported retry file format")
+	}
+	checksum := sha256.Sum256(content[retryFileHeaderSize:])
+	if !bytes.Equal(checksum[:], content[6:retryFileHeaderSize]) {
+		return nil, errors.New("Invalid checksum")
+	}
+	if content[5]&retryFileEncrypted == 0 {
+		return content[retryFileHeaderSize:], nil
+	}
+
+	if encryptionKey == "" {
+		return nil, errors.New("The file is encrypted, set `--encryption-key`")
+	}
+	key := sha256.Sum256([]byte(encryptionKey))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	sealed := content[retryFileHeaderSize:]
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("The nonce is truncated")
+	}
+	return aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], content[:6])
+}